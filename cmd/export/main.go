@@ -0,0 +1,133 @@
+// Command export writes the active catalog as a feed, like the
+// GET /api/v1/admin/products/export endpoint, for cron jobs and one-off
+// uploads to marketplaces.
+//
+// Usage:
+//
+//	export [-format csv|ndjson|xml] [-o file] [-updated-since RFC3339]
+//
+// Without -o the feed goes to stdout. A file is only replaced once the whole
+// feed is written, so readers never see a partial feed.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/config"
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/internal/services"
+	"github.com/Code-Aether/americanas-loja-api/pkg/database"
+	"github.com/Code-Aether/americanas-loja-api/pkg/storage"
+)
+
+func main() {
+	format := flag.String("format", models.ExportFormatCSV, "feed format: csv, ndjson or xml")
+	output := flag.String("o", "", "output file (default stdout)")
+	updatedSince := flag.String("updated-since", "", "only export products changed since this time (RFC 3339)")
+	flag.Parse()
+
+	var since *time.Time
+	if *updatedSince != "" {
+		parsed, err := time.Parse(time.RFC3339, *updatedSince)
+		if err != nil {
+			log.Fatalf("invalid -updated-since %q: %v", *updatedSince, err)
+		}
+		since = &parsed
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	cfg := config.Load()
+
+	db, err := database.NewConnection(cfg)
+	if err != nil {
+		log.Fatal("failed to connect to database:", err)
+	}
+	defer database.Close(db)
+
+	store, err := storage.New(cfg)
+	if err != nil {
+		log.Fatal("failed to setup storage:", err)
+	}
+
+	exportService := services.NewCatalogExportService(
+		repository.NewProductRepository(db),
+		repository.NewCategoryRepository(db),
+		repository.NewProductImageRepository(db),
+		store, cfg.ExportImageURLTTL, cfg.ExportProductURL, cfg.ExportBatchSize,
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	export := func(w io.Writer) (int, error) {
+		return exportService.Export(ctx, w, *format, since)
+	}
+
+	var count int
+	if *output == "" {
+		count, err = exportTo(os.Stdout, export)
+	} else {
+		count, err = exportFile(*output, export)
+	}
+	if err != nil {
+		log.Fatal("failed to export catalog:", err)
+	}
+
+	log.Printf("Exported %d items", count)
+}
+
+func exportTo(w io.Writer, export func(io.Writer) (int, error)) (int, error) {
+	buffered := bufio.NewWriter(w)
+	count, err := export(buffered)
+	if err != nil {
+		return count, err
+	}
+	return count, buffered.Flush()
+}
+
+// exportFile writes the feed to a temporary file next to path and renames it
+// over path once complete.
+func exportFile(path string, export func(io.Writer) (int, error)) (int, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	count, err := exportTo(tmp, export)
+	if err != nil {
+		tmp.Close()
+		return count, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return count, err
+	}
+	if err := tmp.Close(); err != nil {
+		return count, err
+	}
+	// CreateTemp makes the file readable by its owner only.
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return count, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return count, fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return count, nil
+}
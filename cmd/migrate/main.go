@@ -0,0 +1,120 @@
+// Command migrate manages the versioned SQL schema.
+//
+// Usage:
+//
+//	migrate up [n]        apply all (or the next n) pending migrations
+//	migrate down [n]      roll back the last (or the last n) migrations
+//	migrate status        list migrations and whether they are applied
+//	migrate create <name> create empty up/down files for every dialect
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/joho/godotenv"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/config"
+	"github.com/Code-Aether/americanas-loja-api/migrations"
+	"github.com/Code-Aether/americanas-loja-api/pkg/database"
+)
+
+const migrationsDir = "migrations"
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	command := os.Args[1]
+
+	if command == "create" {
+		if len(os.Args) < 3 {
+			usage()
+		}
+		files, err := database.CreateMigration(migrationsDir, os.Args[2])
+		if err != nil {
+			log.Fatal("failed to create migration:", err)
+		}
+		for _, file := range files {
+			fmt.Println("created", file)
+		}
+		return
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	cfg := config.Load()
+
+	db, err := database.NewConnection(cfg)
+	if err != nil {
+		log.Fatal("failed to connect to database:", err)
+	}
+	defer database.Close(db)
+
+	migrator, err := database.NewMigrator(db, migrations.FS)
+	if err != nil {
+		log.Fatal("failed to load migrations:", err)
+	}
+
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		err = migrator.Up(ctx, steps())
+		if err == nil {
+			err = database.EnsureSearchIndex(db)
+		}
+	case "down":
+		err = migrator.Down(ctx, steps())
+	case "status":
+		err = printStatus(ctx, migrator)
+	default:
+		usage()
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func steps() int {
+	if len(os.Args) < 3 {
+		return 0
+	}
+
+	n, err := strconv.Atoi(os.Args[2])
+	if err != nil || n < 0 {
+		log.Fatalf("invalid number of steps %q", os.Args[2])
+	}
+	return n
+}
+
+func printStatus(ctx context.Context, migrator *database.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		switch {
+		case status.Missing:
+			fmt.Printf("%03d  %-40s applied at %s (no migration file!)\n", status.Version, "?", status.AppliedAt.Format("2006-01-02 15:04:05"))
+		case status.Applied:
+			fmt.Printf("%03d  %-40s applied at %s\n", status.Version, status.Name, status.AppliedAt.Format("2006-01-02 15:04:05"))
+		default:
+			fmt.Printf("%03d  %-40s pending\n", status.Version, status.Name)
+		}
+	}
+	return nil
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate up [n] | down [n] | status | create <name>")
+	os.Exit(2)
+}
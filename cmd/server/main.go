@@ -10,23 +10,41 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
-	"os"
+	"net/http"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	"github.com/joho/godotenv"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"gorm.io/gorm"
 
 	_ "github.com/Code-Aether/americanas-loja-api/docs" // This will be generated by swag init
 	"github.com/Code-Aether/americanas-loja-api/internal/config"
 	"github.com/Code-Aether/americanas-loja-api/internal/handlers"
 	"github.com/Code-Aether/americanas-loja-api/internal/middleware"
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
 	"github.com/Code-Aether/americanas-loja-api/internal/repository"
 	"github.com/Code-Aether/americanas-loja-api/internal/services"
+	"github.com/Code-Aether/americanas-loja-api/migrations"
+	"github.com/Code-Aether/americanas-loja-api/pkg/background"
 	"github.com/Code-Aether/americanas-loja-api/pkg/cache"
+	"github.com/Code-Aether/americanas-loja-api/pkg/cep"
 	"github.com/Code-Aether/americanas-loja-api/pkg/database"
+	"github.com/Code-Aether/americanas-loja-api/pkg/notify"
+	"github.com/Code-Aether/americanas-loja-api/pkg/orders"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+	"github.com/Code-Aether/americanas-loja-api/pkg/seal"
+	"github.com/Code-Aether/americanas-loja-api/pkg/shipping"
+	"github.com/Code-Aether/americanas-loja-api/pkg/storage"
+	"github.com/Code-Aether/americanas-loja-api/pkg/telemetry"
 )
 
 func main() {
@@ -36,63 +54,268 @@ func main() {
 
 	cfg := config.Load()
 
+	// Signals are watched from the start, so a SIGTERM received while
+	// migrations run still shuts the process down cleanly.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := telemetry.Setup(ctx, cfg)
+	if err != nil {
+		log.Fatal("failed to setup tracing:", err)
+	}
+
+	documentBox, err := seal.New(cfg.DocumentEncryptionKey)
+	if err != nil {
+		log.Fatal("failed to setup document encryption:", err)
+	}
+	models.SetDocumentBox(documentBox)
+
 	db, err := database.NewConnection(cfg)
 	if err != nil {
 		log.Fatal("failed to connect to database:", err)
 	}
 
-	rdb := cache.NewRedisClient(cfg.RedisURL, "", 0)
+	rdb := cache.NewRedisClient(cfg.RedisURL, "", 0, cfg.CacheTimeout)
 
-	err = database.AutoMigrate(db)
+	store, err := storage.New(cfg)
 	if err != nil {
-		log.Fatal("failed to auto migrate:", err)
+		log.Fatal("failed to setup storage:", err)
 	}
 
+	notifier, err := notify.New(cfg, cfg.NotifierDriver)
+	if err != nil {
+		log.Fatal("failed to setup notifier:", err)
+	}
+	alertNotifier, err := notify.New(cfg, cfg.AlertChannels)
+	if err != nil {
+		log.Fatal("failed to setup alert channels:", err)
+	}
+	carrier, err := shipping.New(cfg, cfg.ShippingCarriers)
+	if err != nil {
+		log.Fatal("failed to setup shipping carriers:", err)
+	}
+	cepResolver, err := cep.New(cfg)
+	if err != nil {
+		log.Fatal("failed to setup CEP resolver:", err)
+	}
+	orderSource, err := orders.New(cfg)
+	if err != nil {
+		log.Fatal("failed to setup order source:", err)
+	}
+
+	// jobs tracks the work done outside of requests, which shutdown waits
+	// for before closing the database and Redis.
+	jobs := &background.Group{}
+
 	productRepo := repository.NewProductRepository(db)
 	userRepo := repository.NewUserRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	imageRepo := repository.NewProductImageRepository(db)
+	pricingRepo := repository.NewPricingRepository(db)
+	importJobRepo := repository.NewImportJobRepository(db)
+	reviewRepo := repository.NewReviewRepository(db)
+	wishlistRepo := repository.NewWishlistRepository(db)
+	stockSubscriptionRepo := repository.NewStockSubscriptionRepository(db)
+	lowStockAlertRepo := repository.NewLowStockAlertRepository(db)
+	couponRepo := repository.NewCouponRepository(db)
+	addressRepo := repository.NewAddressRepository(db)
+	walletRepo := repository.NewWalletRepository(db)
+	loyaltyRepo := repository.NewLoyaltyRepository(db)
+	returnRepo := repository.NewReturnRepository(db)
 	productService := services.NewProductService(productRepo, rdb)
+	categoryService := services.NewCategoryService(categoryRepo, rdb)
 	authService := services.NewAuthService(userRepo, cfg.JWTSecret)
+	userService := services.NewUserService(userRepo)
+	mediaService := services.NewMediaService(imageRepo, productRepo, store, cfg.MediaMaxUploadSize, cfg.MediaURLTTL)
+	pricingService := services.NewPricingService(pricingRepo, productRepo, productService)
+	productImportService := services.NewProductImportService(importJobRepo, productRepo, productService, jobs)
+	// The store takes no orders yet, so there is nothing to verify purchases
+	// against and reviews are never marked as verified.
+	// Purchases are checked against the order source. With ORDER_SOURCE=none,
+	// the default until the store has a checkout, no review is marked as a
+	// verified purchase.
+	reviewService := services.NewReviewService(reviewRepo, productRepo, productService, services.NewOrderPurchases(orderSource))
+	catalogExportService := services.NewCatalogExportService(productRepo, categoryRepo, imageRepo, store, cfg.ExportImageURLTTL, cfg.ExportProductURL, cfg.ExportBatchSize)
+	wishlistService := services.NewWishlistService(wishlistRepo, productRepo)
+	backInStockService := services.NewBackInStockService(stockSubscriptionRepo, productRepo, notifier, jobs)
+	lowStockService := services.NewLowStockService(lowStockAlertRepo, productRepo, categoryRepo, alertNotifier, cfg.LowStockThreshold, cfg.AlertEmailTo, jobs)
+	couponService := services.NewCouponService(couponRepo, productRepo)
+	addressService := services.NewAddressService(addressRepo, cepResolver)
+	shippingService := services.NewShippingService(productRepo, carrier, cfg.ShippingDefaultWeight, cfg.ShippingDefaultVolume)
+	// Store credit pays for orders through PayOrder and RefundOrder, which
+	// the checkout calls once it exists; until then no route debits it.
+	walletService := services.NewWalletService(walletRepo, userRepo, cfg.GiftCardValidity)
+	// Points are earned and redeemed through AccrueOrder and RedeemOrder,
+	// which the checkout calls once it exists; refunded returns already
+	// cancel the points of the items returned.
+	loyaltyService := services.NewLoyaltyService(loyaltyRepo, categoryRepo, productRepo, cfg.LoyaltyDefaultRate, cfg.LoyaltyReleaseDelay, cfg.LoyaltyPointsValidity)
+	returnService := services.NewReturnService(returnRepo, productService, orderSource, store, cfg.ReturnWindow, cfg.MediaMaxUploadSize, cfg.MediaURLTTL)
+	returnService.AddRefundListener(loyaltyService)
+	productService.AddStockListener(backInStockService)
+	productService.AddStockListener(lowStockService)
 
-	productHandler := handlers.NewProductHandler(productService)
+	cursors := pagination.NewSigner(cfg.CursorSigningKey)
+
+	productHandler := handlers.NewProductHandler(productService, cursors)
+	categoryHandler := handlers.NewCategoryHandler(categoryService)
+	mediaHandler := handlers.NewMediaHandler(mediaService, store, cfg.MediaMaxUploadSize)
+	pricingHandler := handlers.NewPricingHandler(pricingService)
+	productImportHandler := handlers.NewProductImportHandler(productImportService, cfg.ImportMaxSize, cfg.ImportSyncMaxRows)
+	catalogExportHandler := handlers.NewCatalogExportHandler(catalogExportService)
+	reviewHandler := handlers.NewReviewHandler(reviewService, cursors)
+	wishlistHandler := handlers.NewWishlistHandler(wishlistService, backInStockService)
+	alertHandler := handlers.NewAlertHandler(lowStockService)
+	couponHandler := handlers.NewCouponHandler(couponService)
+	shippingHandler := handlers.NewShippingHandler(shippingService)
+	addressHandler := handlers.NewAddressHandler(addressService)
+	walletHandler := handlers.NewWalletHandler(walletService, cursors)
+	loyaltyHandler := handlers.NewLoyaltyHandler(loyaltyService, cursors)
+	returnHandler := handlers.NewReturnHandler(returnService, cfg.MediaMaxUploadSize)
 	authHandler := handlers.NewAuthHandler(authService)
+	userHandler := handlers.NewUserHandler(userService, cursors)
+	healthHandler := handlers.NewHealthHandler(db, rdb, cfg.HealthCheckTimeout)
 
-	err = database.SeedData(db)
-	if err != nil {
-		log.Fatal("failed to seed data:", err)
+	r := gin.Default()
+
+	r.Use(gin.Logger())
+	r.Use(gin.Recovery())
+	r.Use(middleware.Tracing(cfg.OTelServiceName)...)
+	// Catalog feeds are streamed for as long as the catalog takes to export.
+	r.Use(middleware.Timeout(cfg.RequestTimeout, "/api/v1/admin/products/export"))
+
+	setupRoutes(r, productHandler, categoryHandler, mediaHandler, pricingHandler, productImportHandler, catalogExportHandler, reviewHandler, wishlistHandler, alertHandler, couponHandler, shippingHandler, addressHandler, walletHandler, loyaltyHandler, returnHandler, authHandler, userHandler, healthHandler, authService)
+
+	srv := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           r,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+
+	// The server starts before migrations so /readyz can report "starting"
+	// instead of the probe failing to connect.
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server starting at http://localhost:%s", cfg.Port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	if err := startup(ctx, cfg, db); err != nil {
+		log.Fatal(err)
 	}
 
-	err = database.SeedAdminUser(db)
+	if failed, err := productImportService.FailInterrupted(ctx); err != nil {
+		log.Println("failed to clean up interrupted import jobs:", err)
+	} else if failed > 0 {
+		log.Printf("Marked %d import jobs interrupted by the last shutdown as failed", failed)
+	}
+
+	// Sale prices are applied, stock is checked and store credit and
+	// loyalty points move only once the schema is migrated. The jobs stop
+	// with ctx, and shutdown waits for them before closing the database.
+	jobs.Go(func() { pricingService.Run(ctx, cfg.PriceSchedulerInterval) })
+	jobs.Go(func() { lowStockService.Run(ctx, cfg.LowStockCheckInterval) })
+	jobs.Go(func() { walletService.Run(ctx, cfg.WalletExpiryInterval) })
+	jobs.Go(func() { loyaltyService.Run(ctx, cfg.LoyaltyJobInterval) })
+
+	healthHandler.SetReady()
+	log.Println("Server is ready")
+
+	select {
+	case <-ctx.Done():
+		log.Println("Shutdown signal received")
+	case err := <-serverErr:
+		log.Println("server failed:", err)
+	}
+	stop()
+
+	shutdown(cfg, srv, healthHandler, jobs, db, rdb, shutdownTracing)
+}
+
+func startup(ctx context.Context, cfg *config.Config, db *gorm.DB) error {
+	migrator, err := database.NewMigrator(db, migrations.FS)
 	if err != nil {
-		log.Fatal("failed to create admin user:", err)
+		return fmt.Errorf("failed to load migrations: %w", err)
 	}
 
-	r := gin.Default()
+	// Without FTS5 product search loses its ranking and accent folding.
+	if cfg.Environment == "prod" && db.Dialector.Name() == "sqlite" && !database.HasFTS5(db) {
+		return errors.New("SQLite was built without FTS5, build with -tags sqlite_fts5")
+	}
 
-	r.Use(gin.Logger())
-	r.Use(gin.Recovery())
+	if cfg.DBAutoMigrate {
+		if err := migrator.Up(ctx, 0); err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
+		if err := database.EnsureSearchIndex(db); err != nil {
+			return fmt.Errorf("failed to create search index: %w", err)
+		}
+	} else if err := migrator.Verify(ctx); err != nil {
+		return err
+	}
+
+	if err := database.SeedData(db); err != nil {
+		return fmt.Errorf("failed to seed data: %w", err)
+	}
+
+	if err := database.SeedAdminUser(db); err != nil {
+		return fmt.Errorf("failed to create admin user: %w", err)
+	}
+
+	return nil
+}
+
+// shutdown stops accepting traffic, drains in-flight requests and then
+// releases dependencies in reverse order of creation.
+func shutdown(cfg *config.Config, srv *http.Server, healthHandler *handlers.HealthHandler, jobs *background.Group, db *gorm.DB, rdb *redis.Client, shutdownTracing func(context.Context) error) {
+	healthHandler.SetShuttingDown()
+
+	if cfg.ShutdownDelay > 0 {
+		log.Printf("Waiting %s for load balancers to stop routing traffic", cfg.ShutdownDelay)
+		time.Sleep(cfg.ShutdownDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	log.Println("Draining in-flight requests...")
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Println("failed to drain requests:", err)
+	}
+
+	log.Println("Waiting for background jobs...")
+	if err := jobs.Wait(ctx); err != nil {
+		log.Println("background jobs did not finish:", err)
+	}
+
+	if err := rdb.Close(); err != nil {
+		log.Println("failed to close redis client:", err)
+	}
 
-	setupRoutes(r, productHandler, authHandler, authService)
+	if err := database.Close(db); err != nil {
+		log.Println("failed to close database:", err)
+	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	if err := shutdownTracing(ctx); err != nil {
+		log.Println("failed to flush traces:", err)
 	}
 
-	log.Printf("Server starting at http://localhost:%s", port)
-	log.Fatal(r.Run(":" + port))
+	log.Println("Server stopped")
 }
 
-func setupRoutes(r *gin.Engine, productHandler *handlers.ProductHandler, authHandler *handlers.AuthHandler, authService *services.AuthService) {
+func setupRoutes(r *gin.Engine, productHandler *handlers.ProductHandler, categoryHandler *handlers.CategoryHandler, mediaHandler *handlers.MediaHandler, pricingHandler *handlers.PricingHandler, productImportHandler *handlers.ProductImportHandler, catalogExportHandler *handlers.CatalogExportHandler, reviewHandler *handlers.ReviewHandler, wishlistHandler *handlers.WishlistHandler, alertHandler *handlers.AlertHandler, couponHandler *handlers.CouponHandler, shippingHandler *handlers.ShippingHandler, addressHandler *handlers.AddressHandler, walletHandler *handlers.WalletHandler, loyaltyHandler *handlers.LoyaltyHandler, returnHandler *handlers.ReturnHandler, authHandler *handlers.AuthHandler, userHandler *handlers.UserHandler, healthHandler *handlers.HealthHandler, authService *services.AuthService) {
 	root := r.Group("/")
 	{
-		root.GET("/health", func(c *gin.Context) {
-			c.JSON(200, gin.H{
-				"status":    "OK",
-				"service":   "americanas-loja-api",
-				"version":   "1.0.0",
-				"timestamp": time.Now().Format(time.RFC3339),
-			})
-		})
+		root.GET("/health", healthHandler.Livez)
+		root.GET("/livez", healthHandler.Livez)
+		root.GET("/readyz", healthHandler.Readyz)
+
+		root.GET("/media/*key", mediaHandler.ServeMedia)
 
 		root.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	}
@@ -116,6 +339,32 @@ func setupRoutes(r *gin.Engine, productHandler *handlers.ProductHandler, authHan
 			user.GET("/profile", authHandler.GetProfile)
 			user.POST("/change-password", authHandler.ChangePassword)
 			user.POST("/logout", authHandler.Logout)
+
+			user.GET("/wishlist", wishlistHandler.ListWishlists)
+			user.POST("/wishlist", wishlistHandler.CreateWishlist)
+			user.GET("/wishlist/:id", wishlistHandler.GetWishlist)
+			user.PUT("/wishlist/:id", wishlistHandler.UpdateWishlist)
+			user.DELETE("/wishlist/:id", wishlistHandler.DeleteWishlist)
+			user.POST("/wishlist/:id/items", wishlistHandler.AddWishlistItem)
+			user.DELETE("/wishlist/:id/items/:productId", wishlistHandler.RemoveWishlistItem)
+
+			user.GET("/addresses", addressHandler.ListAddresses)
+			user.POST("/addresses", addressHandler.CreateAddress)
+			user.GET("/addresses/:id", addressHandler.GetAddress)
+			user.PUT("/addresses/:id", addressHandler.UpdateAddress)
+			user.DELETE("/addresses/:id", addressHandler.DeleteAddress)
+
+			user.GET("/wallet", walletHandler.GetWallet)
+			user.GET("/wallet/transactions", walletHandler.ListWalletTransactions)
+			user.POST("/wallet/gift-cards", walletHandler.RedeemGiftCard)
+
+			user.GET("/loyalty", loyaltyHandler.GetLoyalty)
+			user.GET("/loyalty/history", loyaltyHandler.ListLoyaltyHistory)
+
+			user.GET("/returns", returnHandler.ListMyReturns)
+			user.POST("/returns", returnHandler.CreateReturn)
+			user.GET("/returns/:id", returnHandler.GetMyReturn)
+			user.POST("/returns/:id/photos", returnHandler.UploadReturnPhoto)
 		}
 
 		// Public Product routes
@@ -124,6 +373,12 @@ func setupRoutes(r *gin.Engine, productHandler *handlers.ProductHandler, authHan
 		{
 			public.GET("/products", productHandler.GetProducts)
 			public.GET("/products/:id", productHandler.GetProduct)
+			public.GET("/products/:id/images", mediaHandler.ListImages)
+			public.GET("/products/:id/reviews", reviewHandler.ListReviews)
+			public.GET("/categories", categoryHandler.GetCategories)
+			public.GET("/wishlists/shared/:token", wishlistHandler.GetSharedWishlist)
+			public.POST("/shipping/quote", shippingHandler.QuoteShipping)
+			public.GET("/cep/:cep", addressHandler.LookupCEP)
 		}
 
 		// Protected routes (Creation/Update) Products (Login is needed)
@@ -132,6 +387,11 @@ func setupRoutes(r *gin.Engine, productHandler *handlers.ProductHandler, authHan
 		{
 			protected.POST("/products", productHandler.CreateProduct)
 			protected.PUT("/products/:id", productHandler.UpdateProduct)
+
+			protected.POST("/products/:id/reviews", reviewHandler.CreateReview)
+			protected.POST("/products/:id/notify-me", wishlistHandler.NotifyWhenInStock)
+			protected.DELETE("/products/:id/notify-me", wishlistHandler.CancelStockNotification)
+			protected.POST("/coupons/validate", couponHandler.ValidateCoupon)
 		}
 
 		// Admin only routes
@@ -139,6 +399,21 @@ func setupRoutes(r *gin.Engine, productHandler *handlers.ProductHandler, authHan
 		adminProtected.Use(authMiddleware.RequireAdmin())
 		{
 			adminProtected.DELETE("/products/:id", productHandler.DeleteProduct)
+			adminProtected.POST("/products/:id/images", mediaHandler.UploadImage)
+			adminProtected.PUT("/products/:id/images/order", mediaHandler.ReorderImages)
+			adminProtected.DELETE("/products/:id/images/:imageId", mediaHandler.DeleteImage)
+			adminProtected.POST("/products/:id/sales", pricingHandler.CreateSale)
+			adminProtected.GET("/products/:id/sales", pricingHandler.ListSales)
+			adminProtected.DELETE("/products/:id/sales/:saleId", pricingHandler.DeleteSale)
+			adminProtected.GET("/products/:id/price-history", pricingHandler.PriceHistory)
+			adminProtected.POST("/admin/products/import", productImportHandler.ImportProducts)
+			adminProtected.GET("/admin/products/import/:id", productImportHandler.GetImportJob)
+			adminProtected.GET("/admin/products/export", catalogExportHandler.ExportProducts)
+
+			adminProtected.GET("/admin/categories", categoryHandler.GetAllCategories)
+			adminProtected.POST("/admin/categories", categoryHandler.CreateCategory)
+			adminProtected.PUT("/admin/categories/:id", categoryHandler.UpdateCategory)
+			adminProtected.DELETE("/admin/categories/:id", categoryHandler.DeleteCategory)
 
 			adminProtected.GET("/admin", func(c *gin.Context) {
 				c.JSON(200, gin.H{
@@ -147,12 +422,36 @@ func setupRoutes(r *gin.Engine, productHandler *handlers.ProductHandler, authHan
 				})
 			})
 
-			adminProtected.GET("/admin/users", func(c *gin.Context) {
-				c.JSON(200, gin.H{
-					"message": "User list - TODO",
-					"admin":   true,
-				})
-			})
+			adminProtected.GET("/admin/users", userHandler.ListUsers)
+
+			adminProtected.GET("/admin/reviews", reviewHandler.ListReviewQueue)
+			adminProtected.PUT("/admin/reviews/:id", reviewHandler.ModerateReview)
+			adminProtected.GET("/admin/alerts/low-stock", alertHandler.ListLowStockAlerts)
+
+			adminProtected.GET("/admin/coupons", couponHandler.ListCoupons)
+			adminProtected.POST("/admin/coupons", couponHandler.CreateCoupon)
+			adminProtected.GET("/admin/coupons/:id", couponHandler.GetCoupon)
+			adminProtected.PUT("/admin/coupons/:id", couponHandler.UpdateCoupon)
+			adminProtected.DELETE("/admin/coupons/:id", couponHandler.DeleteCoupon)
+
+			adminProtected.GET("/admin/gift-cards", walletHandler.ListGiftCards)
+			adminProtected.POST("/admin/gift-cards", walletHandler.CreateGiftCard)
+			adminProtected.GET("/admin/gift-cards/:id", walletHandler.GetGiftCard)
+			adminProtected.GET("/admin/users/:id/wallet", walletHandler.GetUserWallet)
+			adminProtected.GET("/admin/users/:id/wallet/transactions", walletHandler.ListUserWalletTransactions)
+			adminProtected.POST("/admin/users/:id/wallet/adjustments", walletHandler.AdjustWallet)
+
+			adminProtected.GET("/admin/loyalty/rules", loyaltyHandler.ListLoyaltyRules)
+			adminProtected.POST("/admin/loyalty/rules", loyaltyHandler.CreateLoyaltyRule)
+			adminProtected.PUT("/admin/loyalty/rules/:id", loyaltyHandler.UpdateLoyaltyRule)
+			adminProtected.DELETE("/admin/loyalty/rules/:id", loyaltyHandler.DeleteLoyaltyRule)
+
+			adminProtected.GET("/admin/returns", returnHandler.ListReturns)
+			adminProtected.GET("/admin/returns/:id", returnHandler.GetReturn)
+			adminProtected.POST("/admin/returns/:id/approve", returnHandler.ApproveReturn)
+			adminProtected.POST("/admin/returns/:id/reject", returnHandler.RejectReturn)
+			adminProtected.POST("/admin/returns/:id/receive", returnHandler.ReceiveReturn)
+			adminProtected.POST("/admin/returns/:id/refund", returnHandler.RefundReturn)
 
 			adminProtected.GET("/admin/stats", func(c *gin.Context) {
 				c.JSON(200, gin.H{
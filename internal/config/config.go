@@ -5,7 +5,9 @@ import (
 	"encoding/base64"
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
@@ -16,26 +18,225 @@ type Config struct {
 	DBPassword   string
 	DBName       string
 	DBPort       string
-	RedisURL     string
-	JWTSecret    string
-	Port         string
-	Environment  string
+	// DBAutoMigrate applies pending migrations on boot. When disabled the
+	// server only verifies the schema version and refuses to start if it
+	// is behind.
+	DBAutoMigrate bool
+	RedisURL      string
+	JWTSecret     string
+	Port          string
+	Environment   string
+
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	ShutdownDelay     time.Duration
+	ShutdownTimeout   time.Duration
+
+	RequestTimeout time.Duration
+	DBTimeout      time.Duration
+	CacheTimeout   time.Duration
+
+	HealthCheckTimeout time.Duration
+
+	OTelExporter    string
+	OTelEndpoint    string
+	OTelServiceName string
+	OTelFilePath    string
+
+	// APIURL is the public base URL of the API, used to build signed media
+	// URLs when files are served by the API itself.
+	APIURL string
+
+	StorageDriver      string
+	StorageLocalPath   string
+	StorageSigningKey  string
+	MediaMaxUploadSize int64
+	MediaURLTTL        time.Duration
+
+	// CursorSigningKey signs the pagination cursors handed to clients.
+	CursorSigningKey string
+
+	// PriceSchedulerInterval bounds how long the price scheduler sleeps
+	// between checks for sales scheduled by other instances.
+	PriceSchedulerInterval time.Duration
+
+	// ImportMaxSize bounds product import files; imports with more than
+	// ImportSyncMaxRows rows run as background jobs.
+	ImportMaxSize     int64
+	ImportSyncMaxRows int
+
+	// ExportProductURL is the storefront page linked from catalog feeds, with
+	// {id} and {sku} placeholders. Image URLs in feeds stay valid for
+	// ExportImageURLTTL.
+	ExportProductURL  string
+	ExportImageURLTTL time.Duration
+	ExportBatchSize   int
+
+	// NotifierDriver selects how customer notifications are delivered, a
+	// comma separated list of channels.
+	NotifierDriver string
+
+	SMTPHost string
+	SMTPPort int
+	SMTPUser string
+	SMTPPass string
+	SMTPFrom string
+
+	// NotifyWebhookURL receives notifications as JSON, signed with
+	// NotifyWebhookSecret when set.
+	NotifyWebhookURL    string
+	NotifyWebhookSecret string
+
+	// AlertChannels are the channels of the alerts sent to the store team,
+	// and AlertEmailTo the addresses of the email channel.
+	AlertChannels string
+	AlertEmailTo  string
+
+	// LowStockThreshold is the reorder threshold of products whose category
+	// tree sets none; stock is checked every LowStockCheckInterval.
+	LowStockThreshold     int
+	LowStockCheckInterval time.Duration
+
+	// ShippingCarriers are the carriers quoted for deliveries, a comma
+	// separated list. The table carrier reads ShippingTablePath, or the
+	// built in table when empty.
+	ShippingCarriers  string
+	ShippingTablePath string
+	// ShippingDefaultWeight (grams) and ShippingDefaultVolume (cm³) stand
+	// for each unit of products without their own measures.
+	ShippingDefaultWeight int
+	ShippingDefaultVolume int
+
+	// CEPResolver looks up addresses by CEP; the table resolver reads
+	// CEPTablePath, or the built in table when empty.
+	CEPResolver  string
+	CEPTablePath string
+
+	// OrderSource is the system orders are read from and refunded through;
+	// "none" until the store has a checkout.
+	OrderSource string
+	// ReturnWindow is how long after delivery customers can ask for a
+	// return.
+	ReturnWindow time.Duration
+
+	// DocumentEncryptionKey encrypts the CPF and CNPJ of users at rest. It
+	// is required in production and must stay the same across restarts:
+	// changing it makes the stored documents unreadable.
+	DocumentEncryptionKey string
+
+	// GiftCardValidity is how long gift cards issued without an expiry
+	// last; expired store credit is written off every WalletExpiryInterval.
+	GiftCardValidity     time.Duration
+	WalletExpiryInterval time.Duration
+
+	// LoyaltyDefaultRate is the cashback rate, in basis points, of items
+	// whose category tree sets none. Points stay pending for
+	// LoyaltyReleaseDelay and then last LoyaltyPointsValidity; both are
+	// moved every LoyaltyJobInterval.
+	LoyaltyDefaultRate    int
+	LoyaltyReleaseDelay   time.Duration
+	LoyaltyPointsValidity time.Duration
+	LoyaltyJobInterval    time.Duration
+
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	AWSRegion          string
+	AWSS3Bucket        string
+	// AWSS3Endpoint points to any S3 compatible service (MinIO, R2, ...).
+	AWSS3Endpoint string
+	AWSS3UseSSL   bool
 }
 
 func Load() *Config {
+	environment := getEnv("ENVIRONMENT", "dev")
+
 	config := &Config{
-		DBDriver:    getEnv("DB_DRIVER", "sqlite"),
-		DBHost:      getEnv("DB_HOST", "localhost"),
-		DBUser:      getEnv("DB_USER", "admin"),
-		DBPassword:  getDBPassword("password"),
-		DBName:      getEnv("DB_NAME", "store"),
-		DBPort:      getEnv("DB_PORT", "5432"),
-		RedisURL:    getEnv("REDIS_URL", "localhost:6379"),
-		JWTSecret:   getJWTSecret(),
-		Port:        getEnv("PORT", "8080"),
-		Environment: getEnv("ENVIRONMENT", "dev"),
+		DBSQlitePath:  getEnv("DB_SQLITE_PATH", "products.db"),
+		DBAutoMigrate: getEnvBool("DB_AUTO_MIGRATE", environment != "prod"),
+		DBDriver:      getEnv("DB_DRIVER", "sqlite"),
+		DBHost:        getEnv("DB_HOST", "localhost"),
+		DBUser:        getEnv("DB_USER", "admin"),
+		DBPassword:    getDBPassword("password"),
+		DBName:        getEnv("DB_NAME", "store"),
+		DBPort:        getEnv("DB_PORT", "5432"),
+		RedisURL:      getEnv("REDIS_URL", "localhost:6379"),
+		JWTSecret:     getJWTSecret(),
+		Port:          getEnv("PORT", "8080"),
+		Environment:   environment,
+
+		ReadTimeout:       getEnvDuration("HTTP_READ_TIMEOUT", 15*time.Second),
+		ReadHeaderTimeout: getEnvDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		WriteTimeout:      getEnvDuration("HTTP_WRITE_TIMEOUT", 35*time.Second),
+		IdleTimeout:       getEnvDuration("HTTP_IDLE_TIMEOUT", 60*time.Second),
+		MaxHeaderBytes:    getEnvInt("HTTP_MAX_HEADER_BYTES", 1<<20),
+		ShutdownDelay:     getEnvDuration("SHUTDOWN_DELAY", 0),
+		ShutdownTimeout:   getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+
+		RequestTimeout: getEnvDuration("REQUEST_TIMEOUT", 30*time.Second),
+		DBTimeout:      getEnvDuration("DB_TIMEOUT", 5*time.Second),
+		CacheTimeout:   getEnvDuration("CACHE_TIMEOUT", 500*time.Millisecond),
+
+		HealthCheckTimeout: getEnvDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+
+		OTelExporter:    getEnv("OTEL_EXPORTER", defaultOTelExporter()),
+		OTelEndpoint:    getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4318"),
+		OTelServiceName: getEnv("OTEL_SERVICE_NAME", "americanas-loja-api"),
+		OTelFilePath:    getEnv("OTEL_FILE_PATH", "traces.json"),
 	}
 
+	config.APIURL = strings.TrimSuffix(getEnv("API_URL", "http://localhost:"+config.Port), "/")
+	config.StorageDriver = getEnv("STORAGE_DRIVER", "local")
+	config.StorageLocalPath = getEnv("STORAGE_LOCAL_PATH", "uploads")
+	config.StorageSigningKey = getSecret("STORAGE_SIGNING_KEY", environment, "")
+	config.MediaMaxUploadSize = int64(getEnvInt("MEDIA_MAX_UPLOAD_SIZE", 5<<20))
+	config.MediaURLTTL = getEnvDuration("MEDIA_URL_TTL", time.Hour)
+	config.CursorSigningKey = getSecret("CURSOR_SIGNING_KEY", environment, "")
+	config.PriceSchedulerInterval = getEnvDuration("PRICE_SCHEDULER_INTERVAL", time.Minute)
+	config.ImportMaxSize = int64(getEnvInt("IMPORT_MAX_SIZE", 10<<20))
+	config.ImportSyncMaxRows = getEnvInt("IMPORT_SYNC_MAX_ROWS", 100)
+	config.ExportProductURL = getEnv("EXPORT_PRODUCT_URL", config.APIURL+"/api/v1/products/{id}")
+	config.ExportImageURLTTL = getEnvDuration("EXPORT_IMAGE_URL_TTL", 7*24*time.Hour)
+	config.ExportBatchSize = getEnvInt("EXPORT_BATCH_SIZE", 500)
+	config.NotifierDriver = getEnv("NOTIFIER_DRIVER", "log")
+	config.SMTPHost = getEnv("SMTP_HOST", "")
+	config.SMTPPort = getEnvInt("SMTP_PORT", 587)
+	config.SMTPUser = getEnv("SMTP_USER", "")
+	config.SMTPPass = getEnv("SMTP_PASS", "")
+	config.SMTPFrom = getEnv("SMTP_FROM", config.SMTPUser)
+	config.NotifyWebhookURL = getEnv("NOTIFY_WEBHOOK_URL", "")
+	config.NotifyWebhookSecret = getEnv("NOTIFY_WEBHOOK_SECRET", "")
+	config.AlertChannels = getEnv("ALERT_CHANNELS", "log")
+	config.AlertEmailTo = getEnv("ALERT_EMAIL_TO", "")
+	config.LowStockThreshold = getEnvInt("LOW_STOCK_THRESHOLD", 5)
+	config.LowStockCheckInterval = getEnvDuration("LOW_STOCK_CHECK_INTERVAL", 15*time.Minute)
+	config.ShippingCarriers = getEnv("SHIPPING_CARRIERS", "table")
+	config.ShippingTablePath = getEnv("SHIPPING_TABLE_PATH", "")
+	config.ShippingDefaultWeight = getEnvInt("SHIPPING_DEFAULT_WEIGHT_GRAMS", 1000)
+	config.ShippingDefaultVolume = getEnvInt("SHIPPING_DEFAULT_VOLUME_CM3", 3000)
+	config.CEPResolver = getEnv("CEP_RESOLVER", "table")
+	config.CEPTablePath = getEnv("CEP_TABLE_PATH", "")
+	// A random key would make the stored documents unreadable after every
+	// restart, so dev falls back to a fixed one instead.
+	config.DocumentEncryptionKey = getSecret("DOCUMENT_ENCRYPTION_KEY", environment, devDocumentEncryptionKey)
+	config.GiftCardValidity = getEnvDuration("GIFT_CARD_VALIDITY", 365*24*time.Hour)
+	config.WalletExpiryInterval = getEnvDuration("WALLET_EXPIRY_INTERVAL", time.Hour)
+	config.LoyaltyDefaultRate = getEnvInt("LOYALTY_DEFAULT_RATE", 100)
+	config.LoyaltyReleaseDelay = getEnvDuration("LOYALTY_RELEASE_DELAY", 30*24*time.Hour)
+	config.LoyaltyPointsValidity = getEnvDuration("LOYALTY_POINTS_VALIDITY", 180*24*time.Hour)
+	config.LoyaltyJobInterval = getEnvDuration("LOYALTY_JOB_INTERVAL", time.Hour)
+	config.OrderSource = getEnv("ORDER_SOURCE", "none")
+	config.ReturnWindow = getEnvDuration("RETURN_WINDOW", 7*24*time.Hour)
+
+	config.AWSAccessKeyID = getEnv("AWS_ACCESS_KEY_ID", "")
+	config.AWSSecretAccessKey = getEnv("AWS_SECRET_ACCESS_KEY", "")
+	config.AWSRegion = getEnv("AWS_REGION", "us-east-1")
+	config.AWSS3Bucket = getEnv("AWS_S3_BUCKET", "")
+	config.AWSS3Endpoint = getEnv("AWS_S3_ENDPOINT", "s3.amazonaws.com")
+	config.AWSS3UseSSL = getEnvBool("AWS_S3_USE_SSL", true)
+
 	validateConfig(config)
 
 	return config
@@ -95,6 +296,42 @@ func getJWTSecret() string {
 	return secret
 }
 
+// devDocumentEncryptionKey encrypts documents in dev when no key is set.
+// It is public, so production refuses to start without a key of its own.
+const devDocumentEncryptionKey = "dev-only-document-encryption-key"
+
+// getSecret reads the secret from its Docker secret file, named after it in
+// lower case (/run/secrets/cursor_signing_key), or from the environment.
+// Secrets are never derived from one another, so rotating JWT_SECRET leaves
+// signed URLs, cursors and encrypted documents alone. Production requires
+// every secret; in dev a missing one falls back to devDefault, or to a
+// random secret for each boot when devDefault is empty.
+func getSecret(key, environment, devDefault string) string {
+	secretPath := "/run/secrets/" + strings.ToLower(key)
+	if _, err := os.Stat(secretPath); err == nil {
+		secretBytes, err := os.ReadFile(secretPath)
+		if err != nil {
+			log.Fatalf("Failed to read %s file: %v", key, err)
+		}
+		return strings.TrimSpace(string(secretBytes))
+	}
+
+	if secret := os.Getenv(key); secret != "" {
+		return secret
+	}
+
+	if environment == "prod" {
+		log.Fatalf("%s is required on production", key)
+	}
+
+	if devDefault != "" {
+		log.Printf("Using the dev default %s; do not use it with real data", key)
+		return devDefault
+	}
+	log.Printf("Generating a random %s in dev environment", key)
+	return generateRandomSecret()
+}
+
 func generateRandomSecret() string {
 	bytes := make([]byte, 64)
 	if _, err := rand.Read(bytes); err != nil {
@@ -121,9 +358,58 @@ func validateConfig(config *Config) {
 	}
 }
 
+// defaultOTelExporter sends spans over OTLP when a collector is configured
+// and leaves tracing off otherwise. The stdout and file exporters are only
+// for local use and must be asked for: the file grows without limit.
+func defaultOTelExporter() string {
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" {
+		return "otlp"
+	}
+	return "none"
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("%s must be a valid duration (e.g. 5s, 500ms): %v", key, err)
+	}
+	return duration
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("%s must be an integer: %v", key, err)
+	}
+	return number
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("%s must be a boolean: %v", key, err)
+	}
+	return enabled
+}
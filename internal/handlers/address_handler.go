@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/services"
+	"github.com/Code-Aether/americanas-loja-api/internal/types"
+	"github.com/Code-Aether/americanas-loja-api/pkg/br"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type AddressHandler struct {
+	addressService *services.AddressService
+	validator      *validator.Validate
+}
+
+func NewAddressHandler(addressService *services.AddressService) *AddressHandler {
+	return &AddressHandler{
+		addressService: addressService,
+		validator:      utils.NewValidator(),
+	}
+}
+
+// ListAddresses godoc
+// @Summary      Listar endereços
+// @Description  Retorna os endereços de entrega do usuário, o padrão primeiro (requer autenticação)
+// @Tags         addresses
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Success      200 {object} utils.Response{data=[]models.Address} "Endereços"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /user/addresses [get]
+func (h *AddressHandler) ListAddresses(c *gin.Context) {
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	addresses, err := h.addressService.List(c.Request.Context(), user.ID)
+	if err != nil {
+		h.errorResponse(c, "LIST_ADDRESSES_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "ADDRESSES_LISTED_SUCCESS", addresses)
+}
+
+// CreateAddress godoc
+// @Summary      Cadastrar endereço
+// @Description  Adiciona um endereço de entrega. O CEP precisa existir e ser da UF informada; o primeiro endereço é o padrão, e is_default true torna o novo endereço o padrão (requer autenticação)
+// @Tags         addresses
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        address body types.AddressRequest true "Dados do endereço"
+// @Success      201 {object} utils.Response{data=models.Address} "Endereço cadastrado com sucesso"
+// @Failure      400 {object} utils.Response "Dados inválidos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      422 {object} utils.Response "CEP inexistente ou de outra UF"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /user/addresses [post]
+func (h *AddressHandler) CreateAddress(c *gin.Context) {
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	var req types.AddressRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	address := types.AddressFromRequest(&req)
+	if err := h.addressService.Create(c.Request.Context(), user.ID, address); err != nil {
+		h.errorResponse(c, "ERROR_CREATING_ADDRESS", err)
+		return
+	}
+
+	utils.SuccessResponseWithStatus(c, http.StatusCreated, "ADDRESS_CREATED_WITH_SUCCESS", address)
+}
+
+// GetAddress godoc
+// @Summary      Obter endereço
+// @Description  Retorna um endereço do usuário (requer autenticação)
+// @Tags         addresses
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID do endereço" example(1)
+// @Success      200 {object} utils.Response{data=models.Address} "Endereço"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      404 {object} utils.Response "Endereço não encontrado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /user/addresses/{id} [get]
+func (h *AddressHandler) GetAddress(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	address, err := h.addressService.Get(c.Request.Context(), user.ID, uint(id))
+	if err != nil {
+		h.errorResponse(c, "GET_ADDRESS_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "ADDRESS_SUCCESS", address)
+}
+
+// UpdateAddress godoc
+// @Summary      Atualizar endereço
+// @Description  Substitui os dados do endereço. O endereço padrão continua padrão até outro ser marcado com is_default true (requer autenticação)
+// @Tags         addresses
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID do endereço" example(1)
+// @Param        address body types.AddressRequest true "Dados do endereço"
+// @Success      200 {object} utils.Response{data=models.Address} "Endereço atualizado com sucesso"
+// @Failure      400 {object} utils.Response "Dados inválidos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      404 {object} utils.Response "Endereço não encontrado"
+// @Failure      422 {object} utils.Response "CEP inexistente ou de outra UF"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /user/addresses/{id} [put]
+func (h *AddressHandler) UpdateAddress(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	var req types.AddressRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	address := types.AddressFromRequest(&req)
+	if err := h.addressService.Update(c.Request.Context(), user.ID, uint(id), address); err != nil {
+		h.errorResponse(c, "ERROR_UPDATING_ADDRESS", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "ADDRESS_UPDATED_WITH_SUCCESS", address)
+}
+
+// DeleteAddress godoc
+// @Summary      Remover endereço
+// @Description  Remove o endereço; se era o padrão, o endereço cadastrado mais recentemente passa a ser o padrão (requer autenticação)
+// @Tags         addresses
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID do endereço" example(1)
+// @Success      200 {object} utils.Response "Endereço removido com sucesso"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      404 {object} utils.Response "Endereço não encontrado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /user/addresses/{id} [delete]
+func (h *AddressHandler) DeleteAddress(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	if err := h.addressService.Delete(c.Request.Context(), user.ID, uint(id)); err != nil {
+		h.errorResponse(c, "ERROR_DELETING_ADDRESS", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "ADDRESS_DELETED_WITH_SUCCESS", nil)
+}
+
+// LookupCEP godoc
+// @Summary      Consultar CEP
+// @Description  Retorna o que se sabe do endereço do CEP, para preencher o formulário de endereço. CEPs de logradouro trazem rua e bairro; CEPs gerais, apenas cidade e UF
+// @Tags         addresses
+// @Accept       json
+// @Produce      json
+// @Param        cep path string true "CEP, com ou sem hífen" example(01310-100)
+// @Success      200 {object} utils.Response{data=cep.Address} "Endereço do CEP"
+// @Failure      400 {object} utils.Response "CEP inválido"
+// @Failure      404 {object} utils.Response "CEP não encontrado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /cep/{cep} [get]
+func (h *AddressHandler) LookupCEP(c *gin.Context) {
+	address, err := h.addressService.LookupCEP(c.Request.Context(), c.Param("cep"))
+	if err != nil {
+		if errors.Is(err, services.ErrCEPNotFound) {
+			utils.NotFoundResponse(c, "CEP_NOT_FOUND", err)
+			return
+		}
+		h.errorResponse(c, "LOOKUP_CEP_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "CEP_SUCCESS", address)
+}
+
+func (h *AddressHandler) errorResponse(c *gin.Context, message string, err error) {
+	if utils.ContextErrorResponse(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, services.ErrAddressNotFound):
+		utils.NotFoundResponse(c, "ADDRESS_NOT_FOUND", err)
+	case errors.Is(err, br.ErrInvalidCEP):
+		utils.BadRequestResponse(c, "INVALID_CEP", err)
+	case errors.Is(err, br.ErrInvalidUF):
+		utils.BadRequestResponse(c, "INVALID_UF", err)
+	case errors.Is(err, services.ErrCEPNotFound):
+		utils.ErrorResponse(c, http.StatusUnprocessableEntity, "CEP_NOT_FOUND", err)
+	case errors.Is(err, services.ErrAddressStateMismatch):
+		utils.ErrorResponse(c, http.StatusUnprocessableEntity, "CEP_STATE_MISMATCH", err)
+	default:
+		utils.InternalServerErrorResponse(c, message, err)
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"github.com/Code-Aether/americanas-loja-api/internal/services"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type AlertHandler struct {
+	lowStockService *services.LowStockService
+}
+
+func NewAlertHandler(lowStockService *services.LowStockService) *AlertHandler {
+	return &AlertHandler{
+		lowStockService: lowStockService,
+	}
+}
+
+// ListLowStockAlerts godoc
+// @Summary      Alertas de estoque baixo
+// @Description  Lista os produtos que chegaram ao limite de reposição e ainda não foram repostos, os mais antigos primeiro. Cada alerta traz o estoque e o limite do momento do alerta e o produto com o estoque atual (apenas admin)
+// @Tags         alerts
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Success      200 {object} utils.Response{data=[]models.LowStockAlert} "Alertas abertos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/alerts/low-stock [get]
+func (h *AlertHandler) ListLowStockAlerts(c *gin.Context) {
+	alerts, err := h.lowStockService.ListOpen(c.Request.Context())
+	if err != nil {
+		if utils.ContextErrorResponse(c, err) {
+			return
+		}
+		utils.InternalServerErrorResponse(c, "LIST_LOW_STOCK_ALERTS_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "LOW_STOCK_ALERTS_SUCCESS", alerts)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -21,7 +22,7 @@ type AuthHandler struct {
 func NewAuthHandler(authService *services.AuthService) *AuthHandler {
 	return &AuthHandler{
 		authService: authService,
-		validator:   validator.New(),
+		validator:   utils.NewValidator(),
 	}
 }
 
@@ -34,7 +35,7 @@ func NewAuthHandler(authService *services.AuthService) *AuthHandler {
 // @Param        user body types.RegisterRequest true "user data"
 // @Success      201  {object} utils.Response{data=types.AuthResponse} "user created with success"
 // @Failure      400  {object} utils.Response "invalid data"
-// @Failure      409  {object} utils.Response "email already exists"
+// @Failure      409  {object} utils.Response "email or document already exists"
 // @Failure      500  {object} utils.Response "internal error"
 // @Router       /auth/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
@@ -51,19 +52,25 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	user := &models.User{
-		Name:     req.Name,
-		Email:    req.Email,
-		Password: req.Password,
-		Role:     "user",
-		Active:   true,
+		Name:         req.Name,
+		Email:        req.Email,
+		Password:     req.Password,
+		Role:         "user",
+		Active:       true,
+		DocumentType: req.DocumentType,
+		Document:     models.Document(req.Document),
 	}
 
-	token, err := h.authService.Register(user)
+	token, err := h.authService.Register(c.Request.Context(), user)
 	if err != nil {
 		if err.Error() == "user already exists" {
 			utils.ErrorResponse(c, http.StatusConflict, "email already exists", err)
 			return
 		}
+		if errors.Is(err, services.ErrDocumentAlreadyExists) {
+			utils.ErrorResponse(c, http.StatusConflict, "document already exists", err)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
@@ -100,7 +107,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, user, err := h.authService.Login(req)
+	token, user, err := h.authService.Login(c.Request.Context(), req)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusUnauthorized, "invalid user or password", err)
 		return
@@ -159,7 +166,7 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 
 	userModel := user.(*models.User)
 
-	err := h.authService.ChangePassword(userModel.ID, req.OldPassword, req.NewPassword)
+	err := h.authService.ChangePassword(c.Request.Context(), userModel.ID, req.OldPassword, req.NewPassword)
 	if err != nil {
 		utils.BadRequestResponse(c, err.Error(), nil)
 		return
@@ -192,7 +199,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	newToken, user, err := h.authService.RefreshToken(tokenString)
+	newToken, user, err := h.authService.RefreshToken(c.Request.Context(), tokenString)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusUnauthorized, "invalid or expired token", err)
 		return
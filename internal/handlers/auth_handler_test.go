@@ -2,7 +2,9 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"testing"
 
@@ -144,6 +146,71 @@ func TestAuthHandler_Register(t *testing.T) {
 		testutils.AssertErrorResponse(t, w, http.StatusConflict)
 	})
 
+	t.Run("✅ Registro com CNPJ mascarado na resposta", func(t *testing.T) {
+		c, w := testutils.MockGinContext()
+
+		registerData := types.RegisterRequest{
+			Name:         "Loja Exemplo",
+			Email:        "loja@test.com",
+			Password:     "password123",
+			DocumentType: "cnpj",
+			Document:     "11.222.333/0001-81",
+		}
+
+		req, err := testutils.MockJSONRequest("POST", "/auth/register", registerData)
+		require.NoError(t, err)
+		c.Request = req
+
+		authHandler.Register(c)
+
+		require.Equal(t, http.StatusCreated, w.Code, "Status deve ser 201 Created")
+		assert.NotContains(t, w.Body.String(), "11222333000181", "Documento não deve aparecer aberto")
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		user := response["data"].(map[string]interface{})["user"].(map[string]interface{})
+		assert.Equal(t, "cnpj", user["document_type"])
+		assert.Equal(t, "**.222.333/0001-**", user["document"])
+	})
+
+	t.Run("❌ Registro com documento inválido ou duplicado", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			documentType   string
+			document       string
+			expectedStatus int
+		}{
+			{"CPF com dígito errado", "cpf", "529.982.247-24", http.StatusBadRequest},
+			{"Tipo sem número", "cpf", "", http.StatusBadRequest},
+			{"Número sem tipo", "", "52998224725", http.StatusBadRequest},
+			{"Tipo desconhecido", "rg", "52998224725", http.StatusBadRequest},
+			{"CPF informado como CNPJ", "cnpj", "52998224725", http.StatusBadRequest},
+			{"CNPJ já cadastrado", "cnpj", "11222333000181", http.StatusConflict},
+		}
+
+		for i, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				c, w := testutils.MockGinContext()
+
+				registerData := types.RegisterRequest{
+					Name:         "Test User",
+					Email:        fmt.Sprintf("document%d@test.com", i),
+					Password:     "password123",
+					DocumentType: tt.documentType,
+					Document:     tt.document,
+				}
+				req, err := testutils.MockJSONRequest("POST", "/auth/register", registerData)
+				require.NoError(t, err)
+				c.Request = req
+
+				authHandler.Register(c)
+
+				assert.Equal(t, tt.expectedStatus, w.Code, "Status code deve estar correto")
+				testutils.AssertErrorResponse(t, w, tt.expectedStatus)
+			})
+		}
+	})
+
 	t.Run("❌ Registro com JSON inválido", func(t *testing.T) {
 		c, w := testutils.MockGinContext()
 
@@ -174,7 +241,7 @@ func TestAuthHandler_Login(t *testing.T) {
 		Role:     "user",
 		Active:   true,
 	}
-	_, err := authService.Register(user)
+	_, err := authService.Register(context.Background(), user)
 	require.NoError(t, err)
 
 	t.Run("✅ Login com sucesso", func(t *testing.T) {
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/services"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// exportContentTypes maps every export format to its Content-Type.
+var exportContentTypes = map[string]string{
+	models.ExportFormatCSV:      "text/csv; charset=utf-8",
+	models.ExportFormatNDJSON:   "application/x-ndjson",
+	models.ExportFormatMerchant: "application/xml; charset=utf-8",
+}
+
+type CatalogExportHandler struct {
+	exportService *services.CatalogExportService
+}
+
+func NewCatalogExportHandler(exportService *services.CatalogExportService) *CatalogExportHandler {
+	return &CatalogExportHandler{
+		exportService: exportService,
+	}
+}
+
+// ExportProducts godoc
+// @Summary      Exportar catálogo
+// @Description  Gera o feed dos produtos ativos, com categoria, preço, preço promocional, estoque e imagens, em CSV, NDJSON ou no XML do Google Merchant Center. Produtos com variantes viram um item por variante, agrupados pelo SKU do produto. O feed é transmitido enquanto é gerado, sem limite de tamanho (apenas admin)
+// @Tags         products
+// @Produce      text/csv,application/x-ndjson,application/xml
+// @Security     Bearer
+// @Param        format        query string false "Formato do feed" Enums(csv, ndjson, xml) default(csv)
+// @Param        updated_since query string false "Exporta apenas os produtos alterados desde a data (RFC 3339)" example(2025-01-01T00:00:00Z)
+// @Success      200 {file} file "Feed do catálogo"
+// @Failure      400 {object} utils.Response "Data inválida"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      415 {object} utils.Response "Formato não suportado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/products/export [get]
+func (h *CatalogExportHandler) ExportProducts(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", models.ExportFormatCSV))
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		h.errorResponse(c, "UNSUPPORTED_EXPORT_FORMAT", services.ErrUnsupportedExportFormat)
+		return
+	}
+
+	var since *time.Time
+	if value := c.Query("updated_since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			utils.BadRequestResponse(c, "INVALID_UPDATED_SINCE", err)
+			return
+		}
+		since = &parsed
+	}
+
+	// The feed takes as long as the catalog is large, so the server write
+	// timeout does not apply to it.
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		catalogExportHandlerLog("Could not clear the write deadline: %v", err)
+	}
+
+	filename := fmt.Sprintf("catalog-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	count, err := h.exportService.Export(c.Request.Context(), c.Writer, format, since)
+	if err != nil {
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			h.errorResponse(c, "ERROR_EXPORTING_PRODUCTS", err)
+			return
+		}
+
+		// Part of the feed is already sent; dropping the connection makes
+		// the client see a truncated download instead of a complete feed.
+		catalogExportHandlerLog("Export failed after %d items: %v", count, err)
+		if conn, _, hijackErr := c.Writer.Hijack(); hijackErr == nil {
+			conn.Close()
+		}
+		c.Abort()
+		return
+	}
+
+	catalogExportHandlerLog("Exported %d items as %s", count, format)
+}
+
+func (h *CatalogExportHandler) errorResponse(c *gin.Context, message string, err error) {
+	if utils.ContextErrorResponse(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, services.ErrUnsupportedExportFormat):
+		utils.ErrorResponse(c, http.StatusUnsupportedMediaType, "UNSUPPORTED_EXPORT_FORMAT", err)
+	default:
+		utils.InternalServerErrorResponse(c, message, err)
+	}
+}
+
+func catalogExportHandlerLog(format string, v ...any) {
+	prefix := "[CATALOG_EXPORT_HANDLER]"
+	message := fmt.Sprintf(format, v...)
+	log.Printf("%s %s", prefix, message)
+}
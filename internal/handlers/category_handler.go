@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/services"
+	"github.com/Code-Aether/americanas-loja-api/internal/types"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type CategoryHandler struct {
+	categoryService *services.CategoryService
+	validator       *validator.Validate
+}
+
+func NewCategoryHandler(categoryService *services.CategoryService) *CategoryHandler {
+	return &CategoryHandler{
+		categoryService: categoryService,
+		validator:       utils.NewValidator(),
+	}
+}
+
+// GetCategories godoc
+// @Summary      Listar categorias
+// @Description  Retorna a árvore de categorias ativas, ordenada por sort_order
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} utils.Response{data=[]models.Category} "Árvore de categorias"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /categories [get]
+func (h *CategoryHandler) GetCategories(c *gin.Context) {
+	tree, err := h.categoryService.GetTree(c.Request.Context())
+	if err != nil {
+		if utils.ContextErrorResponse(c, err) {
+			return
+		}
+		utils.InternalServerErrorResponse(c, "LIST_CATEGORIES_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "CATEGORIES_LISTED_SUCCESS", tree)
+}
+
+// GetAllCategories godoc
+// @Summary      Listar todas as categorias
+// @Description  Retorna a árvore completa de categorias, incluindo as inativas (apenas admins)
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Success      200 {object} utils.Response{data=[]models.Category} "Árvore de categorias"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/categories [get]
+func (h *CategoryHandler) GetAllCategories(c *gin.Context) {
+	tree, err := h.categoryService.GetFullTree(c.Request.Context())
+	if err != nil {
+		if utils.ContextErrorResponse(c, err) {
+			return
+		}
+		utils.InternalServerErrorResponse(c, "LIST_CATEGORIES_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "CATEGORIES_LISTED_SUCCESS", tree)
+}
+
+// CreateCategory godoc
+// @Summary      Criar categoria
+// @Description  Cria uma categoria, opcionalmente abaixo de outra (apenas admins)
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        category body types.CreateCategoryRequest true "Dados da categoria"
+// @Success      201 {object} utils.Response{data=models.Category} "Categoria criada com sucesso"
+// @Failure      400 {object} utils.Response "Dados inválidos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      409 {object} utils.Response "Slug já existe"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/categories [post]
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	var req types.CreateCategoryRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	input := services.CategoryInput{
+		Name:      &req.Name,
+		ParentID:  req.ParentID,
+		SortOrder: &req.SortOrder,
+		Active:    req.Active,
+
+		ReorderThreshold: req.ReorderThreshold,
+	}
+	if req.Slug != "" {
+		input.Slug = &req.Slug
+	}
+
+	category, err := h.categoryService.Create(c.Request.Context(), input)
+	if err != nil {
+		h.errorResponse(c, "ERROR_CREATING_CATEGORY", err)
+		return
+	}
+
+	categoryHandlerLog("Category %d (%s) created at %s", category.ID, category.Slug, category.Path)
+
+	utils.SuccessResponseWithStatus(c, http.StatusCreated, "CATEGORY_CREATED_WITH_SUCCESS", category)
+}
+
+// UpdateCategory godoc
+// @Summary      Atualizar categoria
+// @Description  Atualiza uma categoria; parent_id move a subárvore inteira e 0 a move para a raiz (apenas admins)
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID da categoria" example(1)
+// @Param        category body types.UpdateCategoryRequest true "Dados para atualização"
+// @Success      200 {object} utils.Response{data=models.Category} "Categoria atualizada com sucesso"
+// @Failure      400 {object} utils.Response "Dados inválidos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Categoria não encontrada"
+// @Failure      409 {object} utils.Response "Slug já existe"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/categories/{id} [put]
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	var req types.UpdateCategoryRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	category, err := h.categoryService.Update(c.Request.Context(), uint(id), services.CategoryInput{
+		Name:      req.Name,
+		Slug:      req.Slug,
+		ParentID:  req.ParentID,
+		SortOrder: req.SortOrder,
+		Active:    req.Active,
+
+		ReorderThreshold: req.ReorderThreshold,
+	})
+	if err != nil {
+		h.errorResponse(c, "UPDATE_CATEGORY_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "CATEGORY_UPDATED_WITH_SUCCESS", category)
+}
+
+// DeleteCategory godoc
+// @Summary      Deletar categoria
+// @Description  Remove uma categoria sem subcategorias nem produtos (apenas admins)
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID da categoria" example(1)
+// @Success      200 {object} utils.Response "Categoria deletada com sucesso"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Categoria não encontrada"
+// @Failure      409 {object} utils.Response "Categoria possui subcategorias ou produtos"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/categories/{id} [delete]
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	if err := h.categoryService.Delete(c.Request.Context(), uint(id)); err != nil {
+		h.errorResponse(c, "ERROR_DELETING_CATEGORY", err)
+		return
+	}
+
+	categoryHandlerLog("Category %d deleted", id)
+
+	utils.SuccessResponse(c, "CATEGORY_DELETED_WITH_SUCCESS", nil)
+}
+
+func (h *CategoryHandler) errorResponse(c *gin.Context, message string, err error) {
+	if utils.ContextErrorResponse(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, services.ErrCategoryNotFound):
+		utils.NotFoundResponse(c, "CATEGORY_NOT_FOUND", err)
+	case errors.Is(err, services.ErrParentCategoryNotFound):
+		utils.BadRequestResponse(c, "PARENT_CATEGORY_NOT_FOUND", err)
+	case errors.Is(err, services.ErrCategoryInvalidSlug):
+		utils.BadRequestResponse(c, "INVALID_SLUG", err)
+	case errors.Is(err, services.ErrCategoryCycle):
+		utils.BadRequestResponse(c, "INVALID_PARENT_CATEGORY", err)
+	case errors.Is(err, services.ErrCategorySlugTaken):
+		utils.ErrorResponse(c, http.StatusConflict, "SLUG_ALREADY_EXISTS", err)
+	case errors.Is(err, services.ErrCategoryNotEmpty):
+		utils.ErrorResponse(c, http.StatusConflict, "CATEGORY_NOT_EMPTY", err)
+	default:
+		utils.InternalServerErrorResponse(c, message, err)
+	}
+}
+
+func categoryHandlerLog(format string, v ...any) {
+	prefix := "[CATEGORY_HANDLER]"
+	message := fmt.Sprintf(format, v...)
+	log.Printf("%s %s", prefix, message)
+}
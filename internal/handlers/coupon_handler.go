@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/services"
+	"github.com/Code-Aether/americanas-loja-api/internal/types"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type CouponHandler struct {
+	couponService *services.CouponService
+	validator     *validator.Validate
+}
+
+func NewCouponHandler(couponService *services.CouponService) *CouponHandler {
+	return &CouponHandler{
+		couponService: couponService,
+		validator:     utils.NewValidator(),
+	}
+}
+
+// CreateCoupon godoc
+// @Summary      Criar cupom
+// @Description  Cria um cupom de desconto percentual (percentage), de valor fixo (fixed), de frete grátis (free_shipping) ou "leve X, ganhe Y" (buy_x_get_y). O código é guardado em maiúsculas; categorias valem para as subcategorias (apenas admin)
+// @Tags         coupons
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        coupon body types.CouponRequest true "Dados do cupom"
+// @Success      201 {object} utils.Response{data=models.Coupon} "Cupom criado com sucesso"
+// @Failure      400 {object} utils.Response "Dados inválidos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      409 {object} utils.Response "Já existe um cupom com esse código"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/coupons [post]
+func (h *CouponHandler) CreateCoupon(c *gin.Context) {
+	var req types.CouponRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	coupon := types.CouponFromRequest(&req)
+	if err := h.couponService.Create(c.Request.Context(), coupon); err != nil {
+		h.errorResponse(c, "ERROR_CREATING_COUPON", err)
+		return
+	}
+
+	couponHandlerLog("Coupon %s (%s) created with ID %d", coupon.Code, coupon.Type, coupon.ID)
+
+	utils.SuccessResponseWithStatus(c, http.StatusCreated, "COUPON_CREATED_WITH_SUCCESS", coupon)
+}
+
+// ListCoupons godoc
+// @Summary      Listar cupons
+// @Description  Retorna todos os cupons, dos mais recentes para os mais antigos, com o número de usos (apenas admin)
+// @Tags         coupons
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Success      200 {object} utils.Response{data=[]models.Coupon} "Cupons"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/coupons [get]
+func (h *CouponHandler) ListCoupons(c *gin.Context) {
+	coupons, err := h.couponService.List(c.Request.Context())
+	if err != nil {
+		h.errorResponse(c, "LIST_COUPONS_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "COUPONS_LISTED_SUCCESS", coupons)
+}
+
+// GetCoupon godoc
+// @Summary      Obter cupom
+// @Description  Retorna o cupom com o número de usos (apenas admin)
+// @Tags         coupons
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID do cupom" example(1)
+// @Success      200 {object} utils.Response{data=models.Coupon} "Cupom"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Cupom não encontrado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/coupons/{id} [get]
+func (h *CouponHandler) GetCoupon(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	coupon, err := h.couponService.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		h.errorResponse(c, "GET_COUPON_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "COUPON_SUCCESS", coupon)
+}
+
+// UpdateCoupon godoc
+// @Summary      Atualizar cupom
+// @Description  Substitui a definição do cupom; os usos já feitos são mantidos. Para encerrar um cupom já usado, desative-o com active false (apenas admin)
+// @Tags         coupons
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID do cupom" example(1)
+// @Param        coupon body types.CouponRequest true "Dados do cupom"
+// @Success      200 {object} utils.Response{data=models.Coupon} "Cupom atualizado com sucesso"
+// @Failure      400 {object} utils.Response "Dados inválidos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Cupom não encontrado"
+// @Failure      409 {object} utils.Response "Já existe um cupom com esse código"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/coupons/{id} [put]
+func (h *CouponHandler) UpdateCoupon(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	var req types.CouponRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	coupon := types.CouponFromRequest(&req)
+	if err := h.couponService.Update(c.Request.Context(), uint(id), coupon); err != nil {
+		h.errorResponse(c, "ERROR_UPDATING_COUPON", err)
+		return
+	}
+
+	couponHandlerLog("Coupon %d (%s) updated", coupon.ID, coupon.Code)
+
+	utils.SuccessResponse(c, "COUPON_UPDATED_WITH_SUCCESS", coupon)
+}
+
+// DeleteCoupon godoc
+// @Summary      Remover cupom
+// @Description  Remove um cupom que nunca foi usado; cupons usados ficam como registro dos descontos dados e devem ser desativados (apenas admin)
+// @Tags         coupons
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID do cupom" example(1)
+// @Success      200 {object} utils.Response "Cupom removido com sucesso"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Cupom não encontrado"
+// @Failure      409 {object} utils.Response "Cupom já foi usado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/coupons/{id} [delete]
+func (h *CouponHandler) DeleteCoupon(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	if err := h.couponService.Delete(c.Request.Context(), uint(id)); err != nil {
+		h.errorResponse(c, "ERROR_DELETING_COUPON", err)
+		return
+	}
+
+	couponHandlerLog("Coupon %d deleted", id)
+
+	utils.SuccessResponse(c, "COUPON_DELETED_WITH_SUCCESS", nil)
+}
+
+// ValidateCoupon godoc
+// @Summary      Validar cupom
+// @Description  Aplica o cupom aos itens informados, aos preços atuais, e retorna o desconto de cada item, sem usar o cupom. Um cupom que não vale para o carrinho retorna 422 com o motivo (requer autenticação)
+// @Tags         coupons
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        cart body types.ValidateCouponRequest true "Código e itens do carrinho"
+// @Success      200 {object} utils.Response{data=services.CouponEvaluation} "Desconto do cupom"
+// @Failure      400 {object} utils.Response "Dados inválidos ou item sem variante"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      404 {object} utils.Response "Cupom, produto ou variante não encontrado"
+// @Failure      422 {object} utils.Response "Cupom não vale para o carrinho"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /coupons/validate [post]
+func (h *CouponHandler) ValidateCoupon(c *gin.Context) {
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	var req types.ValidateCouponRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	evaluation, err := h.couponService.Evaluate(c.Request.Context(), user.ID, req.Code, cartItems(req.Items))
+	if err != nil {
+		h.errorResponse(c, "VALIDATE_COUPON_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "COUPON_VALID", evaluation)
+}
+
+func (h *CouponHandler) errorResponse(c *gin.Context, message string, err error) {
+	if utils.ContextErrorResponse(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, services.ErrCouponNotFound):
+		utils.NotFoundResponse(c, "COUPON_NOT_FOUND", err)
+	case errors.Is(err, services.ErrProductNotFound):
+		utils.NotFoundResponse(c, "PRODUCT_NOT_FOUND", err)
+	case errors.Is(err, services.ErrVariantNotFound):
+		utils.NotFoundResponse(c, "VARIANT_NOT_FOUND", err)
+	case errors.Is(err, services.ErrCouponCodeTaken):
+		utils.ErrorResponse(c, http.StatusConflict, "COUPON_CODE_TAKEN", err)
+	case errors.Is(err, services.ErrCouponInUse):
+		utils.ErrorResponse(c, http.StatusConflict, "COUPON_IN_USE", err)
+	case errors.Is(err, services.ErrInvalidCoupon):
+		utils.BadRequestResponse(c, "INVALID_COUPON", err)
+	case errors.Is(err, services.ErrItemNeedsVariant):
+		utils.BadRequestResponse(c, "ITEM_NEEDS_VARIANT", err)
+	case errors.Is(err, services.ErrCouponInactive):
+		utils.ErrorResponse(c, http.StatusUnprocessableEntity, "COUPON_INACTIVE", err)
+	case errors.Is(err, services.ErrCouponNotStarted):
+		utils.ErrorResponse(c, http.StatusUnprocessableEntity, "COUPON_NOT_STARTED", err)
+	case errors.Is(err, services.ErrCouponExpired):
+		utils.ErrorResponse(c, http.StatusUnprocessableEntity, "COUPON_EXPIRED", err)
+	case errors.Is(err, services.ErrCouponUsageLimit):
+		utils.ErrorResponse(c, http.StatusUnprocessableEntity, "COUPON_USAGE_LIMIT_REACHED", err)
+	case errors.Is(err, services.ErrCouponUserLimit):
+		utils.ErrorResponse(c, http.StatusUnprocessableEntity, "COUPON_USER_LIMIT_REACHED", err)
+	case errors.Is(err, services.ErrCouponMinSubtotal):
+		utils.ErrorResponse(c, http.StatusUnprocessableEntity, "COUPON_MIN_SUBTOTAL_NOT_REACHED", err)
+	case errors.Is(err, services.ErrCouponNoEligibleItems):
+		utils.ErrorResponse(c, http.StatusUnprocessableEntity, "COUPON_NO_ELIGIBLE_ITEMS", err)
+	case errors.Is(err, services.ErrCouponQuantityNotReached):
+		utils.ErrorResponse(c, http.StatusUnprocessableEntity, "COUPON_QUANTITY_NOT_REACHED", err)
+	default:
+		utils.InternalServerErrorResponse(c, message, err)
+	}
+}
+
+func cartItems(reqs []types.CartItemRequest) []services.CartItem {
+	items := make([]services.CartItem, len(reqs))
+	for i, item := range reqs {
+		items[i] = services.CartItem{ProductID: item.ProductID, VariantID: item.VariantID, Quantity: item.Quantity}
+	}
+	return items
+}
+
+func couponHandlerLog(format string, v ...any) {
+	prefix := "[COUPON_HANDLER]"
+	message := fmt.Sprintf(format, v...)
+	log.Printf("%s %s", prefix, message)
+}
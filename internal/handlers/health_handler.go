@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/types"
+	"github.com/Code-Aether/americanas-loja-api/pkg/buildinfo"
+)
+
+const serviceName = "americanas-loja-api"
+
+const (
+	phaseStarting int32 = iota
+	phaseReady
+	phaseShuttingDown
+)
+
+type HealthHandler struct {
+	db      *gorm.DB
+	redis   *redis.Client
+	timeout time.Duration
+	phase   atomic.Int32
+}
+
+type dependencyCheck struct {
+	name     string
+	critical bool
+	ping     func(ctx context.Context) error
+}
+
+func NewHealthHandler(db *gorm.DB, redis *redis.Client, timeout time.Duration) *HealthHandler {
+	return &HealthHandler{
+		db:      db,
+		redis:   redis,
+		timeout: timeout,
+	}
+}
+
+// SetReady marks startup (migrations, seeding) as finished.
+func (h *HealthHandler) SetReady() {
+	h.phase.CompareAndSwap(phaseStarting, phaseReady)
+}
+
+// SetShuttingDown makes readiness fail so load balancers stop routing new
+// traffic while in-flight requests drain.
+func (h *HealthHandler) SetShuttingDown() {
+	h.phase.Store(phaseShuttingDown)
+}
+
+// Livez godoc
+// @Summary      Liveness probe
+// @Description  Indica que o processo está no ar. Não verifica dependências
+// @Tags         health
+// @Produce      json
+// @Success      200 {object} types.HealthResponse "Processo vivo"
+// @Router       /livez [get]
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, types.HealthResponse{
+		Status:    "alive",
+		Service:   serviceName,
+		Build:     buildinfo.Get(),
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+}
+
+// Readyz godoc
+// @Summary      Readiness probe
+// @Description  Verifica banco de dados e Redis. Falha durante migração inicial e desligamento
+// @Tags         health
+// @Produce      json
+// @Success      200 {object} types.HealthResponse "Pronto para receber tráfego"
+// @Failure      503 {object} types.HealthResponse "Não está pronto"
+// @Router       /readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	response := types.HealthResponse{
+		Status:    "ready",
+		Service:   serviceName,
+		Build:     buildinfo.Get(),
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	switch h.phase.Load() {
+	case phaseStarting:
+		response.Status = "not_ready"
+		response.Reason = "starting"
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	case phaseShuttingDown:
+		response.Status = "not_ready"
+		response.Reason = "shutting_down"
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+
+	response.Dependencies = h.checkDependencies(c.Request.Context())
+
+	status := http.StatusOK
+	for _, dependency := range response.Dependencies {
+		if dependency.Status == "up" {
+			continue
+		}
+		if dependency.Critical {
+			response.Status = "not_ready"
+			response.Reason = "dependency_down"
+			status = http.StatusServiceUnavailable
+			break
+		}
+		response.Status = "degraded"
+	}
+
+	c.JSON(status, response)
+}
+
+// Redis only backs the product cache, so losing it degrades latency but the
+// API keeps answering from the database; only the database is critical.
+func (h *HealthHandler) dependencyChecks() []dependencyCheck {
+	checks := []dependencyCheck{
+		{
+			name:     "database",
+			critical: true,
+			ping: func(ctx context.Context) error {
+				sqlDB, err := h.db.DB()
+				if err != nil {
+					return err
+				}
+				return sqlDB.PingContext(ctx)
+			},
+		},
+	}
+
+	if h.redis != nil {
+		checks = append(checks, dependencyCheck{
+			name:     "redis",
+			critical: false,
+			ping: func(ctx context.Context) error {
+				return h.redis.Ping(ctx).Err()
+			},
+		})
+	}
+
+	return checks
+}
+
+func (h *HealthHandler) checkDependencies(ctx context.Context) map[string]types.DependencyStatus {
+	checks := h.dependencyChecks()
+	results := make(map[string]types.DependencyStatus, len(checks))
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check dependencyCheck) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, h.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.ping(checkCtx)
+			result := types.DependencyStatus{
+				Status:    "up",
+				Critical:  check.critical,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				result.Status = "down"
+				result.Error = err.Error()
+			}
+
+			mutex.Lock()
+			results[check.name] = result
+			mutex.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,104 @@
+// internal/handlers/health_handler_test.go
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
+	"github.com/Code-Aether/americanas-loja-api/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthHandler_Livez(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	healthHandler := NewHealthHandler(db, nil, time.Second)
+
+	t.Run("✅ Liveness responde mesmo durante a inicialização", func(t *testing.T) {
+		c, w := testutils.MockGinContext()
+		req, err := http.NewRequest("GET", "/livez", nil)
+		require.NoError(t, err)
+		c.Request = req
+
+		healthHandler.Livez(c)
+
+		assert.Equal(t, http.StatusOK, w.Code, "Status deve ser 200 OK")
+
+		var response types.HealthResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "alive", response.Status)
+		assert.NotEmpty(t, response.Build.Version, "Versão deve ser informada")
+	})
+}
+
+func TestHealthHandler_Readyz(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	healthHandler := NewHealthHandler(db, nil, time.Second)
+
+	readyz := func(t *testing.T) (int, types.HealthResponse) {
+		c, w := testutils.MockGinContext()
+		req, err := http.NewRequest("GET", "/readyz", nil)
+		require.NoError(t, err)
+		c.Request = req
+
+		healthHandler.Readyz(c)
+
+		var response types.HealthResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response
+	}
+
+	t.Run("❌ Não está pronto durante a migração inicial", func(t *testing.T) {
+		code, response := readyz(t)
+
+		assert.Equal(t, http.StatusServiceUnavailable, code, "Status deve ser 503")
+		assert.Equal(t, "starting", response.Reason)
+	})
+
+	t.Run("✅ Pronto com banco de dados disponível", func(t *testing.T) {
+		healthHandler.SetReady()
+
+		code, response := readyz(t)
+
+		assert.Equal(t, http.StatusOK, code, "Status deve ser 200 OK")
+		assert.Equal(t, "ready", response.Status)
+		require.Contains(t, response.Dependencies, "database")
+		assert.Equal(t, "up", response.Dependencies["database"].Status)
+	})
+
+	t.Run("❌ Não está pronto com banco de dados fora do ar", func(t *testing.T) {
+		downDB := testutils.SetupTestDB(t)
+		sqlDB, err := downDB.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		downHandler := NewHealthHandler(downDB, nil, time.Second)
+		downHandler.SetReady()
+
+		c, w := testutils.MockGinContext()
+		req, err := http.NewRequest("GET", "/readyz", nil)
+		require.NoError(t, err)
+		c.Request = req
+
+		downHandler.Readyz(c)
+
+		var response types.HealthResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, "Status deve ser 503")
+		assert.Equal(t, "down", response.Dependencies["database"].Status)
+		assert.NotEmpty(t, response.Dependencies["database"].Error)
+	})
+
+	t.Run("❌ Não está pronto durante o desligamento", func(t *testing.T) {
+		healthHandler.SetShuttingDown()
+
+		code, response := readyz(t)
+
+		assert.Equal(t, http.StatusServiceUnavailable, code, "Status deve ser 503")
+		assert.Equal(t, "shutting_down", response.Reason)
+	})
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/services"
+	"github.com/Code-Aether/americanas-loja-api/internal/types"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type LoyaltyHandler struct {
+	loyaltyService *services.LoyaltyService
+	cursors        *pagination.Signer
+	validator      *validator.Validate
+}
+
+func NewLoyaltyHandler(loyaltyService *services.LoyaltyService, cursors *pagination.Signer) *LoyaltyHandler {
+	return &LoyaltyHandler{
+		loyaltyService: loyaltyService,
+		cursors:        cursors,
+		validator:      utils.NewValidator(),
+	}
+}
+
+// GetLoyalty godoc
+// @Summary      Saldo de pontos
+// @Description  Retorna os pontos de cashback do usuário: os disponíveis, quanto valem no checkout (1 ponto = R$ 0,01), os pendentes com a data de liberação e os disponíveis com a data em que expiram (requer autenticação)
+// @Tags         loyalty
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Success      200 {object} utils.Response{data=services.LoyaltyBalance} "Saldo de pontos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /user/loyalty [get]
+func (h *LoyaltyHandler) GetLoyalty(c *gin.Context) {
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	balance, err := h.loyaltyService.Get(c.Request.Context(), user.ID)
+	if err != nil {
+		h.errorResponse(c, "GET_LOYALTY_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "LOYALTY_SUCCESS", balance)
+}
+
+// ListLoyaltyHistory godoc
+// @Summary      Extrato de pontos
+// @Description  Retorna os lançamentos de pontos do usuário (acúmulo, liberação, cancelamento, resgate e expiração), paginados por cursor, mais recentes primeiro (requer autenticação)
+// @Tags         loyalty
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        limit  query int    false "Itens por página" default(20)
+// @Param        cursor query string false "Cursor opaco recebido em next_cursor ou prev_cursor"
+// @Success      200 {object} utils.PaginatedResponse{data=[]models.LoyaltyTransaction} "Lançamentos"
+// @Failure      400 {object} utils.Response "Cursor inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /user/loyalty/history [get]
+func (h *LoyaltyHandler) ListLoyaltyHistory(c *gin.Context) {
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	cursor, err := cursorFromQuery(c, h.cursors)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_CURSOR", err)
+		return
+	}
+
+	listing, err := h.loyaltyService.History(c.Request.Context(), user.ID, limit, cursor)
+	if err != nil {
+		h.errorResponse(c, "LIST_LOYALTY_HISTORY_ERROR", err)
+		return
+	}
+
+	page := utils.Pagination{
+		Limit:      limit,
+		NextCursor: encodeCursor(h.cursors, listing.Next),
+		PrevCursor: encodeCursor(h.cursors, listing.Prev),
+	}
+	utils.PaginatedSuccessResponse(c, "LOYALTY_HISTORY_LISTED_SUCCESS", listing.Transactions, page)
+}
+
+// ListLoyaltyRules godoc
+// @Summary      Listar regras de cashback
+// @Description  Retorna a taxa de cashback de cada categoria com regra própria, em pontos-base (250 = 2,5%). As subcategorias herdam a regra mais próxima e as demais usam LOYALTY_DEFAULT_RATE (apenas admin)
+// @Tags         loyalty
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Success      200 {object} utils.Response{data=[]models.LoyaltyRule} "Regras"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/loyalty/rules [get]
+func (h *LoyaltyHandler) ListLoyaltyRules(c *gin.Context) {
+	rules, err := h.loyaltyService.ListRules(c.Request.Context())
+	if err != nil {
+		h.errorResponse(c, "LIST_LOYALTY_RULES_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "LOYALTY_RULES_LISTED_SUCCESS", rules)
+}
+
+// CreateLoyaltyRule godoc
+// @Summary      Criar regra de cashback
+// @Description  Define a taxa de cashback de uma categoria e das subcategorias sem regra própria, em pontos-base; taxa zero deixa a categoria fora do programa (apenas admin)
+// @Tags         loyalty
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        rule body types.LoyaltyRuleRequest true "Categoria e taxa"
+// @Success      201 {object} utils.Response{data=models.LoyaltyRule} "Regra criada"
+// @Failure      400 {object} utils.Response "Dados inválidos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Categoria não encontrada"
+// @Failure      409 {object} utils.Response "Categoria já tem regra"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/loyalty/rules [post]
+func (h *LoyaltyHandler) CreateLoyaltyRule(c *gin.Context) {
+	var req types.LoyaltyRuleRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	rule := &models.LoyaltyRule{CategoryID: req.CategoryID, Rate: *req.Rate}
+	if err := h.loyaltyService.CreateRule(c.Request.Context(), rule); err != nil {
+		h.errorResponse(c, "ERROR_CREATING_LOYALTY_RULE", err)
+		return
+	}
+
+	loyaltyHandlerLog("Loyalty rule %d created: category %d at %d basis points", rule.ID, rule.CategoryID, rule.Rate)
+
+	utils.SuccessResponseWithStatus(c, http.StatusCreated, "LOYALTY_RULE_CREATED_WITH_SUCCESS", rule)
+}
+
+// UpdateLoyaltyRule godoc
+// @Summary      Atualizar regra de cashback
+// @Description  Altera a taxa de uma regra de cashback; vale para os pedidos pagos a partir de agora (apenas admin)
+// @Tags         loyalty
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id   path int                            true "ID da regra" example(1)
+// @Param        rule body types.UpdateLoyaltyRuleRequest true "Nova taxa"
+// @Success      200 {object} utils.Response{data=models.LoyaltyRule} "Regra atualizada"
+// @Failure      400 {object} utils.Response "Dados inválidos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Regra não encontrada"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/loyalty/rules/{id} [put]
+func (h *LoyaltyHandler) UpdateLoyaltyRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	var req types.UpdateLoyaltyRuleRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	rule, err := h.loyaltyService.UpdateRule(c.Request.Context(), uint(id), *req.Rate)
+	if err != nil {
+		h.errorResponse(c, "ERROR_UPDATING_LOYALTY_RULE", err)
+		return
+	}
+
+	loyaltyHandlerLog("Loyalty rule %d updated to %d basis points", rule.ID, rule.Rate)
+
+	utils.SuccessResponse(c, "LOYALTY_RULE_UPDATED_WITH_SUCCESS", rule)
+}
+
+// DeleteLoyaltyRule godoc
+// @Summary      Remover regra de cashback
+// @Description  Remove a regra; a categoria volta a usar a regra mais próxima acima dela ou a taxa padrão (apenas admin)
+// @Tags         loyalty
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID da regra" example(1)
+// @Success      200 {object} utils.Response "Regra removida com sucesso"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Regra não encontrada"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/loyalty/rules/{id} [delete]
+func (h *LoyaltyHandler) DeleteLoyaltyRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	if err := h.loyaltyService.DeleteRule(c.Request.Context(), uint(id)); err != nil {
+		h.errorResponse(c, "ERROR_DELETING_LOYALTY_RULE", err)
+		return
+	}
+
+	loyaltyHandlerLog("Loyalty rule %d deleted", id)
+
+	utils.SuccessResponse(c, "LOYALTY_RULE_DELETED_WITH_SUCCESS", nil)
+}
+
+func (h *LoyaltyHandler) errorResponse(c *gin.Context, message string, err error) {
+	if utils.ContextErrorResponse(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, pagination.ErrInvalidCursor):
+		utils.BadRequestResponse(c, "INVALID_CURSOR", err)
+	case errors.Is(err, services.ErrLoyaltyRuleNotFound):
+		utils.NotFoundResponse(c, "LOYALTY_RULE_NOT_FOUND", err)
+	case errors.Is(err, services.ErrCategoryNotFound):
+		utils.NotFoundResponse(c, "CATEGORY_NOT_FOUND", err)
+	case errors.Is(err, services.ErrInvalidLoyaltyRule):
+		utils.BadRequestResponse(c, "INVALID_LOYALTY_RULE", err)
+	case errors.Is(err, services.ErrLoyaltyRuleExists):
+		utils.ErrorResponse(c, http.StatusConflict, "LOYALTY_RULE_ALREADY_EXISTS", err)
+	default:
+		utils.InternalServerErrorResponse(c, message, err)
+	}
+}
+
+func loyaltyHandlerLog(format string, v ...any) {
+	prefix := "[LOYALTY_HANDLER]"
+	message := fmt.Sprintf(format, v...)
+	log.Printf("%s %s", prefix, message)
+}
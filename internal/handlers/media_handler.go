@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/services"
+	"github.com/Code-Aether/americanas-loja-api/internal/types"
+	"github.com/Code-Aether/americanas-loja-api/pkg/storage"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// multipartOverhead leaves room for the multipart boundaries and headers on
+// top of the image itself.
+const multipartOverhead = 1 << 20
+
+type MediaHandler struct {
+	mediaService  *services.MediaService
+	local         *storage.LocalStorage
+	maxUploadSize int64
+	validator     *validator.Validate
+}
+
+// NewMediaHandler builds the media handler. Files are only served by the API
+// when store is a local storage; S3 URLs point straight to the bucket.
+func NewMediaHandler(mediaService *services.MediaService, store storage.Storage, maxUploadSize int64) *MediaHandler {
+	local, _ := store.(*storage.LocalStorage)
+
+	return &MediaHandler{
+		mediaService:  mediaService,
+		local:         local,
+		maxUploadSize: maxUploadSize,
+		validator:     utils.NewValidator(),
+	}
+}
+
+// UploadImage godoc
+// @Summary      Enviar imagem do produto
+// @Description  Envia uma imagem (JPEG, PNG ou WebP) para a galeria do produto, gerando miniatura e versões WebP (apenas admins)
+// @Tags         products
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID do produto" example(1)
+// @Param        image formData file true "Arquivo da imagem"
+// @Success      201 {object} utils.Response{data=models.ProductImage} "Imagem enviada com sucesso"
+// @Failure      400 {object} utils.Response "Imagem inválida"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Produto não encontrado"
+// @Failure      413 {object} utils.Response "Imagem muito grande"
+// @Failure      415 {object} utils.Response "Tipo de imagem não suportado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /products/{id}/images [post]
+func (h *MediaHandler) UploadImage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxUploadSize+multipartOverhead)
+
+	file, header, err := c.Request.FormFile("image")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "IMAGE_TOO_LARGE", err)
+			return
+		}
+		utils.BadRequestResponse(c, "IMAGE_REQUIRED", err)
+		return
+	}
+	defer file.Close()
+
+	if header.Size > h.maxUploadSize {
+		utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "IMAGE_TOO_LARGE", services.ErrImageTooLarge)
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, h.maxUploadSize+1))
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_IMAGE", err)
+		return
+	}
+
+	image, err := h.mediaService.Upload(c.Request.Context(), uint(id), data)
+	if err != nil {
+		h.errorResponse(c, "ERROR_UPLOADING_IMAGE", err)
+		return
+	}
+
+	mediaHandlerLog("Image %d uploaded to product %d (%s, %dx%d)", image.ID, id, image.ContentType, image.Width, image.Height)
+
+	utils.SuccessResponseWithStatus(c, http.StatusCreated, "IMAGE_UPLOADED_WITH_SUCCESS", image)
+}
+
+// ListImages godoc
+// @Summary      Listar imagens do produto
+// @Description  Retorna a galeria do produto na ordem de exibição, com URLs assinadas
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "ID do produto" example(1)
+// @Success      200 {object} utils.Response{data=[]models.ProductImage} "Galeria do produto"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      404 {object} utils.Response "Produto não encontrado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /products/{id}/images [get]
+func (h *MediaHandler) ListImages(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	images, err := h.mediaService.List(c.Request.Context(), uint(id))
+	if err != nil {
+		h.errorResponse(c, "LIST_IMAGES_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "IMAGES_LISTED_SUCCESS", images)
+}
+
+// ReorderImages godoc
+// @Summary      Reordenar imagens do produto
+// @Description  Define a ordem da galeria; image_ids deve conter todas as imagens do produto (apenas admins)
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID do produto" example(1)
+// @Param        order body types.ReorderImagesRequest true "Nova ordem das imagens"
+// @Success      200 {object} utils.Response{data=[]models.ProductImage} "Galeria reordenada"
+// @Failure      400 {object} utils.Response "Ordem inválida"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /products/{id}/images/order [put]
+func (h *MediaHandler) ReorderImages(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	var req types.ReorderImagesRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	images, err := h.mediaService.Reorder(c.Request.Context(), uint(id), req.ImageIDs)
+	if err != nil {
+		h.errorResponse(c, "REORDER_IMAGES_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "IMAGES_REORDERED_WITH_SUCCESS", images)
+}
+
+// DeleteImage godoc
+// @Summary      Remover imagem do produto
+// @Description  Remove a imagem da galeria e seus arquivos (apenas admins)
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID do produto" example(1)
+// @Param        imageId path int true "ID da imagem" example(1)
+// @Success      200 {object} utils.Response "Imagem removida com sucesso"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Imagem não encontrada"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /products/{id}/images/{imageId} [delete]
+func (h *MediaHandler) DeleteImage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	imageID, err := strconv.ParseUint(c.Param("imageId"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	if err := h.mediaService.Delete(c.Request.Context(), uint(id), uint(imageID)); err != nil {
+		h.errorResponse(c, "ERROR_DELETING_IMAGE", err)
+		return
+	}
+
+	mediaHandlerLog("Image %d deleted from product %d", imageID, id)
+
+	utils.SuccessResponse(c, "IMAGE_DELETED_WITH_SUCCESS", nil)
+}
+
+// ServeMedia serves files of the local storage behind a signed URL.
+func (h *MediaHandler) ServeMedia(c *gin.Context) {
+	if h.local == nil {
+		utils.NotFoundResponse(c, "MEDIA_NOT_FOUND", nil)
+		return
+	}
+
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if err := h.local.Verify(key, c.Query("expires"), c.Query("signature")); err != nil {
+		utils.ErrorResponse(c, http.StatusForbidden, "INVALID_SIGNATURE", err)
+		return
+	}
+
+	path, err := h.local.Path(key)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_KEY", err)
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		utils.NotFoundResponse(c, "MEDIA_NOT_FOUND", err)
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=300")
+	c.File(path)
+}
+
+func (h *MediaHandler) errorResponse(c *gin.Context, message string, err error) {
+	if utils.ContextErrorResponse(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, services.ErrProductNotFound):
+		utils.NotFoundResponse(c, "PRODUCT_NOT_FOUND", err)
+	case errors.Is(err, services.ErrImageNotFound):
+		utils.NotFoundResponse(c, "IMAGE_NOT_FOUND", err)
+	case errors.Is(err, services.ErrImageTooLarge):
+		utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "IMAGE_TOO_LARGE", err)
+	case errors.Is(err, services.ErrUnsupportedImageType):
+		utils.ErrorResponse(c, http.StatusUnsupportedMediaType, "UNSUPPORTED_IMAGE_TYPE", err)
+	case errors.Is(err, services.ErrInvalidImage):
+		utils.BadRequestResponse(c, "INVALID_IMAGE", err)
+	case errors.Is(err, services.ErrInvalidImageOrder):
+		utils.BadRequestResponse(c, "INVALID_IMAGE_ORDER", err)
+	default:
+		utils.InternalServerErrorResponse(c, message, err)
+	}
+}
+
+func mediaHandlerLog(format string, v ...any) {
+	prefix := "[MEDIA_HANDLER]"
+	message := fmt.Sprintf(format, v...)
+	log.Printf("%s %s", prefix, message)
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+// cursorFromQuery reads the cursor parameter, returning nil when absent.
+func cursorFromQuery(c *gin.Context, cursors *pagination.Signer) (*pagination.Cursor, error) {
+	token := c.Query("cursor")
+	if token == "" {
+		return nil, nil
+	}
+	return cursors.Decode(token)
+}
+
+// countFromQuery reads the total parameter; empty leaves the choice to the
+// service.
+func countFromQuery(c *gin.Context) (string, error) {
+	count := c.Query("total")
+	if count != "" && !slices.Contains(pagination.CountModes, count) {
+		return "", fmt.Errorf("total must be one of %s", strings.Join(pagination.CountModes, ", "))
+	}
+	return count, nil
+}
+
+func encodeCursor(cursors *pagination.Signer, cursor *pagination.Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+	return cursors.Encode(*cursor)
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/services"
+	"github.com/Code-Aether/americanas-loja-api/internal/types"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type PricingHandler struct {
+	pricingService *services.PricingService
+	validator      *validator.Validate
+}
+
+func NewPricingHandler(pricingService *services.PricingService) *PricingHandler {
+	return &PricingHandler{
+		pricingService: pricingService,
+		validator:      utils.NewValidator(),
+	}
+}
+
+// CreateSale godoc
+// @Summary      Agendar promoção
+// @Description  Agenda um preço promocional para o produto entre starts_at e ends_at; o preço é aplicado e removido automaticamente nos limites da janela (apenas admins)
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID do produto" example(1)
+// @Param        sale body types.CreateSaleRequest true "Dados da promoção"
+// @Success      201 {object} utils.Response{data=models.ProductSale} "Promoção agendada com sucesso"
+// @Failure      400 {object} utils.Response "Dados inválidos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Produto não encontrado"
+// @Failure      409 {object} utils.Response "Conflito com outra promoção do produto"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /products/{id}/sales [post]
+func (h *PricingHandler) CreateSale(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	var req types.CreateSaleRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	sale, err := h.pricingService.CreateSale(c.Request.Context(), uint(id), services.SaleInput{
+		SalePrice: req.SalePrice,
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+	})
+	if err != nil {
+		h.errorResponse(c, "ERROR_CREATING_SALE", err)
+		return
+	}
+
+	pricingHandlerLog("Sale %d scheduled for product %d at %s", sale.ID, id, sale.SalePrice)
+
+	utils.SuccessResponseWithStatus(c, http.StatusCreated, "SALE_CREATED_WITH_SUCCESS", sale)
+}
+
+// ListSales godoc
+// @Summary      Listar promoções do produto
+// @Description  Retorna as promoções agendadas, em andamento e encerradas do produto, da mais antiga para a mais recente (apenas admins)
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID do produto" example(1)
+// @Success      200 {object} utils.Response{data=[]models.ProductSale} "Promoções do produto"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Produto não encontrado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /products/{id}/sales [get]
+func (h *PricingHandler) ListSales(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	sales, err := h.pricingService.ListSales(c.Request.Context(), uint(id))
+	if err != nil {
+		h.errorResponse(c, "LIST_SALES_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "SALES_LISTED_SUCCESS", sales)
+}
+
+// DeleteSale godoc
+// @Summary      Cancelar promoção
+// @Description  Remove a promoção; se estiver em andamento, o produto volta ao preço de tabela imediatamente (apenas admins)
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID do produto" example(1)
+// @Param        saleId path int true "ID da promoção" example(1)
+// @Success      200 {object} utils.Response "Promoção cancelada com sucesso"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Promoção não encontrada"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /products/{id}/sales/{saleId} [delete]
+func (h *PricingHandler) DeleteSale(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	saleID, err := strconv.ParseUint(c.Param("saleId"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	if err := h.pricingService.DeleteSale(c.Request.Context(), uint(id), uint(saleID)); err != nil {
+		h.errorResponse(c, "ERROR_DELETING_SALE", err)
+		return
+	}
+
+	pricingHandlerLog("Sale %d deleted from product %d", saleID, id)
+
+	utils.SuccessResponse(c, "SALE_DELETED_WITH_SUCCESS", nil)
+}
+
+// PriceHistory godoc
+// @Summary      Histórico de preços do produto
+// @Description  Retorna todas as mudanças de preço de tabela e promocional do produto, da mais recente para a mais antiga (apenas admins)
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID do produto" example(1)
+// @Success      200 {object} utils.Response{data=[]models.PriceHistory} "Histórico de preços"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Produto não encontrado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /products/{id}/price-history [get]
+func (h *PricingHandler) PriceHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	history, err := h.pricingService.History(c.Request.Context(), uint(id))
+	if err != nil {
+		h.errorResponse(c, "PRICE_HISTORY_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "PRICE_HISTORY_SUCCESS", history)
+}
+
+func (h *PricingHandler) errorResponse(c *gin.Context, message string, err error) {
+	if utils.ContextErrorResponse(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, services.ErrProductNotFound):
+		utils.NotFoundResponse(c, "PRODUCT_NOT_FOUND", err)
+	case errors.Is(err, services.ErrSaleNotFound):
+		utils.NotFoundResponse(c, "SALE_NOT_FOUND", err)
+	case errors.Is(err, services.ErrSaleOverlap):
+		utils.ErrorResponse(c, http.StatusConflict, "SALE_OVERLAP", err)
+	case errors.Is(err, services.ErrInvalidSaleWindow):
+		utils.BadRequestResponse(c, "INVALID_SALE_WINDOW", err)
+	case errors.Is(err, services.ErrInvalidSalePrice):
+		utils.BadRequestResponse(c, "INVALID_SALE_PRICE", err)
+	default:
+		utils.InternalServerErrorResponse(c, message, err)
+	}
+}
+
+func pricingHandlerLog(format string, v ...any) {
+	prefix := "[PRICING_HANDLER]"
+	message := fmt.Sprintf(format, v...)
+	log.Printf("%s %s", prefix, message)
+}
@@ -1,14 +1,19 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/Code-Aether/americanas-loja-api/internal/models"
 	"github.com/Code-Aether/americanas-loja-api/internal/services"
 	"github.com/Code-Aether/americanas-loja-api/internal/types"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
 	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -16,13 +21,15 @@ import (
 
 type ProductHandler struct {
 	productService *services.ProductService
+	cursors        *pagination.Signer
 	validator      *validator.Validate
 }
 
-func NewProductHandler(productService *services.ProductService) *ProductHandler {
+func NewProductHandler(productService *services.ProductService, cursors *pagination.Signer) *ProductHandler {
 	return &ProductHandler{
 		productService: productService,
-		validator:      validator.New(),
+		cursors:        cursors,
+		validator:      utils.NewValidator(),
 	}
 }
 
@@ -35,7 +42,7 @@ func NewProductHandler(productService *services.ProductService) *ProductHandler
 // @Security     Bearer
 // @Param        product body types.CreateProductRequest true "Dados do produto"
 // @Success      201 {object} utils.Response{data=models.Product} "Produto criado com sucesso"
-// @Failure      400 {object} utils.Response "Dados inválidos"
+// @Failure      400 {object} utils.Response "Dados inválidos ou categoria inexistente"
 // @Failure      401 {object} utils.Response "Token inválido"
 // @Failure      409 {object} utils.Response "SKU já existe"
 // @Failure      500 {object} utils.Response "Erro interno"
@@ -68,16 +75,29 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		Price:       req.Price,
 		Stock:       req.Stock,
 		SKU:         req.SKU,
-		Category:    req.Category,
+		CategoryID:  &req.CategoryID,
 		ImageURL:    req.ImageURL,
 		Active:      true,
+		Variants:    types.VariantsFromRequest(req.Variants),
+
+		ReorderThreshold: req.ReorderThreshold,
+		WeightGrams:      req.WeightGrams,
+		LengthCm:         req.LengthCm,
+		WidthCm:          req.WidthCm,
+		HeightCm:         req.HeightCm,
 	}
 
-	if err := h.productService.Create(product); err != nil {
+	if err := h.productService.Create(c.Request.Context(), product); err != nil {
+		if utils.ContextErrorResponse(c, err) {
+			return
+		}
 		if err.Error() == "sku already exists" {
 			utils.ErrorResponse(c, http.StatusConflict, "SKU_ALREADY_EXISTS", err)
 			return
 		}
+		if productErrorResponse(c, err) {
+			return
+		}
 		utils.InternalServerErrorResponse(c, "ERROR_CREATING_PRODUCT", err)
 		return
 	}
@@ -87,22 +107,74 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 
 // GetProducts godoc
 // @Summary      Listar produtos
-// @Description  Retorna lista paginada de produtos disponíveis
+// @Description  Retorna lista paginada de produtos disponíveis, com contagens por categoria, faixa de preço e disponibilidade
 // @Tags         products
 // @Accept       json
 // @Produce      json
-// @Param        page     query int    false "Número da página" default(1)
-// @Param        limit    query int    false "Itens por página" default(10)
-// @Param        category query string false "Filtrar por categoria" example("Eletrônicos")
-// @Param        search   query string false "Buscar produtos" example("iPhone")
-// @Success      200 {object} utils.Response{data=types.ProductListResponse} "Lista de produtos"
+// @Param        page      query int      false "Número da página, ignorado quando cursor é informado" default(1)
+// @Param        limit     query int      false "Itens por página" default(10)
+// @Param        cursor    query string   false "Cursor opaco recebido em next_cursor ou prev_cursor"
+// @Param        total     query string   false "Contagem do total (padrão: exact na primeira página, none com cursor)" Enums(exact, approximate, none)
+// @Param        category  query []string false "Filtrar por categorias (slug ou ID, separadas por vírgula ou repetidas), incluindo subcategorias" collectionFormat(csv) example("smartphones,notebooks")
+// @Param        search    query string   false "Buscar produtos" example("iPhone")
+// @Param        min_price query number   false "Preço mínimo" example(100)
+// @Param        max_price query number   false "Preço máximo" example(5000)
+// @Param        in_stock  query bool     false "true: apenas com estoque; false: apenas sem estoque"
+// @Param        sort      query string   false "Ordenação (padrão: relevance com busca, newest sem)" Enums(relevance, newest, price_asc, price_desc, name)
+// @Success      200 {object} utils.PaginatedResponse{data=[]models.Product,facets=models.ProductFacets} "Lista de produtos"
+// @Failure      400 {object} utils.Response "Filtros ou cursor inválidos"
 // @Failure      500 {object} utils.Response "Erro interno"
 // @Router       /products [get]
 func (h *ProductHandler) GetProducts(c *gin.Context) {
+	filter, err := productFilterFromQuery(c)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_FILTERS", err)
+		return
+	}
+
+	if filter.Cursor, err = cursorFromQuery(c, h.cursors); err != nil {
+		utils.BadRequestResponse(c, "INVALID_CURSOR", err)
+		return
+	}
+
+	listing, err := h.productService.GetAll(c.Request.Context(), filter)
+	if err != nil {
+		if utils.ContextErrorResponse(c, err) {
+			return
+		}
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			utils.BadRequestResponse(c, "INVALID_CURSOR", err)
+			return
+		}
+		utils.InternalServerErrorResponse(c, "SEARCH_PRODUCTS_ERROR", err)
+		return
+	}
+
+	page := utils.Pagination{
+		Limit:      filter.Limit,
+		NextCursor: encodeCursor(h.cursors, listing.Next),
+		PrevCursor: encodeCursor(h.cursors, listing.Prev),
+	}
+	if filter.Cursor == nil {
+		page.Page = filter.Page
+	}
+	if listing.Total != nil {
+		page.SetTotal(*listing.Total, listing.TotalApproximate)
+	}
+
+	// A nil *ProductFacets would still be rendered as null.
+	var facets interface{}
+	if listing.Facets != nil {
+		facets = listing.Facets
+	}
+	utils.FacetedSuccessResponse(c, "PRODUCTS_LISTED_SUCCESS", listing.Products, page, facets)
+}
+
+// productFilterFromQuery reads the listing parameters. Page and limit fall
+// back to their defaults; the filters are rejected when malformed.
+func productFilterFromQuery(c *gin.Context) (models.ProductFilter, error) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	category := c.Query("category")
-	search := c.Query("search")
 
 	if page < 1 {
 		page = 1
@@ -112,29 +184,61 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 		limit = 10
 	}
 
-	products, total, err := h.productService.GetAll(page, limit, category, search)
+	filter := models.ProductFilter{
+		Page:   page,
+		Limit:  limit,
+		Search: c.Query("search"),
+		Sort:   c.Query("sort"),
+	}
+
+	count, err := countFromQuery(c)
 	if err != nil {
-		utils.InternalServerErrorResponse(c, "SEARCH_PRODUCTS_ERROR", err)
-		return
+		return filter, err
 	}
+	filter.Count = count
 
-	totalPages := int(total) / limit
-	if int(total)%limit > 0 {
-		totalPages++
+	for _, value := range c.QueryArray("category") {
+		for _, category := range strings.Split(value, ",") {
+			if category = strings.TrimSpace(category); category != "" {
+				filter.Categories = append(filter.Categories, category)
+			}
+		}
 	}
 
-	pagination := utils.Pagination{
-		Page:       page,
-		Limit:      limit,
-		Total:      int(total),
-		TotalPages: totalPages,
+	for param, target := range map[string]**money.Money{"min_price": &filter.MinPrice, "max_price": &filter.MaxPrice} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		price, err := money.Parse(value)
+		if err != nil || price.IsNegative() {
+			return filter, fmt.Errorf("%s must be a non-negative amount with up to 2 decimal places", param)
+		}
+		*target = &price
 	}
-	utils.PaginatedSuccessResponse(c, "PRODUCTS_LISTED_SUCCESS", products, pagination)
+
+	if filter.MinPrice != nil && filter.MaxPrice != nil && filter.MinPrice.Cmp(*filter.MaxPrice) > 0 {
+		return filter, errors.New("min_price must not be greater than max_price")
+	}
+
+	if value := c.Query("in_stock"); value != "" {
+		inStock, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, errors.New("in_stock must be true or false")
+		}
+		filter.InStock = &inStock
+	}
+
+	if filter.Sort != "" && !slices.Contains(models.ProductSorts, filter.Sort) {
+		return filter, fmt.Errorf("sort must be one of %s", strings.Join(models.ProductSorts, ", "))
+	}
+
+	return filter, nil
 }
 
 // GetProduct godoc
 // @Summary      Obter produto específico
-// @Description  Retorna detalhes de um produto pelo ID
+// @Description  Retorna detalhes de um produto pelo ID, com as variantes ativas e a matriz de opções com disponibilidade
 // @Tags         products
 // @Accept       json
 // @Produce      json
@@ -152,9 +256,13 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := h.productService.GetByID(uint(id))
+	product, err := h.productService.GetByID(c.Request.Context(), uint(id))
 	if err != nil {
+		if utils.ContextErrorResponse(c, err) {
+			return
+		}
 		utils.NotFoundResponse(c, "PRODUCT_NOT_FOUND", err)
+		return
 	}
 
 	utils.SuccessResponse(c, "PRODUCT_FOUND", product)
@@ -170,9 +278,10 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 // @Param        id path int true "ID do produto" example(1)
 // @Param        product body types.UpdateProductRequest true "Dados para atualização"
 // @Success      200 {object} utils.Response{data=models.Product} "Produto atualizado com sucesso"
-// @Failure      400 {object} utils.Response "Dados inválidos"
+// @Failure      400 {object} utils.Response "Dados inválidos ou categoria inexistente"
 // @Failure      401 {object} utils.Response "Token inválido"
 // @Failure      404 {object} utils.Response "Produto não encontrado"
+// @Failure      409 {object} utils.Response "SKU de variante já existe"
 // @Failure      500 {object} utils.Response "Erro interno"
 // @Router       /products/{id} [put]
 func (h *ProductHandler) UpdateProduct(c *gin.Context) {
@@ -204,8 +313,11 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	productHandlerLog("User %s (role %s) updating product ID: %d",
 		user.Email, user.Role, id)
 
-	product, err := h.productService.GetByID(uint(id))
+	product, err := h.productService.GetByID(c.Request.Context(), uint(id))
 	if err != nil {
+		if utils.ContextErrorResponse(c, err) {
+			return
+		}
 		utils.NotFoundResponse(c, "PRODUCT_NOT_FOUND", err)
 		return
 	}
@@ -222,8 +334,9 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	if req.Stock != nil {
 		product.Stock = *req.Stock
 	}
-	if req.Category != nil {
-		product.Category = *req.Category
+	if req.CategoryID != nil {
+		product.CategoryID = req.CategoryID
+		product.Category = nil
 	}
 	if req.ImageURL != nil {
 		product.ImageURL = *req.ImageURL
@@ -231,8 +344,40 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	if req.Active != nil {
 		product.Active = *req.Active
 	}
+	if req.ReorderThreshold != nil {
+		product.ReorderThreshold = req.ReorderThreshold
+	}
+	if req.WeightGrams != nil {
+		product.WeightGrams = *req.WeightGrams
+	}
+	if req.LengthCm != nil {
+		product.LengthCm = *req.LengthCm
+	}
+	if req.WidthCm != nil {
+		product.WidthCm = *req.WidthCm
+	}
+	if req.HeightCm != nil {
+		product.HeightCm = *req.HeightCm
+	}
 
-	if err := h.productService.Update(product); err != nil {
+	// The stock of a product with variants is the sum of the variant stocks.
+	if req.Stock != nil && req.Variants == nil && len(product.Variants) > 0 {
+		utils.BadRequestResponse(c, "VARIANT_STOCK_REQUIRED", services.ErrVariantRequired)
+		return
+	}
+
+	if req.Variants != nil {
+		err = h.productService.UpdateWithVariants(c.Request.Context(), product, types.VariantsFromRequest(*req.Variants))
+	} else {
+		err = h.productService.Update(c.Request.Context(), product)
+	}
+	if err != nil {
+		if utils.ContextErrorResponse(c, err) {
+			return
+		}
+		if productErrorResponse(c, err) {
+			return
+		}
 		utils.InternalServerErrorResponse(c, "UPDATE_PRODUCT_ERROR", err)
 		return
 	}
@@ -271,13 +416,19 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 
 	productHandlerLog("Admin %s deletando produto ID: %d", user.Email, id)
 
-	_, err = h.productService.GetByID(uint(id))
+	_, err = h.productService.GetByID(c.Request.Context(), uint(id))
 	if err != nil {
+		if utils.ContextErrorResponse(c, err) {
+			return
+		}
 		utils.NotFoundResponse(c, "PRODUCT_NOT_FOUND", err)
 		return
 	}
 
-	if err := h.productService.Delete(uint(id)); err != nil {
+	if err := h.productService.Delete(c.Request.Context(), uint(id)); err != nil {
+		if utils.ContextErrorResponse(c, err) {
+			return
+		}
 		utils.InternalServerErrorResponse(c, "ERROR_DELETING_PRODUCT", err)
 		return
 	}
@@ -285,6 +436,22 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	utils.SuccessResponse(c, "PRODUCT_DELETED_WITH_SUCCESS", nil)
 }
 
+// productErrorResponse answers the validation errors shared by product
+// creation and update. It reports whether a response was sent.
+func productErrorResponse(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrCategoryNotFound):
+		utils.BadRequestResponse(c, "CATEGORY_NOT_FOUND", err)
+	case errors.Is(err, services.ErrInvalidVariantOption), errors.Is(err, services.ErrDuplicateVariant):
+		utils.BadRequestResponse(c, "INVALID_VARIANTS", err)
+	case errors.Is(err, services.ErrVariantSKUTaken):
+		utils.ErrorResponse(c, http.StatusConflict, "VARIANT_SKU_ALREADY_EXISTS", err)
+	default:
+		return false
+	}
+	return true
+}
+
 func checkUserLogged(c *gin.Context) (*models.User, error) {
 	user, exists := c.Get("user")
 	if !exists {
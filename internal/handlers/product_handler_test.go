@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"testing"
 
@@ -13,6 +14,9 @@ import (
 	"github.com/Code-Aether/americanas-loja-api/internal/services"
 	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
 	"github.com/Code-Aether/americanas-loja-api/internal/types"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -25,14 +29,17 @@ func TestProductHandler_GetProducts(t *testing.T) {
 	redis := testutils.SetupTestRedis(t)
 	productRepo := repository.NewProductRepository(db)
 	productService := services.NewProductService(productRepo, redis)
-	productHandler := NewProductHandler(productService)
+	productHandler := NewProductHandler(productService, pagination.NewSigner("test-secret"))
 
 	t.Run("✅ Listar produtos com sucesso", func(t *testing.T) {
+		cat1 := testutils.CreateTestCategory(t, db, "Cat1", nil)
+		cat2 := testutils.CreateTestCategory(t, db, "Cat2", nil)
+
 		// Criar alguns produtos de teste
 		products := []*models.Product{
-			{Name: "Produto 1", SKU: "PROD-001", Price: 100.00, Stock: 10, Category: "Cat1", Active: true},
-			{Name: "Produto 2", SKU: "PROD-002", Price: 200.00, Stock: 20, Category: "Cat2", Active: true},
-			{Name: "Produto 3", SKU: "PROD-003", Price: 300.00, Stock: 30, Category: "Cat1", Active: false},
+			{Name: "Produto 1", SKU: "PROD-001", Price: money.MustParse("100.00"), Stock: 10, CategoryID: &cat1.ID, Active: true},
+			{Name: "Produto 2", SKU: "PROD-002", Price: money.MustParse("200.00"), Stock: 20, CategoryID: &cat2.ID, Active: true},
+			{Name: "Produto 3", SKU: "PROD-003", Price: money.MustParse("300.00"), Stock: 30, CategoryID: &cat1.ID, Active: false},
 		}
 
 		for _, product := range products {
@@ -96,7 +103,7 @@ func TestProductHandler_GetProducts(t *testing.T) {
 		cleanRedis := testutils.SetupTestRedis(t)
 		cleanRepo := repository.NewProductRepository(cleanDB)
 		cleanService := services.NewProductService(cleanRepo, cleanRedis)
-		cleanHandler := NewProductHandler(cleanService)
+		cleanHandler := NewProductHandler(cleanService, pagination.NewSigner("test-secret"))
 
 		c, w := testutils.MockGinContext()
 
@@ -126,7 +133,7 @@ func TestProductHandler_GetProduct(t *testing.T) {
 	redis := testutils.SetupTestRedis(t)
 	productRepo := repository.NewProductRepository(db)
 	productService := services.NewProductService(productRepo, redis)
-	productHandler := NewProductHandler(productService)
+	productHandler := NewProductHandler(productService, pagination.NewSigner("test-secret"))
 
 	// Criar produto de teste
 	testProduct := testutils.CreateTestProduct(t, db)
@@ -158,7 +165,7 @@ func TestProductHandler_GetProduct(t *testing.T) {
 		assert.Equal(t, float64(testProduct.ID), product["id"], "ID deve estar correto")
 		assert.Equal(t, testProduct.Name, product["name"], "Nome deve estar correto")
 		assert.Equal(t, testProduct.SKU, product["sku"], "SKU deve estar correto")
-		assert.Equal(t, testProduct.Price, product["price"], "Preço deve estar correto")
+		assert.Equal(t, testProduct.Price.Float64(), product["price"], "Preço deve estar correto")
 	})
 
 	t.Run("❌ Obter produto inexistente", func(t *testing.T) {
@@ -204,7 +211,7 @@ func TestProductHandler_CreateProduct(t *testing.T) {
 	redis := testutils.SetupTestRedis(t)
 	productRepo := repository.NewProductRepository(db)
 	productService := services.NewProductService(productRepo, redis)
-	productHandler := NewProductHandler(productService)
+	productHandler := NewProductHandler(productService, pagination.NewSigner("test-secret"))
 
 	t.Run("✅ Criar produto com sucesso", func(t *testing.T) {
 		c, w := testutils.MockGinContext()
@@ -213,12 +220,13 @@ func TestProductHandler_CreateProduct(t *testing.T) {
 		user := testutils.CreateTestUser(t, db)
 		testutils.MockUserInContext(c, user)
 
+		category := testutils.CreateTestCategory(t, db, "Eletrônicos", nil)
 		productData := types.CreateProductRequest{
 			Name:        "iPhone 15 Pro Max",
 			Description: "Smartphone Apple",
-			Price:       8999.99,
+			Price:       money.MustParse("8999.99"),
 			Stock:       50,
-			Category:    "Eletrônicos",
+			CategoryID:  category.ID,
 			SKU:         "IPHONE-15-PRO-MAX",
 			ImageURL:    "https://example.com/iphone15.jpg",
 		}
@@ -241,7 +249,7 @@ func TestProductHandler_CreateProduct(t *testing.T) {
 
 		product := response["data"].(map[string]interface{})
 		assert.Equal(t, productData.Name, product["name"], "Nome deve estar correto")
-		assert.Equal(t, productData.Price, product["price"], "Preço deve estar correto")
+		assert.Equal(t, productData.Price.Float64(), product["price"], "Preço deve estar correto")
 		assert.Equal(t, productData.SKU, product["sku"], "SKU deve estar correto")
 		assert.True(t, product["active"].(bool), "Produto deve estar ativo")
 		assert.NotZero(t, product["id"], "ID deve ser gerado")
@@ -258,10 +266,11 @@ func TestProductHandler_CreateProduct(t *testing.T) {
 		// Não adicionar usuário ao contexto
 
 		productData := types.CreateProductRequest{
-			Name:  "Produto Teste",
-			Price: 99.99,
-			Stock: 10,
-			SKU:   "TEST-001",
+			Name:       "Produto Teste",
+			Price:      money.MustParse("99.99"),
+			Stock:      10,
+			CategoryID: 1,
+			SKU:        "TEST-001",
 		}
 
 		req, err := testutils.MockJSONRequest("POST", "/products", productData)
@@ -286,7 +295,7 @@ func TestProductHandler_CreateProduct(t *testing.T) {
 				name: "Nome vazio",
 				data: types.CreateProductRequest{
 					Name:  "",
-					Price: 99.99,
+					Price: money.MustParse("99.99"),
 					Stock: 10,
 					SKU:   "TEST-002",
 				},
@@ -295,7 +304,7 @@ func TestProductHandler_CreateProduct(t *testing.T) {
 				name: "Preço negativo",
 				data: types.CreateProductRequest{
 					Name:  "Produto Teste",
-					Price: -10.00,
+					Price: money.MustParse("-10.00"),
 					Stock: 10,
 					SKU:   "TEST-003",
 				},
@@ -304,7 +313,7 @@ func TestProductHandler_CreateProduct(t *testing.T) {
 				name: "Stock negativo",
 				data: types.CreateProductRequest{
 					Name:  "Produto Teste",
-					Price: 99.99,
+					Price: money.MustParse("99.99"),
 					Stock: -5,
 					SKU:   "TEST-004",
 				},
@@ -313,7 +322,7 @@ func TestProductHandler_CreateProduct(t *testing.T) {
 				name: "SKU vazio",
 				data: types.CreateProductRequest{
 					Name:  "Produto Teste",
-					Price: 99.99,
+					Price: money.MustParse("99.99"),
 					Stock: 10,
 					SKU:   "",
 				},
@@ -345,13 +354,14 @@ func TestProductHandler_CreateProduct(t *testing.T) {
 		testutils.MockUserInContext(c, user)
 
 		// Criar produto existente
-		testutils.CreateTestProduct(t, db)
+		existing := testutils.CreateTestProduct(t, db)
 
 		productData := types.CreateProductRequest{
-			Name:  "Produto Duplicado",
-			Price: 99.99,
-			Stock: 10,
-			SKU:   "TEST-001", // SKU já existe
+			Name:       "Produto Duplicado",
+			Price:      money.MustParse("99.99"),
+			Stock:      10,
+			CategoryID: *existing.CategoryID,
+			SKU:        "TEST-001", // SKU já existe
 		}
 
 		req, err := testutils.MockJSONRequest("POST", "/products", productData)
@@ -372,7 +382,7 @@ func TestProductHandler_UpdateProduct(t *testing.T) {
 	redis := testutils.SetupTestRedis(t)
 	productRepo := repository.NewProductRepository(db)
 	productService := services.NewProductService(productRepo, redis)
-	productHandler := NewProductHandler(productService)
+	productHandler := NewProductHandler(productService, pagination.NewSigner("test-secret"))
 
 	// Criar produto de teste
 	testProduct := testutils.CreateTestProduct(t, db)
@@ -384,7 +394,7 @@ func TestProductHandler_UpdateProduct(t *testing.T) {
 		testutils.MockUserInContext(c, user)
 
 		newName := "Nome Atualizado"
-		newPrice := 299.99
+		newPrice := money.MustParse("299.99")
 		updateData := types.UpdateProductRequest{
 			Name:  &newName,
 			Price: &newPrice,
@@ -411,7 +421,7 @@ func TestProductHandler_UpdateProduct(t *testing.T) {
 
 		product := response["data"].(map[string]interface{})
 		assert.Equal(t, newName, product["name"], "Nome deve estar atualizado")
-		assert.Equal(t, newPrice, product["price"], "Preço deve estar atualizado")
+		assert.Equal(t, newPrice.Float64(), product["price"], "Preço deve estar atualizado")
 
 		// Verificar no banco
 		var updatedProduct models.Product
@@ -477,7 +487,7 @@ func TestProductHandler_DeleteProduct(t *testing.T) {
 	redis := testutils.SetupTestRedis(t)
 	productRepo := repository.NewProductRepository(db)
 	productService := services.NewProductService(productRepo, redis)
-	productHandler := NewProductHandler(productService)
+	productHandler := NewProductHandler(productService, pagination.NewSigner("test-secret"))
 
 	t.Run("✅ Deletar produto com sucesso", func(t *testing.T) {
 		// Criar produto para deletar
@@ -553,3 +563,92 @@ func TestProductHandler_DeleteProduct(t *testing.T) {
 		testutils.AssertErrorResponse(t, w, http.StatusUnauthorized)
 	})
 }
+
+func TestProductHandler_GetProductsFilters(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	productHandler := NewProductHandler(services.NewProductService(repository.NewProductRepository(db), nil), pagination.NewSigner("test-secret"))
+
+	category := testutils.CreateTestCategory(t, db, "Áudio", nil)
+	for i, price := range []string{"50", "150", "600"} {
+		product := &models.Product{
+			Name: fmt.Sprintf("Fone %d", i), SKU: fmt.Sprintf("FONE-%d", i),
+			Price: money.MustParse(price), Stock: i, CategoryID: &category.ID, Active: true,
+		}
+		require.NoError(t, db.Create(product).Error)
+	}
+
+	t.Run("✅ Filtros, ordenação e facetas na resposta", func(t *testing.T) {
+		c, w := testutils.MockGinContext()
+		c.Request = httptest.NewRequest("GET", "/products?category=audio,inexistente&min_price=100&in_stock=true&sort=price_desc", nil)
+
+		productHandler.GetProducts(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data       []models.Product     `json:"data"`
+			Pagination map[string]int       `json:"pagination"`
+			Facets     models.ProductFacets `json:"facets"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		require.Len(t, response.Data, 2)
+		assert.Equal(t, money.MustParse("600"), response.Data[0].Price, "Ordenado por preço decrescente")
+		assert.Equal(t, 2, response.Pagination["total"])
+		assert.Equal(t, models.AvailabilityFacet{InStock: 2, OutOfStock: 0}, response.Facets.Availability)
+		require.Len(t, response.Facets.Categories, 1)
+		assert.Equal(t, int64(2), response.Facets.Categories[0].Count)
+	})
+
+	t.Run("✅ Próxima página por cursor", func(t *testing.T) {
+		get := func(query string) (*httptest.ResponseRecorder, utils.Pagination, []models.Product) {
+			c, w := testutils.MockGinContext()
+			c.Request = httptest.NewRequest("GET", "/products?"+query, nil)
+			productHandler.GetProducts(c)
+
+			var response struct {
+				Data       []models.Product `json:"data"`
+				Pagination utils.Pagination `json:"pagination"`
+			}
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+			return w, response.Pagination, response.Data
+		}
+
+		w, first, products := get("limit=2&sort=price_asc")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Len(t, products, 2)
+		require.NotEmpty(t, first.NextCursor)
+		assert.Empty(t, first.PrevCursor)
+		require.NotNil(t, first.Total)
+		assert.Equal(t, int64(3), *first.Total)
+
+		w, second, products := get("limit=2&sort=price_asc&cursor=" + first.NextCursor)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Len(t, products, 1)
+		assert.Equal(t, money.MustParse("600"), products[0].Price)
+		assert.Empty(t, second.NextCursor)
+		assert.NotEmpty(t, second.PrevCursor)
+		assert.Nil(t, second.Total, "Sem total nas páginas seguintes por padrão")
+		assert.NotContains(t, w.Body.String(), "facets")
+
+		w, _, _ = get("limit=2&sort=price_desc&cursor=" + first.NextCursor)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Cursor de outra ordenação")
+		assert.Contains(t, w.Body.String(), "INVALID_CURSOR")
+
+		w, _, _ = get("limit=2&sort=price_asc&cursor=" + first.NextCursor + "x")
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Cursor adulterado")
+		assert.Contains(t, w.Body.String(), "INVALID_CURSOR")
+	})
+
+	for _, query := range []string{"min_price=abc", "min_price=-1", "min_price=500&max_price=100", "in_stock=talvez", "sort=price", "total=todos"} {
+		t.Run("❌ Filtro inválido: "+query, func(t *testing.T) {
+			c, w := testutils.MockGinContext()
+			c.Request = httptest.NewRequest("GET", "/products?"+query, nil)
+
+			productHandler.GetProducts(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), "INVALID_FILTERS")
+		})
+	}
+}
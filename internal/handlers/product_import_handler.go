@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/services"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type ProductImportHandler struct {
+	importService *services.ProductImportService
+	maxSize       int64
+	// syncMaxRows is the largest import answered in the request itself;
+	// larger ones run as a background job.
+	syncMaxRows int
+}
+
+func NewProductImportHandler(importService *services.ProductImportService, maxSize int64, syncMaxRows int) *ProductImportHandler {
+	return &ProductImportHandler{
+		importService: importService,
+		maxSize:       maxSize,
+		syncMaxRows:   syncMaxRows,
+	}
+}
+
+// ImportProducts godoc
+// @Summary      Importar produtos
+// @Description  Cria ou atualiza produtos pelo SKU a partir de um arquivo CSV (com cabeçalho) ou NDJSON, validando cada linha com as mesmas regras da criação de produto. O arquivo pode ser enviado no campo "file" de um formulário multipart ou como corpo da requisição. Arquivos pequenos são importados na hora; os maiores viram um job em segundo plano (apenas admin)
+// @Tags         products
+// @Accept       multipart/form-data,text/csv,application/x-ndjson
+// @Produce      json
+// @Security     Bearer
+// @Param        file    formData file   false "Arquivo CSV ou NDJSON"
+// @Param        format  query    string false "Formato do arquivo; por padrão é deduzido da extensão ou do Content-Type" Enums(csv, ndjson)
+// @Param        dry_run query    bool   false "Apenas valida e informa o que seria feito, sem gravar"
+// @Success      200 {object} utils.Response{data=models.ImportReport} "Resultado da importação, linha a linha"
+// @Success      202 {object} utils.Response{data=models.ImportJob} "Importação iniciada em segundo plano"
+// @Failure      400 {object} utils.Response "Arquivo inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      413 {object} utils.Response "Arquivo muito grande"
+// @Failure      415 {object} utils.Response "Formato não suportado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/products/import [post]
+func (h *ProductImportHandler) ImportProducts(c *gin.Context) {
+	dryRun := false
+	if value := c.Query("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			utils.BadRequestResponse(c, "INVALID_DRY_RUN", err)
+			return
+		}
+		dryRun = parsed
+	}
+
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	var body io.Reader
+	filename := ""
+	if c.ContentType() != "multipart/form-data" {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize)
+		body = c.Request.Body
+	} else {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+multipartOverhead)
+
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "IMPORT_FILE_TOO_LARGE", err)
+				return
+			}
+			utils.BadRequestResponse(c, "FILE_REQUIRED", err)
+			return
+		}
+		defer file.Close()
+
+		if header.Size > h.maxSize {
+			utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "IMPORT_FILE_TOO_LARGE", fmt.Errorf("file has %d bytes, the limit is %d", header.Size, h.maxSize))
+			return
+		}
+		body = file
+		filename = header.Filename
+	}
+
+	format := importFormat(c.Query("format"), filename, c.ContentType())
+
+	rows, err := h.importService.Parse(body, format)
+	if err != nil {
+		h.errorResponse(c, "INVALID_IMPORT_FILE", err)
+		return
+	}
+
+	if len(rows) > h.syncMaxRows {
+		job, err := h.importService.StartImport(c.Request.Context(), user.ID, format, rows, dryRun)
+		if err != nil {
+			h.errorResponse(c, "ERROR_STARTING_IMPORT", err)
+			return
+		}
+
+		productImportHandlerLog("Admin %s started import job %d with %d rows (dry run: %t)", user.Email, job.ID, len(rows), dryRun)
+
+		utils.SuccessResponseWithStatus(c, http.StatusAccepted, "IMPORT_JOB_STARTED", job)
+		return
+	}
+
+	report, err := h.importService.Import(c.Request.Context(), rows, dryRun)
+	if err != nil {
+		h.errorResponse(c, "ERROR_IMPORTING_PRODUCTS", err)
+		return
+	}
+
+	productImportHandlerLog("Admin %s imported %d rows (dry run: %t): %d created, %d updated, %d failed",
+		user.Email, report.TotalRows, dryRun, report.Created, report.Updated, report.Failed)
+
+	utils.SuccessResponse(c, "IMPORT_COMPLETED", report)
+}
+
+// GetImportJob godoc
+// @Summary      Progresso da importação
+// @Description  Retorna o andamento de uma importação em segundo plano; o relatório por linha fica disponível quando o status é completed (apenas admin)
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID do job de importação" example(1)
+// @Success      200 {object} utils.Response{data=models.ImportJob} "Job de importação"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Job não encontrado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/products/import/{id} [get]
+func (h *ProductImportHandler) GetImportJob(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	job, err := h.importService.GetJob(c.Request.Context(), uint(id))
+	if err != nil {
+		h.errorResponse(c, "GET_IMPORT_JOB_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "IMPORT_JOB_SUCCESS", job)
+}
+
+// importFormat picks the format from the query, then the file extension,
+// then the Content-Type of the body.
+func importFormat(format, filename, contentType string) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return models.ImportFormatCSV
+	case ".ndjson", ".jsonl":
+		return models.ImportFormatNDJSON
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return models.ImportFormatCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return models.ImportFormatNDJSON
+	}
+	return ""
+}
+
+func (h *ProductImportHandler) errorResponse(c *gin.Context, message string, err error) {
+	if utils.ContextErrorResponse(c, err) {
+		return
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "IMPORT_FILE_TOO_LARGE", err)
+	case errors.Is(err, services.ErrUnsupportedImportFormat):
+		utils.ErrorResponse(c, http.StatusUnsupportedMediaType, "UNSUPPORTED_IMPORT_FORMAT", err)
+	case errors.Is(err, services.ErrInvalidImportFile), errors.Is(err, services.ErrEmptyImport):
+		utils.BadRequestResponse(c, "INVALID_IMPORT_FILE", err)
+	case errors.Is(err, services.ErrImportJobNotFound):
+		utils.NotFoundResponse(c, "IMPORT_JOB_NOT_FOUND", err)
+	default:
+		utils.InternalServerErrorResponse(c, message, err)
+	}
+}
+
+func productImportHandlerLog(format string, v ...any) {
+	prefix := "[PRODUCT_IMPORT_HANDLER]"
+	message := fmt.Sprintf(format, v...)
+	log.Printf("%s %s", prefix, message)
+}
@@ -0,0 +1,490 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/services"
+	"github.com/Code-Aether/americanas-loja-api/internal/types"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type ReturnHandler struct {
+	returnService *services.ReturnService
+	maxPhotoSize  int64
+	validator     *validator.Validate
+}
+
+func NewReturnHandler(returnService *services.ReturnService, maxPhotoSize int64) *ReturnHandler {
+	return &ReturnHandler{
+		returnService: returnService,
+		maxPhotoSize:  maxPhotoSize,
+		validator:     utils.NewValidator(),
+	}
+}
+
+// CreateReturn godoc
+// @Summary      Solicitar devolução
+// @Description  Solicita a devolução de itens de um pedido entregue dentro do prazo de RETURN_WINDOW. Cada item pode ser devolvido até a quantidade comprada (requer autenticação)
+// @Tags         returns
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        return body types.ReturnRequestInput true "Pedido, motivo e itens"
+// @Success      201 {object} utils.Response{data=models.ReturnRequest} "Devolução solicitada"
+// @Failure      400 {object} utils.Response "Dados inválidos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      404 {object} utils.Response "Pedido não encontrado"
+// @Failure      422 {object} utils.Response "Pedido não entregue, prazo encerrado ou quantidade acima da comprada"
+// @Failure      503 {object} utils.Response "Pedidos indisponíveis"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /user/returns [post]
+func (h *ReturnHandler) CreateReturn(c *gin.Context) {
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	var req types.ReturnRequestInput
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	input := services.ReturnInput{
+		OrderID:     req.OrderID,
+		Reason:      req.Reason,
+		Description: req.Description,
+	}
+	for _, item := range req.Items {
+		input.Items = append(input.Items, services.ReturnItemInput{OrderItemID: item.OrderItemID, Quantity: item.Quantity})
+	}
+
+	ret, err := h.returnService.Create(c.Request.Context(), user.ID, input)
+	if err != nil {
+		h.errorResponse(c, "ERROR_CREATING_RETURN", err)
+		return
+	}
+
+	returnHandlerLog("User %d asked to return %s of order %s (%s)", user.ID, ret.RefundAmount, ret.OrderID, ret.Reason)
+
+	utils.SuccessResponseWithStatus(c, http.StatusCreated, "RETURN_CREATED_WITH_SUCCESS", ret)
+}
+
+// ListMyReturns godoc
+// @Summary      Listar minhas devoluções
+// @Description  Retorna as devoluções do usuário, das mais recentes para as mais antigas (requer autenticação)
+// @Tags         returns
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Success      200 {object} utils.Response{data=[]models.ReturnRequest} "Devoluções"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /user/returns [get]
+func (h *ReturnHandler) ListMyReturns(c *gin.Context) {
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	returns, err := h.returnService.ListByUser(c.Request.Context(), user.ID)
+	if err != nil {
+		h.errorResponse(c, "LIST_RETURNS_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "RETURNS_LISTED_SUCCESS", returns)
+}
+
+// GetMyReturn godoc
+// @Summary      Buscar devolução
+// @Description  Retorna uma devolução do usuário com itens e fotos (requer autenticação)
+// @Tags         returns
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID da devolução" example(1)
+// @Success      200 {object} utils.Response{data=models.ReturnRequest} "Devolução"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      404 {object} utils.Response "Devolução não encontrada"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /user/returns/{id} [get]
+func (h *ReturnHandler) GetMyReturn(c *gin.Context) {
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	ret, err := h.returnService.Get(c.Request.Context(), user.ID, uint(id))
+	if err != nil {
+		h.errorResponse(c, "GET_RETURN_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "RETURN_SUCCESS", ret)
+}
+
+// UploadReturnPhoto godoc
+// @Summary      Enviar foto da devolução
+// @Description  Anexa uma foto (JPEG, PNG ou WebP) à devolução enquanto ela aguarda análise, até 5 fotos (requer autenticação)
+// @Tags         returns
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID da devolução" example(1)
+// @Param        photo formData file true "Arquivo da foto"
+// @Success      201 {object} utils.Response{data=models.ReturnPhoto} "Foto enviada"
+// @Failure      400 {object} utils.Response "Foto inválida"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      404 {object} utils.Response "Devolução não encontrada"
+// @Failure      409 {object} utils.Response "Devolução já analisada"
+// @Failure      413 {object} utils.Response "Foto muito grande"
+// @Failure      415 {object} utils.Response "Tipo de imagem não suportado"
+// @Failure      422 {object} utils.Response "Limite de fotos atingido"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /user/returns/{id}/photos [post]
+func (h *ReturnHandler) UploadReturnPhoto(c *gin.Context) {
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxPhotoSize+multipartOverhead)
+
+	file, header, err := c.Request.FormFile("photo")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "IMAGE_TOO_LARGE", err)
+			return
+		}
+		utils.BadRequestResponse(c, "PHOTO_REQUIRED", err)
+		return
+	}
+	defer file.Close()
+
+	if header.Size > h.maxPhotoSize {
+		utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "IMAGE_TOO_LARGE", services.ErrImageTooLarge)
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, h.maxPhotoSize+1))
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_IMAGE", err)
+		return
+	}
+
+	photo, err := h.returnService.AddPhoto(c.Request.Context(), user.ID, uint(id), data)
+	if err != nil {
+		h.errorResponse(c, "ERROR_UPLOADING_RETURN_PHOTO", err)
+		return
+	}
+
+	utils.SuccessResponseWithStatus(c, http.StatusCreated, "RETURN_PHOTO_UPLOADED_WITH_SUCCESS", photo)
+}
+
+// ListReturns godoc
+// @Summary      Listar devoluções
+// @Description  Retorna as devoluções, das mais antigas para as mais recentes, opcionalmente filtradas pelo status (apenas admin)
+// @Tags         returns
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        status query string false "Status" Enums(requested, approved, rejected, received, refunded)
+// @Success      200 {object} utils.Response{data=[]models.ReturnRequest} "Devoluções"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/returns [get]
+func (h *ReturnHandler) ListReturns(c *gin.Context) {
+	returns, err := h.returnService.List(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		h.errorResponse(c, "LIST_RETURNS_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "RETURNS_LISTED_SUCCESS", returns)
+}
+
+// GetReturn godoc
+// @Summary      Buscar devolução (admin)
+// @Description  Retorna qualquer devolução com itens e fotos (apenas admin)
+// @Tags         returns
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID da devolução" example(1)
+// @Success      200 {object} utils.Response{data=models.ReturnRequest} "Devolução"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Devolução não encontrada"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/returns/{id} [get]
+func (h *ReturnHandler) GetReturn(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	ret, err := h.returnService.GetAny(c.Request.Context(), uint(id))
+	if err != nil {
+		h.errorResponse(c, "GET_RETURN_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "RETURN_SUCCESS", ret)
+}
+
+// ApproveReturn godoc
+// @Summary      Aprovar devolução
+// @Description  Aprova uma devolução solicitada; o cliente pode enviar os itens de volta (apenas admin)
+// @Tags         returns
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID da devolução" example(1)
+// @Success      200 {object} utils.Response{data=models.ReturnRequest} "Devolução aprovada"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Devolução não encontrada"
+// @Failure      409 {object} utils.Response "Devolução já analisada"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/returns/{id}/approve [post]
+func (h *ReturnHandler) ApproveReturn(c *gin.Context) {
+	admin, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	ret, err := h.returnService.Approve(c.Request.Context(), admin, uint(id))
+	if err != nil {
+		h.errorResponse(c, "ERROR_APPROVING_RETURN", err)
+		return
+	}
+
+	returnHandlerLog("Admin %s approved return %d", admin.Email, ret.ID)
+
+	utils.SuccessResponse(c, "RETURN_APPROVED_WITH_SUCCESS", ret)
+}
+
+// RejectReturn godoc
+// @Summary      Recusar devolução
+// @Description  Recusa uma devolução solicitada com o motivo mostrado ao cliente; os itens podem ser pedidos de novo (apenas admin)
+// @Tags         returns
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID da devolução" example(1)
+// @Param        rejection body types.RejectReturnRequest true "Motivo"
+// @Success      200 {object} utils.Response{data=models.ReturnRequest} "Devolução recusada"
+// @Failure      400 {object} utils.Response "Dados inválidos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Devolução não encontrada"
+// @Failure      409 {object} utils.Response "Devolução já analisada"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/returns/{id}/reject [post]
+func (h *ReturnHandler) RejectReturn(c *gin.Context) {
+	admin, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	var req types.RejectReturnRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	ret, err := h.returnService.Reject(c.Request.Context(), admin, uint(id), req.Reason)
+	if err != nil {
+		h.errorResponse(c, "ERROR_REJECTING_RETURN", err)
+		return
+	}
+
+	returnHandlerLog("Admin %s rejected return %d: %s", admin.Email, ret.ID, ret.RejectionReason)
+
+	utils.SuccessResponse(c, "RETURN_REJECTED_WITH_SUCCESS", ret)
+}
+
+// ReceiveReturn godoc
+// @Summary      Receber devolução
+// @Description  Registra a chegada dos itens de uma devolução aprovada e, com restock, devolve-os ao estoque (apenas admin)
+// @Tags         returns
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID da devolução" example(1)
+// @Param        receipt body types.ReceiveReturnRequest true "Devolver ao estoque"
+// @Success      200 {object} utils.Response{data=models.ReturnRequest} "Devolução recebida"
+// @Failure      400 {object} utils.Response "Dados inválidos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Devolução não encontrada"
+// @Failure      409 {object} utils.Response "Devolução não aprovada"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/returns/{id}/receive [post]
+func (h *ReturnHandler) ReceiveReturn(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	var req types.ReceiveReturnRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	ret, err := h.returnService.Receive(c.Request.Context(), uint(id), *req.Restock)
+	if err != nil {
+		h.errorResponse(c, "ERROR_RECEIVING_RETURN", err)
+		return
+	}
+
+	returnHandlerLog("Return %d received (restocked: %t)", ret.ID, ret.Restocked)
+
+	utils.SuccessResponse(c, "RETURN_RECEIVED_WITH_SUCCESS", ret)
+}
+
+// RefundReturn godoc
+// @Summary      Reembolsar devolução
+// @Description  Reembolsa uma devolução recebida pelo meio de pagamento do pedido; o pedido passa a parcial ou totalmente reembolsado (apenas admin)
+// @Tags         returns
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID da devolução" example(1)
+// @Success      200 {object} utils.Response{data=models.ReturnRequest} "Devolução reembolsada"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Devolução não encontrada"
+// @Failure      409 {object} utils.Response "Devolução não recebida"
+// @Failure      503 {object} utils.Response "Pedidos indisponíveis"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/returns/{id}/refund [post]
+func (h *ReturnHandler) RefundReturn(c *gin.Context) {
+	admin, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	ret, err := h.returnService.Refund(c.Request.Context(), uint(id))
+	if err != nil {
+		h.errorResponse(c, "ERROR_REFUNDING_RETURN", err)
+		return
+	}
+
+	returnHandlerLog("Admin %s refunded %s of return %d", admin.Email, ret.RefundAmount, ret.ID)
+
+	utils.SuccessResponse(c, "RETURN_REFUNDED_WITH_SUCCESS", ret)
+}
+
+func (h *ReturnHandler) errorResponse(c *gin.Context, message string, err error) {
+	if utils.ContextErrorResponse(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, services.ErrReturnNotFound):
+		utils.NotFoundResponse(c, "RETURN_NOT_FOUND", err)
+	case errors.Is(err, services.ErrOrderNotFound):
+		utils.NotFoundResponse(c, "ORDER_NOT_FOUND", err)
+	case errors.Is(err, services.ErrInvalidReturn):
+		utils.BadRequestResponse(c, "INVALID_RETURN", err)
+	case errors.Is(err, services.ErrInvalidImage):
+		utils.BadRequestResponse(c, "INVALID_IMAGE", err)
+	case errors.Is(err, services.ErrImageTooLarge):
+		utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "IMAGE_TOO_LARGE", err)
+	case errors.Is(err, services.ErrUnsupportedImageType):
+		utils.ErrorResponse(c, http.StatusUnsupportedMediaType, "UNSUPPORTED_IMAGE_TYPE", err)
+	case errors.Is(err, services.ErrReturnStatus):
+		utils.ErrorResponse(c, http.StatusConflict, "INVALID_RETURN_STATUS", err)
+	case errors.Is(err, services.ErrOrderNotDelivered):
+		utils.ErrorResponse(c, http.StatusUnprocessableEntity, "ORDER_NOT_DELIVERED", err)
+	case errors.Is(err, services.ErrReturnWindowClosed):
+		utils.ErrorResponse(c, http.StatusUnprocessableEntity, "RETURN_WINDOW_CLOSED", err)
+	case errors.Is(err, services.ErrReturnQuantityExceeded):
+		utils.ErrorResponse(c, http.StatusUnprocessableEntity, "RETURN_QUANTITY_EXCEEDED", err)
+	case errors.Is(err, services.ErrTooManyReturnPhotos):
+		utils.ErrorResponse(c, http.StatusUnprocessableEntity, "TOO_MANY_RETURN_PHOTOS", err)
+	case errors.Is(err, services.ErrOrdersUnavailable):
+		utils.ErrorResponse(c, http.StatusServiceUnavailable, "ORDERS_UNAVAILABLE", err)
+	default:
+		utils.InternalServerErrorResponse(c, message, err)
+	}
+}
+
+func returnHandlerLog(format string, v ...any) {
+	prefix := "[RETURN_HANDLER]"
+	message := fmt.Sprintf(format, v...)
+	log.Printf("%s %s", prefix, message)
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/services"
+	"github.com/Code-Aether/americanas-loja-api/internal/types"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type ReviewHandler struct {
+	reviewService *services.ReviewService
+	cursors       *pagination.Signer
+	validator     *validator.Validate
+}
+
+func NewReviewHandler(reviewService *services.ReviewService, cursors *pagination.Signer) *ReviewHandler {
+	return &ReviewHandler{
+		reviewService: reviewService,
+		cursors:       cursors,
+		validator:     utils.NewValidator(),
+	}
+}
+
+// CreateReview godoc
+// @Summary      Avaliar produto
+// @Description  Envia a avaliação (1 a 5 estrelas) do usuário para o produto; cada usuário avalia um produto uma vez. A avaliação fica pendente até ser aprovada pela moderação (requer autenticação)
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID do produto" example(1)
+// @Param        review body types.CreateReviewRequest true "Dados da avaliação"
+// @Success      201 {object} utils.Response{data=models.Review} "Avaliação enviada para moderação"
+// @Failure      400 {object} utils.Response "Dados inválidos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      404 {object} utils.Response "Produto não encontrado"
+// @Failure      409 {object} utils.Response "Usuário já avaliou o produto"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /products/{id}/reviews [post]
+func (h *ReviewHandler) CreateReview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	var req types.CreateReviewRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	review, err := h.reviewService.Create(c.Request.Context(), uint(id), user, services.ReviewInput{
+		Rating: req.Rating,
+		Title:  req.Title,
+		Body:   req.Body,
+	})
+	if err != nil {
+		h.errorResponse(c, "ERROR_CREATING_REVIEW", err)
+		return
+	}
+
+	reviewHandlerLog("User %s reviewed product %d with %d stars (review %d)", user.Email, id, review.Rating, review.ID)
+
+	utils.SuccessResponseWithStatus(c, http.StatusCreated, "REVIEW_CREATED_WITH_SUCCESS", review)
+}
+
+// ListReviews godoc
+// @Summary      Listar avaliações do produto
+// @Description  Retorna as avaliações aprovadas do produto paginadas por cursor, mais recentes primeiro. A nota média e o histograma estão no campo rating do produto
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        id     path  int    true  "ID do produto" example(1)
+// @Param        limit  query int    false "Itens por página" default(20)
+// @Param        cursor query string false "Cursor opaco recebido em next_cursor ou prev_cursor"
+// @Param        total  query string false "Contagem do total (padrão: exact na primeira página, none com cursor)" Enums(exact, approximate, none)
+// @Success      200 {object} utils.PaginatedResponse{data=[]models.Review} "Avaliações do produto"
+// @Failure      400 {object} utils.Response "Cursor inválido"
+// @Failure      404 {object} utils.Response "Produto não encontrado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /products/{id}/reviews [get]
+func (h *ReviewHandler) ListReviews(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	limit, count, cursor, ok := h.pageFromQuery(c)
+	if !ok {
+		return
+	}
+
+	listing, err := h.reviewService.List(c.Request.Context(), uint(id), limit, cursor, count)
+	if err != nil {
+		h.errorResponse(c, "LIST_REVIEWS_ERROR", err)
+		return
+	}
+
+	h.listingResponse(c, "REVIEWS_LISTED_SUCCESS", listing, limit)
+}
+
+// ListReviewQueue godoc
+// @Summary      Fila de moderação de avaliações
+// @Description  Retorna as avaliações de todos os produtos no status, paginadas por cursor, mais antigas primeiro (requer administrador)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        status query string false "Status das avaliações" Enums(pending, approved, rejected) default(pending)
+// @Param        limit  query int    false "Itens por página" default(20)
+// @Param        cursor query string false "Cursor opaco recebido em next_cursor ou prev_cursor"
+// @Param        total  query string false "Contagem do total (padrão: exact na primeira página, none com cursor)" Enums(exact, approximate, none)
+// @Success      200 {object} utils.PaginatedResponse{data=[]models.Review} "Avaliações no status"
+// @Failure      400 {object} utils.Response "Status ou cursor inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/reviews [get]
+func (h *ReviewHandler) ListReviewQueue(c *gin.Context) {
+	limit, count, cursor, ok := h.pageFromQuery(c)
+	if !ok {
+		return
+	}
+
+	status := c.DefaultQuery("status", models.ReviewPending)
+
+	listing, err := h.reviewService.Queue(c.Request.Context(), status, limit, cursor, count)
+	if err != nil {
+		h.errorResponse(c, "LIST_REVIEWS_ERROR", err)
+		return
+	}
+
+	h.listingResponse(c, "REVIEWS_LISTED_SUCCESS", listing, limit)
+}
+
+// ModerateReview godoc
+// @Summary      Moderar avaliação
+// @Description  Aprova ou rejeita a avaliação. Aprovar inclui a nota na média do produto; rejeitar uma avaliação aprovada a retira (requer administrador)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID da avaliação" example(1)
+// @Param        moderation body types.ModerateReviewRequest true "Decisão da moderação"
+// @Success      200 {object} utils.Response{data=models.Review} "Avaliação moderada"
+// @Failure      400 {object} utils.Response "Dados inválidos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Avaliação não encontrada"
+// @Failure      409 {object} utils.Response "Avaliação moderada por outra pessoa ao mesmo tempo"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/reviews/{id} [put]
+func (h *ReviewHandler) ModerateReview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	var req types.ModerateReviewRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	review, err := h.reviewService.Moderate(c.Request.Context(), uint(id), user, req.Status, req.Reason)
+	if err != nil {
+		h.errorResponse(c, "ERROR_MODERATING_REVIEW", err)
+		return
+	}
+
+	reviewHandlerLog("Admin %s set review %d of product %d to %s", user.Email, review.ID, review.ProductID, review.Status)
+
+	utils.SuccessResponse(c, "REVIEW_MODERATED_WITH_SUCCESS", review)
+}
+
+// pageFromQuery reads the limit, total and cursor parameters, answering the
+// request itself when they are invalid.
+func (h *ReviewHandler) pageFromQuery(c *gin.Context) (int, string, *pagination.Cursor, bool) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	count, err := countFromQuery(c)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_FILTERS", err)
+		return 0, "", nil, false
+	}
+
+	cursor, err := cursorFromQuery(c, h.cursors)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_CURSOR", err)
+		return 0, "", nil, false
+	}
+
+	return limit, count, cursor, true
+}
+
+func (h *ReviewHandler) listingResponse(c *gin.Context, message string, listing *models.ReviewListing, limit int) {
+	page := utils.Pagination{
+		Limit:      limit,
+		NextCursor: encodeCursor(h.cursors, listing.Next),
+		PrevCursor: encodeCursor(h.cursors, listing.Prev),
+	}
+	if listing.Total != nil {
+		page.SetTotal(*listing.Total, listing.TotalApproximate)
+	}
+
+	utils.PaginatedSuccessResponse(c, message, listing.Reviews, page)
+}
+
+func (h *ReviewHandler) errorResponse(c *gin.Context, message string, err error) {
+	if utils.ContextErrorResponse(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, pagination.ErrInvalidCursor):
+		utils.BadRequestResponse(c, "INVALID_CURSOR", err)
+	case errors.Is(err, services.ErrInvalidReviewStatus):
+		utils.BadRequestResponse(c, "INVALID_REVIEW_STATUS", err)
+	case errors.Is(err, services.ErrProductNotFound):
+		utils.NotFoundResponse(c, "PRODUCT_NOT_FOUND", err)
+	case errors.Is(err, services.ErrReviewNotFound):
+		utils.NotFoundResponse(c, "REVIEW_NOT_FOUND", err)
+	case errors.Is(err, services.ErrReviewExists):
+		utils.ErrorResponse(c, http.StatusConflict, "REVIEW_ALREADY_EXISTS", err)
+	case errors.Is(err, services.ErrReviewChanged):
+		utils.ErrorResponse(c, http.StatusConflict, "REVIEW_CHANGED", err)
+	default:
+		utils.InternalServerErrorResponse(c, message, err)
+	}
+}
+
+func reviewHandlerLog(format string, v ...any) {
+	prefix := "[REVIEW_HANDLER]"
+	message := fmt.Sprintf(format, v...)
+	log.Printf("%s %s", prefix, message)
+}
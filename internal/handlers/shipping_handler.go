@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/services"
+	"github.com/Code-Aether/americanas-loja-api/internal/types"
+	"github.com/Code-Aether/americanas-loja-api/pkg/br"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type ShippingHandler struct {
+	shippingService *services.ShippingService
+	validator       *validator.Validate
+}
+
+func NewShippingHandler(shippingService *services.ShippingService) *ShippingHandler {
+	return &ShippingHandler{
+		shippingService: shippingService,
+		validator:       utils.NewValidator(),
+	}
+}
+
+// QuoteShipping godoc
+// @Summary      Calcular frete
+// @Description  Calcula o frete dos itens até o CEP. O peso cobrado é o maior entre o peso real e o cúbico (volume em cm³ / 6000); produtos sem medidas usam as medidas padrão. Retorna as opções de entrega, da mais barata para a mais cara, com o prazo em dias úteis
+// @Tags         shipping
+// @Accept       json
+// @Produce      json
+// @Param        quote body types.ShippingQuoteRequest true "CEP e itens"
+// @Success      200 {object} utils.Response{data=services.ShippingQuote} "Opções de entrega"
+// @Failure      400 {object} utils.Response "Dados inválidos ou CEP inválido"
+// @Failure      404 {object} utils.Response "Produto ou variante não encontrado"
+// @Failure      422 {object} utils.Response "Nenhuma opção de entrega para o CEP e o pacote"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /shipping/quote [post]
+func (h *ShippingHandler) QuoteShipping(c *gin.Context) {
+	var req types.ShippingQuoteRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	quote, err := h.shippingService.Quote(c.Request.Context(), req.CEP, cartItems(req.Items))
+	if err != nil {
+		h.errorResponse(c, "QUOTE_SHIPPING_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "SHIPPING_QUOTED_SUCCESS", quote)
+}
+
+func (h *ShippingHandler) errorResponse(c *gin.Context, message string, err error) {
+	if utils.ContextErrorResponse(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, br.ErrInvalidCEP):
+		utils.BadRequestResponse(c, "INVALID_CEP", err)
+	case errors.Is(err, services.ErrProductNotFound):
+		utils.NotFoundResponse(c, "PRODUCT_NOT_FOUND", err)
+	case errors.Is(err, services.ErrVariantNotFound):
+		utils.NotFoundResponse(c, "VARIANT_NOT_FOUND", err)
+	case errors.Is(err, services.ErrNoShippingOptions):
+		utils.ErrorResponse(c, http.StatusUnprocessableEntity, "NO_SHIPPING_OPTIONS", err)
+	default:
+		utils.InternalServerErrorResponse(c, message, err)
+	}
+}
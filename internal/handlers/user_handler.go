@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/services"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type UserHandler struct {
+	userService *services.UserService
+	cursors     *pagination.Signer
+}
+
+func NewUserHandler(userService *services.UserService, cursors *pagination.Signer) *UserHandler {
+	return &UserHandler{
+		userService: userService,
+		cursors:     cursors,
+	}
+}
+
+// ListUsers godoc
+// @Summary      Listar usuários
+// @Description  Retorna os usuários paginados por cursor, mais recentes primeiro (requer administrador)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        limit  query int    false "Itens por página" default(20)
+// @Param        cursor query string false "Cursor opaco recebido em next_cursor ou prev_cursor"
+// @Param        total  query string false "Contagem do total (padrão: exact na primeira página, none com cursor)" Enums(exact, approximate, none)
+// @Success      200 {object} utils.PaginatedResponse{data=[]models.User} "Lista de usuários"
+// @Failure      400 {object} utils.Response "Cursor inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/users [get]
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	count, err := countFromQuery(c)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_FILTERS", err)
+		return
+	}
+
+	cursor, err := cursorFromQuery(c, h.cursors)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_CURSOR", err)
+		return
+	}
+
+	listing, err := h.userService.List(c.Request.Context(), limit, cursor, count)
+	if err != nil {
+		if utils.ContextErrorResponse(c, err) {
+			return
+		}
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			utils.BadRequestResponse(c, "INVALID_CURSOR", err)
+			return
+		}
+		utils.InternalServerErrorResponse(c, "LIST_USERS_ERROR", err)
+		return
+	}
+
+	page := utils.Pagination{
+		Limit:      limit,
+		NextCursor: encodeCursor(h.cursors, listing.Next),
+		PrevCursor: encodeCursor(h.cursors, listing.Prev),
+	}
+	if listing.Total != nil {
+		page.SetTotal(*listing.Total, listing.TotalApproximate)
+	}
+
+	utils.PaginatedSuccessResponse(c, "USERS_LISTED_SUCCESS", listing.Users, page)
+}
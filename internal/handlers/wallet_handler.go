@@ -0,0 +1,399 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/services"
+	"github.com/Code-Aether/americanas-loja-api/internal/types"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type WalletHandler struct {
+	walletService *services.WalletService
+	cursors       *pagination.Signer
+	validator     *validator.Validate
+}
+
+func NewWalletHandler(walletService *services.WalletService, cursors *pagination.Signer) *WalletHandler {
+	return &WalletHandler{
+		walletService: walletService,
+		cursors:       cursors,
+		validator:     utils.NewValidator(),
+	}
+}
+
+// GetWallet godoc
+// @Summary      Saldo da carteira
+// @Description  Retorna o saldo de crédito na loja do usuário e os créditos que expiram, dos mais próximos de expirar para os mais distantes (requer autenticação)
+// @Tags         wallet
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Success      200 {object} utils.Response{data=services.WalletBalance} "Saldo"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /user/wallet [get]
+func (h *WalletHandler) GetWallet(c *gin.Context) {
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	h.balanceResponse(c, user.ID)
+}
+
+// ListWalletTransactions godoc
+// @Summary      Extrato da carteira
+// @Description  Retorna os lançamentos da carteira do usuário (crédito, débito e expiração), paginados por cursor, mais recentes primeiro (requer autenticação)
+// @Tags         wallet
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        limit  query int    false "Itens por página" default(20)
+// @Param        cursor query string false "Cursor opaco recebido em next_cursor ou prev_cursor"
+// @Success      200 {object} utils.PaginatedResponse{data=[]models.WalletTransaction} "Lançamentos"
+// @Failure      400 {object} utils.Response "Cursor inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /user/wallet/transactions [get]
+func (h *WalletHandler) ListWalletTransactions(c *gin.Context) {
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	h.transactionsResponse(c, user.ID)
+}
+
+// RedeemGiftCard godoc
+// @Summary      Resgatar vale-presente
+// @Description  Transfere todo o saldo do vale-presente para a carteira do usuário; o crédito expira junto com o vale. O código aceita letras minúsculas e pode vir sem os hífens (requer autenticação)
+// @Tags         wallet
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        gift_card body types.RedeemGiftCardRequest true "Código do vale-presente"
+// @Success      201 {object} utils.Response{data=models.WalletTransaction} "Crédito lançado"
+// @Failure      400 {object} utils.Response "Dados inválidos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      404 {object} utils.Response "Vale-presente não encontrado"
+// @Failure      422 {object} utils.Response "Vale-presente já resgatado ou vencido"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /user/wallet/gift-cards [post]
+func (h *WalletHandler) RedeemGiftCard(c *gin.Context) {
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	var req types.RedeemGiftCardRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	txn, err := h.walletService.RedeemGiftCard(c.Request.Context(), user.ID, req.Code)
+	if err != nil {
+		h.errorResponse(c, "ERROR_REDEEMING_GIFT_CARD", err)
+		return
+	}
+
+	walletHandlerLog("User %d redeemed gift card %s worth %s", user.ID, txn.Reference, txn.Amount)
+
+	utils.SuccessResponseWithStatus(c, http.StatusCreated, "GIFT_CARD_REDEEMED_WITH_SUCCESS", txn)
+}
+
+// GetUserWallet godoc
+// @Summary      Saldo da carteira de um usuário
+// @Description  Retorna o saldo e os créditos que expiram da carteira do usuário (apenas admin)
+// @Tags         wallet
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID do usuário" example(1)
+// @Success      200 {object} utils.Response{data=services.WalletBalance} "Saldo"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/users/{id}/wallet [get]
+func (h *WalletHandler) GetUserWallet(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	h.balanceResponse(c, uint(id))
+}
+
+// ListUserWalletTransactions godoc
+// @Summary      Extrato da carteira de um usuário
+// @Description  Retorna os lançamentos da carteira do usuário, paginados por cursor, mais recentes primeiro (apenas admin)
+// @Tags         wallet
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id     path  int    true  "ID do usuário" example(1)
+// @Param        limit  query int    false "Itens por página" default(20)
+// @Param        cursor query string false "Cursor opaco recebido em next_cursor ou prev_cursor"
+// @Success      200 {object} utils.PaginatedResponse{data=[]models.WalletTransaction} "Lançamentos"
+// @Failure      400 {object} utils.Response "ID ou cursor inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/users/{id}/wallet/transactions [get]
+func (h *WalletHandler) ListUserWalletTransactions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	h.transactionsResponse(c, uint(id))
+}
+
+// AdjustWallet godoc
+// @Summary      Ajustar saldo da carteira
+// @Description  Credita (valor positivo) ou debita (valor negativo) a carteira do usuário, com motivo obrigatório registrado no extrato. O cabeçalho Idempotency-Key é obrigatório: repetir a requisição com a mesma chave não lança de novo (apenas admin)
+// @Tags         wallet
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id              path   int                           true "ID do usuário" example(1)
+// @Param        Idempotency-Key header string                        true "Chave única do ajuste"
+// @Param        adjustment      body   types.WalletAdjustmentRequest true "Valor e motivo"
+// @Success      201 {object} utils.Response{data=models.WalletTransaction} "Ajuste lançado"
+// @Failure      400 {object} utils.Response "Dados inválidos ou sem Idempotency-Key"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Usuário não encontrado"
+// @Failure      409 {object} utils.Response "Chave usada em outro lançamento"
+// @Failure      422 {object} utils.Response "Saldo insuficiente"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/users/{id}/wallet/adjustments [post]
+func (h *WalletHandler) AdjustWallet(c *gin.Context) {
+	admin, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	var req types.WalletAdjustmentRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	key := strings.TrimSpace(c.GetHeader("Idempotency-Key"))
+	txn, err := h.walletService.Adjust(c.Request.Context(), admin, uint(id), req.Amount, req.Reason, key, req.ExpiresAt)
+	if err != nil {
+		h.errorResponse(c, "ERROR_ADJUSTING_WALLET", err)
+		return
+	}
+
+	walletHandlerLog("Admin %s made a %s of %s on the wallet of user %d: %s", admin.Email, txn.Type, txn.Amount, id, txn.Reason)
+
+	utils.SuccessResponseWithStatus(c, http.StatusCreated, "WALLET_ADJUSTED_WITH_SUCCESS", txn)
+}
+
+// CreateGiftCard godoc
+// @Summary      Emitir vale-presente
+// @Description  Emite um vale-presente com código aleatório e o saldo informado. Sem expires_at, vale por GIFT_CARD_VALIDITY (apenas admin)
+// @Tags         wallet
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        gift_card body types.GiftCardRequest true "Saldo e validade"
+// @Success      201 {object} utils.Response{data=models.GiftCard} "Vale-presente emitido"
+// @Failure      400 {object} utils.Response "Dados inválidos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/gift-cards [post]
+func (h *WalletHandler) CreateGiftCard(c *gin.Context) {
+	admin, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	var req types.GiftCardRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	card := types.GiftCardFromRequest(&req)
+	if err := h.walletService.IssueGiftCard(c.Request.Context(), admin, card); err != nil {
+		h.errorResponse(c, "ERROR_CREATING_GIFT_CARD", err)
+		return
+	}
+
+	walletHandlerLog("Admin %s issued gift card %d worth %s", admin.Email, card.ID, card.Balance)
+
+	utils.SuccessResponseWithStatus(c, http.StatusCreated, "GIFT_CARD_CREATED_WITH_SUCCESS", card)
+}
+
+// ListGiftCards godoc
+// @Summary      Listar vales-presente
+// @Description  Retorna todos os vales-presente, dos mais recentes para os mais antigos, com quem os resgatou (apenas admin)
+// @Tags         wallet
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Success      200 {object} utils.Response{data=[]models.GiftCard} "Vales-presente"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/gift-cards [get]
+func (h *WalletHandler) ListGiftCards(c *gin.Context) {
+	cards, err := h.walletService.ListGiftCards(c.Request.Context())
+	if err != nil {
+		h.errorResponse(c, "LIST_GIFT_CARDS_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "GIFT_CARDS_LISTED_SUCCESS", cards)
+}
+
+// GetGiftCard godoc
+// @Summary      Obter vale-presente
+// @Description  Retorna o vale-presente com o saldo e o resgate (apenas admin)
+// @Tags         wallet
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID do vale-presente" example(1)
+// @Success      200 {object} utils.Response{data=models.GiftCard} "Vale-presente"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      403 {object} utils.Response "Acesso negado"
+// @Failure      404 {object} utils.Response "Vale-presente não encontrado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /admin/gift-cards/{id} [get]
+func (h *WalletHandler) GetGiftCard(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	card, err := h.walletService.GetGiftCard(c.Request.Context(), uint(id))
+	if err != nil {
+		h.errorResponse(c, "GET_GIFT_CARD_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "GIFT_CARD_SUCCESS", card)
+}
+
+func (h *WalletHandler) balanceResponse(c *gin.Context, userID uint) {
+	balance, err := h.walletService.Get(c.Request.Context(), userID)
+	if err != nil {
+		h.errorResponse(c, "GET_WALLET_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "WALLET_SUCCESS", balance)
+}
+
+func (h *WalletHandler) transactionsResponse(c *gin.Context, userID uint) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	cursor, err := cursorFromQuery(c, h.cursors)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_CURSOR", err)
+		return
+	}
+
+	listing, err := h.walletService.Transactions(c.Request.Context(), userID, limit, cursor)
+	if err != nil {
+		h.errorResponse(c, "LIST_WALLET_TRANSACTIONS_ERROR", err)
+		return
+	}
+
+	page := utils.Pagination{
+		Limit:      limit,
+		NextCursor: encodeCursor(h.cursors, listing.Next),
+		PrevCursor: encodeCursor(h.cursors, listing.Prev),
+	}
+	utils.PaginatedSuccessResponse(c, "WALLET_TRANSACTIONS_LISTED_SUCCESS", listing.Transactions, page)
+}
+
+func (h *WalletHandler) errorResponse(c *gin.Context, message string, err error) {
+	if utils.ContextErrorResponse(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, pagination.ErrInvalidCursor):
+		utils.BadRequestResponse(c, "INVALID_CURSOR", err)
+	case errors.Is(err, services.ErrUserNotFound):
+		utils.NotFoundResponse(c, "USER_NOT_FOUND", err)
+	case errors.Is(err, services.ErrGiftCardNotFound):
+		utils.NotFoundResponse(c, "GIFT_CARD_NOT_FOUND", err)
+	case errors.Is(err, services.ErrIdempotencyKeyNeeded):
+		utils.BadRequestResponse(c, "IDEMPOTENCY_KEY_REQUIRED", err)
+	case errors.Is(err, services.ErrInvalidWalletAmount):
+		utils.BadRequestResponse(c, "INVALID_WALLET_AMOUNT", err)
+	case errors.Is(err, services.ErrInvalidGiftCard):
+		utils.BadRequestResponse(c, "INVALID_GIFT_CARD", err)
+	case errors.Is(err, services.ErrIdempotencyKeyReused):
+		utils.ErrorResponse(c, http.StatusConflict, "IDEMPOTENCY_KEY_REUSED", err)
+	case errors.Is(err, services.ErrGiftCardCodeTaken):
+		utils.ErrorResponse(c, http.StatusConflict, "GIFT_CARD_CODE_TAKEN", err)
+	case errors.Is(err, services.ErrInsufficientBalance):
+		utils.ErrorResponse(c, http.StatusUnprocessableEntity, "INSUFFICIENT_WALLET_BALANCE", err)
+	case errors.Is(err, services.ErrGiftCardRedeemed):
+		utils.ErrorResponse(c, http.StatusUnprocessableEntity, "GIFT_CARD_ALREADY_REDEEMED", err)
+	case errors.Is(err, services.ErrGiftCardExpired):
+		utils.ErrorResponse(c, http.StatusUnprocessableEntity, "GIFT_CARD_EXPIRED", err)
+	default:
+		utils.InternalServerErrorResponse(c, message, err)
+	}
+}
+
+func walletHandlerLog(format string, v ...any) {
+	prefix := "[WALLET_HANDLER]"
+	message := fmt.Sprintf(format, v...)
+	log.Printf("%s %s", prefix, message)
+}
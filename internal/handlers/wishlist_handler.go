@@ -0,0 +1,434 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/services"
+	"github.com/Code-Aether/americanas-loja-api/internal/types"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type WishlistHandler struct {
+	wishlistService    *services.WishlistService
+	backInStockService *services.BackInStockService
+	validator          *validator.Validate
+}
+
+func NewWishlistHandler(wishlistService *services.WishlistService, backInStockService *services.BackInStockService) *WishlistHandler {
+	return &WishlistHandler{
+		wishlistService:    wishlistService,
+		backInStockService: backInStockService,
+		validator:          utils.NewValidator(),
+	}
+}
+
+// ListWishlists godoc
+// @Summary      Listar listas de desejos
+// @Description  Retorna as listas de desejos do usuário com seus produtos (requer autenticação)
+// @Tags         wishlist
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Success      200 {object} utils.Response{data=[]models.Wishlist} "Listas de desejos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /user/wishlist [get]
+func (h *WishlistHandler) ListWishlists(c *gin.Context) {
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	wishlists, err := h.wishlistService.List(c.Request.Context(), user.ID)
+	if err != nil {
+		h.errorResponse(c, "LIST_WISHLISTS_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "WISHLISTS_LISTED_SUCCESS", wishlists)
+}
+
+// CreateWishlist godoc
+// @Summary      Criar lista de desejos
+// @Description  Cria uma lista de desejos com o nome informado; cada lista do usuário tem um nome diferente (requer autenticação)
+// @Tags         wishlist
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        wishlist body types.CreateWishlistRequest true "Dados da lista"
+// @Success      201 {object} utils.Response{data=models.Wishlist} "Lista criada com sucesso"
+// @Failure      400 {object} utils.Response "Dados inválidos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      409 {object} utils.Response "Já existe uma lista com esse nome"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /user/wishlist [post]
+func (h *WishlistHandler) CreateWishlist(c *gin.Context) {
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	var req types.CreateWishlistRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	wishlist, err := h.wishlistService.Create(c.Request.Context(), user.ID, req.Name)
+	if err != nil {
+		h.errorResponse(c, "ERROR_CREATING_WISHLIST", err)
+		return
+	}
+
+	utils.SuccessResponseWithStatus(c, http.StatusCreated, "WISHLIST_CREATED_WITH_SUCCESS", wishlist)
+}
+
+// GetWishlist godoc
+// @Summary      Obter lista de desejos
+// @Description  Retorna uma lista de desejos do usuário com seus produtos (requer autenticação)
+// @Tags         wishlist
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID da lista" example(1)
+// @Success      200 {object} utils.Response{data=models.Wishlist} "Lista de desejos"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      404 {object} utils.Response "Lista não encontrada"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /user/wishlist/{id} [get]
+func (h *WishlistHandler) GetWishlist(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	wishlist, err := h.wishlistService.Get(c.Request.Context(), user.ID, uint(id))
+	if err != nil {
+		h.errorResponse(c, "GET_WISHLIST_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "WISHLIST_SUCCESS", wishlist)
+}
+
+// UpdateWishlist godoc
+// @Summary      Atualizar lista de desejos
+// @Description  Renomeia a lista e liga ou desliga o compartilhamento. Compartilhar gera um share_token para o link público; desligar invalida o link (requer autenticação)
+// @Tags         wishlist
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID da lista" example(1)
+// @Param        wishlist body types.UpdateWishlistRequest true "Dados da lista"
+// @Success      200 {object} utils.Response{data=models.Wishlist} "Lista atualizada com sucesso"
+// @Failure      400 {object} utils.Response "Dados inválidos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      404 {object} utils.Response "Lista não encontrada"
+// @Failure      409 {object} utils.Response "Já existe uma lista com esse nome"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /user/wishlist/{id} [put]
+func (h *WishlistHandler) UpdateWishlist(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	var req types.UpdateWishlistRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	wishlist, err := h.wishlistService.Update(c.Request.Context(), user.ID, uint(id), req.Name, req.Shared)
+	if err != nil {
+		h.errorResponse(c, "ERROR_UPDATING_WISHLIST", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "WISHLIST_UPDATED_WITH_SUCCESS", wishlist)
+}
+
+// DeleteWishlist godoc
+// @Summary      Remover lista de desejos
+// @Description  Remove a lista de desejos e seus itens (requer autenticação)
+// @Tags         wishlist
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID da lista" example(1)
+// @Success      200 {object} utils.Response "Lista removida com sucesso"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      404 {object} utils.Response "Lista não encontrada"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /user/wishlist/{id} [delete]
+func (h *WishlistHandler) DeleteWishlist(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	if err := h.wishlistService.Delete(c.Request.Context(), user.ID, uint(id)); err != nil {
+		h.errorResponse(c, "ERROR_DELETING_WISHLIST", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "WISHLIST_DELETED_WITH_SUCCESS", nil)
+}
+
+// AddWishlistItem godoc
+// @Summary      Adicionar produto à lista
+// @Description  Adiciona o produto à lista de desejos; adicionar de novo não duplica o item (requer autenticação)
+// @Tags         wishlist
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID da lista" example(1)
+// @Param        item body types.AddWishlistItemRequest true "Produto"
+// @Success      200 {object} utils.Response{data=models.Wishlist} "Lista atualizada"
+// @Failure      400 {object} utils.Response "Dados inválidos"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      404 {object} utils.Response "Lista ou produto não encontrado"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /user/wishlist/{id}/items [post]
+func (h *WishlistHandler) AddWishlistItem(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	var req types.AddWishlistItemRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		utils.BadRequestResponse(c, "INVALID_DATA", err)
+		return
+	}
+
+	wishlist, err := h.wishlistService.AddItem(c.Request.Context(), user.ID, uint(id), req.ProductID)
+	if err != nil {
+		h.errorResponse(c, "ERROR_ADDING_WISHLIST_ITEM", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "WISHLIST_ITEM_ADDED_WITH_SUCCESS", wishlist)
+}
+
+// RemoveWishlistItem godoc
+// @Summary      Remover produto da lista
+// @Description  Remove o produto da lista de desejos (requer autenticação)
+// @Tags         wishlist
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID da lista" example(1)
+// @Param        productId path int true "ID do produto" example(1)
+// @Success      200 {object} utils.Response "Produto removido da lista"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      404 {object} utils.Response "Lista não encontrada ou produto fora da lista"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /user/wishlist/{id}/items/{productId} [delete]
+func (h *WishlistHandler) RemoveWishlistItem(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	productID, err := strconv.ParseUint(c.Param("productId"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_PRODUCT_ID", err)
+		return
+	}
+
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	if err := h.wishlistService.RemoveItem(c.Request.Context(), user.ID, uint(id), uint(productID)); err != nil {
+		h.errorResponse(c, "ERROR_REMOVING_WISHLIST_ITEM", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "WISHLIST_ITEM_REMOVED_WITH_SUCCESS", nil)
+}
+
+// GetSharedWishlist godoc
+// @Summary      Lista de desejos compartilhada
+// @Description  Retorna a lista de desejos compartilhada pelo link, sem exigir login
+// @Tags         wishlist
+// @Accept       json
+// @Produce      json
+// @Param        token path string true "share_token da lista"
+// @Success      200 {object} utils.Response{data=models.Wishlist} "Lista de desejos"
+// @Failure      404 {object} utils.Response "Lista não encontrada ou não compartilhada"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /wishlists/shared/{token} [get]
+func (h *WishlistHandler) GetSharedWishlist(c *gin.Context) {
+	wishlist, err := h.wishlistService.GetShared(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		h.errorResponse(c, "GET_WISHLIST_ERROR", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "WISHLIST_SUCCESS", wishlist)
+}
+
+// NotifyWhenInStock godoc
+// @Summary      Avise-me quando chegar
+// @Description  Pede um aviso quando o produto, hoje sem estoque, voltar a ficar disponível. O aviso é enviado uma vez; depois disso é possível pedir de novo (requer autenticação)
+// @Tags         wishlist
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID do produto" example(1)
+// @Success      201 {object} utils.Response{data=models.StockSubscription} "Aviso registrado"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      404 {object} utils.Response "Produto não encontrado"
+// @Failure      409 {object} utils.Response "Produto está em estoque"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /products/{id}/notify-me [post]
+func (h *WishlistHandler) NotifyWhenInStock(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	subscription, err := h.backInStockService.Subscribe(c.Request.Context(), user.ID, uint(id))
+	if err != nil {
+		h.errorResponse(c, "ERROR_SUBSCRIBING_TO_STOCK", err)
+		return
+	}
+
+	wishlistHandlerLog("User %s asked to be notified when product %d is back in stock", user.Email, id)
+
+	utils.SuccessResponseWithStatus(c, http.StatusCreated, "STOCK_NOTIFICATION_CREATED", subscription)
+}
+
+// CancelStockNotification godoc
+// @Summary      Cancelar aviso de estoque
+// @Description  Cancela o pedido de aviso de volta ao estoque do produto (requer autenticação)
+// @Tags         wishlist
+// @Accept       json
+// @Produce      json
+// @Security     Bearer
+// @Param        id path int true "ID do produto" example(1)
+// @Success      200 {object} utils.Response "Aviso cancelado"
+// @Failure      400 {object} utils.Response "ID inválido"
+// @Failure      401 {object} utils.Response "Token inválido"
+// @Failure      404 {object} utils.Response "Nenhum aviso pendente para o produto"
+// @Failure      500 {object} utils.Response "Erro interno"
+// @Router       /products/{id}/notify-me [delete]
+func (h *WishlistHandler) CancelStockNotification(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequestResponse(c, "INVALID_ID", err)
+		return
+	}
+
+	user, err := checkUserLogged(c)
+	if err != nil {
+		utils.UnathorizedResponse(c, "USER_NOT_AUTHENTICATED")
+		return
+	}
+
+	if err := h.backInStockService.Unsubscribe(c.Request.Context(), user.ID, uint(id)); err != nil {
+		h.errorResponse(c, "ERROR_CANCELLING_STOCK_NOTIFICATION", err)
+		return
+	}
+
+	utils.SuccessResponse(c, "STOCK_NOTIFICATION_CANCELLED", nil)
+}
+
+func (h *WishlistHandler) errorResponse(c *gin.Context, message string, err error) {
+	if utils.ContextErrorResponse(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, services.ErrWishlistNotFound):
+		utils.NotFoundResponse(c, "WISHLIST_NOT_FOUND", err)
+	case errors.Is(err, services.ErrWishlistItemNotFound):
+		utils.NotFoundResponse(c, "WISHLIST_ITEM_NOT_FOUND", err)
+	case errors.Is(err, services.ErrProductNotFound):
+		utils.NotFoundResponse(c, "PRODUCT_NOT_FOUND", err)
+	case errors.Is(err, services.ErrSubscriptionNotFound):
+		utils.NotFoundResponse(c, "STOCK_NOTIFICATION_NOT_FOUND", err)
+	case errors.Is(err, services.ErrWishlistNameTaken):
+		utils.ErrorResponse(c, http.StatusConflict, "WISHLIST_NAME_TAKEN", err)
+	case errors.Is(err, services.ErrProductInStock):
+		utils.ErrorResponse(c, http.StatusConflict, "PRODUCT_IN_STOCK", err)
+	default:
+		utils.InternalServerErrorResponse(c, message, err)
+	}
+}
+
+func wishlistHandlerLog(format string, v ...any) {
+	prefix := "[WISHLIST_HANDLER]"
+	message := fmt.Sprintf(format, v...)
+	log.Printf("%s %s", prefix, message)
+}
@@ -45,7 +45,7 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
-		user, err := m.authService.GetUserByToken(tokenString)
+		user, err := m.authService.GetUserByToken(c.Request.Context(), tokenString)
 		if err != nil {
 			authMiddlewareLog("Token is invalid, or expired")
 			utils.ErrorResponse(c, http.StatusUnauthorized, "invalid token", nil)
@@ -117,7 +117,7 @@ func (m *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 			return
 		}
 
-		user, err := m.authService.GetUserByToken(tokenString)
+		user, err := m.authService.GetUserByToken(c.Request.Context(), tokenString)
 		if err == nil {
 			c.Set("user", user)
 			c.Set("user_id", user.ID)
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout bounds the request context, so database and cache work started by
+// the handler is cancelled once the deadline passes or the client goes away.
+// Routes in streaming, like feed exports, run for as long as the client
+// keeps reading and are left unbounded.
+func Timeout(timeout time.Duration, streaming ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 || slices.Contains(streaming, c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,67 @@
+// internal/middleware/timeout_middleware_test.go
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeout(t *testing.T) {
+	t.Run("✅ Define deadline no contexto da requisição", func(t *testing.T) {
+		c, _ := testutils.MockGinContext()
+
+		req, err := http.NewRequest("GET", "/products", nil)
+		require.NoError(t, err)
+		c.Request = req
+
+		Timeout(time.Second)(c)
+
+		deadline, ok := c.Request.Context().Deadline()
+		assert.True(t, ok, "Contexto deve ter deadline")
+		assert.WithinDuration(t, time.Now().Add(time.Second), deadline, time.Second)
+	})
+
+	t.Run("✅ Timeout zero não altera o contexto", func(t *testing.T) {
+		c, _ := testutils.MockGinContext()
+
+		req, err := http.NewRequest("GET", "/products", nil)
+		require.NoError(t, err)
+		c.Request = req
+
+		Timeout(0)(c)
+
+		_, ok := c.Request.Context().Deadline()
+		assert.False(t, ok, "Contexto não deve ter deadline")
+	})
+
+	t.Run("✅ Rotas de streaming não têm deadline", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.Use(Timeout(time.Second, "/export"))
+
+		deadlines := map[string]bool{}
+		handler := func(c *gin.Context) {
+			_, ok := c.Request.Context().Deadline()
+			deadlines[c.FullPath()] = ok
+		}
+		r.GET("/export", handler)
+		r.GET("/products", handler)
+
+		for _, path := range []string{"/export", "/products"} {
+			req, err := http.NewRequest("GET", path, nil)
+			require.NoError(t, err)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+		}
+
+		assert.False(t, deadlines["/export"], "Rota de streaming não deve ter deadline")
+		assert.True(t, deadlines["/products"], "Demais rotas devem ter deadline")
+	})
+}
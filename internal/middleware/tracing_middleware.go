@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Tracing starts a server span for every request, continuing any incoming
+// W3C traceparent, and echoes the resulting trace context back in the
+// response headers so clients can correlate their calls.
+func Tracing(serviceName string) []gin.HandlerFunc {
+	return []gin.HandlerFunc{
+		otelgin.Middleware(serviceName),
+		func(c *gin.Context) {
+			otel.GetTextMapPropagator().Inject(c.Request.Context(), propagation.HeaderCarrier(c.Writer.Header()))
+			c.Next()
+		},
+	}
+}
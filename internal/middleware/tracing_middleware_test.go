@@ -0,0 +1,57 @@
+// internal/middleware/tracing_middleware_test.go
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	r := gin.New()
+	r.Use(Tracing("test-service")...)
+	r.GET("/products/:id", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	t.Run("✅ Continua o traceparent recebido", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/products/1", nil)
+		require.NoError(t, err)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("traceparent"), "4bf92f3577b34da6a3ce929d0e0e4736", "Trace ID deve ser propagado")
+
+		spans := recorder.Ended()
+		require.NotEmpty(t, spans, "Span do handler deve ser registrado")
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[len(spans)-1].SpanContext().TraceID().String())
+	})
+
+	t.Run("✅ Inicia novo trace sem traceparent", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/products/1", nil)
+		require.NoError(t, err)
+
+		r.ServeHTTP(w, req)
+
+		assert.NotEmpty(t, w.Header().Get("traceparent"), "Response deve conter traceparent")
+	})
+}
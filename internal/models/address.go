@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Address is a delivery address in the address book of a user. Each user
+// with addresses has exactly one default address.
+type Address struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	UserID uint   `json:"-" gorm:"not null;index"`
+	Label  string `json:"label" gorm:"size:50" example:"Casa"`
+	PostalAddress
+	IsDefault bool      `json:"is_default" gorm:"not null;default:false"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PostalAddress is where a delivery goes. Orders keep a copy, from
+// Address.Snapshot, so later edits to the address book do not change where
+// past orders were sent.
+type PostalAddress struct {
+	Recipient string `json:"recipient" gorm:"not null;size:100" example:"Maria Silva"`
+	// CEP is stored as 01310-100.
+	CEP          string `json:"cep" gorm:"not null;size:9" example:"01310-100"`
+	Street       string `json:"street" gorm:"not null;size:200" example:"Avenida Paulista"`
+	Number       string `json:"number" gorm:"not null;size:20" example:"1578"`
+	Complement   string `json:"complement" gorm:"size:100" example:"Apto 42"`
+	Neighborhood string `json:"neighborhood" gorm:"not null;size:100" example:"Bela Vista"`
+	City         string `json:"city" gorm:"not null;size:100" example:"São Paulo"`
+	State        string `json:"state" gorm:"not null;size:2" example:"SP"`
+}
+
+// Snapshot returns a copy of the postal address, to keep with an order.
+func (a *Address) Snapshot() PostalAddress {
+	return a.PostalAddress
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// LowStockAlert records that a product fell to its reorder threshold. It
+// stays open, and the product is not alerted again, until the product is
+// restocked above the threshold.
+//
+// The threshold is the ReorderThreshold of the product, or else of the
+// nearest category up its tree that sets one, or else the store default.
+type LowStockAlert struct {
+	ID        uint `json:"id" gorm:"primaryKey"`
+	ProductID uint `json:"product_id" gorm:"not null"`
+	// Stock and Threshold are the values when the alert opened; the current
+	// stock is in Product.
+	Stock      int        `json:"stock" gorm:"not null"`
+	Threshold  int        `json:"threshold" gorm:"not null"`
+	Product    *Product   `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+)
+
+// Formats of the catalog export.
+const (
+	ExportFormatCSV      = "csv"
+	ExportFormatNDJSON   = "ndjson"
+	ExportFormatMerchant = "xml"
+)
+
+// Availability of a catalog item, in the vocabulary of Google Merchant
+// Center that marketplaces also accept.
+const (
+	AvailabilityInStock    = "in_stock"
+	AvailabilityOutOfStock = "out_of_stock"
+)
+
+// CatalogItem is a sellable unit in the catalog feeds: a product without
+// variants, or one variant of a product. Variants of the same product share
+// ItemGroupID, the product SKU.
+type CatalogItem struct {
+	ID                  string            `json:"id" example:"NIKE-AIRMAX-90-42"`
+	ItemGroupID         string            `json:"item_group_id,omitempty" example:"NIKE-AIRMAX-90"`
+	ProductID           uint              `json:"product_id" example:"1"`
+	VariantID           uint              `json:"variant_id,omitempty" example:"3"`
+	Title               string            `json:"title" example:"Tênis Nike Air Max 90"`
+	Description         string            `json:"description"`
+	Category            string            `json:"category" example:"Moda > Calçados"`
+	Link                string            `json:"link" example:"https://loja.example.com/produtos/1"`
+	ImageURL            string            `json:"image_url,omitempty"`
+	AdditionalImageURLs []string          `json:"additional_image_urls,omitempty"`
+	Price               money.Money       `json:"price" swaggertype:"number" example:"599.90"`
+	SalePrice           *money.Money      `json:"sale_price,omitempty" swaggertype:"number" example:"499.90"`
+	SaleEndsAt          *time.Time        `json:"sale_ends_at,omitempty"`
+	Stock               int               `json:"stock" example:"5"`
+	Availability        string            `json:"availability" example:"in_stock"`
+	Options             map[string]string `json:"options,omitempty"`
+	UpdatedAt           time.Time         `json:"updated_at"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Category is a node of the catalog tree. Path is a materialized path made of
+// the ancestor IDs and the node's own ID ("/1/4/"), so a whole subtree can be
+// selected with a single prefix match.
+type Category struct {
+	ID               uint        `json:"id" gorm:"primaryKey"`
+	Name             string      `json:"name" gorm:"not null;size:100"`
+	Slug             string      `json:"slug" gorm:"uniqueIndex;not null;size:120"`
+	ParentID         *uint       `json:"parent_id"`
+	Path             string      `json:"path" gorm:"not null;index"`
+	Depth            int         `json:"depth" gorm:"not null;default:0"`
+	SortOrder        int         `json:"sort_order" gorm:"not null;default:0"`
+	Active           bool        `json:"active"`
+	ReorderThreshold *int        `json:"reorder_threshold,omitempty"`
+	Children         []*Category `json:"children,omitempty" gorm:"-"`
+	CreatedAt        time.Time   `json:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at"`
+}
+
+// PathFor returns the materialized path of a category with the given ID
+// placed under parentPath (empty for a root category).
+func PathFor(parentPath string, id uint) string {
+	if parentPath == "" {
+		parentPath = "/"
+	}
+	return fmt.Sprintf("%s%d/", parentPath, id)
+}
+
+// IsAncestorOf reports whether c is other itself or one of its ancestors.
+func (c *Category) IsAncestorOf(other *Category) bool {
+	return strings.HasPrefix(other.Path, c.Path)
+}
+
+// BuildCategoryTree nests a flat list of categories under their parents.
+// The input order is kept among siblings; categories whose parent is not in
+// the list are returned as roots.
+func BuildCategoryTree(categories []Category) []*Category {
+	nodes := make(map[uint]*Category, len(categories))
+	for i := range categories {
+		categories[i].Children = nil
+		nodes[categories[i].ID] = &categories[i]
+	}
+
+	roots := make([]*Category, 0)
+	for i := range categories {
+		node := &categories[i]
+		if node.ParentID != nil {
+			if parent, ok := nodes[*node.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	return roots
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+)
+
+// Coupon types.
+const (
+	// CouponPercentage takes Percent off the eligible items.
+	CouponPercentage = "percentage"
+	// CouponFixed takes Amount off the eligible items, spread over them.
+	CouponFixed = "fixed"
+	// CouponFreeShipping waives the shipping of the order.
+	CouponFreeShipping = "free_shipping"
+	// CouponBuyXGetY gives GetQuantity units free for every BuyQuantity
+	// units bought of the eligible items; the cheapest units are the free
+	// ones.
+	CouponBuyXGetY = "buy_x_get_y"
+)
+
+// Coupon is a discount code. It applies between StartsAt and EndsAt, to
+// carts worth at least MinSubtotal, and up to UsageLimit redemptions in
+// total and PerUserLimit per customer; nil limits and window ends are open.
+//
+// Items are eligible when they match the include lists, or any item when
+// both are empty, and match none of the exclude lists. A category in a
+// list covers its subcategories.
+type Coupon struct {
+	ID                uint        `json:"id" gorm:"primaryKey"`
+	Code              string      `json:"code" gorm:"uniqueIndex;not null;size:50"`
+	Description       string      `json:"description"`
+	Type              string      `json:"type" gorm:"not null;size:20"`
+	Percent           int         `json:"percent,omitempty"`
+	Amount            money.Money `json:"amount" gorm:"not null" swaggertype:"number"`
+	BuyQuantity       int         `json:"buy_quantity,omitempty"`
+	GetQuantity       int         `json:"get_quantity,omitempty"`
+	MinSubtotal       money.Money `json:"min_subtotal" gorm:"not null" swaggertype:"number"`
+	StartsAt          *time.Time  `json:"starts_at,omitempty"`
+	EndsAt            *time.Time  `json:"ends_at,omitempty"`
+	UsageLimit        *int        `json:"usage_limit,omitempty"`
+	PerUserLimit      *int        `json:"per_user_limit,omitempty"`
+	UsedCount         int         `json:"used_count" gorm:"not null;default:0"`
+	IncludeCategories IDList      `json:"include_categories" gorm:"type:text"`
+	ExcludeCategories IDList      `json:"exclude_categories" gorm:"type:text"`
+	IncludeSKUs       StringList  `json:"include_skus" gorm:"column:include_skus;type:text"`
+	ExcludeSKUs       StringList  `json:"exclude_skus" gorm:"column:exclude_skus;type:text"`
+	Active            bool        `json:"active"`
+	CreatedAt         time.Time   `json:"created_at"`
+	UpdatedAt         time.Time   `json:"updated_at"`
+}
+
+// CouponUsage counts the redemptions of a coupon by a customer.
+type CouponUsage struct {
+	CouponID  uint      `json:"coupon_id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"primaryKey"`
+	Uses      int       `json:"uses" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IDList is stored as a JSON array in a text column.
+type IDList []uint
+
+func (l IDList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]uint(l))
+	return string(data), err
+}
+
+func (l *IDList) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("cannot scan %T into IDList", value)
+	}
+
+	if len(data) == 0 {
+		*l = nil
+		return nil
+	}
+	return json.Unmarshal(data, (*[]uint)(l))
+}
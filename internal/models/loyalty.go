@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+)
+
+// Loyalty transaction types.
+const (
+	// LoyaltyAccrue earns the cashback of a paid order as pending points.
+	LoyaltyAccrue = "accrue"
+	// LoyaltyRelease makes the points of an accrual available once its
+	// delay is over.
+	LoyaltyRelease = "release"
+	// LoyaltyCancel takes away the pending points of an accrual, as when
+	// its order is returned before they are released.
+	LoyaltyCancel = "cancel"
+	// LoyaltyRedeem spends available points at checkout.
+	LoyaltyRedeem = "redeem"
+	// LoyaltyExpire takes away what was left of an accrual when it expired.
+	LoyaltyExpire = "expire"
+)
+
+// PointsValue is what points are worth at checkout: each point is one
+// cent.
+func PointsValue(points int64) money.Money {
+	return money.FromCents(points)
+}
+
+// LoyaltyRule sets the cashback rate of the products of a category and its
+// subcategories, down to those with a rule of their own. Rate is in basis
+// points (250 is 2.5%); a rate of zero leaves the category out of the
+// program.
+type LoyaltyRule struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	CategoryID uint      `json:"category_id" gorm:"uniqueIndex;not null"`
+	Rate       int       `json:"rate" gorm:"not null"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// LoyaltyAccount holds the points of a user. Available and Pending are the
+// sums of the ledger, kept with it so reads need not add them up; Version
+// changes with every transaction, so concurrent ones cannot both spend the
+// same points.
+type LoyaltyAccount struct {
+	UserID    uint      `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	Available int64     `json:"available" gorm:"not null"`
+	Pending   int64     `json:"pending" gorm:"not null"`
+	Version   int       `json:"-" gorm:"not null;default:0"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LoyaltyTransaction is an entry of the points ledger of a user, which is
+// only ever appended to. Points is positive for every type; AvailableAfter
+// and PendingAfter are the balances right after the entry.
+//
+// IdempotencyKey is unique per user: applying a transaction again with the
+// same key returns the recorded one instead of moving the points twice.
+type LoyaltyTransaction struct {
+	ID             uint   `json:"id" gorm:"primaryKey"`
+	UserID         uint   `json:"-" gorm:"not null;index"`
+	Type           string `json:"type" gorm:"not null;size:10"`
+	Points         int64  `json:"points" gorm:"not null"`
+	AvailableAfter int64  `json:"available_after" gorm:"not null"`
+	PendingAfter   int64  `json:"pending_after" gorm:"not null"`
+	// Reference is the order an accrual, cancel or redeem entry is for.
+	Reference      string `json:"reference,omitempty"`
+	IdempotencyKey string `json:"-" gorm:"not null"`
+	// AvailableAt and ExpiresAt are when the points of an accrual are
+	// released and when what is left of them expires.
+	AvailableAt *time.Time `json:"available_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	// AccrualID is the accrual a release, cancel or expire entry is for.
+	AccrualID *uint     `json:"accrual_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LoyaltyLot is what is left of the points of an accrual, pending until
+// AvailableAt and then spendable until ExpiresAt. Redemptions take from the
+// lots that expire first. The lots of an account add up to its balances.
+type LoyaltyLot struct {
+	TransactionID uint      `json:"transaction_id" gorm:"primaryKey;autoIncrement:false"`
+	UserID        uint      `json:"-" gorm:"not null;index"`
+	Remaining     int64     `json:"remaining" gorm:"not null"`
+	Pending       bool      `json:"pending" gorm:"not null"`
+	AvailableAt   time.Time `json:"available_at" gorm:"not null"`
+	ExpiresAt     time.Time `json:"expires_at" gorm:"not null"`
+}
+
+// LoyaltyTransactionListing is one page of a points ledger with the
+// cursors to its neighbours.
+type LoyaltyTransactionListing struct {
+	Transactions []LoyaltyTransaction
+	Next         *pagination.Cursor
+	Prev         *pagination.Cursor
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+)
+
+// Reasons recorded in the price history.
+const (
+	PriceChangeCreated     = "created"
+	PriceChangeUpdated     = "updated"
+	PriceChangeSaleStarted = "sale_started"
+	PriceChangeSaleEnded   = "sale_ended"
+)
+
+// ProductSale schedules a promotional price for a window of time. Windows
+// of the same product never overlap; the price scheduler applies each one
+// to the product when it starts and removes it when it ends.
+type ProductSale struct {
+	ID        uint        `json:"id" gorm:"primaryKey"`
+	ProductID uint        `json:"product_id" gorm:"not null;index"`
+	SalePrice money.Money `json:"sale_price" gorm:"not null" swaggertype:"number" example:"799.00"`
+	StartsAt  time.Time   `json:"starts_at" gorm:"not null" example:"2026-11-27T00:00:00-03:00"`
+	EndsAt    time.Time   `json:"ends_at" gorm:"not null" example:"2026-11-30T23:59:59-03:00"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// Active reports whether the sale is running at t.
+func (s *ProductSale) Active(t time.Time) bool {
+	return !t.Before(s.StartsAt) && t.Before(s.EndsAt)
+}
+
+// PriceHistory is a snapshot of the prices of a product taken on every
+// change, whether made by hand or by the price scheduler.
+type PriceHistory struct {
+	ID        uint         `json:"id" gorm:"primaryKey"`
+	ProductID uint         `json:"product_id" gorm:"not null;index"`
+	Price     money.Money  `json:"price" gorm:"not null" swaggertype:"number" example:"999.00"`
+	SalePrice *money.Money `json:"sale_price" swaggertype:"number" example:"799.00"`
+	Reason    string       `json:"reason" gorm:"not null;size:20" example:"sale_started"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+func (PriceHistory) TableName() string {
+	return "price_history"
+}
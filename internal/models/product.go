@@ -3,41 +3,102 @@ package models
 import (
 	"time"
 
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
 	"gorm.io/gorm"
 )
 
 type Product struct {
-	ID          uint           `json:"id" gorm:"PrimaryKey"`
-	Name        string         `json:"name" gorm:"not null;size:255" validate:"required,min=2,max=255"`
-	Description string         `json:"description" gorm:"type:text"`
-	Price       float64        `json:"price" gorm:"not null" validate:"required,gt=0"`
-	Stock       int            `json:"stock" gorm:"not null;default:0" validate:"min=0"`
-	Category    string         `json:"category"`
-	SKU         string         `json:"sku" gorm:"uniqueIndex;size:100"`
-	Active      bool           `json:"active" gorm:"default:true"`
-	ImageURL    string         `json:"image_url" gorm:"type:text"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID               uint             `json:"id" gorm:"PrimaryKey"`
+	Name             string           `json:"name" gorm:"not null;size:255" validate:"required,min=2,max=255"`
+	Description      string           `json:"description" gorm:"type:text"`
+	Price            money.Money      `json:"price" gorm:"not null" validate:"required,gt=0" swaggertype:"number"`
+	ListPrice        money.Money      `json:"list_price" gorm:"-" swaggertype:"number"`
+	SalePrice        *money.Money     `json:"sale_price" swaggertype:"number"`
+	SaleEndsAt       *time.Time       `json:"sale_ends_at,omitempty"`
+	EffectivePrice   money.Money      `json:"effective_price" gorm:"-" swaggertype:"number"`
+	Stock            int              `json:"stock" gorm:"not null;default:0" validate:"min=0"`
+	ReorderThreshold *int             `json:"reorder_threshold,omitempty" validate:"omitempty,min=0"`
+	WeightGrams      int              `json:"weight_grams" gorm:"not null;default:0" validate:"min=0"`
+	LengthCm         int              `json:"length_cm" gorm:"not null;default:0" validate:"min=0"`
+	WidthCm          int              `json:"width_cm" gorm:"not null;default:0" validate:"min=0"`
+	HeightCm         int              `json:"height_cm" gorm:"not null;default:0" validate:"min=0"`
+	CategoryID       *uint            `json:"category_id" gorm:"index"`
+	Category         *Category        `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	SKU              string           `json:"sku" gorm:"uniqueIndex;size:100"`
+	Active           bool             `json:"active" gorm:"default:true"`
+	ImageURL         string           `json:"image_url" gorm:"type:text"`
+	Variants         []ProductVariant `json:"variants,omitempty" gorm:"foreignKey:ProductID"`
+	VariantMatrix    []VariantAxis    `json:"variant_matrix,omitempty" gorm:"-"`
+	Rating           ProductRating    `json:"rating" gorm:"embedded;embeddedPrefix:rating_"`
+	CreatedAt        time.Time        `json:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt   `json:"-" gorm:"index"`
+}
+
+// AfterFind fills the derived variant fields once variants are preloaded,
+// and the average rating.
+func (p *Product) AfterFind(tx *gorm.DB) error {
+	p.ResolveVariants()
+	p.Rating.resolve()
+	return nil
+}
+
+// ResolveVariants computes the list and effective prices, the final price
+// and availability of each variant and the option matrix shown on the
+// product page.
+func (p *Product) ResolveVariants() {
+	p.resolvePrices()
+
+	if len(p.Variants) == 0 {
+		p.VariantMatrix = nil
+		return
+	}
+
+	for i := range p.Variants {
+		variant := &p.Variants[i]
+		variant.FinalPrice = p.EffectivePrice
+		if variant.Price != nil {
+			variant.FinalPrice = *variant.Price
+		}
+		variant.Available = p.Active && variant.Active && variant.Stock > 0
+
+		for j := range variant.Options {
+			if option := &variant.Options[j]; option.OptionType != nil {
+				option.Code = option.OptionType.Code
+			}
+		}
+	}
+
+	p.VariantMatrix = BuildVariantMatrix(p.Variants)
+}
+
+// resolvePrices derives the exposed prices. Price is the list price; the
+// sale price set by the price scheduler only applies while it is lower.
+func (p *Product) resolvePrices() {
+	p.ListPrice = p.Price
+	p.EffectivePrice = p.Price
+	if p.SalePrice != nil && p.SalePrice.Cmp(p.Price) < 0 {
+		p.EffectivePrice = *p.SalePrice
+	}
 }
 
 type ProductCreateRequest struct {
-	Name        string  `json:"name" validate:"required,min=2,max=255"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" validate:"required,gt=0"`
-	Stock       int     `json:"stock" validate:"min=0"`
-	Category    string  `json:"category"`
-	ImageURL    string  `json:"image_url" gorm:"type:text"`
-	SKU         string  `json:"sku"`
+	Name        string      `json:"name" validate:"required,min=2,max=255"`
+	Description string      `json:"description"`
+	Price       money.Money `json:"price" validate:"required,gt=0"`
+	Stock       int         `json:"stock" validate:"min=0"`
+	CategoryID  *uint       `json:"category_id"`
+	ImageURL    string      `json:"image_url" gorm:"type:text"`
+	SKU         string      `json:"sku"`
 }
 
 type ProductUpdateRequest struct {
-	Name        *string  `json:"name,omitempty" validate:"omitempty,min=2,max=255"`
-	Description *string  `json:"description,omitempty"`
-	Price       *float64 `json:"price,omitempty" validate:"omitempty,gt=0"`
-	Stock       *int     `json:"stock,omitempty" validate:"omitempty,min=0"`
-	Category    *string  `json:"category_id,omitempty"`
-	ImageURL    *string  `json:"image_url" gorm:"type:text"`
-	Active      *bool    `json:"active,omitempty"`
-	SKU         *string  `json:"sku"`
+	Name        *string      `json:"name,omitempty" validate:"omitempty,min=2,max=255"`
+	Description *string      `json:"description,omitempty"`
+	Price       *money.Money `json:"price,omitempty" validate:"omitempty,gt=0"`
+	Stock       *int         `json:"stock,omitempty" validate:"omitempty,min=0"`
+	CategoryID  *uint        `json:"category_id,omitempty"`
+	ImageURL    *string      `json:"image_url" gorm:"type:text"`
+	Active      *bool        `json:"active,omitempty"`
+	SKU         *string      `json:"sku"`
 }
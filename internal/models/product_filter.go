@@ -0,0 +1,83 @@
+package models
+
+import (
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+)
+
+// Sort orders accepted by the product listing.
+const (
+	SortRelevance = "relevance"
+	SortNewest    = "newest"
+	SortPriceAsc  = "price_asc"
+	SortPriceDesc = "price_desc"
+	SortName      = "name"
+)
+
+var ProductSorts = []string{SortRelevance, SortNewest, SortPriceAsc, SortPriceDesc, SortName}
+
+// PriceBucketLimits are the upper bounds of the price facet buckets; the
+// last bucket has no upper bound.
+var PriceBucketLimits = []money.Money{
+	money.FromCents(100_00), money.FromCents(500_00), money.FromCents(1000_00), money.FromCents(5000_00),
+}
+
+// ProductFilter holds the parameters of the product listing. Categories are
+// slugs or IDs and include their subcategories; a product matches if it is
+// in any of them. A Cursor takes precedence over Page, and Count is one of
+// the pagination count modes.
+type ProductFilter struct {
+	Page       int
+	Limit      int
+	Cursor     *pagination.Cursor
+	Count      string
+	Categories []string
+	Search     string
+	MinPrice   *money.Money
+	MaxPrice   *money.Money
+	InStock    *bool
+	Sort       string
+}
+
+// ProductListing is one page of products with the cursors to its
+// neighbours. Total is left out when the count mode is none, and Facets on
+// pages reached by cursor, since they describe the whole result set and
+// came with the first page.
+type ProductListing struct {
+	Products         []Product          `json:"products"`
+	Total            *int64             `json:"total,omitempty"`
+	TotalApproximate bool               `json:"total_approximate,omitempty"`
+	Facets           *ProductFacets     `json:"facets,omitempty"`
+	Next             *pagination.Cursor `json:"next,omitempty"`
+	Prev             *pagination.Cursor `json:"prev,omitempty"`
+}
+
+// ProductFacets counts the products matching the filter along each facet.
+// Each facet ignores its own filter, so selecting a category still shows
+// the counts of the others.
+type ProductFacets struct {
+	Categories   []CategoryFacet   `json:"categories"`
+	PriceRanges  []PriceBucket     `json:"price_ranges"`
+	Availability AvailabilityFacet `json:"availability"`
+}
+
+// CategoryFacet counts the products of a category including its
+// subcategories.
+type CategoryFacet struct {
+	ID       uint   `json:"id" example:"1"`
+	ParentID *uint  `json:"parent_id" example:"1"`
+	Name     string `json:"name" example:"Smartphones"`
+	Slug     string `json:"slug" example:"smartphones"`
+	Count    int64  `json:"count" example:"12"`
+}
+
+type PriceBucket struct {
+	Min   money.Money  `json:"min" swaggertype:"number" example:"100"`
+	Max   *money.Money `json:"max" swaggertype:"number" example:"500"`
+	Count int64        `json:"count" example:"8"`
+}
+
+type AvailabilityFacet struct {
+	InStock    int64 `json:"in_stock" example:"40"`
+	OutOfStock int64 `json:"out_of_stock" example:"3"`
+}
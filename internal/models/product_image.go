@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// ProductImage is one entry of a product gallery. The storage keys stay
+// private; clients get short lived signed URLs instead.
+type ProductImage struct {
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	ProductID        uint       `json:"product_id" gorm:"not null;index"`
+	Position         int        `json:"position" gorm:"not null;default:0"`
+	ContentType      string     `json:"content_type" gorm:"not null;size:50"`
+	Width            int        `json:"width"`
+	Height           int        `json:"height"`
+	Size             int64      `json:"size"`
+	OriginalKey      string     `json:"-" gorm:"not null"`
+	ThumbnailKey     string     `json:"-" gorm:"not null"`
+	WebPKey          string     `json:"-" gorm:"column:webp_key;not null"`
+	ThumbnailWebPKey string     `json:"-" gorm:"column:thumbnail_webp_key;not null"`
+	URLs             *ImageURLs `json:"urls,omitempty" gorm:"-"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// Keys lists every stored rendition of the image.
+func (i *ProductImage) Keys() []string {
+	return []string{i.OriginalKey, i.ThumbnailKey, i.WebPKey, i.ThumbnailWebPKey}
+}
+
+type ImageURLs struct {
+	Original      string    `json:"original"`
+	Thumbnail     string    `json:"thumbnail"`
+	WebP          string    `json:"webp"`
+	ThumbnailWebP string    `json:"thumbnail_webp"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
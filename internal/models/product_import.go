@@ -0,0 +1,109 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Formats accepted by the product import.
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+)
+
+// Statuses of an import job.
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+// Outcomes of an imported row.
+const (
+	ImportRowCreated = "created"
+	ImportRowUpdated = "updated"
+	ImportRowFailed  = "failed"
+)
+
+// ImportRowResult is the outcome of one row of the file. On a dry run,
+// created and updated tell what the import would do.
+type ImportRowResult struct {
+	Row       int      `json:"row" example:"2"`
+	SKU       string   `json:"sku,omitempty" example:"IPHONE-15-PRO-MAX-256"`
+	Status    string   `json:"status" example:"created"`
+	ProductID uint     `json:"product_id,omitempty" example:"1"`
+	Errors    []string `json:"errors,omitempty" example:"price: gt=0"`
+}
+
+// ImportReport sums up an import row by row.
+type ImportReport struct {
+	DryRun    bool          `json:"dry_run"`
+	TotalRows int           `json:"total_rows" example:"120"`
+	Created   int           `json:"created" example:"100"`
+	Updated   int           `json:"updated" example:"18"`
+	Failed    int           `json:"failed" example:"2"`
+	Results   ImportResults `json:"results" gorm:"type:text"`
+}
+
+// Add counts the result of a row into the report.
+func (r *ImportReport) Add(result ImportRowResult) {
+	switch result.Status {
+	case ImportRowCreated:
+		r.Created++
+	case ImportRowUpdated:
+		r.Updated++
+	default:
+		r.Failed++
+	}
+	r.Results = append(r.Results, result)
+}
+
+// ImportJob is an import processed in the background. Processed advances
+// while it runs; the results are saved when it finishes.
+type ImportJob struct {
+	ID           uint   `json:"id" gorm:"primaryKey"`
+	UserID       uint   `json:"user_id" gorm:"not null;index"`
+	Format       string `json:"format" gorm:"not null;size:10" example:"csv"`
+	Status       string `json:"status" gorm:"not null;size:20" example:"running"`
+	Processed    int    `json:"processed" example:"40"`
+	ImportReport `gorm:"embedded"`
+	Error        string     `json:"error,omitempty" gorm:"type:text"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+}
+
+// ImportResults is stored as a JSON array in a text column.
+type ImportResults []ImportRowResult
+
+func (r ImportResults) Value() (driver.Value, error) {
+	if r == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]ImportRowResult(r))
+	return string(data), err
+}
+
+func (r *ImportResults) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*r = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("cannot scan %T into ImportResults", value)
+	}
+
+	if len(data) == 0 {
+		*r = nil
+		return nil
+	}
+	return json.Unmarshal(data, (*[]ImportRowResult)(r))
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+)
+
+// Return statuses. A return is requested by the customer, approved or
+// rejected by the store, received when the goods come back and refunded
+// once the payment layer pays it back.
+const (
+	ReturnRequested = "requested"
+	ReturnApproved  = "approved"
+	ReturnRejected  = "rejected"
+	ReturnReceived  = "received"
+	ReturnRefunded  = "refunded"
+)
+
+// Reasons for a return.
+const (
+	// ReturnRegret is the "direito de arrependimento": the customer changed
+	// their mind within the legal window after delivery.
+	ReturnRegret    = "regret"
+	ReturnDefective = "defective"
+	ReturnDamaged   = "damaged"
+	ReturnWrongItem = "wrong_item"
+	ReturnOther     = "other"
+)
+
+// ReturnRequest is a request to return items of an order. RefundAmount is
+// what the returned units were paid, refunded through the payment layer of
+// the order.
+type ReturnRequest struct {
+	ID              uint          `json:"id" gorm:"primaryKey"`
+	UserID          uint          `json:"user_id" gorm:"not null;index"`
+	OrderID         string        `json:"order_id" gorm:"not null;size:64;index"`
+	Status          string        `json:"status" gorm:"not null;size:20;index"`
+	Reason          string        `json:"reason" gorm:"not null;size:20"`
+	Description     string        `json:"description,omitempty"`
+	RefundAmount    money.Money   `json:"refund_amount" gorm:"not null" swaggertype:"number"`
+	RejectionReason string        `json:"rejection_reason,omitempty"`
+	Restocked       bool          `json:"restocked"`
+	ReviewedBy      *uint         `json:"reviewed_by,omitempty"`
+	ReviewedAt      *time.Time    `json:"reviewed_at,omitempty"`
+	ReceivedAt      *time.Time    `json:"received_at,omitempty"`
+	RefundedAt      *time.Time    `json:"refunded_at,omitempty"`
+	Items           []ReturnItem  `json:"items" gorm:"foreignKey:ReturnRequestID"`
+	Photos          []ReturnPhoto `json:"photos" gorm:"foreignKey:ReturnRequestID"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+}
+
+// ReturnItem is the quantity of an order item being returned and what
+// those units were paid.
+type ReturnItem struct {
+	ID              uint        `json:"id" gorm:"primaryKey"`
+	ReturnRequestID uint        `json:"-" gorm:"not null;index"`
+	OrderItemID     uint        `json:"order_item_id" gorm:"not null"`
+	ProductID       uint        `json:"product_id" gorm:"not null"`
+	VariantID       *uint       `json:"variant_id,omitempty"`
+	Quantity        int         `json:"quantity" gorm:"not null"`
+	Amount          money.Money `json:"amount" gorm:"not null" swaggertype:"number"`
+}
+
+// ReturnPhoto is a picture the customer attached to a return, like one of
+// a damaged item. The storage key stays private; clients get a short lived
+// signed URL.
+type ReturnPhoto struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	ReturnRequestID uint      `json:"-" gorm:"not null;index"`
+	StorageKey      string    `json:"-" gorm:"not null"`
+	ContentType     string    `json:"content_type" gorm:"not null;size:50"`
+	Size            int64     `json:"size"`
+	URL             string    `json:"url,omitempty" gorm:"-"`
+	CreatedAt       time.Time `json:"created_at"`
+}
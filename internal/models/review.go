@@ -0,0 +1,72 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+)
+
+// Moderation statuses of a review. Only approved reviews are shown and
+// counted in the rating of the product.
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+// Review is the opinion of a customer on a product; each user reviews a
+// product once.
+type Review struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	ProductID uint   `json:"product_id" gorm:"not null"`
+	UserID    uint   `json:"user_id" gorm:"not null"`
+	Author    string `json:"author" gorm:"not null;size:255" example:"Maria Silva"`
+	Rating    int    `json:"rating" gorm:"not null" example:"5"`
+	Title     string `json:"title" gorm:"not null;size:150" example:"Excelente"`
+	Body      string `json:"body" gorm:"type:text" example:"Chegou antes do prazo e funciona muito bem."`
+	// VerifiedPurchase tells the author bought the product.
+	VerifiedPurchase bool       `json:"verified_purchase"`
+	Status           string     `json:"status" gorm:"not null;size:20" example:"pending"`
+	RejectionReason  string     `json:"rejection_reason,omitempty" gorm:"size:500"`
+	ModeratedBy      *uint      `json:"moderated_by,omitempty"`
+	ModeratedAt      *time.Time `json:"moderated_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// ReviewListing is one page of reviews with the cursors to its neighbours.
+type ReviewListing struct {
+	Reviews          []Review
+	Total            *int64
+	TotalApproximate bool
+	Next             *pagination.Cursor
+	Prev             *pagination.Cursor
+}
+
+// ProductRating aggregates the approved reviews of a product. The counters
+// are updated as reviews are approved or rejected, so reading the rating
+// never scans the reviews.
+type ProductRating struct {
+	Average   float64         `json:"average" gorm:"-" example:"4.5"`
+	Count     int             `json:"count" gorm:"not null;default:0" example:"12"`
+	Sum       int             `json:"-" gorm:"not null;default:0"`
+	Histogram RatingHistogram `json:"histogram" gorm:"embedded;embeddedPrefix:stars"`
+}
+
+// RatingHistogram counts the approved reviews by number of stars.
+type RatingHistogram struct {
+	One   int `json:"1" gorm:"column:1;not null;default:0"`
+	Two   int `json:"2" gorm:"column:2;not null;default:0"`
+	Three int `json:"3" gorm:"column:3;not null;default:0"`
+	Four  int `json:"4" gorm:"column:4;not null;default:0"`
+	Five  int `json:"5" gorm:"column:5;not null;default:0"`
+}
+
+// resolve computes the average, rounded to one decimal like it is shown.
+func (r *ProductRating) resolve() {
+	r.Average = 0
+	if r.Count > 0 {
+		r.Average = math.Round(float64(r.Sum)/float64(r.Count)*10) / 10
+	}
+}
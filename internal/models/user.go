@@ -1,20 +1,122 @@
 package models
 
 import (
-	"gorm.io/gorm"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/pkg/br"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+	"github.com/Code-Aether/americanas-loja-api/pkg/seal"
+	"gorm.io/gorm"
 )
 
+// User is an account of the store. DocumentType is br.DocumentCPF or
+// br.DocumentCNPJ, empty while the user has not informed a document, and
+// DocumentHash is the seal index of the document, which keeps documents
+// unique while they are stored encrypted.
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Email     string         `json:"email" gorm:"uniquyeIndex;not null"`
-	Password  string         `json:"-" gorm:"not null"`
-	Name      string         `json:"name" gorm:"not null"`
-	Role      string         `json:"role" gorm:"default:user"`
-	Active    bool           `json:"active" gorm:"default:true"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	Email        string         `json:"email" gorm:"uniquyeIndex;not null"`
+	Password     string         `json:"-" gorm:"not null"`
+	Name         string         `json:"name" gorm:"not null"`
+	Role         string         `json:"role" gorm:"default:user"`
+	Active       bool           `json:"active" gorm:"default:true"`
+	DocumentType string         `json:"document_type,omitempty"`
+	Document     Document       `json:"document,omitempty"`
+	DocumentHash *string        `json:"-"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// BeforeSave indexes the document for the unique index and lookups.
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	if u.Document == "" {
+		u.DocumentHash = nil
+		return nil
+	}
+	hash, err := u.Document.Index()
+	if err != nil {
+		return err
+	}
+	u.DocumentHash = &hash
+	return nil
+}
+
+var ErrNoDocumentBox = errors.New("document encryption is not configured")
+
+// documentBox seals the documents of users at rest; see SetDocumentBox.
+var documentBox *seal.Box
+
+// SetDocumentBox sets the box, from DOCUMENT_ENCRYPTION_KEY, that seals the
+// documents of users. Until it is set, users with documents can be neither
+// read nor written.
+func SetDocumentBox(box *seal.Box) {
+	documentBox = box
+}
+
+// Document is the CPF or CNPJ of a user, as digits. It is stored encrypted
+// and shown masked, like ***.456.789-**.
+type Document string
+
+// Index is the seal index of the document, see User.DocumentHash.
+func (d Document) Index() (string, error) {
+	if documentBox == nil {
+		return "", ErrNoDocumentBox
+	}
+	return documentBox.Index(string(d)), nil
+}
+
+func (d Document) MarshalJSON() ([]byte, error) {
+	return json.Marshal(br.MaskDocument(string(d)))
+}
+
+// Value stores the document sealed, or NULL when there is none.
+func (d Document) Value() (driver.Value, error) {
+	if d == "" {
+		return nil, nil
+	}
+	if documentBox == nil {
+		return nil, ErrNoDocumentBox
+	}
+	return documentBox.Seal(string(d))
+}
+
+func (d *Document) Scan(value any) error {
+	var sealed string
+	switch v := value.(type) {
+	case nil:
+		*d = ""
+		return nil
+	case []byte:
+		sealed = string(v)
+	case string:
+		sealed = v
+	default:
+		return fmt.Errorf("cannot scan %T into Document", value)
+	}
+
+	if documentBox == nil {
+		return ErrNoDocumentBox
+	}
+	plaintext, err := documentBox.Open(sealed)
+	if err != nil {
+		return err
+	}
+	*d = Document(plaintext)
+	return nil
+}
+
+// UserListing is one page of users with the cursors to its neighbours.
+type UserListing struct {
+	Users            []User
+	Total            *int64
+	TotalApproximate bool
+	Next             *pagination.Cursor
+	Prev             *pagination.Cursor
 }
 
 type LoginRequest struct {
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+)
+
+// Option type codes seeded by the migrations.
+const (
+	OptionSize    = "size"
+	OptionColor   = "color"
+	OptionVoltage = "voltage"
+)
+
+// OptionType is an axis along which a product varies, such as size or color.
+type OptionType struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Code      string    `json:"code" gorm:"uniqueIndex;not null;size:50"`
+	Name      string    `json:"name" gorm:"not null;size:100"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProductVariant is a sellable combination of options of a product. A nil
+// Price means the variant is sold at the product price.
+type ProductVariant struct {
+	ID         uint            `json:"id" gorm:"primaryKey"`
+	ProductID  uint            `json:"product_id" gorm:"not null;index"`
+	SKU        string          `json:"sku" gorm:"uniqueIndex;not null;size:100"`
+	Price      *money.Money    `json:"price,omitempty" swaggertype:"number"`
+	Stock      int             `json:"stock" gorm:"not null;default:0"`
+	Images     StringList      `json:"images" gorm:"type:text"`
+	Active     bool            `json:"active"`
+	Options    []VariantOption `json:"options" gorm:"foreignKey:VariantID"`
+	FinalPrice money.Money     `json:"final_price" gorm:"-" swaggertype:"number"`
+	Available  bool            `json:"available" gorm:"-"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+// VariantOption is the value a variant takes on one option type.
+type VariantOption struct {
+	ID           uint        `json:"-" gorm:"primaryKey"`
+	VariantID    uint        `json:"-" gorm:"not null"`
+	OptionTypeID uint        `json:"-" gorm:"not null"`
+	OptionType   *OptionType `json:"-" gorm:"foreignKey:OptionTypeID"`
+	Code         string      `json:"code" gorm:"-"`
+	Value        string      `json:"value" gorm:"not null;size:100"`
+}
+
+// VariantAxis lists the values offered for one option type and whether any
+// active variant with that value is in stock.
+type VariantAxis struct {
+	Code   string      `json:"code"`
+	Name   string      `json:"name"`
+	Values []AxisValue `json:"values"`
+}
+
+type AxisValue struct {
+	Value     string `json:"value"`
+	Available bool   `json:"available"`
+}
+
+// Key identifies the option combination of the variant, independent of the
+// order in which options were given.
+func (v *ProductVariant) Key() string {
+	values := make(map[string]string, len(v.Options))
+	for _, option := range v.Options {
+		values[option.Code] = option.Value
+	}
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
+// BuildVariantMatrix groups the option values of the variants by option type,
+// keeping the order in which they first appear.
+func BuildVariantMatrix(variants []ProductVariant) []VariantAxis {
+	axes := make([]VariantAxis, 0)
+	axisIndex := make(map[string]int)
+	valueIndex := make(map[string]int)
+
+	for _, variant := range variants {
+		if !variant.Active {
+			continue
+		}
+		for _, option := range variant.Options {
+			i, ok := axisIndex[option.Code]
+			if !ok {
+				name := option.Code
+				if option.OptionType != nil {
+					name = option.OptionType.Name
+				}
+				i = len(axes)
+				axisIndex[option.Code] = i
+				axes = append(axes, VariantAxis{Code: option.Code, Name: name})
+			}
+
+			key := option.Code + "\x00" + option.Value
+			j, ok := valueIndex[key]
+			if !ok {
+				j = len(axes[i].Values)
+				valueIndex[key] = j
+				axes[i].Values = append(axes[i].Values, AxisValue{Value: option.Value})
+			}
+			if variant.Available {
+				axes[i].Values[j].Available = true
+			}
+		}
+	}
+
+	return axes
+}
+
+// StringList is stored as a JSON array in a text column.
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]string(l))
+	return string(data), err
+}
+
+func (l *StringList) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("cannot scan %T into StringList", value)
+	}
+
+	if len(data) == 0 {
+		*l = nil
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(l))
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+)
+
+// Wallet transaction types.
+const (
+	// WalletCredit adds store credit, from a gift card, a refund or an
+	// adjustment by the store.
+	WalletCredit = "credit"
+	// WalletDebit spends store credit, as part of the payment of an order
+	// or in an adjustment by the store.
+	WalletDebit = "debit"
+	// WalletExpire takes away what was left of a credit when it expired.
+	WalletExpire = "expire"
+)
+
+// Wallet is the store credit of a user. Balance is the sum of the ledger,
+// kept with it so reads need not add it up; Version changes with every
+// transaction, so concurrent ones cannot both spend the same balance.
+type Wallet struct {
+	UserID    uint        `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	Balance   money.Money `json:"balance" gorm:"not null" swaggertype:"number"`
+	Version   int         `json:"-" gorm:"not null;default:0"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// WalletTransaction is an entry of the ledger of a wallet, which is only
+// ever appended to. Amount is positive for every type; BalanceAfter is the
+// balance of the wallet right after the entry.
+//
+// IdempotencyKey is unique per wallet: applying a transaction again with
+// the same key returns the recorded one instead of moving the balance
+// twice.
+type WalletTransaction struct {
+	ID             uint        `json:"id" gorm:"primaryKey"`
+	UserID         uint        `json:"-" gorm:"not null;index"`
+	Type           string      `json:"type" gorm:"not null;size:10"`
+	Amount         money.Money `json:"amount" gorm:"not null" swaggertype:"number"`
+	BalanceAfter   money.Money `json:"balance_after" gorm:"not null" swaggertype:"number"`
+	Reason         string      `json:"reason"`
+	Reference      string      `json:"reference,omitempty"`
+	IdempotencyKey string      `json:"-" gorm:"not null"`
+	// ExpiresAt is when what is left of a credit expires; nil never does.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// CreditID is the credit an expire entry took away.
+	CreditID *uint `json:"credit_id,omitempty"`
+	// CreatedBy is the admin who made an adjustment.
+	CreatedBy *uint     `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WalletLot is what is left to spend of a credit. Debits take from the
+// lots that expire first, then from those that never do, oldest first, so
+// expiring credit is used before it is lost. The lots of a wallet add up
+// to its balance.
+type WalletLot struct {
+	TransactionID uint        `json:"transaction_id" gorm:"primaryKey;autoIncrement:false"`
+	UserID        uint        `json:"-" gorm:"not null;index"`
+	Remaining     money.Money `json:"remaining" gorm:"not null" swaggertype:"number"`
+	ExpiresAt     *time.Time  `json:"expires_at,omitempty"`
+}
+
+// WalletTransactionListing is one page of a ledger with the cursors to its
+// neighbours.
+type WalletTransactionListing struct {
+	Transactions []WalletTransaction
+	Next         *pagination.Cursor
+	Prev         *pagination.Cursor
+}
+
+// GiftCard is a code worth Balance in store credit until ExpiresAt. It is
+// redeemed whole into the wallet of a user, where the credit keeps the
+// expiry of the card.
+type GiftCard struct {
+	ID             uint        `json:"id" gorm:"primaryKey"`
+	Code           string      `json:"code" gorm:"uniqueIndex;not null;size:19"`
+	InitialBalance money.Money `json:"initial_balance" gorm:"not null" swaggertype:"number"`
+	Balance        money.Money `json:"balance" gorm:"not null" swaggertype:"number"`
+	Note           string      `json:"note,omitempty"`
+	ExpiresAt      time.Time   `json:"expires_at" gorm:"not null"`
+	CreatedBy      *uint       `json:"created_by,omitempty"`
+	RedeemedBy     *uint       `json:"redeemed_by,omitempty"`
+	RedeemedAt     *time.Time  `json:"redeemed_at,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Wishlist is a named list of products a user wants. A list is private
+// until it is shared; sharing gives it a token that anyone with the link
+// can use to see it.
+type Wishlist struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	UserID     uint           `json:"-" gorm:"not null"`
+	Name       string         `json:"name" gorm:"not null;size:100" example:"Aniversário"`
+	ShareToken *string        `json:"share_token,omitempty" gorm:"size:64"`
+	Items      []WishlistItem `json:"items" gorm:"foreignKey:WishlistID"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+type WishlistItem struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	WishlistID uint      `json:"-" gorm:"not null"`
+	ProductID  uint      `json:"product_id" gorm:"not null"`
+	Product    *Product  `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	CreatedAt  time.Time `json:"added_at"`
+}
+
+// StockSubscription asks for a notice when an out of stock product is back.
+// It is pending until NotifiedAt is set; the user can then subscribe again
+// for the next time the product runs out.
+type StockSubscription struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"-" gorm:"not null"`
+	ProductID  uint       `json:"product_id" gorm:"not null"`
+	NotifiedAt *time.Time `json:"notified_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"gorm.io/gorm"
+)
+
+type AddressRepository struct {
+	db *gorm.DB
+}
+
+func NewAddressRepository(db *gorm.DB) *AddressRepository {
+	return &AddressRepository{
+		db: db,
+	}
+}
+
+// ListByUser returns the addresses of the user, the default first and the
+// others in the order they were added.
+func (r *AddressRepository) ListByUser(ctx context.Context, userID uint) ([]models.Address, error) {
+	var addresses []models.Address
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("is_default DESC").
+		Order("id ASC").
+		Find(&addresses).Error
+	return addresses, err
+}
+
+func (r *AddressRepository) GetByID(ctx context.Context, id uint) (*models.Address, error) {
+	var address models.Address
+	err := r.db.WithContext(ctx).First(&address, id).Error
+	return &address, err
+}
+
+// Create adds the address. The first address of a user is the default one,
+// and a new default takes the place of the previous one.
+func (r *AddressRepository) Create(ctx context.Context, address *models.Address) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Address{}).Where("user_id = ?", address.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			address.IsDefault = true
+		}
+
+		if address.IsDefault {
+			if err := clearDefaultAddress(tx, address); err != nil {
+				return err
+			}
+		}
+		return tx.Create(address).Error
+	})
+}
+
+// Update saves the address; as the default, it takes the place of the
+// previous one.
+func (r *AddressRepository) Update(ctx context.Context, address *models.Address) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if address.IsDefault {
+			if err := clearDefaultAddress(tx, address); err != nil {
+				return err
+			}
+		}
+		return tx.Save(address).Error
+	})
+}
+
+// Delete removes the address. When it was the default, the most recently
+// added of the remaining addresses becomes the default.
+func (r *AddressRepository) Delete(ctx context.Context, address *models.Address) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.Address{}, address.ID).Error; err != nil {
+			return err
+		}
+		if !address.IsDefault {
+			return nil
+		}
+
+		var next models.Address
+		err := tx.Where("user_id = ?", address.UserID).Order("id DESC").Take(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_default", true).Error
+	})
+}
+
+// clearDefaultAddress unsets the default of the other addresses of the
+// user, first, so the unique index on the default never sees two.
+func clearDefaultAddress(tx *gorm.DB, address *models.Address) error {
+	return tx.Model(&models.Address{}).
+		Where("user_id = ? AND is_default = ? AND id <> ?", address.UserID, true, address.ID).
+		Update("is_default", false).Error
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"gorm.io/gorm"
+)
+
+type CategoryRepository struct {
+	db *gorm.DB
+}
+
+func NewCategoryRepository(db *gorm.DB) *CategoryRepository {
+	return &CategoryRepository{
+		db: db,
+	}
+}
+
+// Create inserts the category under parent (nil for a root category). The
+// materialized path needs the generated ID, so it is written right after the
+// insert in the same transaction.
+func (r *CategoryRepository) Create(ctx context.Context, category *models.Category, parent *models.Category) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		parentPath := ""
+		category.ParentID = nil
+		category.Depth = 0
+		if parent != nil {
+			parentPath = parent.Path
+			category.ParentID = &parent.ID
+			category.Depth = parent.Depth + 1
+		}
+
+		category.Path = ""
+		if err := tx.Create(category).Error; err != nil {
+			return err
+		}
+
+		category.Path = models.PathFor(parentPath, category.ID)
+		return tx.Model(category).Update("path", category.Path).Error
+	})
+}
+
+func (r *CategoryRepository) GetByID(ctx context.Context, id uint) (*models.Category, error) {
+	var category models.Category
+	err := r.db.WithContext(ctx).First(&category, id).Error
+	return &category, err
+}
+
+func (r *CategoryRepository) GetBySlug(ctx context.Context, slug string) (*models.Category, error) {
+	var category models.Category
+	err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&category).Error
+	return &category, err
+}
+
+// GetAll returns every category ordered so that parents come before their
+// children and siblings follow their sort order.
+func (r *CategoryRepository) GetAll(ctx context.Context) ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.WithContext(ctx).Order("depth ASC, sort_order ASC, name ASC").Find(&categories).Error
+	return categories, err
+}
+
+func (r *CategoryRepository) Update(ctx context.Context, category *models.Category) error {
+	return r.db.WithContext(ctx).Save(category).Error
+}
+
+// UpdateAndMove re-parents the category under parent (nil for the root),
+// rewrites the path and depth of its whole subtree and saves its other
+// fields, all in one transaction: a failed save leaves the tree untouched.
+func (r *CategoryRepository) UpdateAndMove(ctx context.Context, category *models.Category, parent *models.Category) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := moveCategory(tx, category, parent); err != nil {
+			return err
+		}
+		return tx.Save(category).Error
+	})
+}
+
+func moveCategory(tx *gorm.DB, category *models.Category, parent *models.Category) error {
+	oldPath := category.Path
+	parentPath := ""
+	depth := 0
+	var parentID *uint
+	if parent != nil {
+		parentPath = parent.Path
+		depth = parent.Depth + 1
+		parentID = &parent.ID
+	}
+	newPath := models.PathFor(parentPath, category.ID)
+	delta := depth - category.Depth
+
+	err := tx.Model(&models.Category{}).
+		Where("path LIKE ?", oldPath+"%").
+		Updates(map[string]any{
+			"path":  gorm.Expr("? || SUBSTR(path, ?)", newPath, len(oldPath)+1),
+			"depth": gorm.Expr("depth + ?", delta),
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	category.ParentID = parentID
+	category.Path = newPath
+	category.Depth = depth
+	return nil
+}
+
+func (r *CategoryRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Category{}, id).Error
+}
+
+func (r *CategoryRepository) CountChildren(ctx context.Context, id uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Category{}).Where("parent_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+func (r *CategoryRepository) CountProducts(ctx context.Context, id uint) (int64, error) {
+	var count int64
+	// Soft-deleted products still reference the category.
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.Product{}).Where("category_id = ?", id).Count(&count).Error
+	return count, err
+}
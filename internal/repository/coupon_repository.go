@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCouponCodeTaken = errors.New("coupon code already exists")
+	// ErrCouponExhausted and ErrCouponUserExhausted are returned by Redeem
+	// when the coupon reached its usage limit, in total or for the user.
+	ErrCouponExhausted     = errors.New("coupon reached its usage limit")
+	ErrCouponUserExhausted = errors.New("coupon reached its usage limit for the user")
+)
+
+type CouponRepository struct {
+	db *gorm.DB
+}
+
+func NewCouponRepository(db *gorm.DB) *CouponRepository {
+	return &CouponRepository{
+		db: db,
+	}
+}
+
+// couponOmit leaves the redemption count out of coupon writes; only Redeem
+// and Release change it, so editing a coupon cannot undo redemptions made
+// in the meantime.
+var couponOmit = []string{"used_count"}
+
+// Create adds the coupon, failing with ErrCouponCodeTaken when the code is
+// in use.
+func (r *CouponRepository) Create(ctx context.Context, coupon *models.Coupon) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkCouponCode(tx, coupon); err != nil {
+			return err
+		}
+		return tx.Omit(couponOmit...).Create(coupon).Error
+	})
+}
+
+// Update saves the definition of the coupon.
+func (r *CouponRepository) Update(ctx context.Context, coupon *models.Coupon) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkCouponCode(tx, coupon); err != nil {
+			return err
+		}
+		if err := tx.Omit(couponOmit...).Save(coupon).Error; err != nil {
+			return err
+		}
+		return tx.Model(coupon).Select("used_count").Take(coupon).Error
+	})
+}
+
+func checkCouponCode(tx *gorm.DB, coupon *models.Coupon) error {
+	var taken int64
+	err := tx.Model(&models.Coupon{}).
+		Where("code = ? AND id <> ?", coupon.Code, coupon.ID).
+		Count(&taken).Error
+	if err != nil {
+		return err
+	}
+	if taken > 0 {
+		return ErrCouponCodeTaken
+	}
+	return nil
+}
+
+// List returns every coupon, newest first.
+func (r *CouponRepository) List(ctx context.Context) ([]models.Coupon, error) {
+	var coupons []models.Coupon
+	err := r.db.WithContext(ctx).Order("id DESC").Find(&coupons).Error
+	return coupons, err
+}
+
+func (r *CouponRepository) GetByID(ctx context.Context, id uint) (*models.Coupon, error) {
+	var coupon models.Coupon
+	err := r.db.WithContext(ctx).First(&coupon, id).Error
+	return &coupon, err
+}
+
+func (r *CouponRepository) GetByCode(ctx context.Context, code string) (*models.Coupon, error) {
+	var coupon models.Coupon
+	err := r.db.WithContext(ctx).Where("code = ?", code).First(&coupon).Error
+	return &coupon, err
+}
+
+func (r *CouponRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("coupon_id = ?", id).Delete(&models.CouponUsage{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Coupon{}, id).Error
+	})
+}
+
+// UserUses returns how many times the user redeemed the coupon.
+func (r *CouponRepository) UserUses(ctx context.Context, couponID, userID uint) (int, error) {
+	var uses int
+	err := r.db.WithContext(ctx).Model(&models.CouponUsage{}).
+		Select("COALESCE(SUM(uses), 0)").
+		Where("coupon_id = ? AND user_id = ?", couponID, userID).
+		Scan(&uses).Error
+	return uses, err
+}
+
+// Redeem counts a redemption of the coupon by the user. Both counters only
+// move while under their limits, in conditional writes the database
+// serializes, so concurrent redemptions cannot take a coupon past them.
+func (r *CouponRepository) Redeem(ctx context.Context, coupon *models.Coupon, userID uint, now time.Time) error {
+	// The limit is only checked when the upsert hits an existing usage, so a
+	// limit below one would let the first redemption through.
+	if coupon.PerUserLimit != nil && *coupon.PerUserLimit < 1 {
+		return ErrCouponUserExhausted
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Coupon{}).
+			Where("id = ? AND (usage_limit IS NULL OR used_count < usage_limit)", coupon.ID).
+			UpdateColumn("used_count", gorm.Expr("used_count + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrCouponExhausted
+		}
+
+		upsert := "INSERT INTO coupon_usages (coupon_id, user_id, uses, updated_at) VALUES (?, ?, 1, ?) " +
+			"ON CONFLICT (coupon_id, user_id) DO UPDATE SET uses = coupon_usages.uses + 1, updated_at = excluded.updated_at"
+		args := []any{coupon.ID, userID, now}
+		if coupon.PerUserLimit != nil {
+			upsert += " WHERE coupon_usages.uses < ?"
+			args = append(args, *coupon.PerUserLimit)
+		}
+		result = tx.Exec(upsert, args...)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrCouponUserExhausted
+		}
+		return nil
+	})
+}
+
+// Release gives back a redemption of the coupon by the user, as when the
+// order that used it is cancelled.
+func (r *CouponRepository) Release(ctx context.Context, couponID, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.CouponUsage{}).
+			Where("coupon_id = ? AND user_id = ? AND uses > 0", couponID, userID).
+			UpdateColumn("uses", gorm.Expr("uses - 1"))
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.Coupon{}).
+			Where("id = ? AND used_count > 0", couponID).
+			UpdateColumn("used_count", gorm.Expr("used_count - 1")).Error
+	})
+}
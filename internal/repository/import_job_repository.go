@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"gorm.io/gorm"
+)
+
+type ImportJobRepository struct {
+	db *gorm.DB
+}
+
+func NewImportJobRepository(db *gorm.DB) *ImportJobRepository {
+	return &ImportJobRepository{
+		db: db,
+	}
+}
+
+func (r *ImportJobRepository) Create(ctx context.Context, job *models.ImportJob) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+func (r *ImportJobRepository) GetByID(ctx context.Context, id uint) (*models.ImportJob, error) {
+	var job models.ImportJob
+	err := r.db.WithContext(ctx).First(&job, id).Error
+	return &job, err
+}
+
+// UpdateProgress writes the counters of a running job, leaving the results
+// to Save once it finishes.
+func (r *ImportJobRepository) UpdateProgress(ctx context.Context, job *models.ImportJob) error {
+	return r.db.WithContext(ctx).Model(job).
+		Select("status", "processed", "created", "updated", "failed").
+		Updates(job).Error
+}
+
+// FailUnfinished marks every pending or running job as failed with reason
+// and returns how many it marked.
+func (r *ImportJobRepository) FailUnfinished(ctx context.Context, reason string, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.ImportJob{}).
+		Where("status IN ?", []string{models.ImportStatusPending, models.ImportStatusRunning}).
+		Updates(map[string]any{"status": models.ImportStatusFailed, "error": reason, "finished_at": now, "updated_at": now})
+	return result.RowsAffected, result.Error
+}
+
+func (r *ImportJobRepository) Save(ctx context.Context, job *models.ImportJob) error {
+	return r.db.WithContext(ctx).Save(job).Error
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+	"gorm.io/gorm"
+)
+
+// keyset pages through rows ordered by a column and then by ID, both in
+// the same direction. Instead of skipping rows with OFFSET, each page starts
+// right after the (key, id) of the last row seen, so deep pages cost the
+// same as the first one and rows inserted meanwhile do not shift the pages.
+type keyset[T any] struct {
+	column   string
+	idColumn string
+	// param wraps the placeholder of the key, like LOWER(?) for a column
+	// compared case-insensitively.
+	param string
+	desc  bool
+	// key and parse convert the sort key of a row to the cursor and back.
+	key   func(row *T) string
+	parse func(key string) (any, error)
+	id    func(row *T) uint
+}
+
+// seek keeps the rows after cursor in its direction of travel.
+func (k keyset[T]) seek(query *gorm.DB, cursor *pagination.Cursor) (*gorm.DB, error) {
+	value, err := k.parse(cursor.Key)
+	if err != nil {
+		return nil, pagination.ErrInvalidCursor
+	}
+
+	op := ">"
+	if k.desc != cursor.Backward {
+		op = "<"
+	}
+
+	condition := fmt.Sprintf("%[1]s %[2]s %[3]s OR (%[1]s = %[3]s AND %[4]s %[2]s ?)", k.column, op, k.param, k.idColumn)
+	return query.Where(condition, value, value, cursor.ID), nil
+}
+
+// order sorts the query for the direction of travel; backward pages are
+// read in reverse and flipped back by page.
+func (k keyset[T]) order(query *gorm.DB, backward bool) *gorm.DB {
+	dir := " ASC"
+	if k.desc != backward {
+		dir = " DESC"
+	}
+	return query.Order(k.column + dir).Order(k.idColumn + dir)
+}
+
+// page trims rows, fetched with one extra row to tell whether there is more,
+// to limit and returns the cursors to the neighbouring pages. cursor is the
+// one the page was requested with; first tells whether an offset page is the
+// first one, which has nothing before it.
+func (k keyset[T]) page(rows []T, limit int, cursor *pagination.Cursor, first bool) ([]T, *pagination.Cursor, *pagination.Cursor) {
+	backward := cursor != nil && cursor.Backward
+
+	more := len(rows) > limit
+	if more {
+		rows = rows[:limit]
+	}
+	if backward {
+		slices.Reverse(rows)
+	}
+	if len(rows) == 0 {
+		return rows, nil, nil
+	}
+
+	var next, prev *pagination.Cursor
+	if more || backward {
+		next = k.cursor(&rows[len(rows)-1], false)
+	}
+	if (backward && more) || (!backward && (cursor != nil || !first)) {
+		prev = k.cursor(&rows[0], true)
+	}
+	return rows, next, prev
+}
+
+func (k keyset[T]) cursor(row *T, backward bool) *pagination.Cursor {
+	return &pagination.Cursor{
+		Key:      k.key(row),
+		ID:       k.id(row),
+		Backward: backward,
+	}
+}
+
+// offsetPage is the counterpart of keyset.page for listings ordered by an
+// expression, where the cursor carries the offset of the page.
+func offsetPage[T any](rows []T, limit, offset int) ([]T, *pagination.Cursor, *pagination.Cursor) {
+	var next, prev *pagination.Cursor
+	if len(rows) > limit {
+		rows = rows[:limit]
+		next = &pagination.Cursor{Offset: offset + limit}
+	}
+	if offset > 0 {
+		prev = &pagination.Cursor{Offset: max(0, offset-limit)}
+	}
+	return rows, next, prev
+}
+
+// countRows counts the rows of query. When approximate is set it returns
+// the planner estimate on Postgres, which skips scanning the rows; the
+// second result tells whether it did.
+func countRows(ctx context.Context, query *gorm.DB, approximate bool) (int64, bool, error) {
+	if approximate && query.Dialector.Name() == "postgres" {
+		if estimate, err := estimateRows(ctx, query); err == nil {
+			return estimate, true, nil
+		}
+	}
+
+	var total int64
+	err := query.Count(&total).Error
+	return total, false, err
+}
+
+// estimateRows reads the row estimate of the query plan.
+func estimateRows(ctx context.Context, query *gorm.DB) (int64, error) {
+	stmt := query.Session(&gorm.Session{DryRun: true}).Find(&[]map[string]any{}).Statement
+
+	var plan string
+	err := query.Session(&gorm.Session{NewDB: true}).WithContext(ctx).
+		Raw("EXPLAIN (FORMAT JSON) "+stmt.SQL.String(), stmt.Vars...).Row().Scan(&plan)
+	if err != nil {
+		return 0, err
+	}
+
+	var explain []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(plan), &explain); err != nil {
+		return 0, err
+	}
+	if len(explain) == 0 {
+		return 0, errors.New("empty query plan")
+	}
+	return int64(explain[0].Plan.Rows), nil
+}
+
+func formatTimeKey(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// parseTimeKey returns the time in the local zone, the one GORM writes
+// timestamps in, so SQLite compares the stored text against the same
+// representation.
+func parseTimeKey(key string) (any, error) {
+	t, err := time.Parse(time.RFC3339Nano, key)
+	if err != nil {
+		return nil, err
+	}
+	return t.Local(), nil
+}
+
+func parseMoneyKey(key string) (any, error) {
+	return money.Parse(key)
+}
+
+func parseStringKey(key string) (any, error) {
+	return key, nil
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LowStockAlertRepository struct {
+	db *gorm.DB
+}
+
+func NewLowStockAlertRepository(db *gorm.DB) *LowStockAlertRepository {
+	return &LowStockAlertRepository{
+		db: db,
+	}
+}
+
+// Open creates the alert unless the product already has an open one,
+// reporting whether it was created.
+func (r *LowStockAlertRepository) Open(ctx context.Context, alert *models.LowStockAlert) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(alert)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListOpen returns the open alerts, oldest first, with their products.
+func (r *LowStockAlertRepository) ListOpen(ctx context.Context) ([]models.LowStockAlert, error) {
+	var alerts []models.LowStockAlert
+	err := r.db.WithContext(ctx).Preload("Product").
+		Where("resolved_at IS NULL").
+		Order("created_at ASC, id ASC").
+		Find(&alerts).Error
+	return alerts, err
+}
+
+// Resolve closes the open alert of the product, reporting whether there
+// was one.
+func (r *LowStockAlertRepository) Resolve(ctx context.Context, productID uint, now time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.LowStockAlert{}).
+		Where("product_id = ? AND resolved_at IS NULL", productID).
+		Update("resolved_at", now)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Delete removes an alert whose notice failed, so the next check opens it
+// again.
+func (r *LowStockAlertRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.LowStockAlert{}, id).Error
+}
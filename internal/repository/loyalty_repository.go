@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInsufficientPoints = errors.New("available points are not enough")
+	// ErrAccrualReleased is returned by Apply when a cancel entry is for an
+	// accrual whose points were already released.
+	ErrAccrualReleased   = errors.New("accrual points were already released")
+	ErrLoyaltyRuleExists = errors.New("category already has a loyalty rule")
+
+	// errLoyaltyChanged makes a transaction start over when another one
+	// changed the account first.
+	errLoyaltyChanged = errors.New("loyalty account changed during the transaction")
+	// errLoyaltyUnchanged rolls back a transaction that has nothing to
+	// write, like a retry of one already in the ledger.
+	errLoyaltyUnchanged = errors.New("loyalty transaction has nothing to apply")
+)
+
+// loyaltyRetries bounds how many times a transaction starts over when the
+// account keeps changing under it.
+const loyaltyRetries = 10
+
+type LoyaltyRepository struct {
+	db *gorm.DB
+}
+
+func NewLoyaltyRepository(db *gorm.DB) *LoyaltyRepository {
+	return &LoyaltyRepository{
+		db: db,
+	}
+}
+
+// loyaltyTransactionKeyset lists the newest entries of a ledger first.
+var loyaltyTransactionKeyset = keyset[models.LoyaltyTransaction]{
+	column: "loyalty_transactions.created_at", idColumn: "loyalty_transactions.id", param: "?", desc: true,
+	key:   func(t *models.LoyaltyTransaction) string { return formatTimeKey(t.CreatedAt) },
+	parse: parseTimeKey,
+	id:    func(t *models.LoyaltyTransaction) uint { return t.ID },
+}
+
+// Get returns the account of the user, empty when the user never had one.
+func (r *LoyaltyRepository) Get(ctx context.Context, userID uint) (*models.LoyaltyAccount, error) {
+	var account models.LoyaltyAccount
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Take(&account).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.LoyaltyAccount{UserID: userID}, nil
+	}
+	return &account, err
+}
+
+// ListTransactions returns a page of the ledger of the user, newest first.
+func (r *LoyaltyRepository) ListTransactions(ctx context.Context, userID uint, limit int, cursor *pagination.Cursor) ([]models.LoyaltyTransaction, *pagination.Cursor, *pagination.Cursor, error) {
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID).Limit(limit + 1)
+	if cursor != nil {
+		var err error
+		if query, err = loyaltyTransactionKeyset.seek(query, cursor); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	var transactions []models.LoyaltyTransaction
+	if err := loyaltyTransactionKeyset.order(query, cursor != nil && cursor.Backward).Find(&transactions).Error; err != nil {
+		return nil, nil, nil, err
+	}
+
+	transactions, next, prev := loyaltyTransactionKeyset.page(transactions, limit, cursor, true)
+	return transactions, next, prev, nil
+}
+
+// GetTransactionByKey returns the entry recorded with the key in the
+// ledger of the user.
+func (r *LoyaltyRepository) GetTransactionByKey(ctx context.Context, userID uint, key string) (*models.LoyaltyTransaction, error) {
+	var txn models.LoyaltyTransaction
+	err := r.db.WithContext(ctx).Where("user_id = ? AND idempotency_key = ?", userID, key).Take(&txn).Error
+	return &txn, err
+}
+
+// Lots returns what is left of the accruals of the user, those still
+// pending first in the order they are released, then the available ones in
+// the order redemptions take from them.
+func (r *LoyaltyRepository) Lots(ctx context.Context, userID uint) ([]models.LoyaltyLot, error) {
+	var lots []models.LoyaltyLot
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("pending DESC").Order("available_at ASC").Order("expires_at ASC").Order("transaction_id ASC").
+		Find(&lots).Error
+	return lots, err
+}
+
+// DueReleases returns the pending lots whose delay is over by now.
+func (r *LoyaltyRepository) DueReleases(ctx context.Context, now time.Time) ([]models.LoyaltyLot, error) {
+	var lots []models.LoyaltyLot
+	err := r.db.WithContext(ctx).
+		Where("pending = ? AND available_at <= ?", true, now).
+		Order("available_at ASC").
+		Find(&lots).Error
+	return lots, err
+}
+
+// DueExpiries returns the available lots that expired by now.
+func (r *LoyaltyRepository) DueExpiries(ctx context.Context, now time.Time) ([]models.LoyaltyLot, error) {
+	var lots []models.LoyaltyLot
+	err := r.db.WithContext(ctx).
+		Where("pending = ? AND expires_at <= ?", false, now).
+		Order("expires_at ASC").
+		Find(&lots).Error
+	return lots, err
+}
+
+// Apply appends the transaction to the ledger of its account and moves the
+// balances and the lots, all in one database transaction. A key already in
+// the ledger returns the entry recorded with it and changes nothing, so a
+// retried transaction applies once.
+//
+// Redemptions fail with ErrInsufficientPoints when the available points
+// are short. Release, cancel and expire entries move whatever is left of
+// their AccrualID, or only txn.Points of it for a cancel that sets them;
+// when nothing is left, no entry is written and txn.ID stays zero.
+func (r *LoyaltyRepository) Apply(ctx context.Context, txn *models.LoyaltyTransaction, now time.Time) error {
+	input := *txn
+	for attempt := 1; ; attempt++ {
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			*txn = input
+			return applyLoyaltyTransaction(tx, txn, now)
+		})
+		switch {
+		case errors.Is(err, errLoyaltyUnchanged):
+			return nil
+		case errors.Is(err, errLoyaltyChanged) && attempt < loyaltyRetries:
+			continue
+		default:
+			return err
+		}
+	}
+}
+
+func applyLoyaltyTransaction(tx *gorm.DB, txn *models.LoyaltyTransaction, now time.Time) error {
+	account, err := lockLoyaltyAccount(tx, txn.UserID, now)
+	if err != nil {
+		return err
+	}
+
+	var existing models.LoyaltyTransaction
+	err = tx.Where("user_id = ? AND idempotency_key = ?", txn.UserID, txn.IdempotencyKey).Take(&existing).Error
+	if err == nil {
+		// Entries for an accrual move what is left of it, which the caller
+		// does not know.
+		if existing.Type != txn.Type || (txn.AccrualID == nil && existing.Points != txn.Points) {
+			return ErrIdempotencyKeyReused
+		}
+		*txn = existing
+		return errLoyaltyUnchanged
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	var lot models.LoyaltyLot
+	if txn.AccrualID != nil {
+		err := tx.Where("transaction_id = ? AND user_id = ?", *txn.AccrualID, txn.UserID).Take(&lot).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errLoyaltyUnchanged
+		}
+		if err != nil {
+			return err
+		}
+		switch {
+		case txn.Type == models.LoyaltyCancel && !lot.Pending:
+			return ErrAccrualReleased
+		case txn.Type != models.LoyaltyCancel && lot.Pending != (txn.Type == models.LoyaltyRelease):
+			return errLoyaltyUnchanged
+		}
+		// A cancel may take part of the accrual, as for a partial return;
+		// every other entry moves what is left of it.
+		if txn.Type != models.LoyaltyCancel || txn.Points <= 0 || txn.Points > lot.Remaining {
+			txn.Points = lot.Remaining
+		}
+	}
+
+	available, pending := account.Available, account.Pending
+	switch txn.Type {
+	case models.LoyaltyAccrue:
+		pending += txn.Points
+	case models.LoyaltyRelease:
+		pending -= txn.Points
+		available += txn.Points
+	case models.LoyaltyCancel:
+		pending -= txn.Points
+	case models.LoyaltyRedeem:
+		if available < txn.Points {
+			return ErrInsufficientPoints
+		}
+		available -= txn.Points
+	case models.LoyaltyExpire:
+		available -= txn.Points
+	}
+
+	err = tx.Model(&models.LoyaltyAccount{}).Where("user_id = ?", account.UserID).
+		Updates(map[string]any{"available": available, "pending": pending, "updated_at": now}).Error
+	if err != nil {
+		return err
+	}
+
+	txn.AvailableAfter, txn.PendingAfter = available, pending
+	txn.CreatedAt = now
+	if err := tx.Create(txn).Error; err != nil {
+		return err
+	}
+
+	switch txn.Type {
+	case models.LoyaltyAccrue:
+		return tx.Create(&models.LoyaltyLot{
+			TransactionID: txn.ID,
+			UserID:        txn.UserID,
+			Remaining:     txn.Points,
+			Pending:       true,
+			AvailableAt:   *txn.AvailableAt,
+			ExpiresAt:     *txn.ExpiresAt,
+		}).Error
+	case models.LoyaltyRelease:
+		return tx.Model(&models.LoyaltyLot{}).Where("transaction_id = ?", lot.TransactionID).
+			Update("pending", false).Error
+	case models.LoyaltyRedeem:
+		return spendLoyaltyLots(tx, txn.UserID, txn.Points)
+	default:
+		if txn.Points < lot.Remaining {
+			return tx.Model(&models.LoyaltyLot{}).Where("transaction_id = ?", lot.TransactionID).
+				Update("remaining", lot.Remaining-txn.Points).Error
+		}
+		return tx.Delete(&models.LoyaltyLot{}, lot.TransactionID).Error
+	}
+}
+
+// lockLoyaltyAccount reads the account of the user, creating it empty, and
+// bumps its version, like lockWallet.
+func lockLoyaltyAccount(tx *gorm.DB, userID uint, now time.Time) (*models.LoyaltyAccount, error) {
+	account := models.LoyaltyAccount{UserID: userID, UpdatedAt: now}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&account).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("user_id = ?", userID).Take(&account).Error; err != nil {
+		return nil, err
+	}
+
+	result := tx.Model(&models.LoyaltyAccount{}).
+		Where("user_id = ? AND version = ?", userID, account.Version).
+		UpdateColumn("version", account.Version+1)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, errLoyaltyChanged
+	}
+	return &account, nil
+}
+
+// spendLoyaltyLots takes points from the available lots of the user, those
+// that expire first first, removing the lots it empties.
+func spendLoyaltyLots(tx *gorm.DB, userID uint, points int64) error {
+	var lots []models.LoyaltyLot
+	err := tx.Where("user_id = ? AND pending = ?", userID, false).
+		Order("expires_at ASC").Order("transaction_id ASC").
+		Find(&lots).Error
+	if err != nil {
+		return err
+	}
+
+	for _, lot := range lots {
+		if points <= 0 {
+			break
+		}
+		if lot.Remaining <= points {
+			points -= lot.Remaining
+			if err := tx.Delete(&models.LoyaltyLot{}, lot.TransactionID).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		err := tx.Model(&models.LoyaltyLot{}).Where("transaction_id = ?", lot.TransactionID).
+			Update("remaining", lot.Remaining-points).Error
+		if err != nil {
+			return err
+		}
+		points = 0
+	}
+	return nil
+}
+
+// ListRules returns every loyalty rule.
+func (r *LoyaltyRepository) ListRules(ctx context.Context) ([]models.LoyaltyRule, error) {
+	var rules []models.LoyaltyRule
+	err := r.db.WithContext(ctx).Order("category_id ASC").Find(&rules).Error
+	return rules, err
+}
+
+func (r *LoyaltyRepository) GetRule(ctx context.Context, id uint) (*models.LoyaltyRule, error) {
+	var rule models.LoyaltyRule
+	err := r.db.WithContext(ctx).First(&rule, id).Error
+	return &rule, err
+}
+
+// CreateRule adds the rule, failing with ErrLoyaltyRuleExists when its
+// category has one.
+func (r *LoyaltyRepository) CreateRule(ctx context.Context, rule *models.LoyaltyRule) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var taken int64
+		if err := tx.Model(&models.LoyaltyRule{}).Where("category_id = ?", rule.CategoryID).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrLoyaltyRuleExists
+		}
+		return tx.Create(rule).Error
+	})
+}
+
+func (r *LoyaltyRepository) UpdateRule(ctx context.Context, rule *models.LoyaltyRule) error {
+	return r.db.WithContext(ctx).Model(rule).Select("rate", "updated_at").Updates(rule).Error
+}
+
+func (r *LoyaltyRepository) DeleteRule(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.LoyaltyRule{}, id).Error
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"gorm.io/gorm"
+)
+
+var ErrSaleOverlap = errors.New("sale overlaps another sale of the product")
+
+type PricingRepository struct {
+	db *gorm.DB
+}
+
+func NewPricingRepository(db *gorm.DB) *PricingRepository {
+	return &PricingRepository{
+		db: db,
+	}
+}
+
+// CreateSale adds a sale window, failing with ErrSaleOverlap when the
+// product already has a sale during any part of it.
+func (r *PricingRepository) CreateSale(ctx context.Context, sale *models.ProductSale) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var overlapping int64
+		err := tx.Model(&models.ProductSale{}).
+			Where("product_id = ? AND starts_at < ? AND ends_at > ?", sale.ProductID, sale.EndsAt, sale.StartsAt).
+			Count(&overlapping).Error
+		if err != nil {
+			return err
+		}
+		if overlapping > 0 {
+			return ErrSaleOverlap
+		}
+
+		return tx.Create(sale).Error
+	})
+}
+
+func (r *PricingRepository) GetSale(ctx context.Context, id uint) (*models.ProductSale, error) {
+	var sale models.ProductSale
+	err := r.db.WithContext(ctx).First(&sale, id).Error
+	return &sale, err
+}
+
+func (r *PricingRepository) ListSales(ctx context.Context, productID uint) ([]models.ProductSale, error) {
+	var sales []models.ProductSale
+	err := r.db.WithContext(ctx).
+		Where("product_id = ?", productID).
+		Order("starts_at ASC").
+		Find(&sales).Error
+	return sales, err
+}
+
+func (r *PricingRepository) DeleteSale(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.ProductSale{}, id).Error
+}
+
+// ListHistory returns the price changes of the product, newest first.
+func (r *PricingRepository) ListHistory(ctx context.Context, productID uint) ([]models.PriceHistory, error) {
+	var history []models.PriceHistory
+	err := r.db.WithContext(ctx).
+		Where("product_id = ?", productID).
+		Order("created_at DESC").
+		Order("id DESC").
+		Find(&history).Error
+	return history, err
+}
+
+// NextBoundary returns the next time after now at which a sale starts or
+// ends, or nil when nothing is scheduled.
+func (r *PricingRepository) NextBoundary(ctx context.Context, now time.Time) (*time.Time, error) {
+	var next *time.Time
+	for _, column := range []string{"starts_at", "ends_at"} {
+		var sale models.ProductSale
+		err := r.db.WithContext(ctx).
+			Where(column+" > ?", now).
+			Order(column + " ASC").
+			Take(&sale).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		boundary := sale.StartsAt
+		if column == "ends_at" {
+			boundary = sale.EndsAt
+		}
+		if next == nil || boundary.Before(*next) {
+			next = &boundary
+		}
+	}
+	return next, nil
+}
+
+// ApplySales sets the sale price of every product to the one of its sale
+// running at now, clearing it where none is, and records each change in
+// the price history. It returns the IDs of the products it changed.
+//
+// Each product is updated only if its sale price is still the one read, so
+// several instances running the scheduler at the same boundary change and
+// record it once.
+func (r *PricingRepository) ApplySales(ctx context.Context, now time.Time) ([]uint, error) {
+	var running []models.ProductSale
+	err := r.db.WithContext(ctx).
+		Where("starts_at <= ? AND ends_at > ?", now, now).
+		Order("starts_at ASC").
+		Find(&running).Error
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[uint]models.ProductSale, len(running))
+	ids := make([]uint, 0, len(running))
+	for _, sale := range running {
+		wanted[sale.ProductID] = sale
+		ids = append(ids, sale.ProductID)
+	}
+
+	var products []struct {
+		ID         uint
+		Price      money.Money
+		SalePrice  *money.Money
+		SaleEndsAt *time.Time
+	}
+	err = r.db.WithContext(ctx).Model(&models.Product{}).
+		Select("id", "price", "sale_price", "sale_ends_at").
+		Where("sale_price IS NOT NULL OR id IN ?", ids).
+		Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []uint
+	for _, product := range products {
+		sale, onSale := wanted[product.ID]
+
+		var salePrice *money.Money
+		var endsAt *time.Time
+		reason := models.PriceChangeSaleEnded
+		if onSale {
+			salePrice, endsAt = &sale.SalePrice, &sale.EndsAt
+			reason = models.PriceChangeSaleStarted
+		}
+
+		if equalMoney(product.SalePrice, salePrice) && equalTime(product.SaleEndsAt, endsAt) {
+			continue
+		}
+
+		applied := false
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			update := tx.Model(&models.Product{}).Where("id = ?", product.ID)
+			if product.SalePrice == nil {
+				update = update.Where("sale_price IS NULL")
+			} else {
+				update = update.Where("sale_price = ?", *product.SalePrice)
+			}
+
+			result := update.Updates(map[string]any{"sale_price": salePrice, "sale_ends_at": endsAt})
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+
+			applied = true
+			return recordPrice(tx, product.ID, product.Price, salePrice, reason)
+		})
+		if err != nil {
+			return changed, err
+		}
+		if applied {
+			changed = append(changed, product.ID)
+		}
+	}
+
+	return changed, nil
+}
+
+func equalMoney(a, b *money.Money) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
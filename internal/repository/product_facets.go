@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+)
+
+// GetFacets counts the products matching filter by category, price range
+// and availability. Each facet ignores its own filter.
+func (r *ProductRepository) GetFacets(ctx context.Context, filter models.ProductFilter) (*models.ProductFacets, error) {
+	categories, err := r.categoryFacets(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	prices, err := r.priceFacets(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var availability models.AvailabilityFacet
+	err = r.filteredQuery(ctx, filter, facetAvailability).
+		Select("COALESCE(SUM(CASE WHEN stock > 0 THEN 1 ELSE 0 END), 0) AS in_stock, " +
+			"COALESCE(SUM(CASE WHEN stock > 0 THEN 0 ELSE 1 END), 0) AS out_of_stock").
+		Scan(&availability).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ProductFacets{
+		Categories:   categories,
+		PriceRanges:  prices,
+		Availability: availability,
+	}, nil
+}
+
+// categoryFacets counts products per category and adds each count to the
+// ancestors of the category, taken from its materialized path.
+func (r *ProductRepository) categoryFacets(ctx context.Context, filter models.ProductFilter) ([]models.CategoryFacet, error) {
+	var direct []struct {
+		CategoryID uint
+		Count      int64
+	}
+	err := r.filteredQuery(ctx, filter, facetCategory).
+		Select("category_id, COUNT(*) AS count").
+		Where("category_id IS NOT NULL").
+		Group("category_id").
+		Scan(&direct).Error
+	if err != nil || len(direct) == 0 {
+		return []models.CategoryFacet{}, err
+	}
+
+	var categories []models.Category
+	err = r.db.WithContext(ctx).
+		Where("active = ?", true).
+		Order("depth ASC, sort_order ASC, name ASC").
+		Find(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[uint]string, len(categories))
+	for _, category := range categories {
+		paths[category.ID] = category.Path
+	}
+
+	counts := make(map[uint]int64)
+	for _, row := range direct {
+		for _, segment := range strings.Split(strings.Trim(paths[row.CategoryID], "/"), "/") {
+			if id, err := strconv.ParseUint(segment, 10, 64); err == nil {
+				counts[uint(id)] += row.Count
+			}
+		}
+	}
+
+	facets := []models.CategoryFacet{}
+	for _, category := range categories {
+		if counts[category.ID] == 0 {
+			continue
+		}
+		facets = append(facets, models.CategoryFacet{
+			ID:       category.ID,
+			ParentID: category.ParentID,
+			Name:     category.Name,
+			Slug:     category.Slug,
+			Count:    counts[category.ID],
+		})
+	}
+
+	return facets, nil
+}
+
+// priceFacets counts products in each bucket of models.PriceBucketLimits,
+// including empty buckets so the ranges are stable across requests.
+func (r *ProductRepository) priceFacets(ctx context.Context, filter models.ProductFilter) ([]models.PriceBucket, error) {
+	limits := models.PriceBucketLimits
+
+	bucket := "CASE"
+	args := make([]any, 0, len(limits))
+	for i, limit := range limits {
+		bucket += " WHEN " + effectivePrice + " < " + moneyParam + " THEN " + strconv.Itoa(i)
+		args = append(args, limit)
+	}
+	bucket += " ELSE " + strconv.Itoa(len(limits)) + " END"
+
+	var rows []struct {
+		Bucket int
+		Count  int64
+	}
+	err := r.filteredQuery(ctx, filter, facetPrice).
+		Select(bucket+" AS bucket, COUNT(*) AS count", args...).
+		Group("bucket").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]models.PriceBucket, len(limits)+1)
+	for i := range buckets {
+		if i > 0 {
+			buckets[i].Min = limits[i-1]
+		}
+		if i < len(limits) {
+			max := limits[i]
+			buckets[i].Max = &max
+		}
+	}
+	for _, row := range rows {
+		buckets[row.Bucket].Count = row.Count
+	}
+
+	return buckets, nil
+}
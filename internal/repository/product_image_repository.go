@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"gorm.io/gorm"
+)
+
+type ProductImageRepository struct {
+	db *gorm.DB
+}
+
+func NewProductImageRepository(db *gorm.DB) *ProductImageRepository {
+	return &ProductImageRepository{
+		db: db,
+	}
+}
+
+// Create appends the image to the end of the product gallery.
+func (r *ProductImageRepository) Create(ctx context.Context, image *models.ProductImage) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var next int
+		err := tx.Model(&models.ProductImage{}).
+			Select("COALESCE(MAX(position), -1) + 1").
+			Where("product_id = ?", image.ProductID).
+			Scan(&next).Error
+		if err != nil {
+			return err
+		}
+
+		image.Position = next
+		return tx.Create(image).Error
+	})
+}
+
+func (r *ProductImageRepository) GetByID(ctx context.Context, id uint) (*models.ProductImage, error) {
+	var image models.ProductImage
+	err := r.db.WithContext(ctx).First(&image, id).Error
+	return &image, err
+}
+
+func (r *ProductImageRepository) ListByProduct(ctx context.Context, productID uint) ([]models.ProductImage, error) {
+	var images []models.ProductImage
+	err := r.db.WithContext(ctx).
+		Where("product_id = ?", productID).
+		Order("position ASC, id ASC").
+		Find(&images).Error
+	return images, err
+}
+
+// ListByProducts returns the galleries of the products, in display order,
+// keyed by product ID.
+func (r *ProductImageRepository) ListByProducts(ctx context.Context, productIDs []uint) (map[uint][]models.ProductImage, error) {
+	var images []models.ProductImage
+	err := r.db.WithContext(ctx).
+		Where("product_id IN ?", productIDs).
+		Order("product_id ASC, position ASC, id ASC").
+		Find(&images).Error
+	if err != nil {
+		return nil, err
+	}
+
+	galleries := make(map[uint][]models.ProductImage, len(productIDs))
+	for _, image := range images {
+		galleries[image.ProductID] = append(galleries[image.ProductID], image)
+	}
+	return galleries, nil
+}
+
+// Reorder sets the gallery order to the given image IDs.
+func (r *ProductImageRepository) Reorder(ctx context.Context, productID uint, ids []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for position, id := range ids {
+			err := tx.Model(&models.ProductImage{}).
+				Where("id = ? AND product_id = ?", id, productID).
+				Update("position", position).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *ProductImageRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.ProductImage{}, id).Error
+}
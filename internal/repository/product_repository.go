@@ -1,146 +1,626 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
 	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrProductHasVariants rejects product-level stock changes on products
+	// whose stock is the sum of their variants.
+	ErrProductHasVariants = errors.New("product has variants, change the stock of a variant instead")
 )
 
+// effectivePrice is the price a product sells for: the sale price while it
+// is set and lower than the list price, the list price otherwise.
+const effectivePrice = "(CASE WHEN products.sale_price IS NOT NULL AND products.sale_price < products.price " +
+	"THEN products.sale_price ELSE products.price END)"
+
+// moneyParam is the placeholder for a Money compared against an expression.
+// Money is bound as a decimal string, which SQLite would otherwise compare
+// as text.
+const moneyParam = "CAST(? AS NUMERIC)"
+
 type ProductRepository struct {
-	db *gorm.DB
+	db     *gorm.DB
+	search ProductSearch
 }
 
 func NewProductRepository(db *gorm.DB) *ProductRepository {
 	return &ProductRepository{
-		db: db,
+		db:     db,
+		search: NewProductSearch(db),
 	}
 }
 
-func (r *ProductRepository) Create(product *models.Product) error {
-	return r.db.Create(product).Error
+// productOmit leaves out of product writes the associations, the sale
+// columns and the rating. The sale columns belong to the price scheduler and
+// the rating to review moderation, so a stale copy of the product cannot
+// undo a sale or lose a review.
+var productOmit = []string{
+	clause.Associations, "sale_price", "sale_ends_at",
+	"rating_count", "rating_sum", "rating_stars1", "rating_stars2", "rating_stars3", "rating_stars4", "rating_stars5",
 }
 
-func (r *ProductRepository) GetAll() ([]models.Product, int, error) {
+// Create and Update leave the Category association alone: products only
+// point at existing categories through CategoryID. Variants are written
+// explicitly so their options can be replaced as a whole.
+func (r *ProductRepository) Create(ctx context.Context, product *models.Product) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(productOmit...).Create(product).Error; err != nil {
+			return err
+		}
+		if err := recordPrice(tx, product.ID, product.Price, nil, models.PriceChangeCreated); err != nil {
+			return err
+		}
+		if len(product.Variants) == 0 {
+			return nil
+		}
+
+		if err := saveVariants(tx, product.ID, product.Variants); err != nil {
+			return err
+		}
+		return syncProductStock(tx, product)
+	})
+}
+
+func (r *ProductRepository) GetAll(ctx context.Context) ([]models.Product, int, error) {
 	var products []models.Product
-	err := r.db.Where("active = ?", true).Find(&products).Error
+	err := r.db.WithContext(ctx).Where("active = ?", true).Find(&products).Error
 	return products, len(products), err
 }
 
-func (r *ProductRepository) GetWithFilters(page, limit int, category, search string) ([]models.Product, int64, error) {
+// productKeysets are the listing sorts that page by cursor. Relevance is
+// not a column, so it pages by offset instead.
+var productKeysets = map[string]keyset[models.Product]{
+	models.SortNewest: {
+		column: "products.created_at", idColumn: "products.id", param: "?", desc: true,
+		key:   func(p *models.Product) string { return formatTimeKey(p.CreatedAt) },
+		parse: parseTimeKey,
+		id:    productID,
+	},
+	models.SortPriceAsc: {
+		column: effectivePrice, idColumn: "products.id", param: moneyParam,
+		key:   func(p *models.Product) string { return p.EffectivePrice.String() },
+		parse: parseMoneyKey,
+		id:    productID,
+	},
+	models.SortPriceDesc: {
+		column: effectivePrice, idColumn: "products.id", param: moneyParam, desc: true,
+		key:   func(p *models.Product) string { return p.EffectivePrice.String() },
+		parse: parseMoneyKey,
+		id:    productID,
+	},
+	models.SortName: {
+		column: "LOWER(products.name)", idColumn: "products.id", param: "LOWER(?)",
+		key:   func(p *models.Product) string { return p.Name },
+		parse: parseStringKey,
+		id:    productID,
+	},
+}
+
+func productID(p *models.Product) uint { return p.ID }
+
+// GetWithFilters returns one page of the listing with the cursors to the
+// next and previous pages, nil at either end. It starts at filter.Cursor
+// when set and falls back to filter.Page otherwise. The total is counted
+// apart by CountWithFilters.
+func (r *ProductRepository) GetWithFilters(ctx context.Context, filter models.ProductFilter) ([]models.Product, *pagination.Cursor, *pagination.Cursor, error) {
 	var products []models.Product
-	var total int64
 
-	query := r.db.Model(&models.Product{}).Where("active = ?", true)
+	query := r.filteredQuery(ctx, filter, "").Preload("Category").Limit(filter.Limit + 1)
+	offset := (filter.Page - 1) * filter.Limit
+
+	if terms := searchTerms(filter.Search); filter.Sort == models.SortRelevance && len(terms) > 0 {
+		if filter.Cursor != nil {
+			offset = filter.Cursor.Offset
+		}
+		err := r.search.OrderByRank(query, terms).Order("products.id DESC").Offset(offset).Find(&products).Error
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		products, next, prev := offsetPage(products, filter.Limit, offset)
+		return products, next, prev, nil
+	}
+
+	// Newest first is the order when nothing else applies.
+	keyset, ok := productKeysets[filter.Sort]
+	if !ok {
+		keyset = productKeysets[models.SortNewest]
+	}
+
+	if filter.Cursor != nil {
+		var err error
+		if query, err = keyset.seek(query, filter.Cursor); err != nil {
+			return nil, nil, nil, err
+		}
+	} else {
+		query = query.Offset(offset)
+	}
+
+	backward := filter.Cursor != nil && filter.Cursor.Backward
+	if err := keyset.order(query, backward).Find(&products).Error; err != nil {
+		return nil, nil, nil, err
+	}
+
+	products, next, prev := keyset.page(products, filter.Limit, filter.Cursor, offset == 0)
+	return products, next, prev, nil
+}
+
+// CountWithFilters counts the products matching the filters; see
+// countRows for approximate counts.
+func (r *ProductRepository) CountWithFilters(ctx context.Context, filter models.ProductFilter, approximate bool) (int64, bool, error) {
+	return countRows(ctx, r.filteredQuery(ctx, filter, ""), approximate)
+}
+
+// Facets passed to filteredQuery to leave their own filter out.
+const (
+	facetCategory     = "category"
+	facetPrice        = "price"
+	facetAvailability = "availability"
+)
+
+// filteredQuery applies every filter except the one of the given facet.
+func (r *ProductRepository) filteredQuery(ctx context.Context, filter models.ProductFilter, facet string) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&models.Product{}).Where("active = ?", true)
 
-	if category != "" {
-		query = query.Where("category ILIKE ?", "%"+category+"%")
+	if len(filter.Categories) > 0 && facet != facetCategory {
+		query = query.Where("category_id IN (?)", r.categorySubtree(ctx, filter.Categories...))
 	}
 
-	if search != "" {
-		query = query.Where("name ILIKE ? OR description ILIKE ?", "%"+search+"%", "%"+search+"%")
+	if terms := searchTerms(filter.Search); len(terms) > 0 {
+		query = r.search.Match(query, terms)
 	}
 
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+	if facet != facetPrice {
+		if filter.MinPrice != nil {
+			query = query.Where(effectivePrice+" >= "+moneyParam, *filter.MinPrice)
+		}
+		if filter.MaxPrice != nil {
+			query = query.Where(effectivePrice+" <= "+moneyParam, *filter.MaxPrice)
+		}
 	}
 
-	offset := (page - 1) * limit
-	err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&products).Error
+	if filter.InStock != nil && facet != facetAvailability {
+		if *filter.InStock {
+			query = query.Where("stock > 0")
+		} else {
+			query = query.Where("stock <= 0")
+		}
+	}
 
-	return products, total, err
+	return query
 }
 
-func (r *ProductRepository) GetByID(id uint) (*models.Product, error) {
+func (r *ProductRepository) GetByID(ctx context.Context, id uint) (*models.Product, error) {
 	var product models.Product
-	err := r.db.Where("id = ? AND active = ?", id, true).First(&product, id).Error
+	err := r.db.WithContext(ctx).
+		Preload("Category").
+		Preload("Variants", func(db *gorm.DB) *gorm.DB {
+			return db.Where("active = ?", true).Order("id ASC")
+		}).
+		Preload("Variants.Options", func(db *gorm.DB) *gorm.DB {
+			return db.Order("option_type_id ASC")
+		}).
+		Preload("Variants.Options.OptionType").
+		Where("id = ? AND active = ?", id, true).
+		First(&product, id).Error
 	return &product, err
 }
 
-func (r *ProductRepository) Update(product *models.Product) error {
-	return r.db.Save(product).Error
+// ExportBatch returns up to limit active products with an ID after afterID,
+// in ID order, so an export can walk the whole catalog one batch at a time.
+// With since set, only products changed since then are returned, counting
+// changes to their variants. Products and variants deactivated or deleted
+// since then are returned too, so the platform can take them down.
+func (r *ProductRepository) ExportBatch(ctx context.Context, afterID uint, limit int, since *time.Time) ([]models.Product, error) {
+	// Local time, the zone GORM writes timestamps in, so SQLite compares the
+	// stored text against the same representation.
+	var changed time.Time
+	if since != nil {
+		changed = since.Local()
+	}
+
+	query := r.db.WithContext(ctx).
+		Preload("Variants", func(db *gorm.DB) *gorm.DB {
+			if since != nil {
+				return db.Where("active = ? OR updated_at >= ?", true, changed).Order("id ASC")
+			}
+			return db.Where("active = ?", true).Order("id ASC")
+		}).
+		Preload("Variants.Options", func(db *gorm.DB) *gorm.DB {
+			return db.Order("option_type_id ASC")
+		}).
+		Preload("Variants.Options.OptionType").
+		Where("products.id > ?", afterID)
+
+	if since == nil {
+		query = query.Where("products.active = ?", true)
+	} else {
+		variants := r.db.Model(&models.ProductVariant{}).Select("product_id").Where("updated_at >= ?", changed)
+		query = query.Unscoped().
+			Where("products.deleted_at IS NULL OR products.deleted_at >= ?", changed).
+			Where("products.updated_at >= ? OR products.deleted_at >= ? OR products.id IN (?)", changed, changed, variants)
+	}
+
+	var products []models.Product
+	err := query.Order("products.id ASC").Limit(limit).Find(&products).Error
+	return products, err
 }
-func (r *ProductRepository) Delete(id uint) error {
-	return r.db.Model(&models.Product{}).Where("id = ?", id).Update("active", false).Error
+
+// GetBySKU finds the product with the SKU, active or not, with its active
+// variants. Deleted products are included too, since they still hold their
+// SKU; callers tell them apart by DeletedAt.
+func (r *ProductRepository) GetBySKU(ctx context.Context, sku string) (*models.Product, error) {
+	var product models.Product
+	err := r.db.WithContext(ctx).Unscoped().
+		Preload("Variants", func(db *gorm.DB) *gorm.DB {
+			return db.Where("active = ?", true).Order("id ASC")
+		}).
+		Preload("Variants.Options", func(db *gorm.DB) *gorm.DB {
+			return db.Order("option_type_id ASC")
+		}).
+		Preload("Variants.Options.OptionType").
+		Where("sku = ?", sku).
+		First(&product).Error
+	return &product, err
 }
 
-func (r *ProductRepository) HardDelete(id uint) error {
-	return r.db.Delete(&models.Product{}, id).Error
+func (r *ProductRepository) Update(ctx context.Context, product *models.Product) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return saveProduct(tx, product)
+	})
 }
 
-func (r *ProductRepository) GetByCategory(category string) ([]models.Product, error) {
-	var products []models.Product
-	err := r.db.Where("category ILIKE ? AND active = ?", "%"+category+"%", true).Find(&products).Error
-	return products, err
+// UpdateWithVariants saves the product and replaces its variant set:
+// variants with an ID are updated, new ones are created and the ones left
+// out are deactivated, since they may already be referenced elsewhere.
+func (r *ProductRepository) UpdateWithVariants(ctx context.Context, product *models.Product) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := saveProduct(tx, product); err != nil {
+			return err
+		}
+		if err := saveVariants(tx, product.ID, product.Variants); err != nil {
+			return err
+		}
+
+		keep := make([]uint, 0, len(product.Variants))
+		for _, variant := range product.Variants {
+			keep = append(keep, variant.ID)
+		}
+		deactivate := tx.Model(&models.ProductVariant{}).Where("product_id = ?", product.ID)
+		if len(keep) > 0 {
+			deactivate = deactivate.Where("id NOT IN ?", keep)
+		}
+		if err := deactivate.Update("active", false).Error; err != nil {
+			return err
+		}
+
+		// Without variants the product keeps managing its own stock.
+		if len(product.Variants) == 0 {
+			return nil
+		}
+		return syncProductStock(tx, product)
+	})
+}
+func (r *ProductRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&models.Product{}).Where("id = ?", id).Update("active", false).Error
 }
 
-func (r *ProductRepository) SearchByName(name string) ([]models.Product, error) {
+func (r *ProductRepository) HardDelete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Product{}, id).Error
+}
+
+func (r *ProductRepository) GetByCategory(ctx context.Context, category string) ([]models.Product, error) {
 	var products []models.Product
-	err := r.db.Where("name <= ? AND active = ?", "%"+name+"%", true).Find(&products).Error
+	err := r.db.WithContext(ctx).
+		Where("category_id IN (?) AND active = ?", r.categorySubtree(ctx, category), true).
+		Find(&products).Error
 	return products, err
 }
 
-func (r *ProductRepository) GetLowStock(threshold int) ([]models.Product, error) {
+func (r *ProductRepository) SearchByName(ctx context.Context, name string) ([]models.Product, error) {
 	var products []models.Product
-	err := r.db.Where("stock <= ? AND active = ?", threshold, true).Find(&products).Error
+
+	terms := searchTerms(name)
+	if len(terms) == 0 {
+		return products, nil
+	}
+
+	query := r.search.Match(r.db.WithContext(ctx).Where("active = ?", true), terms)
+	err := r.search.OrderByRank(query, terms).Find(&products).Error
 	return products, err
 }
 
-func (r *ProductRepository) GetByPriceRange(minPrice, maxPrice float64) ([]models.Product, error) {
+// GetStock reads the stock of the product, the sum of its variants when it
+// has any.
+func (r *ProductRepository) GetStock(ctx context.Context, id uint) (int, error) {
+	var stock int
+	err := r.db.WithContext(ctx).Model(&models.Product{}).Select("stock").Where("id = ?", id).Take(&stock).Error
+	return stock, err
+}
+
+func (r *ProductRepository) GetLowStock(ctx context.Context, threshold int) ([]models.Product, error) {
 	var products []models.Product
-	err := r.db.Where("price BETWEEN ? AND ? AND active = ?", minPrice, maxPrice, true).Find(&products).Error
+	err := r.db.WithContext(ctx).Where("stock <= ? AND active = ?", threshold, true).Find(&products).Error
 	return products, err
 }
 
-func (r *ProductRepository) GetMostExpensive(limit int) ([]models.Product, error) {
+// MaxReorderThreshold returns the highest threshold set on a product, 0
+// when none sets one.
+func (r *ProductRepository) MaxReorderThreshold(ctx context.Context) (int, error) {
+	var threshold int
+	err := r.db.WithContext(ctx).Model(&models.Product{}).
+		Select("COALESCE(MAX(reorder_threshold), 0)").
+		Scan(&threshold).Error
+	return threshold, err
+}
+
+func (r *ProductRepository) GetByPriceRange(ctx context.Context, minPrice, maxPrice money.Money) ([]models.Product, error) {
 	var products []models.Product
-	err := r.db.Where("active = ?", true).Order("price DESC").Limit(limit).Find(&products).Error
+	err := r.db.WithContext(ctx).Where("price BETWEEN ? AND ? AND active = ?", minPrice, maxPrice, true).Find(&products).Error
 	return products, err
 }
 
-func (r *ProductRepository) GetNewest(limit int) ([]models.Product, error) {
+func (r *ProductRepository) GetMostExpensive(ctx context.Context, limit int) ([]models.Product, error) {
 	var products []models.Product
-	err := r.db.Where("active = ?", true).Order("created_at DESC").Limit(limit).Find(&products).Error
+	err := r.db.WithContext(ctx).Where("active = ?", true).Order("price DESC").Limit(limit).Find(&products).Error
 	return products, err
 }
 
-func (r *ProductRepository) GetCategories() ([]string, error) {
-	var categories []string
-	err := r.db.Model(&models.Product{}).Where("active = ?", true).Distinct("category").Pluck("category", &categories).Error
-	return categories, err
+func (r *ProductRepository) GetNewest(ctx context.Context, limit int) ([]models.Product, error) {
+	var products []models.Product
+	err := r.db.WithContext(ctx).Where("active = ?", true).Order("created_at DESC").Limit(limit).Find(&products).Error
+	return products, err
 }
 
-func (r *ProductRepository) CountByCategory() (map[string]int64, error) {
+func (r *ProductRepository) CountByCategory(ctx context.Context) (map[uint]int64, error) {
 	type CategoryCount struct {
-		Category string
-		Count    int64
+		CategoryID uint
+		Count      int64
 	}
 
 	var results []CategoryCount
-	err := r.db.Model(&models.Product{}).
-		Select("category, count(*) as count").
-		Where("active = ?", true).
-		Group("category").
+	err := r.db.WithContext(ctx).Model(&models.Product{}).
+		Select("category_id, count(*) as count").
+		Where("active = ? AND category_id IS NOT NULL", true).
+		Group("category_id").
 		Scan(&results).Error
 
 	if err != nil {
 		return nil, err
 	}
 
-	categoryMap := make(map[string]int64)
+	categoryMap := make(map[uint]int64)
 	for _, result := range results {
-		categoryMap[result.Category] = result.Count
+		categoryMap[result.CategoryID] = result.Count
 	}
 
 	return categoryMap, nil
 }
 
-func (r *ProductRepository) UpdateStock(id uint, stock int) error {
-	return r.db.Model(&models.Product{}).Where("id = ?", id).Update("stock", stock).Error
+func (r *ProductRepository) CategoryExists(ctx context.Context, id uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Category{}).Where("id = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+// categorySubtree selects the IDs of the categories identified by slug or
+// ID and of all their descendants, so listing a parent category includes
+// the products of its subcategories.
+func (r *ProductRepository) categorySubtree(ctx context.Context, categories ...string) *gorm.DB {
+	var ids []uint64
+	for _, category := range categories {
+		if id, err := strconv.ParseUint(category, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	roots := r.db.Table("categories AS roots").Select("1").Where("categories.path LIKE roots.path || '%'")
+	if len(ids) > 0 {
+		roots = roots.Where("roots.slug IN ? OR roots.id IN ?", categories, ids)
+	} else {
+		roots = roots.Where("roots.slug IN ?", categories)
+	}
+
+	return r.db.WithContext(ctx).Model(&models.Category{}).Select("id").Where("EXISTS (?)", roots)
+}
+
+// GetVariants returns every variant of the product, including inactive ones.
+func (r *ProductRepository) GetVariants(ctx context.Context, productID uint) ([]models.ProductVariant, error) {
+	var variants []models.ProductVariant
+	err := r.db.WithContext(ctx).
+		Preload("Options.OptionType").
+		Where("product_id = ?", productID).
+		Order("id ASC").
+		Find(&variants).Error
+	return variants, err
+}
+
+func (r *ProductRepository) GetVariant(ctx context.Context, id uint) (*models.ProductVariant, error) {
+	var variant models.ProductVariant
+	err := r.db.WithContext(ctx).Preload("Options.OptionType").First(&variant, id).Error
+	return &variant, err
+}
+
+func (r *ProductRepository) GetOptionTypes(ctx context.Context) ([]models.OptionType, error) {
+	var optionTypes []models.OptionType
+	err := r.db.WithContext(ctx).Order("id ASC").Find(&optionTypes).Error
+	return optionTypes, err
+}
+
+// VariantSKUExists reports whether a variant of another product already uses
+// the SKU.
+func (r *ProductRepository) VariantSKUExists(ctx context.Context, sku string, productID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.ProductVariant{}).
+		Where("sku = ? AND product_id <> ?", sku, productID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// The variant stock operations keep the product stock equal to the sum of
+// its active variants, so listings and low stock checks keep working.
+
+func (r *ProductRepository) UpdateVariantStock(ctx context.Context, variantID uint, stock int) error {
+	return r.changeVariantStock(ctx, variantID, func(tx *gorm.DB) *gorm.DB {
+		return tx.Update("stock", stock)
+	})
+}
+
+func (r *ProductRepository) IncrementVariantStock(ctx context.Context, variantID uint, quantity int) error {
+	return r.changeVariantStock(ctx, variantID, func(tx *gorm.DB) *gorm.DB {
+		return tx.Update("stock", gorm.Expr("stock + ?", quantity))
+	})
+}
+
+// DecrementVariantStock fails with ErrInsufficientStock instead of letting
+// the stock go negative.
+func (r *ProductRepository) DecrementVariantStock(ctx context.Context, variantID uint, quantity int) error {
+	return r.changeVariantStock(ctx, variantID, func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("stock >= ?", quantity).Update("stock", gorm.Expr("stock - ?", quantity))
+	})
+}
+
+func (r *ProductRepository) changeVariantStock(ctx context.Context, variantID uint, change func(tx *gorm.DB) *gorm.DB) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var variant models.ProductVariant
+		if err := tx.Select("id", "product_id").First(&variant, variantID).Error; err != nil {
+			return err
+		}
+
+		result := change(tx.Model(&models.ProductVariant{}).Where("id = ?", variantID))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInsufficientStock
+		}
+
+		return syncProductStock(tx, &models.Product{ID: variant.ProductID})
+	})
+}
+
+// saveProduct writes the product and records the new list price in the
+// price history when it changed.
+func saveProduct(tx *gorm.DB, product *models.Product) error {
+	var current struct {
+		Price     money.Money
+		SalePrice *money.Money
+	}
+	err := tx.Model(&models.Product{}).Select("price", "sale_price").Where("id = ?", product.ID).Take(&current).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if err := tx.Omit(productOmit...).Save(product).Error; err != nil {
+		return err
+	}
+
+	if err == nil && current.Price == product.Price {
+		return nil
+	}
+	return recordPrice(tx, product.ID, product.Price, current.SalePrice, models.PriceChangeUpdated)
+}
+
+func recordPrice(tx *gorm.DB, productID uint, price money.Money, salePrice *money.Money, reason string) error {
+	return tx.Create(&models.PriceHistory{
+		ProductID: productID,
+		Price:     price,
+		SalePrice: salePrice,
+		Reason:    reason,
+	}).Error
+}
+
+func saveVariants(tx *gorm.DB, productID uint, variants []models.ProductVariant) error {
+	for i := range variants {
+		variant := &variants[i]
+		variant.ProductID = productID
+
+		save := tx.Omit(clause.Associations)
+		if variant.ID == 0 {
+			save = save.Create(variant)
+		} else {
+			save = save.Save(variant)
+		}
+		if save.Error != nil {
+			return save.Error
+		}
+
+		if err := tx.Where("variant_id = ?", variant.ID).Delete(&models.VariantOption{}).Error; err != nil {
+			return err
+		}
+		for j := range variant.Options {
+			variant.Options[j].ID = 0
+			variant.Options[j].VariantID = variant.ID
+		}
+		if len(variant.Options) > 0 {
+			if err := tx.Omit(clause.Associations).Create(&variant.Options).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
-func (r *ProductRepository) IncrementStock(id uint, quantity int) error {
-	return r.db.Model(&models.Product{}).Where("id = ?", id).Update("stock", gorm.Expr("stock + ?", quantity)).Error
+func syncProductStock(tx *gorm.DB, product *models.Product) error {
+	total := tx.Model(&models.ProductVariant{}).
+		Select("COALESCE(SUM(stock), 0)").
+		Where("product_id = ? AND active = ?", product.ID, true)
+
+	err := tx.Model(&models.Product{}).Where("id = ?", product.ID).Update("stock", total).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Product{}).Select("stock").Where("id = ?", product.ID).Scan(&product.Stock).Error
 }
 
-func (r *ProductRepository) DecrementStock(id uint, quantity int) error {
-	return r.db.Model(&models.Product{}).Where("id = ? AND stock >= ?", id, quantity).Update("stock", gorm.Expr("stock - ?", quantity)).Error
+func (r *ProductRepository) UpdateStock(ctx context.Context, id uint, stock int) error {
+	return r.db.WithContext(ctx).Model(&models.Product{}).Where("id = ?", id).Update("stock", stock).Error
+}
+
+func (r *ProductRepository) IncrementStock(ctx context.Context, id uint, quantity int) error {
+	return r.db.WithContext(ctx).Model(&models.Product{}).Where("id = ?", id).Update("stock", gorm.Expr("stock + ?", quantity)).Error
+}
+
+// DecrementStock fails with ErrInsufficientStock instead of letting the
+// stock go negative. The stock of a product with variants is the sum of
+// theirs, so it fails with ErrProductHasVariants there; use
+// DecrementVariantStock instead.
+func (r *ProductRepository) DecrementStock(ctx context.Context, id uint, quantity int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.Select("id").First(&product, id).Error; err != nil {
+			return err
+		}
+
+		var variants int64
+		err := tx.Model(&models.ProductVariant{}).Where("product_id = ? AND active = ?", id, true).Count(&variants).Error
+		if err != nil {
+			return err
+		}
+		if variants > 0 {
+			return ErrProductHasVariants
+		}
+
+		result := tx.Model(&models.Product{}).Where("id = ? AND stock >= ?", id, quantity).Update("stock", gorm.Expr("stock - ?", quantity))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInsufficientStock
+		}
+		return nil
+	})
 }
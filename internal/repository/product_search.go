@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/Code-Aether/americanas-loja-api/pkg/database"
+	"gorm.io/gorm"
+)
+
+// maxSearchTerms caps how many words of the search input are used.
+const maxSearchTerms = 10
+
+// ProductSearch is the full-text backend behind product search. The index
+// is kept in sync by database triggers, so every write to products is
+// searchable, whatever path it takes.
+type ProductSearch interface {
+	// Match restricts query to products matching every term.
+	Match(query *gorm.DB, terms []string) *gorm.DB
+	// OrderByRank orders query by relevance, best match first. The score is
+	// selected as search_rank next to the product columns.
+	OrderByRank(query *gorm.DB, terms []string) *gorm.DB
+}
+
+// NewProductSearch picks the backend for the database driver. SQLite needs
+// the FTS5 module, which go-sqlite3 only includes when built with
+// -tags sqlite_fts5; without it search falls back to LIKE.
+func NewProductSearch(db *gorm.DB) ProductSearch {
+	switch {
+	case db.Dialector.Name() == "postgres":
+		return postgresSearch{}
+	case database.HasFTS5(db):
+		return sqliteSearch{}
+	default:
+		log.Println("SQLite built without FTS5, product search falls back to LIKE without ranking or accent folding (build with -tags sqlite_fts5)")
+		return likeSearch{}
+	}
+}
+
+// searchTerms splits the input into lowercase words, dropping punctuation
+// and any query syntax of the underlying engine.
+func searchTerms(search string) []string {
+	terms := strings.FieldsFunc(strings.ToLower(search), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(terms) > maxSearchTerms {
+		terms = terms[:maxSearchTerms]
+	}
+	return terms
+}
+
+// likeSearch is the fallback for SQLite builds without FTS5. It matches
+// substrings and ranks products with the first term in the name higher.
+type likeSearch struct{}
+
+func (likeSearch) Match(query *gorm.DB, terms []string) *gorm.DB {
+	for _, term := range terms {
+		pattern := "%" + term + "%"
+		query = query.Where("LOWER(products.name) LIKE ? OR LOWER(products.description) LIKE ? OR LOWER(products.sku) LIKE ?",
+			pattern, pattern, pattern)
+	}
+	return query
+}
+
+func (likeSearch) OrderByRank(query *gorm.DB, terms []string) *gorm.DB {
+	return query.
+		Select("products.*, CASE WHEN LOWER(products.name) LIKE ? THEN 0 ELSE 1 END AS search_rank", "%"+terms[0]+"%").
+		Order("search_rank ASC")
+}
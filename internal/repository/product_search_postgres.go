@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// postgresSearch queries products.search_vector, filled by a trigger with
+// the unaccented name, SKU and description (see migration 005). Terms are
+// matched as prefixes after portuguese stemming, so "eletronico" finds
+// "Eletrônicos".
+type postgresSearch struct{}
+
+func (postgresSearch) Match(query *gorm.DB, terms []string) *gorm.DB {
+	return query.Where("products.search_vector @@ to_tsquery('portuguese', unaccent(?))", tsQuery(terms))
+}
+
+func (postgresSearch) OrderByRank(query *gorm.DB, terms []string) *gorm.DB {
+	return query.
+		Select("products.*, ts_rank(products.search_vector, to_tsquery('portuguese', unaccent(?))) AS search_rank", tsQuery(terms)).
+		Order("search_rank DESC")
+}
+
+func tsQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = term + ":*"
+	}
+	return strings.Join(parts, " & ")
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// sqliteSearch queries the products_fts table created by
+// database.EnsureSearchIndex. It ranks with bm25, weighting name over SKU
+// over description, and the unicode61 tokenizer folds accents, so
+// "eletronico" finds "Eletrônicos".
+type sqliteSearch struct{}
+
+func (sqliteSearch) Match(query *gorm.DB, terms []string) *gorm.DB {
+	return query.Where("products.id IN (SELECT rowid FROM products_fts WHERE products_fts MATCH ?)", ftsQuery(terms))
+}
+
+// bm25 scores are negative, lower is better.
+func (sqliteSearch) OrderByRank(query *gorm.DB, terms []string) *gorm.DB {
+	return query.
+		Select("products.*, (SELECT bm25(products_fts, 10.0, 5.0, 1.0) FROM products_fts WHERE products_fts MATCH ? AND rowid = products.id) AS search_rank", ftsQuery(terms)).
+		Order("search_rank ASC")
+}
+
+// ftsQuery matches every term as a prefix. Terms only hold letters and
+// digits, so quoting them is enough to keep FTS5 syntax out.
+func ftsQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = `"` + term + `"*`
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrReturnQuantityExceeded is returned by Create when an item would
+	// have more units returned than were ordered.
+	ErrReturnQuantityExceeded = errors.New("more units returned than ordered")
+	// ErrReturnStatusChanged is returned by Transition when the return is
+	// no longer in the status the change starts from.
+	ErrReturnStatusChanged = errors.New("return status changed")
+)
+
+type ReturnRepository struct {
+	db *gorm.DB
+}
+
+func NewReturnRepository(db *gorm.DB) *ReturnRepository {
+	return &ReturnRepository{
+		db: db,
+	}
+}
+
+// Create adds the return with its items. ordered holds the quantity of
+// each order item; returns that were not rejected count against it, so an
+// item cannot be returned twice.
+func (r *ReturnRepository) Create(ctx context.Context, ret *models.ReturnRequest, ordered map[uint]int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		returned, err := returnedQuantities(tx, ret.OrderID)
+		if err != nil {
+			return err
+		}
+		for _, item := range ret.Items {
+			if returned[item.OrderItemID]+item.Quantity > ordered[item.OrderItemID] {
+				return ErrReturnQuantityExceeded
+			}
+		}
+		return tx.Create(ret).Error
+	})
+}
+
+// ReturnedQuantities returns how many units of each item of the order are
+// in returns that were not rejected.
+func (r *ReturnRepository) ReturnedQuantities(ctx context.Context, orderID string) (map[uint]int, error) {
+	return returnedQuantities(r.db.WithContext(ctx), orderID)
+}
+
+func returnedQuantities(tx *gorm.DB, orderID string) (map[uint]int, error) {
+	var rows []struct {
+		OrderItemID uint
+		Quantity    int
+	}
+	err := tx.Model(&models.ReturnItem{}).
+		Select("return_items.order_item_id, SUM(return_items.quantity) AS quantity").
+		Joins("JOIN return_requests ON return_requests.id = return_items.return_request_id").
+		Where("return_requests.order_id = ? AND return_requests.status <> ?", orderID, models.ReturnRejected).
+		Group("return_items.order_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	returned := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		returned[row.OrderItemID] = row.Quantity
+	}
+	return returned, nil
+}
+
+func (r *ReturnRepository) GetByID(ctx context.Context, id uint) (*models.ReturnRequest, error) {
+	var ret models.ReturnRequest
+	err := r.preload(ctx).First(&ret, id).Error
+	return &ret, err
+}
+
+// ListByUser returns the returns of the user, newest first.
+func (r *ReturnRepository) ListByUser(ctx context.Context, userID uint) ([]models.ReturnRequest, error) {
+	var returns []models.ReturnRequest
+	err := r.preload(ctx).Where("user_id = ?", userID).Order("id DESC").Find(&returns).Error
+	return returns, err
+}
+
+// List returns the returns in the status, or all of them when it is
+// empty, oldest first so the queue is worked in order.
+func (r *ReturnRepository) List(ctx context.Context, status string) ([]models.ReturnRequest, error) {
+	query := r.preload(ctx).Order("id ASC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var returns []models.ReturnRequest
+	err := query.Find(&returns).Error
+	return returns, err
+}
+
+func (r *ReturnRepository) preload(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Photos", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") })
+}
+
+// Transition moves the return from one status to another with the other
+// changes, failing with ErrReturnStatusChanged when it is not in from
+// anymore, so two admins cannot process the same return twice.
+func (r *ReturnRepository) Transition(ctx context.Context, id uint, from, to string, changes map[string]any) error {
+	updates := map[string]any{"status": to}
+	for column, value := range changes {
+		updates[column] = value
+	}
+
+	result := r.db.WithContext(ctx).Model(&models.ReturnRequest{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrReturnStatusChanged
+	}
+	return nil
+}
+
+func (r *ReturnRepository) CountPhotos(ctx context.Context, returnID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.ReturnPhoto{}).Where("return_request_id = ?", returnID).Count(&count).Error
+	return count, err
+}
+
+func (r *ReturnRepository) AddPhoto(ctx context.Context, photo *models.ReturnPhoto) error {
+	return r.db.WithContext(ctx).Create(photo).Error
+}
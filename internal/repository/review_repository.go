@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReviewExists  = errors.New("user already reviewed the product")
+	ErrReviewChanged = errors.New("review was moderated meanwhile")
+)
+
+type ReviewRepository struct {
+	db *gorm.DB
+}
+
+func NewReviewRepository(db *gorm.DB) *ReviewRepository {
+	return &ReviewRepository{
+		db: db,
+	}
+}
+
+// reviewKeyset lists the newest reviews first, as shown on the product page.
+var reviewKeyset = keyset[models.Review]{
+	column: "reviews.created_at", idColumn: "reviews.id", param: "?", desc: true,
+	key:   func(r *models.Review) string { return formatTimeKey(r.CreatedAt) },
+	parse: parseTimeKey,
+	id:    func(r *models.Review) uint { return r.ID },
+}
+
+// reviewQueueKeyset lists the oldest reviews first, so moderators work
+// through the queue in the order reviews arrived.
+var reviewQueueKeyset = keyset[models.Review]{
+	column: "reviews.created_at", idColumn: "reviews.id", param: "?",
+	key:   reviewKeyset.key,
+	parse: parseTimeKey,
+	id:    reviewKeyset.id,
+}
+
+// Create adds the review, failing with ErrReviewExists when the user has
+// already reviewed the product.
+func (r *ReviewRepository) Create(ctx context.Context, review *models.Review) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		err := tx.Model(&models.Review{}).
+			Where("product_id = ? AND user_id = ?", review.ProductID, review.UserID).
+			Count(&existing).Error
+		if err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrReviewExists
+		}
+
+		return tx.Create(review).Error
+	})
+}
+
+func (r *ReviewRepository) GetByID(ctx context.Context, id uint) (*models.Review, error) {
+	var review models.Review
+	err := r.db.WithContext(ctx).First(&review, id).Error
+	return &review, err
+}
+
+// ListByProduct returns a page of the reviews of the product in the status,
+// newest first.
+func (r *ReviewRepository) ListByProduct(ctx context.Context, productID uint, status string, limit int, cursor *pagination.Cursor) ([]models.Review, *pagination.Cursor, *pagination.Cursor, error) {
+	query := r.db.WithContext(ctx).Where("product_id = ? AND status = ?", productID, status)
+	return r.list(query, reviewKeyset, limit, cursor)
+}
+
+// ListByStatus returns a page of the reviews of every product in the
+// status, oldest first.
+func (r *ReviewRepository) ListByStatus(ctx context.Context, status string, limit int, cursor *pagination.Cursor) ([]models.Review, *pagination.Cursor, *pagination.Cursor, error) {
+	query := r.db.WithContext(ctx).Where("status = ?", status)
+	return r.list(query, reviewQueueKeyset, limit, cursor)
+}
+
+func (r *ReviewRepository) list(query *gorm.DB, keys keyset[models.Review], limit int, cursor *pagination.Cursor) ([]models.Review, *pagination.Cursor, *pagination.Cursor, error) {
+	query = query.Limit(limit + 1)
+	if cursor != nil {
+		var err error
+		if query, err = keys.seek(query, cursor); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	var reviews []models.Review
+	if err := keys.order(query, cursor != nil && cursor.Backward).Find(&reviews).Error; err != nil {
+		return nil, nil, nil, err
+	}
+
+	reviews, next, prev := keys.page(reviews, limit, cursor, true)
+	return reviews, next, prev, nil
+}
+
+// CountByProduct counts the reviews of the product in the status; see
+// countRows for approximate counts.
+func (r *ReviewRepository) CountByProduct(ctx context.Context, productID uint, status string, approximate bool) (int64, bool, error) {
+	query := r.db.WithContext(ctx).Model(&models.Review{}).Where("product_id = ? AND status = ?", productID, status)
+	return countRows(ctx, query, approximate)
+}
+
+// CountByStatus counts the reviews in the status; see countRows for
+// approximate counts.
+func (r *ReviewRepository) CountByStatus(ctx context.Context, status string, approximate bool) (int64, bool, error) {
+	query := r.db.WithContext(ctx).Model(&models.Review{}).Where("status = ?", status)
+	return countRows(ctx, query, approximate)
+}
+
+// Moderate moves the review to the status and updates the rating of its
+// product in the same transaction: approving adds the review to the rating
+// and moving it out of approved takes it back out.
+//
+// The review only changes if it is still in the status it was read in, so
+// two moderators acting at once cannot count it twice; the loser gets
+// ErrReviewChanged.
+func (r *ReviewRepository) Moderate(ctx context.Context, review *models.Review, status, reason string, moderatorID uint, now time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Review{}).
+			Where("id = ? AND status = ?", review.ID, review.Status).
+			Updates(map[string]any{
+				"status":           status,
+				"rejection_reason": reason,
+				"moderated_by":     moderatorID,
+				"moderated_at":     now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrReviewChanged
+		}
+
+		switch {
+		case status == models.ReviewApproved && review.Status != models.ReviewApproved:
+			if err := addRating(tx, review.ProductID, review.Rating, 1); err != nil {
+				return err
+			}
+		case status != models.ReviewApproved && review.Status == models.ReviewApproved:
+			if err := addRating(tx, review.ProductID, review.Rating, -1); err != nil {
+				return err
+			}
+		}
+
+		review.Status = status
+		review.RejectionReason = reason
+		review.ModeratedBy = &moderatorID
+		review.ModeratedAt = &now
+		return nil
+	})
+}
+
+// addRating adds (delta 1) or removes (delta -1) a rating of stars to the
+// aggregate of the product.
+func addRating(tx *gorm.DB, productID uint, stars, delta int) error {
+	if stars < 1 || stars > 5 {
+		return fmt.Errorf("invalid rating %d", stars)
+	}
+
+	bucket := fmt.Sprintf("rating_stars%d", stars)
+	return tx.Model(&models.Product{}).Where("id = ?", productID).UpdateColumns(map[string]any{
+		"rating_count": gorm.Expr("rating_count + ?", delta),
+		"rating_sum":   gorm.Expr("rating_sum + ?", delta*stars),
+		bucket:         gorm.Expr(bucket+" + ?", delta),
+	}).Error
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"gorm.io/gorm"
+)
+
+type StockSubscriptionRepository struct {
+	db *gorm.DB
+}
+
+func NewStockSubscriptionRepository(db *gorm.DB) *StockSubscriptionRepository {
+	return &StockSubscriptionRepository{
+		db: db,
+	}
+}
+
+// Subscriber is a pending subscription with the contact of its user.
+type Subscriber struct {
+	models.StockSubscription
+	Email string
+	Name  string
+}
+
+// Subscribe returns the pending subscription of the user to the product,
+// creating it when there is none.
+func (r *StockSubscriptionRepository) Subscribe(ctx context.Context, userID, productID uint) (*models.StockSubscription, error) {
+	var subscription models.StockSubscription
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ? AND product_id = ? AND notified_at IS NULL", userID, productID).
+			Take(&subscription).Error
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		subscription = models.StockSubscription{UserID: userID, ProductID: productID}
+		return tx.Create(&subscription).Error
+	})
+	return &subscription, err
+}
+
+// Unsubscribe removes the pending subscription of the user to the product,
+// reporting whether there was one.
+func (r *StockSubscriptionRepository) Unsubscribe(ctx context.Context, userID, productID uint) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("user_id = ? AND product_id = ? AND notified_at IS NULL", userID, productID).
+		Delete(&models.StockSubscription{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListPending returns up to limit pending subscriptions to the product with
+// an ID after afterID, in ID order, with the contact of their users.
+func (r *StockSubscriptionRepository) ListPending(ctx context.Context, productID, afterID uint, limit int) ([]Subscriber, error) {
+	var subscribers []Subscriber
+	err := r.db.WithContext(ctx).Model(&models.StockSubscription{}).
+		Select("stock_subscriptions.*, users.email, users.name").
+		Joins("JOIN users ON users.id = stock_subscriptions.user_id AND users.deleted_at IS NULL").
+		Where("stock_subscriptions.product_id = ? AND stock_subscriptions.notified_at IS NULL AND stock_subscriptions.id > ?", productID, afterID).
+		Order("stock_subscriptions.id ASC").
+		Limit(limit).
+		Find(&subscribers).Error
+	return subscribers, err
+}
+
+// MarkNotified claims the subscription for notifying, reporting false when
+// another instance already claimed it.
+func (r *StockSubscriptionRepository) MarkNotified(ctx context.Context, id uint, now time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.StockSubscription{}).
+		Where("id = ? AND notified_at IS NULL", id).
+		Update("notified_at", now)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Unmark puts the subscription back to pending after its notice failed, so
+// the next restock tries again.
+func (r *StockSubscriptionRepository) Unmark(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&models.StockSubscription{}).
+		Where("id = ?", id).
+		Update("notified_at", nil).Error
+}
@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
 	"gorm.io/gorm"
 )
 
@@ -15,105 +18,139 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	}
 }
 
-func (r *UserRepository) Create(user *models.User) error {
-	return r.db.Create(user).Error
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	return &user, err
 }
 
-func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
+func (r *UserRepository) GetByID(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.WithContext(ctx).First(&user, id).Error
 	return &user, err
 }
 
-func (r *UserRepository) GetByID(id uint) (*models.User, error) {
+// GetByDocument finds the user with the document by its index, as the
+// document itself is stored encrypted. Deleted users are ignored, as the
+// unique index only covers live rows.
+func (r *UserRepository) GetByDocument(ctx context.Context, document models.Document) (*models.User, error) {
+	hash, err := document.Index()
+	if err != nil {
+		return nil, err
+	}
+
 	var user models.User
-	err := r.db.First(&user, id).Error
+	err = r.db.WithContext(ctx).Where("document_hash = ?", hash).First(&user).Error
 	return &user, err
 }
 
-func (r *UserRepository) Update(user *models.User) error {
-	return r.db.Save(user).Error
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Save(user).Error
 }
 
-func (r *UserRepository) GetAll() ([]models.User, error) {
+func (r *UserRepository) GetAll(ctx context.Context) ([]models.User, error) {
 	var users []models.User
-	err := r.db.Find(&users).Error
+	err := r.db.WithContext(ctx).Find(&users).Error
 	return users, err
 }
 
-func (r *UserRepository) GetAllWithPagination(page, limit int) ([]models.User, int64, error) {
+// userKeyset lists the newest users first.
+var userKeyset = keyset[models.User]{
+	column: "users.created_at", idColumn: "users.id", param: "?", desc: true,
+	key:   func(u *models.User) string { return formatTimeKey(u.CreatedAt) },
+	parse: parseTimeKey,
+	id:    func(u *models.User) uint { return u.ID },
+}
+
+// List returns a page of users, newest first, starting at cursor or at the
+// first user when it is nil, with the cursors to the neighbouring pages.
+func (r *UserRepository) List(ctx context.Context, limit int, cursor *pagination.Cursor) ([]models.User, *pagination.Cursor, *pagination.Cursor, error) {
 	var users []models.User
-	var total int64
 
-	if err := r.db.Model(&models.User{}).Count(&total).Error; err != nil {
-		return nil, 0, err
+	query := r.bindUserModel(ctx).Limit(limit + 1)
+	if cursor != nil {
+		var err error
+		if query, err = userKeyset.seek(query, cursor); err != nil {
+			return nil, nil, nil, err
+		}
 	}
 
-	offset := (page - 1) * limit
-	err := r.db.Order("created_at DESC").Limit(limit).Offset(offset).Find(&users).Error
+	if err := userKeyset.order(query, cursor != nil && cursor.Backward).Find(&users).Error; err != nil {
+		return nil, nil, nil, err
+	}
+
+	users, next, prev := userKeyset.page(users, limit, cursor, true)
+	return users, next, prev, nil
+}
 
-	return users, total, err
+// Count counts the users; see countRows for approximate counts.
+func (r *UserRepository) Count(ctx context.Context, approximate bool) (int64, bool, error) {
+	return countRows(ctx, r.bindUserModel(ctx), approximate)
 }
 
-func (r *UserRepository) GetActiveUsers() ([]models.User, error) {
+func (r *UserRepository) GetActiveUsers(ctx context.Context) ([]models.User, error) {
 	var users []models.User
-	err := r.db.Where("active = ?", true).Find(&users).Error
+	err := r.db.WithContext(ctx).Where("active = ?", true).Find(&users).Error
 	return users, err
 }
 
-func (r *UserRepository) GetByRole(role string) ([]models.User, error) {
+func (r *UserRepository) GetByRole(ctx context.Context, role string) ([]models.User, error) {
 	var users []models.User
-	err := r.db.Where("role = ?", role).Find(&users).Error
+	err := r.db.WithContext(ctx).Where("role = ?", role).Find(&users).Error
 	return users, err
 }
 
-func (r *UserRepository) Delete(id uint) error {
-	return r.db.Delete(&models.User{}, id).Error
+func (r *UserRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.User{}, id).Error
 }
 
-func (r *UserRepository) Deactivate(id uint) error {
-	return r.getUserById(id).Update("activate", false).Error
+func (r *UserRepository) Deactivate(ctx context.Context, id uint) error {
+	return r.getUserById(ctx, id).Update("activate", false).Error
 }
 
-func (r *UserRepository) Activate(id uint) error {
-	return r.getUserById(id).Update("active = ?", true).Error
+func (r *UserRepository) Activate(ctx context.Context, id uint) error {
+	return r.getUserById(ctx, id).Update("active = ?", true).Error
 }
 
-func (r *UserRepository) UpdatePassword(id uint, hashedPassword string) error {
-	return r.getUserById(id).Update("password", hashedPassword).Error
+func (r *UserRepository) UpdatePassword(ctx context.Context, id uint, hashedPassword string) error {
+	return r.getUserById(ctx, id).Update("password", hashedPassword).Error
 }
 
-func (r *UserRepository) UpdateRole(id uint, role string) error {
-	return r.getUserById(id).Update("role", role).Error
+func (r *UserRepository) UpdateRole(ctx context.Context, id uint, role string) error {
+	return r.getUserById(ctx, id).Update("role", role).Error
 }
 
-func (r *UserRepository) UpdateLastLogin(id uint) error {
-	return r.getUserById(id).Update("updated_at", "NOW()").Error
+func (r *UserRepository) UpdateLastLogin(ctx context.Context, id uint) error {
+	return r.getUserById(ctx, id).Update("updated_at", "NOW()").Error
 }
 
-func (r *UserRepository) CountUsers() (int64, error) {
+func (r *UserRepository) CountUsers(ctx context.Context) (int64, error) {
 	var count int64
-	err := r.bindUserModel().Count(&count).Error
+	err := r.bindUserModel(ctx).Count(&count).Error
 	return count, err
 }
 
-func (r *UserRepository) CountActiveUsers() (int64, error) {
+func (r *UserRepository) CountActiveUsers(ctx context.Context) (int64, error) {
 	var count int64
-	err := r.bindUserModel().Where("active = ?", true).Count(&count).Error
+	err := r.bindUserModel(ctx).Where("active = ?", true).Count(&count).Error
 	return count, err
 }
 
-func (r *UserRepository) SearchUsers(query string) ([]models.User, error) {
+func (r *UserRepository) SearchUsers(ctx context.Context, query string) ([]models.User, error) {
 	var users []models.User
-	err := r.db.Where("name ILIKE ? or email ILIKE ?", "%"+query+"%", "%"+query+"%").Find(&users).Error
+	err := r.db.WithContext(ctx).Where("name ILIKE ? or email ILIKE ?", "%"+query+"%", "%"+query+"%").Find(&users).Error
 	return users, err
 }
 
 // Private functions
-func (r *UserRepository) bindUserModel() *gorm.DB {
-	return r.db.Model(&models.User{})
+func (r *UserRepository) bindUserModel(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&models.User{})
 }
 
-func (r *UserRepository) getUserById(id uint) *gorm.DB {
-	return r.bindUserModel().Where("id = ?", id)
+func (r *UserRepository) getUserById(ctx context.Context, id uint) *gorm.DB {
+	return r.bindUserModel(ctx).Where("id = ?", id)
 }
@@ -0,0 +1,359 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInsufficientBalance = errors.New("wallet balance is not enough")
+	// ErrIdempotencyKeyReused is returned by Apply when the key was used for
+	// a transaction of another type or amount.
+	ErrIdempotencyKeyReused = errors.New("idempotency key was used for another transaction")
+	ErrGiftCardCodeTaken    = errors.New("gift card code already exists")
+	// ErrGiftCardUnavailable is returned by RedeemGiftCard when the card
+	// was redeemed or expired in the meantime.
+	ErrGiftCardUnavailable = errors.New("gift card was redeemed or has expired")
+
+	// errWalletChanged makes a transaction start over when another one
+	// changed the wallet first.
+	errWalletChanged = errors.New("wallet changed during the transaction")
+	// errWalletUnchanged rolls back a transaction that has nothing to
+	// write, like a retry of one already in the ledger.
+	errWalletUnchanged = errors.New("wallet transaction has nothing to apply")
+)
+
+// walletRetries bounds how many times a transaction starts over when the
+// wallet keeps changing under it.
+const walletRetries = 10
+
+type WalletRepository struct {
+	db *gorm.DB
+}
+
+func NewWalletRepository(db *gorm.DB) *WalletRepository {
+	return &WalletRepository{
+		db: db,
+	}
+}
+
+// walletTransactionKeyset lists the newest entries of a ledger first.
+var walletTransactionKeyset = keyset[models.WalletTransaction]{
+	column: "wallet_transactions.created_at", idColumn: "wallet_transactions.id", param: "?", desc: true,
+	key:   func(t *models.WalletTransaction) string { return formatTimeKey(t.CreatedAt) },
+	parse: parseTimeKey,
+	id:    func(t *models.WalletTransaction) uint { return t.ID },
+}
+
+// Get returns the wallet of the user, empty when the user never had one.
+func (r *WalletRepository) Get(ctx context.Context, userID uint) (*models.Wallet, error) {
+	var wallet models.Wallet
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Take(&wallet).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.Wallet{UserID: userID, Balance: money.FromCents(0)}, nil
+	}
+	return &wallet, err
+}
+
+// ListTransactions returns a page of the ledger of the user, newest first.
+func (r *WalletRepository) ListTransactions(ctx context.Context, userID uint, limit int, cursor *pagination.Cursor) ([]models.WalletTransaction, *pagination.Cursor, *pagination.Cursor, error) {
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID).Limit(limit + 1)
+	if cursor != nil {
+		var err error
+		if query, err = walletTransactionKeyset.seek(query, cursor); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	var transactions []models.WalletTransaction
+	if err := walletTransactionKeyset.order(query, cursor != nil && cursor.Backward).Find(&transactions).Error; err != nil {
+		return nil, nil, nil, err
+	}
+
+	transactions, next, prev := walletTransactionKeyset.page(transactions, limit, cursor, true)
+	return transactions, next, prev, nil
+}
+
+// Lots returns what is left of the credits of the user, in the order
+// debits take from them.
+func (r *WalletRepository) Lots(ctx context.Context, userID uint) ([]models.WalletLot, error) {
+	var lots []models.WalletLot
+	err := walletLotOrder(r.db.WithContext(ctx).Where("user_id = ?", userID)).Find(&lots).Error
+	return lots, err
+}
+
+// GetTransactionByKey returns the entry recorded with the idempotency key
+// in the ledger of the user.
+func (r *WalletRepository) GetTransactionByKey(ctx context.Context, userID uint, key string) (*models.WalletTransaction, error) {
+	var txn models.WalletTransaction
+	err := r.db.WithContext(ctx).Where("user_id = ? AND idempotency_key = ?", userID, key).Take(&txn).Error
+	if err != nil {
+		return nil, err
+	}
+	return &txn, nil
+}
+
+// SumByReference adds up the entries of the type with the reference in the
+// ledger of the user, like what an order was paid with or refunded to the
+// wallet.
+func (r *WalletRepository) SumByReference(ctx context.Context, userID uint, txnType, reference string) (money.Money, error) {
+	var sum money.Money
+	err := r.db.WithContext(ctx).Model(&models.WalletTransaction{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("user_id = ? AND type = ? AND reference = ?", userID, txnType, reference).
+		Row().Scan(&sum)
+	return sum, err
+}
+
+// DueLots returns the lots that expired by now and still have credit.
+func (r *WalletRepository) DueLots(ctx context.Context, now time.Time) ([]models.WalletLot, error) {
+	var lots []models.WalletLot
+	err := r.db.WithContext(ctx).
+		Where("expires_at <= ?", now).
+		Order("expires_at ASC").
+		Find(&lots).Error
+	return lots, err
+}
+
+// Apply appends the transaction to the ledger of its wallet and moves the
+// balance and the lots, all in one database transaction. A key already in
+// the ledger returns the entry recorded with it and changes nothing, so a
+// retried transaction applies once.
+//
+// Debits fail with ErrInsufficientBalance when the balance is short. An
+// expire entry takes whatever is left of its CreditID; when nothing is,
+// no entry is written and txn.ID stays zero.
+func (r *WalletRepository) Apply(ctx context.Context, txn *models.WalletTransaction, now time.Time) error {
+	input := *txn
+	return r.retry(ctx, func(tx *gorm.DB) error {
+		*txn = input
+		return applyWalletTransaction(tx, txn, now)
+	})
+}
+
+// RedeemGiftCard moves the whole balance of the card into the wallet of
+// the user, as a credit that expires with the card.
+func (r *WalletRepository) RedeemGiftCard(ctx context.Context, card *models.GiftCard, userID uint, now time.Time) (*models.WalletTransaction, error) {
+	var txn models.WalletTransaction
+	err := r.retry(ctx, func(tx *gorm.DB) error {
+		result := tx.Model(&models.GiftCard{}).
+			Where("id = ? AND redeemed_by IS NULL AND expires_at > ?", card.ID, now).
+			Updates(map[string]any{"balance": money.FromCents(0), "redeemed_by": userID, "redeemed_at": now, "updated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrGiftCardUnavailable
+		}
+
+		expiresAt := card.ExpiresAt
+		txn = models.WalletTransaction{
+			UserID:         userID,
+			Type:           models.WalletCredit,
+			Amount:         card.Balance,
+			Reason:         "Resgate de vale-presente",
+			Reference:      giftCardReference(card.ID),
+			IdempotencyKey: giftCardReference(card.ID),
+			ExpiresAt:      &expiresAt,
+		}
+		return applyWalletTransaction(tx, &txn, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &txn, nil
+}
+
+func giftCardReference(id uint) string {
+	return fmt.Sprintf("gift_card:%d", id)
+}
+
+// retry runs fn in a database transaction, starting over while another
+// transaction changes the wallet first. errWalletUnchanged rolls back and
+// succeeds.
+func (r *WalletRepository) retry(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	for attempt := 1; ; attempt++ {
+		err := r.db.WithContext(ctx).Transaction(fn)
+		switch {
+		case errors.Is(err, errWalletUnchanged):
+			return nil
+		case errors.Is(err, errWalletChanged) && attempt < walletRetries:
+			continue
+		default:
+			return err
+		}
+	}
+}
+
+func applyWalletTransaction(tx *gorm.DB, txn *models.WalletTransaction, now time.Time) error {
+	wallet, err := lockWallet(tx, txn.UserID, now)
+	if err != nil {
+		return err
+	}
+
+	var existing models.WalletTransaction
+	err = tx.Where("user_id = ? AND idempotency_key = ?", txn.UserID, txn.IdempotencyKey).Take(&existing).Error
+	if err == nil {
+		// Expire entries are keyed by their credit, whose remaining
+		// amount the caller does not know.
+		if existing.Type != txn.Type || (txn.Type != models.WalletExpire && existing.Amount.Cmp(txn.Amount) != 0) {
+			return ErrIdempotencyKeyReused
+		}
+		*txn = existing
+		return errWalletUnchanged
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	balance := wallet.Balance
+	switch txn.Type {
+	case models.WalletCredit:
+		balance = balance.Add(txn.Amount)
+	case models.WalletDebit:
+		if balance.Cmp(txn.Amount) < 0 {
+			return ErrInsufficientBalance
+		}
+		balance = balance.Sub(txn.Amount)
+	case models.WalletExpire:
+		var lot models.WalletLot
+		err := tx.Where("transaction_id = ? AND user_id = ?", txn.CreditID, txn.UserID).Take(&lot).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errWalletUnchanged
+		}
+		if err != nil {
+			return err
+		}
+		txn.Amount = lot.Remaining
+		balance = balance.Sub(txn.Amount)
+	}
+
+	err = tx.Model(&models.Wallet{}).Where("user_id = ?", wallet.UserID).
+		Updates(map[string]any{"balance": balance, "updated_at": now}).Error
+	if err != nil {
+		return err
+	}
+
+	txn.BalanceAfter = balance
+	txn.CreatedAt = now
+	if err := tx.Create(txn).Error; err != nil {
+		return err
+	}
+
+	switch txn.Type {
+	case models.WalletCredit:
+		return tx.Create(&models.WalletLot{
+			TransactionID: txn.ID,
+			UserID:        txn.UserID,
+			Remaining:     txn.Amount,
+			ExpiresAt:     txn.ExpiresAt,
+		}).Error
+	case models.WalletDebit:
+		return spendWalletLots(tx, txn.UserID, txn.Amount)
+	default:
+		return tx.Delete(&models.WalletLot{}, *txn.CreditID).Error
+	}
+}
+
+// lockWallet reads the wallet of the user, creating it empty, and bumps its
+// version. The bump fails with errWalletChanged when another transaction
+// bumped it since the read; on Postgres it also holds the row until the
+// transaction ends.
+func lockWallet(tx *gorm.DB, userID uint, now time.Time) (*models.Wallet, error) {
+	wallet := models.Wallet{UserID: userID, Balance: money.FromCents(0), UpdatedAt: now}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&wallet).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("user_id = ?", userID).Take(&wallet).Error; err != nil {
+		return nil, err
+	}
+
+	result := tx.Model(&models.Wallet{}).
+		Where("user_id = ? AND version = ?", userID, wallet.Version).
+		UpdateColumn("version", wallet.Version+1)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, errWalletChanged
+	}
+	return &wallet, nil
+}
+
+// spendWalletLots takes amount from the lots of the user in the order of
+// walletLotOrder, removing the lots it empties.
+func spendWalletLots(tx *gorm.DB, userID uint, amount money.Money) error {
+	var lots []models.WalletLot
+	if err := walletLotOrder(tx.Where("user_id = ?", userID)).Find(&lots).Error; err != nil {
+		return err
+	}
+
+	for _, lot := range lots {
+		if !amount.IsPositive() {
+			break
+		}
+		if lot.Remaining.Cmp(amount) <= 0 {
+			amount = amount.Sub(lot.Remaining)
+			if err := tx.Delete(&models.WalletLot{}, lot.TransactionID).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		remaining := lot.Remaining.Sub(amount)
+		amount = money.FromCents(0)
+		err := tx.Model(&models.WalletLot{}).Where("transaction_id = ?", lot.TransactionID).
+			Update("remaining", remaining).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walletLotOrder sorts lots by the time they expire, the soonest first and
+// those that never do last, then by age.
+func walletLotOrder(query *gorm.DB) *gorm.DB {
+	return query.Order("expires_at IS NULL").Order("expires_at ASC").Order("transaction_id ASC")
+}
+
+// CreateGiftCard adds the card, failing with ErrGiftCardCodeTaken when the
+// code is in use.
+func (r *WalletRepository) CreateGiftCard(ctx context.Context, card *models.GiftCard) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var taken int64
+		if err := tx.Model(&models.GiftCard{}).Where("code = ?", card.Code).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrGiftCardCodeTaken
+		}
+		return tx.Create(card).Error
+	})
+}
+
+// ListGiftCards returns every gift card, newest first.
+func (r *WalletRepository) ListGiftCards(ctx context.Context) ([]models.GiftCard, error) {
+	var cards []models.GiftCard
+	err := r.db.WithContext(ctx).Order("id DESC").Find(&cards).Error
+	return cards, err
+}
+
+func (r *WalletRepository) GetGiftCard(ctx context.Context, id uint) (*models.GiftCard, error) {
+	var card models.GiftCard
+	err := r.db.WithContext(ctx).First(&card, id).Error
+	return &card, err
+}
+
+func (r *WalletRepository) GetGiftCardByCode(ctx context.Context, code string) (*models.GiftCard, error) {
+	var card models.GiftCard
+	err := r.db.WithContext(ctx).Where("code = ?", code).First(&card).Error
+	return &card, err
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrWishlistNameTaken = errors.New("user already has a wishlist with this name")
+
+type WishlistRepository struct {
+	db *gorm.DB
+}
+
+func NewWishlistRepository(db *gorm.DB) *WishlistRepository {
+	return &WishlistRepository{
+		db: db,
+	}
+}
+
+// withItems loads the items of the lists, oldest first, with their products.
+func withItems(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC").Order("id ASC")
+		}).
+		Preload("Items.Product")
+}
+
+// ListByUser returns the wishlists of the user in the order they were made.
+func (r *WishlistRepository) ListByUser(ctx context.Context, userID uint) ([]models.Wishlist, error) {
+	var wishlists []models.Wishlist
+	err := withItems(r.db.WithContext(ctx)).
+		Where("user_id = ?", userID).
+		Order("id ASC").
+		Find(&wishlists).Error
+	return wishlists, err
+}
+
+func (r *WishlistRepository) GetByID(ctx context.Context, id uint) (*models.Wishlist, error) {
+	var wishlist models.Wishlist
+	err := withItems(r.db.WithContext(ctx)).First(&wishlist, id).Error
+	return &wishlist, err
+}
+
+func (r *WishlistRepository) GetByShareToken(ctx context.Context, token string) (*models.Wishlist, error) {
+	var wishlist models.Wishlist
+	err := withItems(r.db.WithContext(ctx)).Where("share_token = ?", token).First(&wishlist).Error
+	return &wishlist, err
+}
+
+// Create adds the wishlist, failing with ErrWishlistNameTaken when the user
+// already has one with the name.
+func (r *WishlistRepository) Create(ctx context.Context, wishlist *models.Wishlist) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkWishlistName(tx, wishlist); err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Create(wishlist).Error
+	})
+}
+
+// Update saves the name and share token of the wishlist.
+func (r *WishlistRepository) Update(ctx context.Context, wishlist *models.Wishlist) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkWishlistName(tx, wishlist); err != nil {
+			return err
+		}
+		return tx.Model(wishlist).Select("name", "share_token").Updates(wishlist).Error
+	})
+}
+
+func checkWishlistName(tx *gorm.DB, wishlist *models.Wishlist) error {
+	var taken int64
+	err := tx.Model(&models.Wishlist{}).
+		Where("user_id = ? AND name = ? AND id <> ?", wishlist.UserID, wishlist.Name, wishlist.ID).
+		Count(&taken).Error
+	if err != nil {
+		return err
+	}
+	if taken > 0 {
+		return ErrWishlistNameTaken
+	}
+	return nil
+}
+
+func (r *WishlistRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("wishlist_id = ?", id).Delete(&models.WishlistItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Wishlist{}, id).Error
+	})
+}
+
+// AddItem adds the product to the wishlist; adding it again keeps the
+// original item.
+func (r *WishlistRepository) AddItem(ctx context.Context, wishlistID, productID uint) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.WishlistItem{WishlistID: wishlistID, ProductID: productID}).Error
+}
+
+// RemoveItem removes the product from the wishlist, reporting whether it
+// was there.
+func (r *WishlistRepository) RemoveItem(ctx context.Context, wishlistID, productID uint) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("wishlist_id = ? AND product_id = ?", wishlistID, productID).
+		Delete(&models.WishlistItem{})
+	return result.RowsAffected > 0, result.Error
+}
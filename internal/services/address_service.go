@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/pkg/br"
+	"github.com/Code-Aether/americanas-loja-api/pkg/cep"
+	"github.com/Code-Aether/americanas-loja-api/pkg/telemetry"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAddressNotFound      = errors.New("address not found")
+	ErrCEPNotFound          = errors.New("CEP not found")
+	ErrAddressStateMismatch = errors.New("CEP belongs to another state")
+)
+
+// AddressService manages the address books of users. Addresses are checked
+// against the CEP resolver, which also serves address lookups by CEP.
+type AddressService struct {
+	addressRepo *repository.AddressRepository
+	resolver    cep.Resolver
+}
+
+func NewAddressService(addressRepo *repository.AddressRepository, resolver cep.Resolver) *AddressService {
+	return &AddressService{
+		addressRepo: addressRepo,
+		resolver:    resolver,
+	}
+}
+
+func (s *AddressService) List(ctx context.Context, userID uint) ([]models.Address, error) {
+	ctx, span := tracer.Start(ctx, "AddressService.List")
+	defer span.End()
+
+	addresses, err := s.addressRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return addresses, nil
+}
+
+// Get returns the address when it belongs to the user; the addresses of
+// other users are reported as not found.
+func (s *AddressService) Get(ctx context.Context, userID, id uint) (*models.Address, error) {
+	ctx, span := tracer.Start(ctx, "AddressService.Get")
+	defer span.End()
+
+	address, err := s.get(ctx, userID, id)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return address, nil
+}
+
+func (s *AddressService) get(ctx context.Context, userID, id uint) (*models.Address, error) {
+	address, err := s.addressRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && address.UserID != userID) {
+		err = ErrAddressNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// Create adds the address to the address book of the user; the first one
+// is the default.
+func (s *AddressService) Create(ctx context.Context, userID uint, address *models.Address) error {
+	ctx, span := tracer.Start(ctx, "AddressService.Create")
+	defer span.End()
+
+	address.ID, address.UserID = 0, userID
+	if err := s.prepare(ctx, address); err != nil {
+		return telemetry.RecordError(span, err)
+	}
+	return telemetry.RecordError(span, s.addressRepo.Create(ctx, address))
+}
+
+// Update replaces the address. The default address stays the default until
+// another one is made the default.
+func (s *AddressService) Update(ctx context.Context, userID, id uint, address *models.Address) error {
+	ctx, span := tracer.Start(ctx, "AddressService.Update")
+	defer span.End()
+
+	current, err := s.get(ctx, userID, id)
+	if err != nil {
+		return telemetry.RecordError(span, err)
+	}
+
+	address.ID, address.UserID, address.CreatedAt = current.ID, current.UserID, current.CreatedAt
+	address.IsDefault = address.IsDefault || current.IsDefault
+	if err := s.prepare(ctx, address); err != nil {
+		return telemetry.RecordError(span, err)
+	}
+	return telemetry.RecordError(span, s.addressRepo.Update(ctx, address))
+}
+
+// Delete removes the address; if it was the default, the most recently
+// added of the others takes its place.
+func (s *AddressService) Delete(ctx context.Context, userID, id uint) error {
+	ctx, span := tracer.Start(ctx, "AddressService.Delete")
+	defer span.End()
+
+	address, err := s.get(ctx, userID, id)
+	if err != nil {
+		return telemetry.RecordError(span, err)
+	}
+	return telemetry.RecordError(span, s.addressRepo.Delete(ctx, address))
+}
+
+// LookupCEP returns what the resolver knows of the address of the CEP.
+func (s *AddressService) LookupCEP(ctx context.Context, code string) (*cep.Address, error) {
+	ctx, span := tracer.Start(ctx, "AddressService.LookupCEP")
+	defer span.End()
+
+	address, err := s.resolver.Lookup(ctx, code)
+	if errors.Is(err, cep.ErrNotFound) {
+		err = fmt.Errorf("%w: %s", ErrCEPNotFound, code)
+	}
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	address.CEP, _ = br.FormatCEP(address.CEP)
+	return address, nil
+}
+
+// prepare normalizes the address and checks its CEP against the resolver:
+// the CEP must exist and be in the state of the address. When the resolver
+// fails the address is taken as it is, rather than blocking the customer.
+func (s *AddressService) prepare(ctx context.Context, address *models.Address) error {
+	formatted, err := br.FormatCEP(address.CEP)
+	if err != nil {
+		return err
+	}
+	address.CEP = formatted
+	address.State = strings.ToUpper(strings.TrimSpace(address.State))
+	if !br.ValidUF(address.State) {
+		return fmt.Errorf("%w: %q", br.ErrInvalidUF, address.State)
+	}
+
+	for _, field := range []*string{&address.Label, &address.Recipient, &address.Street, &address.Number, &address.Complement, &address.Neighborhood, &address.City} {
+		*field = strings.TrimSpace(*field)
+	}
+
+	found, err := s.resolver.Lookup(ctx, address.CEP)
+	switch {
+	case errors.Is(err, cep.ErrNotFound):
+		return fmt.Errorf("%w: %s", ErrCEPNotFound, address.CEP)
+	case err != nil:
+		log.Printf("failed to look up CEP %s, keeping the address as given: %v", address.CEP, err)
+	case found.State != address.State:
+		return fmt.Errorf("%w: %s is in %s", ErrAddressStateMismatch, address.CEP, found.State)
+	}
+	return nil
+}
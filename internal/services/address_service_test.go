@@ -0,0 +1,142 @@
+// internal/services/address_service_test.go
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
+	"github.com/Code-Aether/americanas-loja-api/pkg/br"
+	"github.com/Code-Aether/americanas-loja-api/pkg/cep"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingResolver simula um serviço de CEP fora do ar
+type failingResolver struct{}
+
+func (failingResolver) Lookup(ctx context.Context, code string) (*cep.Address, error) {
+	return nil, errors.New("serviço indisponível")
+}
+
+func paulista(label string) *models.Address {
+	return &models.Address{
+		Label: label,
+		PostalAddress: models.PostalAddress{
+			Recipient: "Maria Silva", CEP: "01310100", Street: "Avenida Paulista", Number: "1578",
+			Neighborhood: "Bela Vista", City: "São Paulo", State: "sp",
+		},
+	}
+}
+
+func TestAddressService(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	addressRepo := repository.NewAddressRepository(db)
+	addressService := NewAddressService(addressRepo, cep.DefaultTableResolver())
+	user := testutils.CreateTestUser(t, db)
+	other := testutils.CreateTestAdmin(t, db)
+	ctx := context.Background()
+
+	defaultOf := func(t *testing.T) uint {
+		addresses, err := addressService.List(ctx, user.ID)
+		require.NoError(t, err)
+		var defaults []uint
+		for _, address := range addresses {
+			if address.IsDefault {
+				defaults = append(defaults, address.ID)
+			}
+		}
+		require.Len(t, defaults, 1, "Deve haver exatamente um endereço padrão")
+		assert.Equal(t, defaults[0], addresses[0].ID, "Endereço padrão vem primeiro")
+		return defaults[0]
+	}
+
+	home := paulista("Casa")
+	work := paulista("Trabalho")
+	parents := paulista("Pais")
+
+	t.Run("✅ Primeiro endereço é o padrão e o CEP é formatado", func(t *testing.T) {
+		require.NoError(t, addressService.Create(ctx, user.ID, home))
+		assert.True(t, home.IsDefault)
+		assert.Equal(t, "01310-100", home.CEP)
+		assert.Equal(t, "SP", home.State)
+
+		require.NoError(t, addressService.Create(ctx, user.ID, work))
+		assert.False(t, work.IsDefault)
+		assert.Equal(t, home.ID, defaultOf(t))
+	})
+
+	t.Run("✅ Novo padrão substitui o anterior", func(t *testing.T) {
+		parents.IsDefault = true
+		require.NoError(t, addressService.Create(ctx, user.ID, parents))
+		assert.Equal(t, parents.ID, defaultOf(t))
+
+		update := paulista("Trabalho novo")
+		update.IsDefault = true
+		require.NoError(t, addressService.Update(ctx, user.ID, work.ID, update))
+		assert.Equal(t, work.ID, defaultOf(t))
+
+		// Desmarcar o padrão não o deixa sem padrão
+		update = paulista("Trabalho")
+		require.NoError(t, addressService.Update(ctx, user.ID, work.ID, update))
+		assert.True(t, update.IsDefault)
+		assert.Equal(t, work.ID, defaultOf(t))
+	})
+
+	t.Run("✅ Remover o padrão promove o mais recente", func(t *testing.T) {
+		require.NoError(t, addressService.Delete(ctx, user.ID, work.ID))
+		assert.Equal(t, parents.ID, defaultOf(t))
+
+		require.NoError(t, addressService.Delete(ctx, user.ID, home.ID))
+		assert.Equal(t, parents.ID, defaultOf(t))
+	})
+
+	t.Run("✅ Snapshot não acompanha edições do endereço", func(t *testing.T) {
+		snapshot := parents.Snapshot()
+
+		update := paulista("Pais")
+		update.Number = "900"
+		require.NoError(t, addressService.Update(ctx, user.ID, parents.ID, update))
+		assert.Equal(t, "1578", snapshot.Number)
+	})
+
+	t.Run("❌ CEP de outra UF, inexistente ou UF inválida", func(t *testing.T) {
+		address := paulista("Rio")
+		address.State = "RJ"
+		assert.ErrorIs(t, addressService.Create(ctx, user.ID, address), ErrAddressStateMismatch)
+
+		address = paulista("Nada")
+		address.CEP = "00000-000"
+		assert.ErrorIs(t, addressService.Create(ctx, user.ID, address), ErrCEPNotFound)
+
+		address = paulista("UF")
+		address.State = "XX"
+		assert.ErrorIs(t, addressService.Create(ctx, user.ID, address), br.ErrInvalidUF)
+	})
+
+	t.Run("✅ Serviço de CEP fora do ar não bloqueia o cadastro", func(t *testing.T) {
+		offline := NewAddressService(addressRepo, failingResolver{})
+		address := paulista("Offline")
+		address.State = "RJ"
+		assert.NoError(t, offline.Create(ctx, other.ID, address))
+	})
+
+	t.Run("❌ Endereço de outro usuário", func(t *testing.T) {
+		_, err := addressService.Get(ctx, other.ID, parents.ID)
+		assert.ErrorIs(t, err, ErrAddressNotFound)
+		assert.ErrorIs(t, addressService.Delete(ctx, other.ID, parents.ID), ErrAddressNotFound)
+	})
+
+	t.Run("✅ Consultar CEP", func(t *testing.T) {
+		address, err := addressService.LookupCEP(ctx, "01310100")
+		require.NoError(t, err)
+		assert.Equal(t, "01310-100", address.CEP)
+		assert.Equal(t, "Avenida Paulista", address.Street)
+
+		_, err = addressService.LookupCEP(ctx, "00000000")
+		assert.ErrorIs(t, err, ErrCEPNotFound)
+	})
+}
@@ -1,7 +1,9 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -11,8 +13,12 @@ import (
 	"github.com/Code-Aether/americanas-loja-api/internal/models"
 	"github.com/Code-Aether/americanas-loja-api/internal/repository"
 	"github.com/Code-Aether/americanas-loja-api/internal/types"
+	"github.com/Code-Aether/americanas-loja-api/pkg/br"
+	"github.com/Code-Aether/americanas-loja-api/pkg/telemetry"
 )
 
+var ErrDocumentAlreadyExists = errors.New("document already exists")
+
 type AuthService struct {
 	userRepo  *repository.UserRepository
 	jwtSecret string
@@ -32,32 +38,38 @@ func NewAuthService(userRepo *repository.UserRepository, jwtSecret string) *Auth
 	}
 }
 
-func (s *AuthService) Login(req types.LoginRequest) (*string, *models.User, error) {
-	user, err := s.userRepo.GetByEmail(req.Email)
+func (s *AuthService) Login(ctx context.Context, req types.LoginRequest) (*string, *models.User, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Login")
+	defer span.End()
+
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil, errors.New("invalid credentials")
+			return nil, nil, telemetry.RecordError(span, errors.New("invalid credentials"))
 		}
-		return nil, nil, errors.New("internal error")
+		return nil, nil, telemetry.RecordError(span, errors.New("internal error"))
 	}
 
 	if !user.Active {
-		return nil, nil, errors.New("user is inactive")
+		return nil, nil, telemetry.RecordError(span, errors.New("user is inactive"))
 	}
 
 	if !s.checkPassword(req.Password, user.Password) {
-		return nil, nil, errors.New("invalid credentials")
+		return nil, nil, telemetry.RecordError(span, errors.New("invalid credentials"))
 	}
 
 	token, _, err := s.GenerateJWT(user)
 	if err != nil {
-		return nil, nil, errors.New("error generating access token")
+		return nil, nil, telemetry.RecordError(span, errors.New("error generating access token"))
 	}
 
 	return &token, user, nil
 }
 
-func (s *AuthService) Register(user *models.User) (*string, error) {
+func (s *AuthService) Register(ctx context.Context, user *models.User) (*string, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Register")
+	defer span.End()
+
 	if user.Email == "" {
 		return nil, errors.New("email is required")
 	}
@@ -66,11 +78,15 @@ func (s *AuthService) Register(user *models.User) (*string, error) {
 		return nil, errors.New("password must be at least 6 characters long")
 	}
 
-	existingUser, err := s.userRepo.GetByEmail(user.Email)
+	existingUser, err := s.userRepo.GetByEmail(ctx, user.Email)
 	if err == nil && existingUser != nil {
 		return nil, errors.New("user already exists")
 	}
 
+	if err := s.checkDocument(ctx, user); err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
 	hashedPassword, err := s.hashPassword(user.Password)
 	if err != nil {
 		return nil, errors.New("error processing the user password")
@@ -78,8 +94,8 @@ func (s *AuthService) Register(user *models.User) (*string, error) {
 
 	user.Password = hashedPassword
 
-	if err := s.userRepo.Create(user); err != nil {
-		return nil, errors.New("error create a new user")
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, telemetry.RecordError(span, errors.New("error create a new user"))
 	}
 
 	token, _, err := s.GenerateJWT(user)
@@ -90,6 +106,30 @@ func (s *AuthService) Register(user *models.User) (*string, error) {
 	return &token, nil
 }
 
+// checkDocument normalizes the CPF or CNPJ of a new user, if any, and
+// checks that no other user holds it.
+func (s *AuthService) checkDocument(ctx context.Context, user *models.User) error {
+	if user.DocumentType == "" && user.Document == "" {
+		return nil
+	}
+
+	number, err := br.NormalizeDocument(user.DocumentType, string(user.Document))
+	if err != nil {
+		return err
+	}
+	user.DocumentType = strings.ToLower(user.DocumentType)
+	user.Document = models.Document(number)
+
+	_, err = s.userRepo.GetByDocument(ctx, user.Document)
+	if err == nil {
+		return ErrDocumentAlreadyExists
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
 func (s *AuthService) GenerateJWT(user *models.User) (string, *models.User, error) {
 	timeNow := time.Now()
 	claims := JWTClaims{
@@ -130,56 +170,65 @@ func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
 	return nil, errors.New("INVALID_TOKEN")
 }
 
-func (s *AuthService) GetUserByToken(tokenString string) (*models.User, error) {
+func (s *AuthService) GetUserByToken(ctx context.Context, tokenString string) (*models.User, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.GetUserByToken")
+	defer span.End()
+
 	claims, err := s.ValidateToken(tokenString)
 	if err != nil {
-		return nil, err
+		return nil, telemetry.RecordError(span, err)
 	}
 
-	user, err := s.userRepo.GetByID(claims.UserID)
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
 	if err != nil {
-		return nil, errors.New("USER_NOT_FOUND")
+		return nil, telemetry.RecordError(span, errors.New("USER_NOT_FOUND"))
 	}
 
 	if !user.Active {
-		return nil, errors.New("INACTIVE_USER")
+		return nil, telemetry.RecordError(span, errors.New("INACTIVE_USER"))
 	}
 
 	user.Password = ""
 	return user, nil
 }
 
-func (s *AuthService) RefreshToken(tokenString string) (string, *models.User, error) {
+func (s *AuthService) RefreshToken(ctx context.Context, tokenString string) (string, *models.User, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.RefreshToken")
+	defer span.End()
+
 	claims, err := s.ValidateToken(tokenString)
 	if err != nil {
-		return "", nil, err
+		return "", nil, telemetry.RecordError(span, err)
 	}
 
-	user, err := s.userRepo.GetByID(claims.UserID)
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
 	if err != nil {
-		return "", nil, errors.New("USER_NOT_FOUND")
+		return "", nil, telemetry.RecordError(span, errors.New("USER_NOT_FOUND"))
 	}
 
 	return s.GenerateJWT(user)
 }
 
-func (s *AuthService) ChangePassword(userID uint, oldPassword, newPassword string) error {
-	user, err := s.userRepo.GetByID(userID)
+func (s *AuthService) ChangePassword(ctx context.Context, userID uint, oldPassword, newPassword string) error {
+	ctx, span := tracer.Start(ctx, "AuthService.ChangePassword")
+	defer span.End()
+
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
-		return errors.New("USER_NOT_FOUND")
+		return telemetry.RecordError(span, errors.New("USER_NOT_FOUND"))
 	}
 
 	if !s.checkPassword(oldPassword, user.Password) {
-		return errors.New("INCORRECT_PASSWORD")
+		return telemetry.RecordError(span, errors.New("INCORRECT_PASSWORD"))
 	}
 
 	hashedPassword, err := s.hashPassword(newPassword)
 	if err != nil {
-		return errors.New("ERROR_WHEN_PROCESSING_CHANGE_PASS")
+		return telemetry.RecordError(span, errors.New("ERROR_WHEN_PROCESSING_CHANGE_PASS"))
 	}
 
 	user.Password = hashedPassword
-	return s.userRepo.Update(user)
+	return telemetry.RecordError(span, s.userRepo.Update(ctx, user))
 }
 
 func (s *AuthService) hashPassword(password string) (string, error) {
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/Code-Aether/americanas-loja-api/internal/repository"
 	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
 	"github.com/Code-Aether/americanas-loja-api/internal/types"
+	"github.com/Code-Aether/americanas-loja-api/pkg/br"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
@@ -30,7 +32,7 @@ func TestAuthService_Register(t *testing.T) {
 			Active:   true,
 		}
 
-		token, err := authService.Register(user)
+		token, err := authService.Register(context.Background(), user)
 
 		// Assertions
 		assert.NoError(t, err, "Registro não deve retornar erro")
@@ -63,7 +65,7 @@ func TestAuthService_Register(t *testing.T) {
 			Active:   true,
 		}
 
-		token, err := authService.Register(user)
+		token, err := authService.Register(context.Background(), user)
 
 		// Assertions
 		assert.Error(t, err, "Deve retornar erro para email duplicado")
@@ -71,6 +73,67 @@ func TestAuthService_Register(t *testing.T) {
 		assert.Contains(t, err.Error(), "user already exists", "Erro deve mencionar email duplicado")
 	})
 
+	t.Run("✅ Registro com CPF normalizado e criptografado", func(t *testing.T) {
+		user := &models.User{
+			Name:         "Maria Silva",
+			Email:        "maria@test.com",
+			Password:     "password123",
+			DocumentType: "CPF",
+			Document:     "529.982.247-25",
+		}
+
+		_, err := authService.Register(context.Background(), user)
+		require.NoError(t, err)
+		assert.Equal(t, "cpf", user.DocumentType)
+		assert.Equal(t, models.Document("52998224725"), user.Document)
+
+		// No banco o documento fica cifrado, e volta aberto na leitura
+		var raw string
+		require.NoError(t, db.Raw("SELECT document FROM users WHERE id = ?", user.ID).Scan(&raw).Error)
+		assert.NotContains(t, raw, "52998224725")
+
+		saved, err := userRepo.GetByDocument(context.Background(), "52998224725")
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, saved.ID)
+		assert.Equal(t, models.Document("52998224725"), saved.Document)
+	})
+
+	t.Run("❌ Registro com documento duplicado ou inválido", func(t *testing.T) {
+		user := &models.User{
+			Name:         "Outra Maria",
+			Email:        "outra@test.com",
+			Password:     "password123",
+			DocumentType: "cpf",
+			Document:     "52998224725",
+		}
+		_, err := authService.Register(context.Background(), user)
+		assert.ErrorIs(t, err, ErrDocumentAlreadyExists)
+
+		user.DocumentType = "cnpj"
+		_, err = authService.Register(context.Background(), user)
+		assert.ErrorIs(t, err, br.ErrInvalidCNPJ)
+	})
+
+	t.Run("✅ Documento de usuário excluído pode ser usado de novo", func(t *testing.T) {
+		saved, err := userRepo.GetByDocument(context.Background(), "52998224725")
+		require.NoError(t, err)
+		require.NoError(t, db.Delete(saved).Error)
+
+		user := &models.User{
+			Name:         "Maria Souza",
+			Email:        "maria.souza@test.com",
+			Password:     "password123",
+			DocumentType: "cpf",
+			Document:     "52998224725",
+		}
+		_, err = authService.Register(context.Background(), user)
+		require.NoError(t, err)
+
+		found, err := userRepo.GetByDocument(context.Background(), "52998224725")
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, found.ID)
+	})
+
 	t.Run("❌ Registro com dados inválidos", func(t *testing.T) {
 		tests := []struct {
 			name    string
@@ -102,7 +165,7 @@ func TestAuthService_Register(t *testing.T) {
 
 		for _, tt := range tests {
 			t.Run(tt.name, func(t *testing.T) {
-				token, err := authService.Register(tt.user)
+				token, err := authService.Register(context.Background(), tt.user)
 
 				if tt.wantErr {
 					assert.Error(t, err, "Deve retornar erro")
@@ -129,7 +192,7 @@ func TestAuthService_Login(t *testing.T) {
 	user := testutils.CreateTestUser(t, db)
 
 	t.Run("✅ Login com sucesso", func(t *testing.T) {
-		token, returnedUser, err := authService.Login(types.LoginRequest{
+		token, returnedUser, err := authService.Login(context.Background(), types.LoginRequest{
 			Email:    user.Email,
 			Password: "password123",
 		})
@@ -141,7 +204,7 @@ func TestAuthService_Login(t *testing.T) {
 	})
 
 	t.Run("❌ Login com email inexistente", func(t *testing.T) {
-		token, returnedUser, err := authService.Login(types.LoginRequest{
+		token, returnedUser, err := authService.Login(context.Background(), types.LoginRequest{
 			Email:    "nonexistent@test.com",
 			Password: "password123",
 		})
@@ -153,7 +216,7 @@ func TestAuthService_Login(t *testing.T) {
 	})
 
 	t.Run("❌ Login com password incorreta", func(t *testing.T) {
-		token, returnedUser, err := authService.Login(types.LoginRequest{
+		token, returnedUser, err := authService.Login(context.Background(), types.LoginRequest{
 			Email:    user.Email,
 			Password: "wrongpassword",
 		})
@@ -176,14 +239,14 @@ func TestAuthService_Login(t *testing.T) {
 			Role:     "user",
 			Active:   false,
 		}
-		err = userRepo.Create(inactiveUser)
+		err = userRepo.Create(context.Background(), inactiveUser)
 		require.NoError(t, err)
 
 		// Explicitly update the user to ensure it is inactive in the DB
 		err = db.Model(inactiveUser).Update("active", false).Error
 		assert.NoError(t, err, "Erro ao atualizar usuário inativo")
 
-		token, returnedUser, err := authService.Login(types.LoginRequest{
+		token, returnedUser, err := authService.Login(context.Background(), types.LoginRequest{
 			Email:    inactiveUser.Email,
 			Password: "password123",
 		})
@@ -266,7 +329,7 @@ func TestAuthService_GetUserByToken(t *testing.T) {
 		require.NoError(t, err)
 
 		// Buscar usuário pelo token
-		foundUser, err := authService.GetUserByToken(token)
+		foundUser, err := authService.GetUserByToken(context.Background(), token)
 
 		// Assertions
 		assert.NoError(t, err, "Busca por token válido não deve retornar erro")
@@ -279,7 +342,7 @@ func TestAuthService_GetUserByToken(t *testing.T) {
 	t.Run("❌ Token inválido", func(t *testing.T) {
 		invalidToken := "token.invalido.aqui"
 
-		foundUser, err := authService.GetUserByToken(invalidToken)
+		foundUser, err := authService.GetUserByToken(context.Background(), invalidToken)
 
 		// Assertions
 		assert.Error(t, err, "Token inválido deve retornar erro")
@@ -302,7 +365,7 @@ func TestAuthService_GetUserByToken(t *testing.T) {
 		tokenString, err := token.SignedString([]byte("test-secret"))
 		require.NoError(t, err)
 
-		foundUser, err := authService.GetUserByToken(tokenString)
+		foundUser, err := authService.GetUserByToken(context.Background(), tokenString)
 
 		// Assertions
 		assert.Error(t, err, "Token expirado deve retornar erro")
@@ -329,7 +392,7 @@ func TestAuthService_GetUserByToken(t *testing.T) {
 		db.Delete(deletedUser)
 
 		// Tentar buscar com token
-		foundUser, err := authService.GetUserByToken(token)
+		foundUser, err := authService.GetUserByToken(context.Background(), token)
 
 		// Assertions
 		assert.Error(t, err, "Token de usuário deletado deve retornar erro")
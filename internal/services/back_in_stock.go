@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/pkg/background"
+	"github.com/Code-Aether/americanas-loja-api/pkg/notify"
+	"github.com/Code-Aether/americanas-loja-api/pkg/telemetry"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrProductInStock       = errors.New("product is in stock")
+	ErrSubscriptionNotFound = errors.New("no pending stock notification for the product")
+)
+
+// restockBatchSize is how many subscribers are notified per query.
+const restockBatchSize = 100
+
+// BackInStockService lets users ask to be told when a product out of stock
+// is back, and tells them once it is. It listens to the stock changes of
+// ProductService.
+type BackInStockService struct {
+	subscriptionRepo *repository.StockSubscriptionRepository
+	productRepo      *repository.ProductRepository
+	notifier         notify.Notifier
+	// jobs sends the notices, so shutdown can wait for them.
+	jobs *background.Group
+	now  func() time.Time
+}
+
+func NewBackInStockService(subscriptionRepo *repository.StockSubscriptionRepository, productRepo *repository.ProductRepository, notifier notify.Notifier, jobs *background.Group) *BackInStockService {
+	return &BackInStockService{
+		subscriptionRepo: subscriptionRepo,
+		productRepo:      productRepo,
+		notifier:         notifier,
+		jobs:             jobs,
+		now:              time.Now,
+	}
+}
+
+// Subscribe asks for a notice when the product is back in stock. Asking
+// again while the first request is pending returns it.
+func (s *BackInStockService) Subscribe(ctx context.Context, userID, productID uint) (*models.StockSubscription, error) {
+	ctx, span := tracer.Start(ctx, "BackInStockService.Subscribe")
+	defer span.End()
+
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrProductNotFound
+		}
+		return nil, telemetry.RecordError(span, err)
+	}
+	if product.Stock > 0 {
+		return nil, telemetry.RecordError(span, ErrProductInStock)
+	}
+
+	subscription, err := s.subscriptionRepo.Subscribe(ctx, userID, productID)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return subscription, nil
+}
+
+func (s *BackInStockService) Unsubscribe(ctx context.Context, userID, productID uint) error {
+	ctx, span := tracer.Start(ctx, "BackInStockService.Unsubscribe")
+	defer span.End()
+
+	removed, err := s.subscriptionRepo.Unsubscribe(ctx, userID, productID)
+	if err != nil {
+		return telemetry.RecordError(span, err)
+	}
+	if !removed {
+		return telemetry.RecordError(span, ErrSubscriptionNotFound)
+	}
+	return nil
+}
+
+// StockChanged notifies the subscribers of a product that went from no
+// stock to some. Notices go out in the background, so the stock update
+// that triggered them is not held up, and shutdown waits for them.
+func (s *BackInStockService) StockChanged(ctx context.Context, product *models.Product, previous int) {
+	if previous > 0 || product.Stock <= 0 {
+		return
+	}
+
+	restocked := *product
+	s.jobs.Go(func() {
+		sent, err := s.NotifyRestock(ctx, &restocked)
+		if err != nil {
+			log.Printf("failed to notify restock of product %d: %v", restocked.ID, err)
+		}
+		if sent > 0 {
+			log.Printf("Notified %d users that product %d is back in stock", sent, restocked.ID)
+		}
+	})
+}
+
+// NotifyRestock sends the notice to every pending subscriber of the
+// product and returns how many were sent. Each subscription is claimed
+// before its notice goes out, so instances notifying the same restock send
+// it once; a notice that fails is put back to pending for the next restock.
+func (s *BackInStockService) NotifyRestock(ctx context.Context, product *models.Product) (int, error) {
+	ctx, span := tracer.Start(ctx, "BackInStockService.NotifyRestock")
+	defer span.End()
+
+	sent := 0
+	var afterID uint
+	for {
+		subscribers, err := s.subscriptionRepo.ListPending(ctx, product.ID, afterID, restockBatchSize)
+		if err != nil {
+			return sent, telemetry.RecordError(span, err)
+		}
+
+		for _, subscriber := range subscribers {
+			claimed, err := s.subscriptionRepo.MarkNotified(ctx, subscriber.ID, s.now())
+			if err != nil {
+				return sent, telemetry.RecordError(span, err)
+			}
+			if !claimed {
+				continue
+			}
+
+			if err := s.notifier.Notify(ctx, restockMessage(&subscriber, product)); err != nil {
+				log.Printf("failed to notify user %d of restock of product %d: %v", subscriber.UserID, product.ID, err)
+				if err := s.subscriptionRepo.Unmark(ctx, subscriber.ID); err != nil {
+					log.Printf("failed to restore stock subscription %d: %v", subscriber.ID, err)
+				}
+				continue
+			}
+			sent++
+		}
+
+		if len(subscribers) < restockBatchSize {
+			return sent, nil
+		}
+		afterID = subscribers[len(subscribers)-1].ID
+	}
+}
+
+func restockMessage(subscriber *repository.Subscriber, product *models.Product) notify.Message {
+	return notify.Message{
+		Event:   notify.EventBackInStock,
+		UserID:  subscriber.UserID,
+		To:      subscriber.Email,
+		Subject: fmt.Sprintf("%s voltou ao estoque", product.Name),
+		Body:    fmt.Sprintf("Olá, %s! O produto %s que você pediu para acompanhar está disponível novamente.", subscriber.Name, product.Name),
+		Data: map[string]any{
+			"product_id": product.ID,
+			"sku":        product.SKU,
+			"name":       product.Name,
+			"stock":      product.Stock,
+		},
+	}
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/pkg/storage"
+	"github.com/Code-Aether/americanas-loja-api/pkg/telemetry"
+)
+
+var ErrUnsupportedExportFormat = errors.New("unsupported export format, use csv, ndjson or xml")
+
+// CatalogExportService writes the active catalog as a feed for marketplaces
+// and ad platforms. Products are read in batches of batchSize and written
+// as they arrive, so memory stays flat whatever the size of the catalog.
+type CatalogExportService struct {
+	productRepo  *repository.ProductRepository
+	categoryRepo *repository.CategoryRepository
+	imageRepo    *repository.ProductImageRepository
+	storage      storage.Storage
+	// imageURLTTL is how long the signed image URLs of a feed stay valid;
+	// platforms fetch the images some time after reading the feed.
+	imageURLTTL time.Duration
+	// productURL is the storefront page of a product, with {id} and {sku}
+	// placeholders.
+	productURL string
+	batchSize  int
+}
+
+func NewCatalogExportService(productRepo *repository.ProductRepository, categoryRepo *repository.CategoryRepository, imageRepo *repository.ProductImageRepository, storage storage.Storage, imageURLTTL time.Duration, productURL string, batchSize int) *CatalogExportService {
+	return &CatalogExportService{
+		productRepo:  productRepo,
+		categoryRepo: categoryRepo,
+		imageRepo:    imageRepo,
+		storage:      storage,
+		imageURLTTL:  imageURLTTL,
+		productURL:   productURL,
+		batchSize:    max(batchSize, 1),
+	}
+}
+
+// flusher is implemented by HTTP response writers; the feed is flushed to
+// the client after every batch.
+type flusher interface {
+	Flush()
+}
+
+// Export writes the active products in the format to w and returns how many
+// items it wrote. With since set, only products changed since then are
+// exported, and the ones taken off the catalog since then are exported as
+// out of stock.
+func (s *CatalogExportService) Export(ctx context.Context, w io.Writer, format string, since *time.Time) (int, error) {
+	ctx, span := tracer.Start(ctx, "CatalogExportService.Export")
+	defer span.End()
+
+	categories, err := s.categoryPaths(ctx)
+	if err != nil {
+		return 0, telemetry.RecordError(span, err)
+	}
+
+	feed, err := newFeedWriter(w, format, storeURL(s.productURL))
+	if err != nil {
+		return 0, telemetry.RecordError(span, err)
+	}
+
+	count := 0
+	var afterID uint
+	for {
+		products, err := s.productRepo.ExportBatch(ctx, afterID, s.batchSize, since)
+		if err != nil {
+			return count, telemetry.RecordError(span, err)
+		}
+		if len(products) == 0 {
+			break
+		}
+
+		ids := make([]uint, len(products))
+		for i := range products {
+			ids[i] = products[i].ID
+		}
+		galleries, err := s.imageRepo.ListByProducts(ctx, ids)
+		if err != nil {
+			return count, telemetry.RecordError(span, err)
+		}
+
+		for i := range products {
+			items, err := s.catalogItems(ctx, &products[i], categories, galleries[products[i].ID])
+			if err != nil {
+				return count, telemetry.RecordError(span, err)
+			}
+			for _, item := range items {
+				if err := feed.Write(&item); err != nil {
+					return count, telemetry.RecordError(span, err)
+				}
+				count++
+			}
+		}
+
+		if err := feed.Flush(); err != nil {
+			return count, telemetry.RecordError(span, err)
+		}
+		if f, ok := w.(flusher); ok {
+			f.Flush()
+		}
+
+		if len(products) < s.batchSize {
+			break
+		}
+		afterID = products[len(products)-1].ID
+	}
+
+	return count, telemetry.RecordError(span, feed.Close())
+}
+
+// categoryPaths maps every category to its path of names, like
+// "Eletrônicos > Smartphones".
+func (s *CatalogExportService) categoryPaths(ctx context.Context) (map[uint]string, error) {
+	categories, err := s.categoryRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(categories))
+	for _, category := range categories {
+		names[strconv.FormatUint(uint64(category.ID), 10)] = category.Name
+	}
+
+	paths := make(map[uint]string, len(categories))
+	for _, category := range categories {
+		var path []string
+		for _, id := range strings.Split(strings.Trim(category.Path, "/"), "/") {
+			if name, ok := names[id]; ok {
+				path = append(path, name)
+			}
+		}
+		paths[category.ID] = strings.Join(path, " > ")
+	}
+	return paths, nil
+}
+
+// catalogItems turns a product into its items: the product itself, or one
+// item per active variant.
+func (s *CatalogExportService) catalogItems(ctx context.Context, product *models.Product, categories map[uint]string, gallery []models.ProductImage) ([]models.CatalogItem, error) {
+	images := make([]string, 0, len(gallery)+1)
+	if product.ImageURL != "" {
+		images = append(images, product.ImageURL)
+	}
+	for _, image := range gallery {
+		signed, err := s.storage.SignedURL(ctx, image.OriginalKey, s.imageURLTTL)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, signed)
+	}
+
+	base := models.CatalogItem{
+		ID:          product.SKU,
+		ProductID:   product.ID,
+		Title:       product.Name,
+		Description: product.Description,
+		Link:        s.link(product),
+		Price:       product.Price,
+		Stock:       product.Stock,
+		UpdatedAt:   product.UpdatedAt,
+	}
+	if product.CategoryID != nil {
+		base.Category = categories[*product.CategoryID]
+	}
+	if product.EffectivePrice.Cmp(product.Price) < 0 {
+		sale := product.EffectivePrice
+		base.SalePrice = &sale
+		base.SaleEndsAt = product.SaleEndsAt
+	}
+	setImages(&base, images)
+
+	// Deactivated and deleted products only show up in delta exports.
+	listed := product.Active && !product.DeletedAt.Valid
+	if len(product.Variants) == 0 {
+		base.Availability = availability(listed && product.Stock > 0)
+		return []models.CatalogItem{base}, nil
+	}
+
+	items := make([]models.CatalogItem, 0, len(product.Variants))
+	for _, variant := range product.Variants {
+		item := base
+		item.ID = variant.SKU
+		item.ItemGroupID = product.SKU
+		item.VariantID = variant.ID
+		item.Stock = variant.Stock
+		item.Availability = availability(listed && variant.Available)
+
+		// A variant with its own price is not on sale.
+		if variant.Price != nil {
+			item.Price = *variant.Price
+			item.SalePrice, item.SaleEndsAt = nil, nil
+		}
+		if len(variant.Images) > 0 {
+			setImages(&item, []string(variant.Images))
+		}
+
+		item.Options = make(map[string]string, len(variant.Options))
+		for _, option := range variant.Options {
+			item.Options[option.Code] = option.Value
+		}
+		if variant.UpdatedAt.After(item.UpdatedAt) {
+			item.UpdatedAt = variant.UpdatedAt
+		}
+
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (s *CatalogExportService) link(product *models.Product) string {
+	return strings.NewReplacer(
+		"{id}", strconv.FormatUint(uint64(product.ID), 10),
+		"{sku}", url.PathEscape(product.SKU),
+	).Replace(s.productURL)
+}
+
+// storeURL is the root of the storefront, the link of the feed itself.
+func storeURL(productURL string) string {
+	u, err := url.Parse(productURL)
+	if err != nil || u.Host == "" {
+		return productURL
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func setImages(item *models.CatalogItem, images []string) {
+	item.ImageURL, item.AdditionalImageURLs = "", nil
+	if len(images) > 0 {
+		item.ImageURL = images[0]
+		item.AdditionalImageURLs = images[1:]
+	}
+}
+
+func availability(inStock bool) string {
+	if inStock {
+		return models.AvailabilityInStock
+	}
+	return models.AvailabilityOutOfStock
+}
@@ -0,0 +1,197 @@
+// internal/services/catalog_export_test.go
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogExportService_Export(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	productRepo := repository.NewProductRepository(db)
+	productService := NewProductService(productRepo, nil)
+	ctx := context.Background()
+
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8080/media", []byte("secret"))
+	require.NoError(t, err)
+
+	newExportService := func(batchSize int) *CatalogExportService {
+		return NewCatalogExportService(productRepo, repository.NewCategoryRepository(db), repository.NewProductImageRepository(db),
+			store, time.Hour, "https://loja.example.com/produtos/{sku}", batchSize)
+	}
+
+	parent := testutils.CreateTestCategory(t, db, "Calçados", nil)
+	category := testutils.CreateTestCategory(t, db, "Tênis", parent)
+
+	simple := &models.Product{Name: "Meia Esportiva", Description: "Meia de algodão", SKU: "MEIA-1", Price: money.MustParse("29.90"), Stock: 4, CategoryID: &category.ID, Active: true, ImageURL: "https://cdn.example.com/meia.jpg"}
+	require.NoError(t, productService.Create(ctx, simple))
+
+	shoe := &models.Product{
+		Name: "Nike Air Max 90", SKU: "NIKE-90", Price: money.MustParse("499.99"), CategoryID: &category.ID, Active: true,
+		Variants: []models.ProductVariant{
+			sizeVariant("NIKE-90-40", "40", 3),
+			sizeVariant("NIKE-90-41", "41", 0),
+		},
+	}
+	require.NoError(t, productService.Create(ctx, shoe))
+
+	inactive := &models.Product{Name: "Fora de Linha", SKU: "OLD-1", Price: money.MustParse("10.00"), Stock: 1, CategoryID: &category.ID, Active: true}
+	require.NoError(t, productService.Create(ctx, inactive))
+	require.NoError(t, db.Model(inactive).Update("active", false).Error)
+
+	export := func(t *testing.T, service *CatalogExportService, format string, since *time.Time) (string, int) {
+		var buf bytes.Buffer
+		count, err := service.Export(ctx, &buf, format, since)
+		require.NoError(t, err)
+		return buf.String(), count
+	}
+
+	t.Run("✅ CSV tem um item por variante", func(t *testing.T) {
+		out, count := export(t, newExportService(500), models.ExportFormatCSV, nil)
+		assert.Equal(t, 3, count)
+
+		records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 4)
+		assert.Equal(t, csvFeedColumns, records[0])
+
+		item := func(record []string) map[string]string {
+			fields := make(map[string]string, len(record))
+			for i, column := range csvFeedColumns {
+				fields[column] = record[i]
+			}
+			return fields
+		}
+
+		meia := item(records[1])
+		assert.Equal(t, "MEIA-1", meia["id"])
+		assert.Equal(t, "Calçados > Tênis", meia["category"])
+		assert.Equal(t, "https://loja.example.com/produtos/MEIA-1", meia["link"])
+		assert.Equal(t, "https://cdn.example.com/meia.jpg", meia["image_url"])
+		assert.Equal(t, "29.90", meia["price"])
+		assert.Equal(t, models.AvailabilityInStock, meia["availability"])
+
+		size40, size41 := item(records[2]), item(records[3])
+		assert.Equal(t, "NIKE-90-40", size40["id"])
+		assert.Equal(t, "NIKE-90", size40["item_group_id"])
+		assert.Equal(t, "size=40", size40["options"])
+		assert.Equal(t, models.AvailabilityInStock, size40["availability"])
+		assert.Equal(t, models.AvailabilityOutOfStock, size41["availability"])
+	})
+
+	t.Run("✅ NDJSON em lotes pequenos exporta todos os produtos", func(t *testing.T) {
+		out, count := export(t, newExportService(1), models.ExportFormatNDJSON, nil)
+		assert.Equal(t, 3, count)
+
+		var ids []string
+		scanner := bufio.NewScanner(strings.NewReader(out))
+		for scanner.Scan() {
+			var item models.CatalogItem
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &item))
+			ids = append(ids, item.ID)
+		}
+		assert.Equal(t, []string{"MEIA-1", "NIKE-90-40", "NIKE-90-41"}, ids)
+	})
+
+	t.Run("✅ XML do Google Merchant com preço promocional", func(t *testing.T) {
+		ends := time.Now().Add(24 * time.Hour).UTC()
+		require.NoError(t, db.Model(simple).Updates(map[string]any{"sale_price": money.MustParse("19.90"), "sale_ends_at": ends}).Error)
+		t.Cleanup(func() {
+			db.Model(simple).Updates(map[string]any{"sale_price": nil, "sale_ends_at": nil})
+		})
+
+		out, count := export(t, newExportService(500), models.ExportFormatMerchant, nil)
+		assert.Equal(t, 3, count)
+		assert.Contains(t, out, `xmlns:g="http://base.google.com/ns/1.0"`)
+
+		var feed struct {
+			Link  string `xml:"channel>link"`
+			Items []struct {
+				ID           string `xml:"id"`
+				GroupID      string `xml:"item_group_id"`
+				Price        string `xml:"price"`
+				SalePrice    string `xml:"sale_price"`
+				Availability string `xml:"availability"`
+				Size         string `xml:"size"`
+			} `xml:"channel>item"`
+		}
+		require.NoError(t, xml.Unmarshal([]byte(out), &feed))
+		assert.Equal(t, "https://loja.example.com", feed.Link)
+		require.Len(t, feed.Items, 3)
+
+		assert.Equal(t, "29.90 BRL", feed.Items[0].Price)
+		assert.Equal(t, "19.90 BRL", feed.Items[0].SalePrice)
+		assert.Equal(t, "NIKE-90", feed.Items[1].GroupID)
+		assert.Equal(t, "40", feed.Items[1].Size)
+		assert.Equal(t, "out_of_stock", feed.Items[2].Availability)
+	})
+
+	t.Run("✅ updated_since exporta apenas os alterados", func(t *testing.T) {
+		since := time.Now().Add(time.Hour)
+		out, count := export(t, newExportService(500), models.ExportFormatCSV, &since)
+		assert.Equal(t, 0, count)
+		assert.Equal(t, strings.Join(csvFeedColumns, ",")+"\n", out)
+
+		past := time.Now().Add(-time.Hour)
+		require.NoError(t, db.Exec("UPDATE products SET updated_at = ?", past.Add(-time.Hour)).Error)
+		require.NoError(t, db.Exec("UPDATE product_variants SET updated_at = ? WHERE sku <> ?", past.Add(-time.Hour), "NIKE-90-41").Error)
+
+		out, count = export(t, newExportService(500), models.ExportFormatNDJSON, &past)
+		assert.Equal(t, 2, count, out)
+		assert.Contains(t, out, `"id":"NIKE-90-41"`)
+		assert.NotContains(t, out, `"id":"MEIA-1"`)
+	})
+
+	t.Run("✅ updated_since exporta os desativados como fora de estoque", func(t *testing.T) {
+		past := time.Now().Add(-time.Hour)
+		require.NoError(t, db.Exec("UPDATE products SET updated_at = ?", past.Add(-time.Hour)).Error)
+		require.NoError(t, db.Exec("UPDATE product_variants SET updated_at = ?", past.Add(-time.Hour)).Error)
+
+		require.NoError(t, productRepo.Delete(ctx, simple.ID))
+		require.NoError(t, db.Model(&models.ProductVariant{}).Where("sku = ?", "NIKE-90-40").Updates(map[string]any{"active": false, "updated_at": time.Now()}).Error)
+
+		out, count := export(t, newExportService(500), models.ExportFormatNDJSON, &past)
+		assert.Equal(t, 3, count, out)
+
+		availabilities := make(map[string]string)
+		scanner := bufio.NewScanner(strings.NewReader(out))
+		for scanner.Scan() {
+			var item models.CatalogItem
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &item))
+			availabilities[item.ID] = item.Availability
+		}
+		assert.Equal(t, models.AvailabilityOutOfStock, availabilities["MEIA-1"], "Produto desativado deve sair do feed")
+		assert.Equal(t, models.AvailabilityOutOfStock, availabilities["NIKE-90-40"], "Variante desativada deve sair do feed")
+		assert.NotContains(t, availabilities, "OLD-1", "Produto desativado antes da data não entra no delta")
+
+		out, count = export(t, newExportService(500), models.ExportFormatNDJSON, nil)
+		assert.Equal(t, 1, count, out)
+		assert.Contains(t, out, `"id":"NIKE-90-41"`)
+	})
+
+	t.Run("❌ Formato não suportado", func(t *testing.T) {
+		_, err := newExportService(500).Export(ctx, &bytes.Buffer{}, "xlsx", nil)
+		assert.ErrorIs(t, err, ErrUnsupportedExportFormat)
+	})
+}
+
+func TestFormatOptions(t *testing.T) {
+	assert.Equal(t, "color=azul;size=40", formatOptions(map[string]string{"size": "40", "color": "azul"}))
+	assert.Equal(t, "", formatOptions(nil))
+	assert.Equal(t, "8999.99 BRL", merchantPrice(money.MustParse("8999.99")))
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+)
+
+// feedWriter encodes catalog items one at a time.
+type feedWriter interface {
+	Write(item *models.CatalogItem) error
+	// Flush pushes buffered items to the underlying writer.
+	Flush() error
+	// Close writes whatever ends the feed.
+	Close() error
+}
+
+func newFeedWriter(w io.Writer, format, link string) (feedWriter, error) {
+	switch format {
+	case models.ExportFormatCSV:
+		return newCSVFeed(w), nil
+	case models.ExportFormatNDJSON:
+		return &ndjsonFeed{enc: json.NewEncoder(w)}, nil
+	case models.ExportFormatMerchant:
+		return newMerchantFeed(w, link)
+	default:
+		return nil, ErrUnsupportedExportFormat
+	}
+}
+
+// csvFeedColumns are the columns of the CSV feed. Lists are separated by
+// commas and options are written as code=value pairs separated by ";".
+var csvFeedColumns = []string{
+	"id", "item_group_id", "product_id", "variant_id", "title", "description", "category", "link",
+	"image_url", "additional_image_urls", "price", "sale_price", "sale_ends_at", "stock", "availability",
+	"options", "updated_at",
+}
+
+type csvFeed struct {
+	w      *csv.Writer
+	header bool
+}
+
+func newCSVFeed(w io.Writer) *csvFeed {
+	return &csvFeed{w: csv.NewWriter(w)}
+}
+
+func (f *csvFeed) Write(item *models.CatalogItem) error {
+	if !f.header {
+		if err := f.w.Write(csvFeedColumns); err != nil {
+			return err
+		}
+		f.header = true
+	}
+
+	var variantID, salePrice, saleEndsAt string
+	if item.VariantID != 0 {
+		variantID = strconv.FormatUint(uint64(item.VariantID), 10)
+	}
+	if item.SalePrice != nil {
+		salePrice = item.SalePrice.String()
+	}
+	if item.SaleEndsAt != nil {
+		saleEndsAt = item.SaleEndsAt.UTC().Format(time.RFC3339)
+	}
+
+	return f.w.Write([]string{
+		item.ID,
+		item.ItemGroupID,
+		strconv.FormatUint(uint64(item.ProductID), 10),
+		variantID,
+		item.Title,
+		item.Description,
+		item.Category,
+		item.Link,
+		item.ImageURL,
+		strings.Join(item.AdditionalImageURLs, ","),
+		item.Price.String(),
+		salePrice,
+		saleEndsAt,
+		strconv.Itoa(item.Stock),
+		item.Availability,
+		formatOptions(item.Options),
+		item.UpdatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+func (f *csvFeed) Flush() error {
+	f.w.Flush()
+	return f.w.Error()
+}
+
+// Close writes the header of an empty feed, so it still names the columns.
+func (f *csvFeed) Close() error {
+	if !f.header {
+		if err := f.w.Write(csvFeedColumns); err != nil {
+			return err
+		}
+		f.header = true
+	}
+	return f.Flush()
+}
+
+func formatOptions(options map[string]string) string {
+	codes := make([]string, 0, len(options))
+	for code := range options {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+
+	pairs := make([]string, len(codes))
+	for i, code := range codes {
+		pairs[i] = code + "=" + options[code]
+	}
+	return strings.Join(pairs, ";")
+}
+
+type ndjsonFeed struct {
+	enc *json.Encoder
+}
+
+func (f *ndjsonFeed) Write(item *models.CatalogItem) error {
+	return f.enc.Encode(item)
+}
+
+func (f *ndjsonFeed) Flush() error { return nil }
+func (f *ndjsonFeed) Close() error { return nil }
+
+// merchantFeed writes the RSS 2.0 feed of Google Merchant Center, which
+// other ad platforms read too.
+//
+// https://support.google.com/merchants/answer/7052112
+type merchantFeed struct {
+	w   io.Writer
+	enc *xml.Encoder
+}
+
+// merchantMaxImages is the most additional images Merchant Center reads.
+const merchantMaxImages = 10
+
+type merchantItem struct {
+	XMLName             xml.Name `xml:"item"`
+	ID                  string   `xml:"g:id"`
+	Title               string   `xml:"g:title"`
+	Description         string   `xml:"g:description"`
+	Link                string   `xml:"g:link"`
+	ImageLink           string   `xml:"g:image_link,omitempty"`
+	AdditionalImageLink []string `xml:"g:additional_image_link,omitempty"`
+	Availability        string   `xml:"g:availability"`
+	Price               string   `xml:"g:price"`
+	SalePrice           string   `xml:"g:sale_price,omitempty"`
+	SalePriceEffective  string   `xml:"g:sale_price_effective_date,omitempty"`
+	ProductType         string   `xml:"g:product_type,omitempty"`
+	ItemGroupID         string   `xml:"g:item_group_id,omitempty"`
+	Condition           string   `xml:"g:condition"`
+	IdentifierExists    string   `xml:"g:identifier_exists"`
+	Color               string   `xml:"g:color,omitempty"`
+	Size                string   `xml:"g:size,omitempty"`
+}
+
+func newMerchantFeed(w io.Writer, link string) (*merchantFeed, error) {
+	f := &merchantFeed{w: w, enc: xml.NewEncoder(w)}
+
+	var channel strings.Builder
+	channel.WriteString(xml.Header)
+	channel.WriteString(`<rss version="2.0" xmlns:g="http://base.google.com/ns/1.0">` + "\n<channel>\n")
+	channel.WriteString("<title>Americanas Loja</title>\n<link>")
+	if err := xml.EscapeText(&channel, []byte(link)); err != nil {
+		return nil, err
+	}
+	channel.WriteString("</link>\n<description>Catálogo de produtos</description>\n")
+
+	if _, err := io.WriteString(w, channel.String()); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *merchantFeed) Write(item *models.CatalogItem) error {
+	entry := merchantItem{
+		ID:           item.ID,
+		Title:        item.Title,
+		Description:  item.Description,
+		Link:         item.Link,
+		ImageLink:    item.ImageURL,
+		Availability: item.Availability,
+		Price:        merchantPrice(item.Price),
+		ProductType:  item.Category,
+		ItemGroupID:  item.ItemGroupID,
+		Condition:    "new",
+		// Products have no brand or GTIN to identify them.
+		IdentifierExists: "no",
+		Color:            item.Options["color"],
+		Size:             item.Options["size"],
+	}
+	if len(item.AdditionalImageURLs) > 0 {
+		entry.AdditionalImageLink = item.AdditionalImageURLs[:min(len(item.AdditionalImageURLs), merchantMaxImages)]
+	}
+	if item.SalePrice != nil {
+		entry.SalePrice = merchantPrice(*item.SalePrice)
+		if item.SaleEndsAt != nil {
+			// The sale is already running, so it is effective from now.
+			entry.SalePriceEffective = time.Now().UTC().Format(time.RFC3339) + "/" + item.SaleEndsAt.UTC().Format(time.RFC3339)
+		}
+	}
+
+	if err := f.enc.Encode(entry); err != nil {
+		return err
+	}
+	_, err := io.WriteString(f.w, "\n")
+	return err
+}
+
+func (f *merchantFeed) Flush() error {
+	return f.enc.Flush()
+}
+
+func (f *merchantFeed) Close() error {
+	if err := f.enc.Flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(f.w, "</channel>\n</rss>\n")
+	return err
+}
+
+// merchantPrice formats a price as Merchant Center expects, "8999.99 BRL".
+func merchantPrice(m money.Money) string {
+	currency := m.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	return m.String() + " " + currency
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/pkg/telemetry"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+const categoryTreeCacheKey = "categories:tree"
+
+var (
+	ErrCategoryNotFound       = errors.New("category not found")
+	ErrParentCategoryNotFound = errors.New("parent category not found")
+	ErrCategorySlugTaken      = errors.New("category slug already exists")
+	ErrCategoryInvalidSlug    = errors.New("category slug is empty")
+	ErrCategoryCycle          = errors.New("category cannot be moved under itself or its subcategories")
+	ErrCategoryNotEmpty       = errors.New("category still has subcategories or products")
+)
+
+// CategoryInput carries the editable fields of a category. Nil fields are
+// left untouched on update; a ParentID of 0 moves the category to the root.
+type CategoryInput struct {
+	Name      *string
+	Slug      *string
+	ParentID  *uint
+	SortOrder *int
+	Active    *bool
+
+	ReorderThreshold *int
+}
+
+type CategoryService struct {
+	categoryRepo *repository.CategoryRepository
+	redis        *redis.Client
+}
+
+func NewCategoryService(categoryRepo *repository.CategoryRepository, redis *redis.Client) *CategoryService {
+	return &CategoryService{
+		categoryRepo: categoryRepo,
+		redis:        redis,
+	}
+}
+
+// GetTree returns the active categories nested by parent. Categories below
+// an inactive one are hidden along with it.
+func (s *CategoryService) GetTree(ctx context.Context) ([]*models.Category, error) {
+	ctx, span := tracer.Start(ctx, "CategoryService.GetTree")
+	defer span.End()
+
+	if s.redis != nil {
+		cached, err := s.redis.Get(ctx, categoryTreeCacheKey).Result()
+		if err == nil {
+			var tree []*models.Category
+			if json.Unmarshal([]byte(cached), &tree) == nil {
+				return tree, nil
+			}
+		}
+	}
+
+	tree, err := s.GetFullTree(ctx)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	tree = pruneInactive(tree)
+
+	if s.redis != nil && ctx.Err() == nil {
+		data, _ := json.Marshal(tree)
+		s.redis.Set(ctx, categoryTreeCacheKey, data, 10*time.Minute)
+	}
+
+	return tree, nil
+}
+
+// GetFullTree returns every category, including inactive ones, for the admin.
+func (s *CategoryService) GetFullTree(ctx context.Context) ([]*models.Category, error) {
+	ctx, span := tracer.Start(ctx, "CategoryService.GetFullTree")
+	defer span.End()
+
+	categories, err := s.categoryRepo.GetAll(ctx)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	return models.BuildCategoryTree(categories), nil
+}
+
+func (s *CategoryService) GetByID(ctx context.Context, id uint) (*models.Category, error) {
+	ctx, span := tracer.Start(ctx, "CategoryService.GetByID")
+	defer span.End()
+
+	category, err := s.categoryRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrCategoryNotFound
+		}
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	return category, nil
+}
+
+func (s *CategoryService) Create(ctx context.Context, input CategoryInput) (*models.Category, error) {
+	ctx, span := tracer.Start(ctx, "CategoryService.Create")
+	defer span.End()
+
+	category := &models.Category{Active: true}
+	if input.Name != nil {
+		category.Name = *input.Name
+	}
+	if input.SortOrder != nil {
+		category.SortOrder = *input.SortOrder
+	}
+	if input.Active != nil {
+		category.Active = *input.Active
+	}
+	category.ReorderThreshold = input.ReorderThreshold
+
+	slug := category.Name
+	if input.Slug != nil {
+		slug = *input.Slug
+	}
+	if err := s.assignSlug(ctx, category, slug); err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	var parent *models.Category
+	if input.ParentID != nil && *input.ParentID != 0 {
+		var err error
+		parent, err = s.getParent(ctx, *input.ParentID)
+		if err != nil {
+			return nil, telemetry.RecordError(span, err)
+		}
+	}
+
+	if err := s.categoryRepo.Create(ctx, category, parent); err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	s.invalidateCache(ctx)
+
+	return category, nil
+}
+
+func (s *CategoryService) Update(ctx context.Context, id uint, input CategoryInput) (*models.Category, error) {
+	ctx, span := tracer.Start(ctx, "CategoryService.Update")
+	defer span.End()
+
+	category, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	if input.Name != nil {
+		category.Name = *input.Name
+	}
+	if input.SortOrder != nil {
+		category.SortOrder = *input.SortOrder
+	}
+	if input.Active != nil {
+		category.Active = *input.Active
+	}
+	if input.ReorderThreshold != nil {
+		category.ReorderThreshold = input.ReorderThreshold
+	}
+	if input.Slug != nil {
+		if err := s.assignSlug(ctx, category, *input.Slug); err != nil {
+			return nil, telemetry.RecordError(span, err)
+		}
+	}
+
+	if input.ParentID != nil && !sameParent(category.ParentID, *input.ParentID) {
+		var parent *models.Category
+		if *input.ParentID != 0 {
+			parent, err = s.getParent(ctx, *input.ParentID)
+			if err != nil {
+				return nil, telemetry.RecordError(span, err)
+			}
+			if category.IsAncestorOf(parent) {
+				return nil, telemetry.RecordError(span, ErrCategoryCycle)
+			}
+		}
+
+		err = s.categoryRepo.UpdateAndMove(ctx, category, parent)
+	} else {
+		err = s.categoryRepo.Update(ctx, category)
+	}
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	s.invalidateCache(ctx)
+
+	return category, nil
+}
+
+// Delete removes an empty category. Categories with subcategories or
+// products must be emptied first, so no product is left uncategorized by
+// accident.
+func (s *CategoryService) Delete(ctx context.Context, id uint) error {
+	ctx, span := tracer.Start(ctx, "CategoryService.Delete")
+	defer span.End()
+
+	if _, err := s.GetByID(ctx, id); err != nil {
+		return telemetry.RecordError(span, err)
+	}
+
+	children, err := s.categoryRepo.CountChildren(ctx, id)
+	if err != nil {
+		return telemetry.RecordError(span, err)
+	}
+	products, err := s.categoryRepo.CountProducts(ctx, id)
+	if err != nil {
+		return telemetry.RecordError(span, err)
+	}
+	if children > 0 || products > 0 {
+		return telemetry.RecordError(span, ErrCategoryNotEmpty)
+	}
+
+	if err := s.categoryRepo.Delete(ctx, id); err != nil {
+		return telemetry.RecordError(span, err)
+	}
+
+	s.invalidateCache(ctx)
+
+	return nil
+}
+
+func (s *CategoryService) assignSlug(ctx context.Context, category *models.Category, value string) error {
+	slug := utils.Slugify(value)
+	if slug == "" {
+		return ErrCategoryInvalidSlug
+	}
+
+	existing, err := s.categoryRepo.GetBySlug(ctx, slug)
+	if err == nil && existing.ID != category.ID {
+		return ErrCategorySlugTaken
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	category.Slug = slug
+	return nil
+}
+
+func (s *CategoryService) getParent(ctx context.Context, id uint) (*models.Category, error) {
+	parent, err := s.categoryRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrParentCategoryNotFound
+	}
+	return parent, err
+}
+
+// invalidateCache drops the category tree and the cached products, which
+// embed their category.
+func (s *CategoryService) invalidateCache(ctx context.Context) {
+	ctx = context.WithoutCancel(ctx)
+	if s.redis != nil {
+		s.redis.Del(ctx, categoryTreeCacheKey)
+		// Product entries and listings embed category data.
+		keys, err := s.redis.Keys(ctx, "product*").Result()
+		if err == nil && len(keys) > 0 {
+			s.redis.Del(ctx, keys...)
+		}
+	}
+}
+
+func sameParent(current *uint, requested uint) bool {
+	if current == nil {
+		return requested == 0
+	}
+	return *current == requested
+}
+
+func pruneInactive(nodes []*models.Category) []*models.Category {
+	active := make([]*models.Category, 0, len(nodes))
+	for _, node := range nodes {
+		if !node.Active {
+			continue
+		}
+		node.Children = pruneInactive(node.Children)
+		if len(node.Children) == 0 {
+			node.Children = nil
+		}
+		active = append(active, node)
+	}
+	return active
+}
@@ -0,0 +1,217 @@
+// internal/services/category_service_test.go
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestCategoryService_Create(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	categoryService := NewCategoryService(repository.NewCategoryRepository(db), nil)
+	ctx := context.Background()
+
+	t.Run("✅ Criar categoria raiz e subcategoria", func(t *testing.T) {
+		parent, err := categoryService.Create(ctx, CategoryInput{Name: ptr("Eletrônicos ")})
+		require.NoError(t, err)
+		assert.Equal(t, "eletronicos", parent.Slug, "Slug deve ser normalizado")
+		assert.Equal(t, models.PathFor("", parent.ID), parent.Path)
+		assert.Nil(t, parent.ParentID)
+		assert.True(t, parent.Active, "Categoria deve nascer ativa")
+
+		child, err := categoryService.Create(ctx, CategoryInput{Name: ptr("Smartphones"), ParentID: &parent.ID})
+		require.NoError(t, err)
+		assert.Equal(t, parent.ID, *child.ParentID)
+		assert.Equal(t, 1, child.Depth)
+		assert.Equal(t, models.PathFor(parent.Path, child.ID), child.Path)
+	})
+
+	t.Run("❌ Criar categoria com slug duplicado", func(t *testing.T) {
+		_, err := categoryService.Create(ctx, CategoryInput{Name: ptr("ELETRÔNICOS")})
+		assert.ErrorIs(t, err, ErrCategorySlugTaken)
+	})
+
+	t.Run("❌ Criar categoria com pai inexistente", func(t *testing.T) {
+		_, err := categoryService.Create(ctx, CategoryInput{Name: ptr("Órfã"), ParentID: ptr(uint(999))})
+		assert.ErrorIs(t, err, ErrParentCategoryNotFound)
+	})
+}
+
+func TestCategoryService_Update(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	categoryService := NewCategoryService(repository.NewCategoryRepository(db), nil)
+	ctx := context.Background()
+
+	eletronicos := testutils.CreateTestCategory(t, db, "Eletrônicos", nil)
+	celulares := testutils.CreateTestCategory(t, db, "Celulares", nil)
+	smartphones := testutils.CreateTestCategory(t, db, "Smartphones", celulares)
+
+	t.Run("✅ Mover categoria leva a subárvore junto", func(t *testing.T) {
+		moved, err := categoryService.Update(ctx, celulares.ID, CategoryInput{ParentID: &eletronicos.ID})
+		require.NoError(t, err)
+		assert.Equal(t, models.PathFor(eletronicos.Path, celulares.ID), moved.Path)
+		assert.Equal(t, 1, moved.Depth)
+
+		child, err := categoryService.GetByID(ctx, smartphones.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.PathFor(moved.Path, smartphones.ID), child.Path, "Caminho do filho deve ser reescrito")
+		assert.Equal(t, 2, child.Depth)
+	})
+
+	t.Run("✅ Mover categoria para a raiz", func(t *testing.T) {
+		moved, err := categoryService.Update(ctx, celulares.ID, CategoryInput{ParentID: ptr(uint(0))})
+		require.NoError(t, err)
+		assert.Nil(t, moved.ParentID)
+		assert.Equal(t, models.PathFor("", celulares.ID), moved.Path)
+		assert.Equal(t, 0, moved.Depth)
+	})
+
+	t.Run("❌ Mover categoria para dentro de si mesma", func(t *testing.T) {
+		_, err := categoryService.Update(ctx, celulares.ID, CategoryInput{ParentID: &smartphones.ID})
+		assert.ErrorIs(t, err, ErrCategoryCycle)
+	})
+
+	t.Run("❌ Atualizar categoria inexistente", func(t *testing.T) {
+		_, err := categoryService.Update(ctx, 999, CategoryInput{Name: ptr("Nada")})
+		assert.ErrorIs(t, err, ErrCategoryNotFound)
+	})
+
+	t.Run("❌ Falha ao salvar desfaz a mudança de pai", func(t *testing.T) {
+		errSave := errors.New("falha simulada ao salvar")
+		require.NoError(t, db.Callback().Update().Before("gorm:update").Register("test:fail_category_save", func(tx *gorm.DB) {
+			if category, ok := tx.Statement.Dest.(*models.Category); ok && category.Name == "Falha" {
+				tx.AddError(errSave)
+			}
+		}))
+		t.Cleanup(func() { db.Callback().Update().Remove("test:fail_category_save") })
+
+		_, err := categoryService.Update(ctx, celulares.ID, CategoryInput{Name: ptr("Falha"), ParentID: &eletronicos.ID})
+		assert.ErrorIs(t, err, errSave)
+
+		found, err := categoryService.GetByID(ctx, celulares.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Celulares", found.Name)
+		assert.Nil(t, found.ParentID, "Categoria não deve ter sido movida")
+		assert.Equal(t, models.PathFor("", celulares.ID), found.Path)
+
+		child, err := categoryService.GetByID(ctx, smartphones.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.PathFor(found.Path, smartphones.ID), child.Path, "Caminho do filho não deve ser reescrito")
+		assert.Equal(t, 1, child.Depth)
+	})
+}
+
+func TestCategoryService_GetTree(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	categoryService := NewCategoryService(repository.NewCategoryRepository(db), nil)
+	ctx := context.Background()
+
+	eletronicos := testutils.CreateTestCategory(t, db, "Eletrônicos", nil)
+	testutils.CreateTestCategory(t, db, "Smartphones", eletronicos)
+	livros := testutils.CreateTestCategory(t, db, "Livros", nil)
+	testutils.CreateTestCategory(t, db, "E-readers", livros)
+	require.NoError(t, db.Model(livros).Update("active", false).Error)
+
+	t.Run("✅ Árvore pública esconde ramos inativos", func(t *testing.T) {
+		tree, err := categoryService.GetTree(ctx)
+		require.NoError(t, err)
+		require.Len(t, tree, 1, "Apenas a raiz ativa deve aparecer")
+		assert.Equal(t, "eletronicos", tree[0].Slug)
+		require.Len(t, tree[0].Children, 1)
+		assert.Equal(t, "smartphones", tree[0].Children[0].Slug)
+	})
+
+	t.Run("✅ Árvore completa inclui inativas", func(t *testing.T) {
+		tree, err := categoryService.GetFullTree(ctx)
+		require.NoError(t, err)
+		assert.Len(t, tree, 2)
+	})
+}
+
+func TestCategoryService_Delete(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	categoryService := NewCategoryService(repository.NewCategoryRepository(db), nil)
+	ctx := context.Background()
+
+	parent := testutils.CreateTestCategory(t, db, "Eletrônicos", nil)
+	child := testutils.CreateTestCategory(t, db, "Smartphones", parent)
+
+	t.Run("❌ Deletar categoria com subcategorias", func(t *testing.T) {
+		err := categoryService.Delete(ctx, parent.ID)
+		assert.ErrorIs(t, err, ErrCategoryNotEmpty)
+	})
+
+	t.Run("✅ Deletar categoria vazia", func(t *testing.T) {
+		require.NoError(t, categoryService.Delete(ctx, child.ID))
+
+		_, err := categoryService.GetByID(ctx, child.ID)
+		assert.ErrorIs(t, err, ErrCategoryNotFound)
+	})
+}
+
+func TestProductService_CategoryHierarchy(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	productService := NewProductService(repository.NewProductRepository(db), nil)
+	ctx := context.Background()
+
+	eletronicos := testutils.CreateTestCategory(t, db, "Eletrônicos", nil)
+	smartphones := testutils.CreateTestCategory(t, db, "Smartphones", eletronicos)
+	livros := testutils.CreateTestCategory(t, db, "Livros", nil)
+
+	products := []*models.Product{
+		{Name: "TV", SKU: "TV-001", CategoryID: &eletronicos.ID, Price: money.MustParse("2000"), Stock: 5, Active: true},
+		{Name: "iPhone", SKU: "IP-001", CategoryID: &smartphones.ID, Price: money.MustParse("1000"), Stock: 10, Active: true},
+		{Name: "Romance", SKU: "LV-001", CategoryID: &livros.ID, Price: money.MustParse("50"), Stock: 30, Active: true},
+	}
+	for _, product := range products {
+		require.NoError(t, productService.Create(ctx, product))
+	}
+
+	t.Run("✅ Categoria pai inclui produtos das subcategorias", func(t *testing.T) {
+		listing, err := productService.GetAll(ctx, models.ProductFilter{Page: 1, Limit: 10, Categories: []string{"Eletrônicos"}})
+		require.NoError(t, err)
+		require.NotNil(t, listing.Total)
+		assert.Equal(t, int64(2), *listing.Total)
+		assert.Len(t, listing.Products, 2)
+	})
+
+	t.Run("✅ Subcategoria filtrada por ID", func(t *testing.T) {
+		listing, err := productService.GetAll(ctx, models.ProductFilter{
+			Page: 1, Limit: 10, Categories: []string{strconv.FormatUint(uint64(smartphones.ID), 10)},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, listing.Total)
+		assert.Equal(t, int64(1), *listing.Total)
+		result := listing.Products
+		require.Len(t, result, 1)
+		assert.Equal(t, "iPhone", result[0].Name)
+		require.NotNil(t, result[0].Category, "Categoria deve vir carregada")
+		assert.Equal(t, "smartphones", result[0].Category.Slug)
+	})
+
+	t.Run("✅ Buscar por categoria usa o slug", func(t *testing.T) {
+		result, err := productService.GetByCategory(ctx, "LIVROS")
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, "Romance", result[0].Name)
+	})
+
+	t.Run("❌ Criar produto com categoria inexistente", func(t *testing.T) {
+		err := productService.Create(ctx, &models.Product{Name: "X", SKU: "X-001", CategoryID: ptr(uint(999)), Price: money.MustParse("1"), Active: true})
+		assert.ErrorIs(t, err, ErrCategoryNotFound)
+	})
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+)
+
+// Reasons a coupon is rejected for a cart.
+var (
+	ErrCouponInactive           = errors.New("coupon is not active")
+	ErrCouponNotStarted         = errors.New("coupon is not valid yet")
+	ErrCouponExpired            = errors.New("coupon has expired")
+	ErrCouponUsageLimit         = errors.New("coupon reached its usage limit")
+	ErrCouponUserLimit          = errors.New("coupon reached its usage limit for this customer")
+	ErrCouponMinSubtotal        = errors.New("cart is below the coupon minimum")
+	ErrCouponNoEligibleItems    = errors.New("no item of the cart is eligible for the coupon")
+	ErrCouponQuantityNotReached = errors.New("not enough eligible items for the coupon")
+)
+
+// LineItem is an item of the cart a coupon is evaluated against.
+type LineItem struct {
+	ProductID uint
+	VariantID uint
+	// SKU is the SKU of the product and VariantSKU the one of the variant,
+	// if any; the SKU lists of a coupon match either.
+	SKU        string
+	VariantSKU string
+	// Categories are the category of the product and its ancestors, so a
+	// coupon for a category covers its subcategories.
+	Categories []uint
+	Price      money.Money
+	Quantity   int
+}
+
+// Total is the price of all units of the line.
+func (l *LineItem) Total() money.Money {
+	return l.Price.Mul(int64(l.Quantity))
+}
+
+// LineDiscount is the part of the discount given on a line of the cart.
+type LineDiscount struct {
+	ProductID uint        `json:"product_id"`
+	VariantID uint        `json:"variant_id,omitempty"`
+	Quantity  int         `json:"quantity"`
+	Total     money.Money `json:"total" swaggertype:"number"`
+	Discount  money.Money `json:"discount" swaggertype:"number"`
+	Eligible  bool        `json:"eligible"`
+}
+
+// CouponEvaluation is the discount a coupon gives on a cart, in the order
+// of its lines.
+type CouponEvaluation struct {
+	Code         string         `json:"code"`
+	Type         string         `json:"type"`
+	Subtotal     money.Money    `json:"subtotal" swaggertype:"number"`
+	Discount     money.Money    `json:"discount" swaggertype:"number"`
+	Total        money.Money    `json:"total" swaggertype:"number"`
+	FreeShipping bool           `json:"free_shipping"`
+	Lines        []LineDiscount `json:"lines"`
+}
+
+// EvaluateCoupon works out the discount of the coupon on the items at now,
+// for a customer who already redeemed it userUses times. It reads nothing
+// but its arguments; a coupon that does not apply returns one of the
+// ErrCoupon reasons.
+func EvaluateCoupon(coupon *models.Coupon, items []LineItem, now time.Time, userUses int) (*CouponEvaluation, error) {
+	switch {
+	case !coupon.Active:
+		return nil, ErrCouponInactive
+	case coupon.StartsAt != nil && now.Before(*coupon.StartsAt):
+		return nil, fmt.Errorf("%w: valid from %s", ErrCouponNotStarted, coupon.StartsAt.UTC().Format(time.RFC3339))
+	case coupon.EndsAt != nil && !now.Before(*coupon.EndsAt):
+		return nil, ErrCouponExpired
+	case coupon.UsageLimit != nil && coupon.UsedCount >= *coupon.UsageLimit:
+		return nil, ErrCouponUsageLimit
+	case coupon.PerUserLimit != nil && userUses >= *coupon.PerUserLimit:
+		return nil, ErrCouponUserLimit
+	}
+
+	evaluation := &CouponEvaluation{
+		Code:     coupon.Code,
+		Type:     coupon.Type,
+		Subtotal: money.FromCents(0),
+		Discount: money.FromCents(0),
+		Lines:    make([]LineDiscount, len(items)),
+	}
+
+	var eligible []int
+	eligibleSubtotal := money.FromCents(0)
+	for i := range items {
+		item := &items[i]
+		line := &evaluation.Lines[i]
+		line.ProductID, line.VariantID, line.Quantity = item.ProductID, item.VariantID, item.Quantity
+		line.Total = item.Total()
+		line.Discount = money.FromCents(0)
+		line.Eligible = couponCovers(coupon, item)
+
+		evaluation.Subtotal = evaluation.Subtotal.Add(line.Total)
+		if line.Eligible {
+			eligible = append(eligible, i)
+			eligibleSubtotal = eligibleSubtotal.Add(line.Total)
+		}
+	}
+
+	if evaluation.Subtotal.Cmp(coupon.MinSubtotal) < 0 {
+		return nil, fmt.Errorf("%w of %s", ErrCouponMinSubtotal, coupon.MinSubtotal)
+	}
+	if len(eligible) == 0 {
+		return nil, ErrCouponNoEligibleItems
+	}
+
+	switch coupon.Type {
+	case models.CouponPercentage:
+		for _, i := range eligible {
+			line := &evaluation.Lines[i]
+			line.Discount = line.Total.Percent(int64(coupon.Percent) * 100)
+		}
+	case models.CouponFixed:
+		amount := coupon.Amount
+		if amount.Cmp(eligibleSubtotal) > 0 {
+			amount = eligibleSubtotal
+		}
+		ratios := make([]int64, len(eligible))
+		for j, i := range eligible {
+			ratios[j] = evaluation.Lines[i].Total.Amount
+		}
+		for j, part := range amount.Allocate(ratios...) {
+			evaluation.Lines[eligible[j]].Discount = part
+		}
+	case models.CouponFreeShipping:
+		evaluation.FreeShipping = true
+	case models.CouponBuyXGetY:
+		if err := buyXGetY(coupon, items, eligible, evaluation.Lines); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range evaluation.Lines {
+		evaluation.Discount = evaluation.Discount.Add(evaluation.Lines[i].Discount)
+	}
+	evaluation.Total = evaluation.Subtotal.Sub(evaluation.Discount)
+	return evaluation, nil
+}
+
+// buyXGetY lines up the eligible units from the most to the least
+// expensive and, in every group of BuyQuantity+GetQuantity units, gives the
+// last GetQuantity ones free, so the free units are never worth more than
+// the ones paid for.
+func buyXGetY(coupon *models.Coupon, items []LineItem, eligible []int, lines []LineDiscount) error {
+	group := coupon.BuyQuantity + coupon.GetQuantity
+
+	var units []int
+	for _, i := range eligible {
+		for range items[i].Quantity {
+			units = append(units, i)
+		}
+	}
+	if len(units) < group {
+		return fmt.Errorf("%w: buy %d to get %d free", ErrCouponQuantityNotReached, coupon.BuyQuantity, coupon.GetQuantity)
+	}
+
+	slices.SortStableFunc(units, func(a, b int) int {
+		return items[b].Price.Cmp(items[a].Price)
+	})
+	for n, i := range units[:len(units)-len(units)%group] {
+		if n%group >= coupon.BuyQuantity {
+			lines[i].Discount = lines[i].Discount.Add(items[i].Price)
+		}
+	}
+	return nil
+}
+
+// couponCovers reports whether the item is eligible for the coupon.
+func couponCovers(coupon *models.Coupon, item *LineItem) bool {
+	matchesSKU := func(skus models.StringList) bool {
+		return slices.Contains(skus, item.SKU) || (item.VariantSKU != "" && slices.Contains(skus, item.VariantSKU))
+	}
+	matchesCategory := func(categories models.IDList) bool {
+		for _, id := range item.Categories {
+			if slices.Contains(categories, id) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if matchesSKU(coupon.ExcludeSKUs) || matchesCategory(coupon.ExcludeCategories) {
+		return false
+	}
+	if len(coupon.IncludeSKUs) == 0 && len(coupon.IncludeCategories) == 0 {
+		return true
+	}
+	return matchesSKU(coupon.IncludeSKUs) || matchesCategory(coupon.IncludeCategories)
+}
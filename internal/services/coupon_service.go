@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/telemetry"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCouponNotFound   = errors.New("coupon not found")
+	ErrCouponCodeTaken  = errors.New("coupon code already exists")
+	ErrInvalidCoupon    = errors.New("invalid coupon")
+	ErrCouponInUse      = errors.New("coupon was already redeemed, deactivate it instead")
+	ErrItemNeedsVariant = errors.New("product has variants, the item must name one")
+)
+
+// CartItem is a product, or a variant of it, in the quantity a customer
+// wants to buy.
+type CartItem struct {
+	ProductID uint
+	VariantID uint
+	Quantity  int
+}
+
+// CouponService manages coupons and applies them to carts with
+// EvaluateCoupon, at the current prices of the products.
+type CouponService struct {
+	couponRepo  *repository.CouponRepository
+	productRepo *repository.ProductRepository
+	now         func() time.Time
+}
+
+func NewCouponService(couponRepo *repository.CouponRepository, productRepo *repository.ProductRepository) *CouponService {
+	return &CouponService{
+		couponRepo:  couponRepo,
+		productRepo: productRepo,
+		now:         time.Now,
+	}
+}
+
+func (s *CouponService) List(ctx context.Context) ([]models.Coupon, error) {
+	ctx, span := tracer.Start(ctx, "CouponService.List")
+	defer span.End()
+
+	coupons, err := s.couponRepo.List(ctx)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return coupons, nil
+}
+
+func (s *CouponService) Get(ctx context.Context, id uint) (*models.Coupon, error) {
+	ctx, span := tracer.Start(ctx, "CouponService.Get")
+	defer span.End()
+
+	coupon, err := s.couponRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrCouponNotFound
+	}
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return coupon, nil
+}
+
+func (s *CouponService) Create(ctx context.Context, coupon *models.Coupon) error {
+	ctx, span := tracer.Start(ctx, "CouponService.Create")
+	defer span.End()
+
+	coupon.ID, coupon.UsedCount = 0, 0
+	if err := prepareCoupon(coupon); err != nil {
+		return telemetry.RecordError(span, err)
+	}
+
+	err := s.couponRepo.Create(ctx, coupon)
+	if errors.Is(err, repository.ErrCouponCodeTaken) {
+		err = ErrCouponCodeTaken
+	}
+	return telemetry.RecordError(span, err)
+}
+
+// Update replaces the definition of the coupon; its redemptions are kept.
+func (s *CouponService) Update(ctx context.Context, id uint, coupon *models.Coupon) error {
+	ctx, span := tracer.Start(ctx, "CouponService.Update")
+	defer span.End()
+
+	current, err := s.Get(ctx, id)
+	if err != nil {
+		return telemetry.RecordError(span, err)
+	}
+
+	coupon.ID, coupon.CreatedAt = current.ID, current.CreatedAt
+	if err := prepareCoupon(coupon); err != nil {
+		return telemetry.RecordError(span, err)
+	}
+
+	err = s.couponRepo.Update(ctx, coupon)
+	if errors.Is(err, repository.ErrCouponCodeTaken) {
+		err = ErrCouponCodeTaken
+	}
+	return telemetry.RecordError(span, err)
+}
+
+// Delete removes a coupon nobody redeemed; redeemed ones stay as a record
+// of the discounts given.
+func (s *CouponService) Delete(ctx context.Context, id uint) error {
+	ctx, span := tracer.Start(ctx, "CouponService.Delete")
+	defer span.End()
+
+	coupon, err := s.Get(ctx, id)
+	if err != nil {
+		return telemetry.RecordError(span, err)
+	}
+	if coupon.UsedCount > 0 {
+		return telemetry.RecordError(span, ErrCouponInUse)
+	}
+	return telemetry.RecordError(span, s.couponRepo.Delete(ctx, id))
+}
+
+// Evaluate applies the coupon with the code to the items for the user,
+// without redeeming it.
+func (s *CouponService) Evaluate(ctx context.Context, userID uint, code string, items []CartItem) (*CouponEvaluation, error) {
+	ctx, span := tracer.Start(ctx, "CouponService.Evaluate")
+	defer span.End()
+
+	_, evaluation, err := s.evaluate(ctx, userID, code, items)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return evaluation, nil
+}
+
+// Redeem applies the coupon like Evaluate and counts the redemption, for
+// the checkout that places the order.
+func (s *CouponService) Redeem(ctx context.Context, userID uint, code string, items []CartItem) (*CouponEvaluation, error) {
+	ctx, span := tracer.Start(ctx, "CouponService.Redeem")
+	defer span.End()
+
+	coupon, evaluation, err := s.evaluate(ctx, userID, code, items)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	err = s.couponRepo.Redeem(ctx, coupon, userID, s.now())
+	switch {
+	case errors.Is(err, repository.ErrCouponExhausted):
+		err = ErrCouponUsageLimit
+	case errors.Is(err, repository.ErrCouponUserExhausted):
+		err = ErrCouponUserLimit
+	}
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return evaluation, nil
+}
+
+// Release gives back a redemption, as when the order that used the coupon
+// is cancelled.
+func (s *CouponService) Release(ctx context.Context, couponID, userID uint) error {
+	ctx, span := tracer.Start(ctx, "CouponService.Release")
+	defer span.End()
+
+	return telemetry.RecordError(span, s.couponRepo.Release(ctx, couponID, userID))
+}
+
+func (s *CouponService) evaluate(ctx context.Context, userID uint, code string, items []CartItem) (*models.Coupon, *CouponEvaluation, error) {
+	coupon, err := s.couponRepo.GetByCode(ctx, normalizeCouponCode(code))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrCouponNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lines, err := s.lineItems(ctx, items)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	uses, err := s.couponRepo.UserUses(ctx, coupon.ID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	evaluation, err := EvaluateCoupon(coupon, lines, s.now(), uses)
+	if err != nil {
+		return nil, nil, err
+	}
+	return coupon, evaluation, nil
+}
+
+// lineItems prices the items at the current effective price of their
+// product or variant.
+func (s *CouponService) lineItems(ctx context.Context, items []CartItem) ([]LineItem, error) {
+	lines := make([]LineItem, 0, len(items))
+	for _, item := range items {
+		product, err := s.productRepo.GetByID(ctx, item.ProductID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = fmt.Errorf("%w: %d", ErrProductNotFound, item.ProductID)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line := LineItem{
+			ProductID: product.ID,
+			SKU:       product.SKU,
+			Price:     product.EffectivePrice,
+			Quantity:  item.Quantity,
+		}
+		if product.Category != nil {
+			line.Categories = categoryAncestors(product.Category.Path)
+		}
+
+		if item.VariantID != 0 {
+			variant := findVariant(product, item.VariantID)
+			if variant == nil {
+				return nil, fmt.Errorf("%w: %d", ErrVariantNotFound, item.VariantID)
+			}
+			line.VariantID, line.VariantSKU, line.Price = variant.ID, variant.SKU, variant.FinalPrice
+		} else if len(product.Variants) > 0 {
+			return nil, fmt.Errorf("%w: %d", ErrItemNeedsVariant, product.ID)
+		}
+
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+func findVariant(product *models.Product, id uint) *models.ProductVariant {
+	for i := range product.Variants {
+		if product.Variants[i].ID == id {
+			return &product.Variants[i]
+		}
+	}
+	return nil
+}
+
+// categoryAncestors returns the IDs in a category path, the category and
+// its ancestors.
+func categoryAncestors(path string) []uint {
+	var ids []uint
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		if id, err := strconv.ParseUint(part, 10, 32); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// prepareCoupon normalizes the code and checks the rules that depend on
+// the type of the coupon, clearing the fields the type does not use.
+func prepareCoupon(coupon *models.Coupon) error {
+	coupon.Code = normalizeCouponCode(coupon.Code)
+	if coupon.Code == "" {
+		return fmt.Errorf("%w: code is required", ErrInvalidCoupon)
+	}
+	if coupon.StartsAt != nil && coupon.EndsAt != nil && !coupon.EndsAt.After(*coupon.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidCoupon)
+	}
+	if coupon.MinSubtotal.IsNegative() {
+		return fmt.Errorf("%w: min_subtotal cannot be negative", ErrInvalidCoupon)
+	}
+	if coupon.UsageLimit != nil && *coupon.UsageLimit < 1 {
+		return fmt.Errorf("%w: usage_limit must be at least 1", ErrInvalidCoupon)
+	}
+	if coupon.PerUserLimit != nil && *coupon.PerUserLimit < 1 {
+		return fmt.Errorf("%w: per_user_limit must be at least 1", ErrInvalidCoupon)
+	}
+
+	percent, amount, buy, get := coupon.Percent, coupon.Amount, coupon.BuyQuantity, coupon.GetQuantity
+	coupon.Percent, coupon.Amount, coupon.BuyQuantity, coupon.GetQuantity = 0, money.FromCents(0), 0, 0
+
+	switch coupon.Type {
+	case models.CouponPercentage:
+		if percent < 1 || percent > 100 {
+			return fmt.Errorf("%w: percent must be between 1 and 100", ErrInvalidCoupon)
+		}
+		coupon.Percent = percent
+	case models.CouponFixed:
+		if !amount.IsPositive() {
+			return fmt.Errorf("%w: amount must be positive", ErrInvalidCoupon)
+		}
+		coupon.Amount = amount
+	case models.CouponBuyXGetY:
+		if buy < 1 || get < 1 {
+			return fmt.Errorf("%w: buy_quantity and get_quantity must be at least 1", ErrInvalidCoupon)
+		}
+		coupon.BuyQuantity, coupon.GetQuantity = buy, get
+	case models.CouponFreeShipping:
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidCoupon, coupon.Type)
+	}
+	return nil
+}
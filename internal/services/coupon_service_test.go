@@ -0,0 +1,250 @@
+// internal/services/coupon_service_test.go
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateCoupon(t *testing.T) {
+	now := time.Date(2026, 11, 27, 12, 0, 0, 0, time.UTC)
+
+	// Celular (categoria 2, filha de 1) e livro (categoria 3)
+	phone := LineItem{ProductID: 1, SKU: "PHONE", Categories: []uint{1, 2}, Price: money.MustParse("1000.00"), Quantity: 1}
+	case_ := LineItem{ProductID: 2, SKU: "CASE", Categories: []uint{1, 2}, Price: money.MustParse("50.00"), Quantity: 2}
+	book := LineItem{ProductID: 3, SKU: "BOOK", Categories: []uint{3}, Price: money.MustParse("40.00"), Quantity: 1}
+	cart := []LineItem{phone, case_, book}
+
+	t.Run("✅ Percentual sobre todos os itens", func(t *testing.T) {
+		coupon := &models.Coupon{Code: "DEZ", Type: models.CouponPercentage, Percent: 10, Active: true}
+
+		evaluation, err := EvaluateCoupon(coupon, cart, now, 0)
+		require.NoError(t, err)
+		assert.Equal(t, money.MustParse("1140.00"), evaluation.Subtotal)
+		assert.Equal(t, money.MustParse("114.00"), evaluation.Discount)
+		assert.Equal(t, money.MustParse("1026.00"), evaluation.Total)
+		assert.Equal(t, money.MustParse("10.00"), evaluation.Lines[1].Discount)
+	})
+
+	t.Run("✅ Categoria incluída vale para subcategorias e exclusão vence", func(t *testing.T) {
+		coupon := &models.Coupon{
+			Code: "ELETRO", Type: models.CouponPercentage, Percent: 10, Active: true,
+			IncludeCategories: models.IDList{1},
+			ExcludeSKUs:       models.StringList{"PHONE"},
+		}
+
+		evaluation, err := EvaluateCoupon(coupon, cart, now, 0)
+		require.NoError(t, err)
+		assert.False(t, evaluation.Lines[0].Eligible, "SKU excluído não recebe desconto")
+		assert.True(t, evaluation.Lines[1].Eligible)
+		assert.False(t, evaluation.Lines[2].Eligible, "Livro está fora da categoria")
+		assert.Equal(t, money.MustParse("10.00"), evaluation.Discount)
+	})
+
+	t.Run("✅ Valor fixo é distribuído proporcionalmente e limitado aos itens elegíveis", func(t *testing.T) {
+		coupon := &models.Coupon{Code: "CINQUENTA", Type: models.CouponFixed, Amount: money.MustParse("50.00"), Active: true, IncludeSKUs: models.StringList{"CASE", "BOOK"}}
+
+		evaluation, err := EvaluateCoupon(coupon, cart, now, 0)
+		require.NoError(t, err)
+		assert.Equal(t, money.MustParse("50.00"), evaluation.Discount)
+		assert.Equal(t, money.MustParse("35.72"), evaluation.Lines[1].Discount)
+		assert.Equal(t, money.MustParse("14.28"), evaluation.Lines[2].Discount)
+
+		coupon.Amount = money.MustParse("500.00")
+		evaluation, err = EvaluateCoupon(coupon, cart, now, 0)
+		require.NoError(t, err)
+		assert.Equal(t, money.MustParse("140.00"), evaluation.Discount, "Desconto não passa do valor dos itens elegíveis")
+	})
+
+	t.Run("✅ Frete grátis não muda o total dos itens", func(t *testing.T) {
+		coupon := &models.Coupon{Code: "FRETE", Type: models.CouponFreeShipping, Active: true}
+
+		evaluation, err := EvaluateCoupon(coupon, cart, now, 0)
+		require.NoError(t, err)
+		assert.True(t, evaluation.FreeShipping)
+		assert.True(t, evaluation.Discount.IsZero())
+	})
+
+	t.Run("✅ Leve 2, ganhe 1 dá os itens mais baratos", func(t *testing.T) {
+		coupon := &models.Coupon{Code: "LEVE3", Type: models.CouponBuyXGetY, BuyQuantity: 2, GetQuantity: 1, Active: true}
+
+		// Unidades: 1000, 50, 50, 40 -> um grupo de 3 (1000, 50 pagos; 50 grátis)
+		evaluation, err := EvaluateCoupon(coupon, cart, now, 0)
+		require.NoError(t, err)
+		assert.Equal(t, money.MustParse("50.00"), evaluation.Discount)
+		assert.Equal(t, money.MustParse("50.00"), evaluation.Lines[1].Discount)
+
+		_, err = EvaluateCoupon(coupon, []LineItem{phone, book}, now, 0)
+		assert.ErrorIs(t, err, ErrCouponQuantityNotReached)
+	})
+
+	t.Run("❌ Cupom fora da janela, inativo ou esgotado", func(t *testing.T) {
+		before, after := now.Add(time.Hour), now.Add(-time.Hour)
+		limit := 1
+
+		cases := []struct {
+			coupon   models.Coupon
+			userUses int
+			want     error
+		}{
+			{models.Coupon{Type: models.CouponFreeShipping}, 0, ErrCouponInactive},
+			{models.Coupon{Type: models.CouponFreeShipping, Active: true, StartsAt: &before}, 0, ErrCouponNotStarted},
+			{models.Coupon{Type: models.CouponFreeShipping, Active: true, EndsAt: &after}, 0, ErrCouponExpired},
+			{models.Coupon{Type: models.CouponFreeShipping, Active: true, UsageLimit: &limit, UsedCount: 1}, 0, ErrCouponUsageLimit},
+			{models.Coupon{Type: models.CouponFreeShipping, Active: true, PerUserLimit: &limit}, 1, ErrCouponUserLimit},
+			{models.Coupon{Type: models.CouponFreeShipping, Active: true, MinSubtotal: money.MustParse("2000.00")}, 0, ErrCouponMinSubtotal},
+			{models.Coupon{Type: models.CouponFreeShipping, Active: true, IncludeSKUs: models.StringList{"TV"}}, 0, ErrCouponNoEligibleItems},
+		}
+		for _, tc := range cases {
+			_, err := EvaluateCoupon(&tc.coupon, cart, now, tc.userUses)
+			assert.ErrorIs(t, err, tc.want)
+		}
+	})
+}
+
+func TestCouponService(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	productRepo := repository.NewProductRepository(db)
+	couponService := NewCouponService(repository.NewCouponRepository(db), productRepo)
+	productService := NewProductService(productRepo, nil)
+	user := testutils.CreateTestUser(t, db)
+	ctx := context.Background()
+
+	product := testutils.CreateTestProduct(t, db)
+	shoes := &models.Product{
+		Name: "Nike Air Max 90", SKU: "NIKE-90", Price: money.MustParse("500.00"), Active: true,
+		Variants: []models.ProductVariant{sizeVariant("NIKE-90-40", "40", 3)},
+	}
+	require.NoError(t, productService.Create(ctx, shoes))
+
+	t.Run("✅ Criar cupom normaliza o código", func(t *testing.T) {
+		coupon := &models.Coupon{Code: " bemvindo10 ", Type: models.CouponPercentage, Percent: 10, Amount: money.MustParse("5.00"), Active: true}
+
+		require.NoError(t, couponService.Create(ctx, coupon))
+		assert.Equal(t, "BEMVINDO10", coupon.Code)
+		assert.True(t, coupon.Amount.IsZero(), "Campos de outros tipos são descartados")
+
+		err := couponService.Create(ctx, &models.Coupon{Code: "BemVindo10", Type: models.CouponFreeShipping, Active: true})
+		assert.ErrorIs(t, err, ErrCouponCodeTaken)
+	})
+
+	t.Run("❌ Criar cupom inválido para o tipo", func(t *testing.T) {
+		err := couponService.Create(ctx, &models.Coupon{Code: "SEMVALOR", Type: models.CouponFixed, Active: true})
+		assert.ErrorIs(t, err, ErrInvalidCoupon)
+
+		err = couponService.Create(ctx, &models.Coupon{Code: "LEVE", Type: models.CouponBuyXGetY, BuyQuantity: 2, Active: true})
+		assert.ErrorIs(t, err, ErrInvalidCoupon)
+
+		zero := 0
+		err = couponService.Create(ctx, &models.Coupon{Code: "NENHUM", Type: models.CouponFreeShipping, PerUserLimit: &zero, Active: true})
+		assert.ErrorIs(t, err, ErrInvalidCoupon, "Limite por cliente deve ser pelo menos 1")
+	})
+
+	t.Run("❌ Resgatar cupom com limite por cliente zerado", func(t *testing.T) {
+		zero := 0
+		coupon := &models.Coupon{Code: "ZERADO", Type: models.CouponFreeShipping, Active: true}
+		require.NoError(t, couponService.Create(ctx, coupon))
+		require.NoError(t, db.Model(coupon).Update("per_user_limit", zero).Error)
+		coupon.PerUserLimit = &zero
+
+		err := repository.NewCouponRepository(db).Redeem(ctx, coupon, user.ID, time.Now())
+		assert.ErrorIs(t, err, repository.ErrCouponUserExhausted)
+
+		found, err := couponService.Get(ctx, coupon.ID)
+		require.NoError(t, err)
+		assert.Zero(t, found.UsedCount, "Resgate recusado não conta uso")
+	})
+
+	t.Run("✅ Validar cupom usa os preços atuais", func(t *testing.T) {
+		evaluation, err := couponService.Evaluate(ctx, user.ID, "bemvindo10", []CartItem{
+			{ProductID: product.ID, Quantity: 2},
+			{ProductID: shoes.ID, VariantID: shoes.Variants[0].ID, Quantity: 1},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, product.Price.Mul(2).Add(money.MustParse("500.00")), evaluation.Subtotal)
+		assert.Equal(t, money.MustParse("50.00"), evaluation.Lines[1].Discount)
+	})
+
+	t.Run("❌ Validar cupom com item inválido", func(t *testing.T) {
+		_, err := couponService.Evaluate(ctx, user.ID, "BEMVINDO10", []CartItem{{ProductID: shoes.ID, Quantity: 1}})
+		assert.ErrorIs(t, err, ErrItemNeedsVariant)
+
+		_, err = couponService.Evaluate(ctx, user.ID, "BEMVINDO10", []CartItem{{ProductID: 9999, Quantity: 1}})
+		assert.ErrorIs(t, err, ErrProductNotFound)
+
+		_, err = couponService.Evaluate(ctx, user.ID, "NAOEXISTE", []CartItem{{ProductID: product.ID, Quantity: 1}})
+		assert.ErrorIs(t, err, ErrCouponNotFound)
+	})
+
+	t.Run("✅ Resgatar respeita o limite por cliente e a liberação devolve o uso", func(t *testing.T) {
+		perUser := 1
+		coupon := &models.Coupon{Code: "UMAVEZ", Type: models.CouponFreeShipping, PerUserLimit: &perUser, Active: true}
+		require.NoError(t, couponService.Create(ctx, coupon))
+		items := []CartItem{{ProductID: product.ID, Quantity: 1}}
+
+		_, err := couponService.Redeem(ctx, user.ID, "UMAVEZ", items)
+		require.NoError(t, err)
+		_, err = couponService.Redeem(ctx, user.ID, "UMAVEZ", items)
+		assert.ErrorIs(t, err, ErrCouponUserLimit)
+
+		require.NoError(t, couponService.Release(ctx, coupon.ID, user.ID))
+		_, err = couponService.Redeem(ctx, user.ID, "UMAVEZ", items)
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, couponService.Delete(ctx, coupon.ID), ErrCouponInUse, "Cupom usado não pode ser removido")
+	})
+
+	t.Run("✅ Resgates simultâneos não passam do limite total", func(t *testing.T) {
+		limit := 3
+		coupon := &models.Coupon{Code: "TRES", Type: models.CouponFreeShipping, UsageLimit: &limit, Active: true}
+		require.NoError(t, couponService.Create(ctx, coupon))
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		redeemed := 0
+		for userID := range uint(10) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := couponService.couponRepo.Redeem(ctx, coupon, userID+100, time.Now()); err == nil {
+					mu.Lock()
+					redeemed++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, limit, redeemed)
+		found, err := couponService.Get(ctx, coupon.ID)
+		require.NoError(t, err)
+		assert.Equal(t, limit, found.UsedCount)
+	})
+
+	t.Run("✅ Atualizar mantém os usos e remover cupom sem uso", func(t *testing.T) {
+		coupon, err := couponService.couponRepo.GetByCode(ctx, "TRES")
+		require.NoError(t, err)
+
+		update := &models.Coupon{Code: "TRES", Type: models.CouponPercentage, Percent: 5, Active: false}
+		require.NoError(t, couponService.Update(ctx, coupon.ID, update))
+		assert.Equal(t, 3, update.UsedCount)
+
+		_, err = couponService.Evaluate(ctx, user.ID, "TRES", []CartItem{{ProductID: product.ID, Quantity: 1}})
+		assert.ErrorIs(t, err, ErrCouponInactive)
+
+		fresh := &models.Coupon{Code: "NOVO", Type: models.CouponFreeShipping, Active: true}
+		require.NoError(t, couponService.Create(ctx, fresh))
+		require.NoError(t, couponService.Delete(ctx, fresh.ID))
+		_, err = couponService.Get(ctx, fresh.ID)
+		assert.ErrorIs(t, err, ErrCouponNotFound)
+	})
+}
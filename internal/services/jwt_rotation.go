@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"log"
@@ -8,6 +9,11 @@ import (
 	"time"
 )
 
+// JWTKeyManager rotates in-memory JWT signing keys. Nothing uses it yet:
+// AuthService signs and verifies tokens with JWT_SECRET, so the server does
+// not create a manager or start its rotation loop. Whoever moves token
+// signing onto it must run StartAutoRotation through the server's
+// background.Group, so shutdown waits for the loop.
 type JWTKeyManager struct {
 	currentKey   string
 	previousKey  string
@@ -74,15 +80,29 @@ func (m *JWTKeyManager) generateNewKey() {
 	m.rotationTime = time.Now()
 }
 
-func (m *JWTKeyManager) StartAutoRotation() {
+// StartAutoRotation checks the key age once a day until ctx is cancelled.
+// The returned channel is closed once the rotation goroutine has exited.
+func (m *JWTKeyManager) StartAutoRotation(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+
 	go func() {
+		defer close(done)
+
 		ticker := time.NewTicker(24 * time.Hour)
 		defer ticker.Stop()
 
-		for range ticker.C {
-			if m.ShouldRotate() {
-				m.RotateKey()
+		for {
+			select {
+			case <-ctx.Done():
+				log.Println("JWT key rotation stopped")
+				return
+			case <-ticker.C:
+				if m.ShouldRotate() {
+					m.RotateKey()
+				}
 			}
 		}
 	}()
+
+	return done
 }
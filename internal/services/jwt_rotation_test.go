@@ -0,0 +1,28 @@
+// internal/services/jwt_rotation_test.go
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWTKeyManager_StartAutoRotation(t *testing.T) {
+	t.Run("✅ Rotação automática para quando o contexto é cancelado", func(t *testing.T) {
+		manager := NewJWTKeyManager()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		done := manager.StartAutoRotation(ctx)
+		cancel()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Goroutine de rotação deve encerrar após o cancelamento")
+		}
+
+		assert.NotEmpty(t, manager.GetCurrentKey(), "Chave atual deve continuar disponível")
+	})
+}
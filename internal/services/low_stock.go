@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/pkg/background"
+	"github.com/Code-Aether/americanas-loja-api/pkg/notify"
+	"github.com/Code-Aether/americanas-loja-api/pkg/telemetry"
+)
+
+// LowStockService alerts the store team when products fall to their
+// reorder threshold. Check runs periodically; each product is alerted once
+// until it is restocked above the threshold. It listens to the stock
+// changes of ProductService to notice restocks between checks.
+type LowStockService struct {
+	alertRepo    *repository.LowStockAlertRepository
+	productRepo  *repository.ProductRepository
+	categoryRepo *repository.CategoryRepository
+	notifier     notify.Notifier
+	// defaultThreshold applies to products whose category tree sets none.
+	defaultThreshold int
+	// recipients is the To of the alerts, used by the email channel.
+	recipients string
+	// jobs resolves the alerts of restocked products, so shutdown can
+	// wait for it.
+	jobs *background.Group
+	now  func() time.Time
+}
+
+func NewLowStockService(alertRepo *repository.LowStockAlertRepository, productRepo *repository.ProductRepository, categoryRepo *repository.CategoryRepository, notifier notify.Notifier, defaultThreshold int, recipients string, jobs *background.Group) *LowStockService {
+	return &LowStockService{
+		alertRepo:        alertRepo,
+		productRepo:      productRepo,
+		categoryRepo:     categoryRepo,
+		notifier:         notifier,
+		defaultThreshold: defaultThreshold,
+		recipients:       recipients,
+		jobs:             jobs,
+		now:              time.Now,
+	}
+}
+
+// ListOpen returns the alerts of the products still at or under their
+// threshold.
+func (s *LowStockService) ListOpen(ctx context.Context) ([]models.LowStockAlert, error) {
+	ctx, span := tracer.Start(ctx, "LowStockService.ListOpen")
+	defer span.End()
+
+	alerts, err := s.alertRepo.ListOpen(ctx)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return alerts, nil
+}
+
+// Check opens and sends an alert for every active product at or under its
+// threshold that has none open, resolves the alerts of products that are
+// no longer, and returns how many alerts it opened.
+func (s *LowStockService) Check(ctx context.Context) (int, error) {
+	ctx, span := tracer.Start(ctx, "LowStockService.Check")
+	defer span.End()
+
+	thresholds, err := s.thresholds(ctx)
+	if err != nil {
+		return 0, telemetry.RecordError(span, err)
+	}
+
+	// Every low product is under the highest threshold; the ones under
+	// their own are kept.
+	candidates, err := s.productRepo.GetLowStock(ctx, thresholds.max)
+	if err != nil {
+		return 0, telemetry.RecordError(span, err)
+	}
+	low := make(map[uint]bool, len(candidates))
+	for i := range candidates {
+		if candidates[i].Stock <= thresholds.of(&candidates[i]) {
+			low[candidates[i].ID] = true
+		}
+	}
+
+	open, err := s.alertRepo.ListOpen(ctx)
+	if err != nil {
+		return 0, telemetry.RecordError(span, err)
+	}
+	alerted := make(map[uint]bool, len(open))
+	for _, alert := range open {
+		if low[alert.ProductID] {
+			alerted[alert.ProductID] = true
+			continue
+		}
+		if _, err := s.alertRepo.Resolve(ctx, alert.ProductID, s.now()); err != nil {
+			return 0, telemetry.RecordError(span, err)
+		}
+	}
+
+	opened := 0
+	for i := range candidates {
+		product := &candidates[i]
+		if !low[product.ID] || alerted[product.ID] {
+			continue
+		}
+
+		sent, err := s.alert(ctx, product, thresholds.of(product))
+		if err != nil {
+			return opened, telemetry.RecordError(span, err)
+		}
+		if sent {
+			opened++
+		}
+	}
+	return opened, nil
+}
+
+// alert opens the alert of the product and sends it. An alert whose notice
+// fails is removed, so the next check tries again.
+func (s *LowStockService) alert(ctx context.Context, product *models.Product, threshold int) (bool, error) {
+	alert := &models.LowStockAlert{ProductID: product.ID, Stock: product.Stock, Threshold: threshold}
+
+	opened, err := s.alertRepo.Open(ctx, alert)
+	if err != nil || !opened {
+		return false, err
+	}
+
+	if err := s.notifier.Notify(ctx, lowStockMessage(s.recipients, product, threshold)); err != nil {
+		log.Printf("failed to send low stock alert of product %d: %v", product.ID, err)
+		if err := s.alertRepo.Delete(ctx, alert.ID); err != nil {
+			log.Printf("failed to remove low stock alert %d: %v", alert.ID, err)
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// Run checks the stock every interval until ctx is cancelled.
+func (s *LowStockService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		opened, err := s.Check(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Failed to check low stock: %v", err)
+		}
+		if opened > 0 {
+			log.Printf("Opened %d low stock alerts", opened)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// StockChanged resolves the alert of a restocked product right away, so a
+// product restocked and sold out again between two checks is alerted again.
+// It runs in the background, and shutdown waits for it.
+func (s *LowStockService) StockChanged(ctx context.Context, product *models.Product, previous int) {
+	if product.Stock <= previous {
+		return
+	}
+
+	restocked := *product
+	s.jobs.Go(func() {
+		thresholds, err := s.thresholds(ctx)
+		if err != nil {
+			log.Printf("failed to load reorder thresholds: %v", err)
+			return
+		}
+		if restocked.Stock <= thresholds.of(&restocked) {
+			return
+		}
+		if _, err := s.alertRepo.Resolve(ctx, restocked.ID, s.now()); err != nil {
+			log.Printf("failed to resolve low stock alert of product %d: %v", restocked.ID, err)
+		}
+	})
+}
+
+// reorderThresholds resolves the threshold of each product.
+type reorderThresholds struct {
+	// categories holds the threshold of every category that sets one or
+	// has an ancestor that does.
+	categories map[uint]int
+	fallback   int
+	// max is the highest threshold of any product.
+	max int
+}
+
+func (t *reorderThresholds) of(product *models.Product) int {
+	if product.ReorderThreshold != nil {
+		return *product.ReorderThreshold
+	}
+	if product.CategoryID != nil {
+		if threshold, ok := t.categories[*product.CategoryID]; ok {
+			return threshold
+		}
+	}
+	return t.fallback
+}
+
+// thresholds gives each category the threshold of the nearest category up
+// its tree that sets one.
+func (s *LowStockService) thresholds(ctx context.Context) (*reorderThresholds, error) {
+	categories, err := s.categoryRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	productMax, err := s.productRepo.MaxReorderThreshold(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	own := make(map[string]int, len(categories))
+	for _, category := range categories {
+		if category.ReorderThreshold != nil {
+			own[strconv.FormatUint(uint64(category.ID), 10)] = *category.ReorderThreshold
+		}
+	}
+
+	t := &reorderThresholds{
+		categories: make(map[uint]int, len(categories)),
+		fallback:   s.defaultThreshold,
+		max:        max(s.defaultThreshold, productMax),
+	}
+	for _, category := range categories {
+		path := strings.Split(strings.Trim(category.Path, "/"), "/")
+		for i := len(path) - 1; i >= 0; i-- {
+			if threshold, ok := own[path[i]]; ok {
+				t.categories[category.ID] = threshold
+				t.max = max(t.max, threshold)
+				break
+			}
+		}
+	}
+	return t, nil
+}
+
+func lowStockMessage(recipients string, product *models.Product, threshold int) notify.Message {
+	return notify.Message{
+		Event:   notify.EventLowStock,
+		To:      recipients,
+		Subject: fmt.Sprintf("Estoque baixo: %s (%s)", product.Name, product.SKU),
+		Body:    fmt.Sprintf("O produto %s (SKU %s) está com %d unidades em estoque, no limite de reposição de %d unidades.", product.Name, product.SKU, product.Stock, threshold),
+		Data: map[string]any{
+			"product_id": product.ID,
+			"sku":        product.SKU,
+			"name":       product.Name,
+			"stock":      product.Stock,
+			"threshold":  threshold,
+		},
+	}
+}
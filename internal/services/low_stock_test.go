@@ -0,0 +1,133 @@
+// internal/services/low_stock_test.go
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
+	"github.com/Code-Aether/americanas-loja-api/pkg/background"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingNotifier simula um canal fora do ar
+type failingNotifier struct{}
+
+func (failingNotifier) Notify(ctx context.Context, msg notify.Message) error {
+	return errors.New("canal indisponível")
+}
+
+func TestLowStockService(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	productRepo := repository.NewProductRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	alertRepo := repository.NewLowStockAlertRepository(db)
+	productService := NewProductService(productRepo, nil)
+	notifier := notify.NewMemoryNotifier()
+	jobs := &background.Group{}
+	lowStockService := NewLowStockService(alertRepo, productRepo, categoryRepo, notifier, 5, "estoque@loja.com", jobs)
+	productService.AddStockListener(lowStockService)
+	ctx := context.Background()
+
+	// Eletrônicos define limite 10, herdado por Celulares
+	electronics := testutils.CreateTestCategory(t, db, "Eletrônicos", nil)
+	require.NoError(t, db.Model(electronics).Update("reorder_threshold", 10).Error)
+	phones := testutils.CreateTestCategory(t, db, "Celulares", electronics)
+	books := testutils.CreateTestCategory(t, db, "Livros", nil)
+
+	ownThreshold := 2
+	newProduct := func(t *testing.T, sku string, stock int, category *models.Category, threshold *int) *models.Product {
+		product := &models.Product{Name: "Produto " + sku, SKU: sku, Price: money.MustParse("10.00"), Stock: stock, CategoryID: &category.ID, Active: true, ReorderThreshold: threshold}
+		require.NoError(t, productService.Create(ctx, product))
+		return product
+	}
+
+	phone := newProduct(t, "LOW-PHONE", 8, phones, nil)      // 8 <= 10 herdado: baixo
+	newProduct(t, "LOW-BOOK", 8, books, nil)                 // 8 > 5 padrão: ok
+	newProduct(t, "LOW-OWN", 3, phones, &ownThreshold)       // 3 > 2 próprio: ok
+	inactive := newProduct(t, "LOW-INACTIVE", 0, books, nil) // inativo: ignorado
+	inactive.Active = false
+	require.NoError(t, productService.Update(ctx, inactive))
+
+	openAlerts := func(t *testing.T) []models.LowStockAlert {
+		alerts, err := lowStockService.ListOpen(ctx)
+		require.NoError(t, err)
+		return alerts
+	}
+
+	t.Run("✅ Alerta produtos no limite herdado da categoria", func(t *testing.T) {
+		opened, err := lowStockService.Check(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, opened)
+
+		alerts := openAlerts(t)
+		require.Len(t, alerts, 1)
+		assert.Equal(t, phone.ID, alerts[0].ProductID)
+		assert.Equal(t, 8, alerts[0].Stock)
+		assert.Equal(t, 10, alerts[0].Threshold)
+		require.NotNil(t, alerts[0].Product)
+		assert.Equal(t, phone.SKU, alerts[0].Product.SKU)
+
+		messages := notifier.Messages()
+		require.Len(t, messages, 1)
+		assert.Equal(t, notify.EventLowStock, messages[0].Event)
+		assert.Equal(t, "estoque@loja.com", messages[0].To)
+		assert.Contains(t, messages[0].Subject, phone.SKU)
+	})
+
+	t.Run("✅ Não repete o alerta até a reposição", func(t *testing.T) {
+		require.NoError(t, productService.UpdateStock(ctx, phone.ID, -3))
+
+		opened, err := lowStockService.Check(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, opened)
+		assert.Len(t, notifier.Messages(), 1)
+	})
+
+	t.Run("✅ Reposição encerra o alerta e nova queda alerta de novo", func(t *testing.T) {
+		require.NoError(t, productService.UpdateStock(ctx, phone.ID, 20))
+
+		waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		require.NoError(t, jobs.Wait(waitCtx))
+		assert.Empty(t, openAlerts(t), "Reposição deve encerrar o alerta sem esperar a verificação")
+
+		require.NoError(t, productService.UpdateStock(ctx, phone.ID, -20))
+
+		opened, err := lowStockService.Check(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, opened)
+		assert.Len(t, notifier.Messages(), 2)
+	})
+
+	t.Run("✅ Verificação encerra alerta de produto acima do limite", func(t *testing.T) {
+		// Estoque alterado fora do ProductService
+		require.NoError(t, productRepo.UpdateStock(ctx, phone.ID, 50))
+
+		_, err := lowStockService.Check(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, openAlerts(t))
+	})
+
+	t.Run("❌ Falha no envio não deixa alerta aberto", func(t *testing.T) {
+		require.NoError(t, productRepo.UpdateStock(ctx, phone.ID, 1))
+
+		failing := NewLowStockService(alertRepo, productRepo, categoryRepo, failingNotifier{}, 5, "", jobs)
+		opened, err := failing.Check(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, opened)
+		assert.Empty(t, openAlerts(t))
+
+		// A próxima verificação tenta de novo
+		opened, err = lowStockService.Check(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, opened)
+	})
+}
@@ -0,0 +1,553 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/orders"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+	"github.com/Code-Aether/americanas-loja-api/pkg/telemetry"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPoints          = errors.New("points must be positive")
+	ErrInsufficientPoints     = errors.New("available points are not enough")
+	ErrLoyaltyReferenceNeeded = errors.New("order reference is required")
+	ErrAccrualNotFound        = errors.New("no points were earned for this order")
+	ErrAccrualReleased        = errors.New("points of this order were already released")
+	ErrLoyaltyRuleNotFound    = errors.New("loyalty rule not found")
+	ErrLoyaltyRuleExists      = errors.New("category already has a loyalty rule")
+	ErrInvalidLoyaltyRule     = errors.New("invalid loyalty rule")
+	ErrOrderNotPaid           = errors.New("order was not paid")
+)
+
+// maxLoyaltyRate is 100% in basis points.
+const maxLoyaltyRate = 10000
+
+// LoyaltyLine is a line of a paid order, for the points it earns. Paid is
+// what the customer paid for it after discounts; Categories are the
+// category of the product and its ancestors, as in LineItem.
+type LoyaltyLine struct {
+	Categories []uint
+	Paid       money.Money
+}
+
+// LoyaltyBalance is the points of a user: those available to redeem and
+// what they are worth, and those pending. Upcoming lists the pending
+// accruals in the order they are released and Expiring the available ones
+// in the order they expire.
+type LoyaltyBalance struct {
+	Available int64               `json:"available"`
+	Pending   int64               `json:"pending"`
+	Value     money.Money         `json:"value" swaggertype:"number"`
+	Upcoming  []models.LoyaltyLot `json:"upcoming"`
+	Expiring  []models.LoyaltyLot `json:"expiring"`
+}
+
+// LoyaltyService runs the cashback program. Paid orders earn points at the
+// rate of the category of each item; the points stay pending for the
+// release delay, so those of a returned order can be cancelled, and then
+// can be redeemed at checkout until they expire. Every change goes through
+// the ledger keyed by the order, so callers can retry safely.
+//
+// The checkout calls AccrueOrder when an order is paid and RedeemOrder to
+// take points off its total; refunded returns cancel the points of the
+// items returned through ReturnRefunded.
+type LoyaltyService struct {
+	loyaltyRepo  *repository.LoyaltyRepository
+	categoryRepo *repository.CategoryRepository
+	productRepo  *repository.ProductRepository
+	// defaultRate applies to items whose category tree sets no rate.
+	defaultRate  int
+	releaseDelay time.Duration
+	// validity is how long points last once released.
+	validity time.Duration
+	now      func() time.Time
+}
+
+func NewLoyaltyService(loyaltyRepo *repository.LoyaltyRepository, categoryRepo *repository.CategoryRepository, productRepo *repository.ProductRepository, defaultRate int, releaseDelay, validity time.Duration) *LoyaltyService {
+	return &LoyaltyService{
+		loyaltyRepo:  loyaltyRepo,
+		categoryRepo: categoryRepo,
+		productRepo:  productRepo,
+		defaultRate:  defaultRate,
+		releaseDelay: releaseDelay,
+		validity:     validity,
+		now:          time.Now,
+	}
+}
+
+// loyaltyListQuery fingerprints the ledger of a user, so a cursor of one
+// ledger cannot be replayed on another.
+func loyaltyListQuery(userID uint) string {
+	return pagination.Fingerprint("loyalty", fmt.Sprint(userID))
+}
+
+// Get returns the points of the user.
+func (s *LoyaltyService) Get(ctx context.Context, userID uint) (*LoyaltyBalance, error) {
+	ctx, span := tracer.Start(ctx, "LoyaltyService.Get")
+	defer span.End()
+
+	account, err := s.loyaltyRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	lots, err := s.loyaltyRepo.Lots(ctx, userID)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	balance := &LoyaltyBalance{
+		Available: account.Available,
+		Pending:   account.Pending,
+		Value:     models.PointsValue(account.Available),
+		Upcoming:  []models.LoyaltyLot{},
+		Expiring:  []models.LoyaltyLot{},
+	}
+	for _, lot := range lots {
+		if lot.Pending {
+			balance.Upcoming = append(balance.Upcoming, lot)
+		} else {
+			balance.Expiring = append(balance.Expiring, lot)
+		}
+	}
+	return balance, nil
+}
+
+// History returns a page of the ledger of the user, newest first.
+func (s *LoyaltyService) History(ctx context.Context, userID uint, limit int, cursor *pagination.Cursor) (*models.LoyaltyTransactionListing, error) {
+	ctx, span := tracer.Start(ctx, "LoyaltyService.History")
+	defer span.End()
+
+	query := loyaltyListQuery(userID)
+	if cursor != nil && cursor.Query != query {
+		return nil, telemetry.RecordError(span, pagination.ErrInvalidCursor)
+	}
+
+	transactions, next, prev, err := s.loyaltyRepo.ListTransactions(ctx, userID, limit, cursor)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	for _, c := range []*pagination.Cursor{next, prev} {
+		if c != nil {
+			c.Query = query
+		}
+	}
+	return &models.LoyaltyTransactionListing{Transactions: transactions, Next: next, Prev: prev}, nil
+}
+
+// Points works out the points the lines earn, each at the rate of the
+// nearest category up its tree with a rule, or the default rate.
+func (s *LoyaltyService) Points(ctx context.Context, lines []LoyaltyLine) (int64, error) {
+	ctx, span := tracer.Start(ctx, "LoyaltyService.Points")
+	defer span.End()
+
+	rules, err := s.loyaltyRepo.ListRules(ctx)
+	if err != nil {
+		return 0, telemetry.RecordError(span, err)
+	}
+	rates := make(map[uint]int, len(rules))
+	for _, rule := range rules {
+		rates[rule.CategoryID] = rule.Rate
+	}
+
+	var points int64
+	for _, line := range lines {
+		rate := s.defaultRate
+		for i := len(line.Categories) - 1; i >= 0; i-- {
+			if own, ok := rates[line.Categories[i]]; ok {
+				rate = own
+				break
+			}
+		}
+		points += line.Paid.Percent(int64(rate)).Amount
+	}
+	return points, nil
+}
+
+// Accrue earns the user the points of the paid order, pending until the
+// release delay is over. It returns nil when the order earns nothing.
+func (s *LoyaltyService) Accrue(ctx context.Context, userID uint, reference string, lines []LoyaltyLine) (*models.LoyaltyTransaction, error) {
+	ctx, span := tracer.Start(ctx, "LoyaltyService.Accrue")
+	defer span.End()
+
+	if strings.TrimSpace(reference) == "" {
+		return nil, telemetry.RecordError(span, ErrLoyaltyReferenceNeeded)
+	}
+	points, err := s.Points(ctx, lines)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	if points <= 0 {
+		return nil, nil
+	}
+
+	now := s.now()
+	availableAt := now.Add(s.releaseDelay)
+	expiresAt := availableAt.Add(s.validity)
+	txn := &models.LoyaltyTransaction{
+		UserID:         userID,
+		Type:           models.LoyaltyAccrue,
+		Points:         points,
+		Reference:      reference,
+		IdempotencyKey: "accrue:" + reference,
+		AvailableAt:    &availableAt,
+		ExpiresAt:      &expiresAt,
+	}
+	if err := s.apply(ctx, txn, now); err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return txn, nil
+}
+
+// CancelAccrual takes away the points the order earned while they are
+// still pending, as when the order is returned. Once released they stay.
+func (s *LoyaltyService) CancelAccrual(ctx context.Context, userID uint, reference string) (*models.LoyaltyTransaction, error) {
+	ctx, span := tracer.Start(ctx, "LoyaltyService.CancelAccrual")
+	defer span.End()
+
+	accrual, err := s.loyaltyRepo.GetTransactionByKey(ctx, userID, "accrue:"+reference)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrAccrualNotFound
+	}
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	txn := &models.LoyaltyTransaction{
+		UserID:         userID,
+		Type:           models.LoyaltyCancel,
+		Reference:      reference,
+		IdempotencyKey: "cancel:" + reference,
+		AccrualID:      &accrual.ID,
+	}
+	if err := s.apply(ctx, txn, s.now()); err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return txn, nil
+}
+
+// Redeem spends available points of the user at the checkout of the
+// order, failing with ErrInsufficientPoints when they are short. The
+// discount they give is models.PointsValue of txn.Points.
+func (s *LoyaltyService) Redeem(ctx context.Context, userID uint, points int64, reference string) (*models.LoyaltyTransaction, error) {
+	ctx, span := tracer.Start(ctx, "LoyaltyService.Redeem")
+	defer span.End()
+
+	if points <= 0 {
+		return nil, telemetry.RecordError(span, ErrInvalidPoints)
+	}
+	if strings.TrimSpace(reference) == "" {
+		return nil, telemetry.RecordError(span, ErrLoyaltyReferenceNeeded)
+	}
+
+	txn := &models.LoyaltyTransaction{
+		UserID:         userID,
+		Type:           models.LoyaltyRedeem,
+		Points:         points,
+		Reference:      reference,
+		IdempotencyKey: "redeem:" + reference,
+	}
+	if err := s.apply(ctx, txn, s.now()); err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return txn, nil
+}
+
+// AccrueOrder earns the customer the points of a paid order, each item at
+// the rate of the category of its product. It returns nil when the order
+// earns nothing.
+func (s *LoyaltyService) AccrueOrder(ctx context.Context, order *orders.Order) (*models.LoyaltyTransaction, error) {
+	ctx, span := tracer.Start(ctx, "LoyaltyService.AccrueOrder")
+	defer span.End()
+
+	if order.PaidAt == nil {
+		return nil, telemetry.RecordError(span, ErrOrderNotPaid)
+	}
+
+	lines := make([]LoyaltyLine, 0, len(order.Items))
+	for _, item := range order.Items {
+		line, err := s.orderLine(ctx, item.ProductID, item.Paid)
+		if err != nil {
+			return nil, telemetry.RecordError(span, err)
+		}
+		lines = append(lines, line)
+	}
+
+	txn, err := s.Accrue(ctx, order.UserID, order.ID, lines)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return txn, nil
+}
+
+// RedeemOrder takes points of the customer off the total of a pending
+// order. The points are worth at most the total.
+func (s *LoyaltyService) RedeemOrder(ctx context.Context, order *orders.Order, points int64) (*models.LoyaltyTransaction, error) {
+	ctx, span := tracer.Start(ctx, "LoyaltyService.RedeemOrder")
+	defer span.End()
+
+	switch {
+	case order.Status != orders.StatusPending:
+		return nil, telemetry.RecordError(span, ErrOrderNotPayable)
+	case models.PointsValue(points).Cmp(order.Total) > 0:
+		return nil, telemetry.RecordError(span, fmt.Errorf("%w: worth more than the order total", ErrInvalidPoints))
+	}
+
+	txn, err := s.Redeem(ctx, order.UserID, points, order.ID)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return txn, nil
+}
+
+// ReturnRefunded cancels the points the returned items earned while they
+// are pending, so a partial return keeps the points of the rest of the
+// order. Points already released stay with the customer.
+func (s *LoyaltyService) ReturnRefunded(ctx context.Context, ret *models.ReturnRequest, order *orders.Order) {
+	ctx, span := tracer.Start(ctx, "LoyaltyService.ReturnRefunded")
+	defer span.End()
+
+	if err := s.cancelReturn(ctx, ret, order); err != nil {
+		telemetry.RecordError(span, err)
+		log.Printf("Failed to cancel the loyalty points of return %d of order %s: %v", ret.ID, order.ID, err)
+	}
+}
+
+func (s *LoyaltyService) cancelReturn(ctx context.Context, ret *models.ReturnRequest, order *orders.Order) error {
+	accrual, err := s.loyaltyRepo.GetTransactionByKey(ctx, order.UserID, "accrue:"+order.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The order earned no points.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	lines := make([]LoyaltyLine, 0, len(ret.Items))
+	for _, item := range ret.Items {
+		line, err := s.orderLine(ctx, item.ProductID, item.Amount)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, line)
+	}
+	points, err := s.Points(ctx, lines)
+	if err != nil || points <= 0 {
+		return err
+	}
+
+	txn := &models.LoyaltyTransaction{
+		UserID:         order.UserID,
+		Type:           models.LoyaltyCancel,
+		Points:         points,
+		Reference:      order.ID,
+		IdempotencyKey: fmt.Sprintf("cancel:%s:return:%d", order.ID, ret.ID),
+		AccrualID:      &accrual.ID,
+	}
+	err = s.apply(ctx, txn, s.now())
+	if errors.Is(err, ErrAccrualReleased) {
+		return nil
+	}
+	return err
+}
+
+// orderLine is the loyalty line of an item of the product, paid. Items of
+// products removed since then earn the default rate.
+func (s *LoyaltyService) orderLine(ctx context.Context, productID uint, paid money.Money) (LoyaltyLine, error) {
+	line := LoyaltyLine{Paid: paid}
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return line, nil
+	}
+	if err != nil {
+		return line, err
+	}
+	if product.Category != nil {
+		line.Categories = categoryAncestors(product.Category.Path)
+	}
+	return line, nil
+}
+
+func (s *LoyaltyService) apply(ctx context.Context, txn *models.LoyaltyTransaction, now time.Time) error {
+	err := s.loyaltyRepo.Apply(ctx, txn, now)
+	switch {
+	case errors.Is(err, repository.ErrInsufficientPoints):
+		err = ErrInsufficientPoints
+	case errors.Is(err, repository.ErrAccrualReleased):
+		err = ErrAccrualReleased
+	case errors.Is(err, repository.ErrIdempotencyKeyReused):
+		err = ErrIdempotencyKeyReused
+	}
+	return err
+}
+
+// Release makes available the pending points whose delay is over and
+// returns how many accruals it released.
+func (s *LoyaltyService) Release(ctx context.Context) (int, error) {
+	ctx, span := tracer.Start(ctx, "LoyaltyService.Release")
+	defer span.End()
+
+	lots, err := s.loyaltyRepo.DueReleases(ctx, s.now())
+	if err != nil {
+		return 0, telemetry.RecordError(span, err)
+	}
+
+	released, err := s.settle(ctx, lots, models.LoyaltyRelease)
+	return released, telemetry.RecordError(span, err)
+}
+
+// Expire takes away what is left of the points that expired and returns
+// how many accruals it took from.
+func (s *LoyaltyService) Expire(ctx context.Context) (int, error) {
+	ctx, span := tracer.Start(ctx, "LoyaltyService.Expire")
+	defer span.End()
+
+	lots, err := s.loyaltyRepo.DueExpiries(ctx, s.now())
+	if err != nil {
+		return 0, telemetry.RecordError(span, err)
+	}
+
+	expired, err := s.settle(ctx, lots, models.LoyaltyExpire)
+	return expired, telemetry.RecordError(span, err)
+}
+
+// settle applies an entry of the type to each lot, keyed by its accrual.
+func (s *LoyaltyService) settle(ctx context.Context, lots []models.LoyaltyLot, kind string) (int, error) {
+	settled := 0
+	for _, lot := range lots {
+		accrualID := lot.TransactionID
+		txn := &models.LoyaltyTransaction{
+			UserID:         lot.UserID,
+			Type:           kind,
+			IdempotencyKey: fmt.Sprintf("%s:%d", kind, accrualID),
+			AccrualID:      &accrualID,
+		}
+		if err := s.loyaltyRepo.Apply(ctx, txn, s.now()); err != nil {
+			return settled, err
+		}
+		if txn.ID != 0 {
+			settled++
+		}
+	}
+	return settled, nil
+}
+
+// Run releases and expires points every interval until ctx is cancelled.
+func (s *LoyaltyService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		released, err := s.Release(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Failed to release loyalty points: %v", err)
+		}
+		if released > 0 {
+			log.Printf("Released the loyalty points of %d orders", released)
+		}
+
+		expired, err := s.Expire(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Failed to expire loyalty points: %v", err)
+		}
+		if expired > 0 {
+			log.Printf("Expired the loyalty points of %d orders", expired)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *LoyaltyService) ListRules(ctx context.Context) ([]models.LoyaltyRule, error) {
+	ctx, span := tracer.Start(ctx, "LoyaltyService.ListRules")
+	defer span.End()
+
+	rules, err := s.loyaltyRepo.ListRules(ctx)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return rules, nil
+}
+
+// CreateRule sets the rate of a category that has no rule yet.
+func (s *LoyaltyService) CreateRule(ctx context.Context, rule *models.LoyaltyRule) error {
+	ctx, span := tracer.Start(ctx, "LoyaltyService.CreateRule")
+	defer span.End()
+
+	if err := validateLoyaltyRate(rule.Rate); err != nil {
+		return telemetry.RecordError(span, err)
+	}
+	if _, err := s.categoryRepo.GetByID(ctx, rule.CategoryID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrCategoryNotFound
+		}
+		return telemetry.RecordError(span, err)
+	}
+
+	rule.ID = 0
+	err := s.loyaltyRepo.CreateRule(ctx, rule)
+	if errors.Is(err, repository.ErrLoyaltyRuleExists) {
+		err = ErrLoyaltyRuleExists
+	}
+	return telemetry.RecordError(span, err)
+}
+
+// UpdateRule changes the rate of the rule.
+func (s *LoyaltyService) UpdateRule(ctx context.Context, id uint, rate int) (*models.LoyaltyRule, error) {
+	ctx, span := tracer.Start(ctx, "LoyaltyService.UpdateRule")
+	defer span.End()
+
+	if err := validateLoyaltyRate(rate); err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	rule, err := s.getRule(ctx, id)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	rule.Rate = rate
+	if err := s.loyaltyRepo.UpdateRule(ctx, rule); err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return rule, nil
+}
+
+// DeleteRule removes the rule; the category takes the rate of its
+// ancestors again.
+func (s *LoyaltyService) DeleteRule(ctx context.Context, id uint) error {
+	ctx, span := tracer.Start(ctx, "LoyaltyService.DeleteRule")
+	defer span.End()
+
+	if _, err := s.getRule(ctx, id); err != nil {
+		return telemetry.RecordError(span, err)
+	}
+	return telemetry.RecordError(span, s.loyaltyRepo.DeleteRule(ctx, id))
+}
+
+func (s *LoyaltyService) getRule(ctx context.Context, id uint) (*models.LoyaltyRule, error) {
+	rule, err := s.loyaltyRepo.GetRule(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrLoyaltyRuleNotFound
+	}
+	return rule, err
+}
+
+func validateLoyaltyRate(rate int) error {
+	if rate < 0 || rate > maxLoyaltyRate {
+		return fmt.Errorf("%w: rate must be between 0 and %d basis points", ErrInvalidLoyaltyRule, maxLoyaltyRate)
+	}
+	return nil
+}
@@ -0,0 +1,349 @@
+// internal/services/loyalty_service_test.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/orders"
+	"github.com/Code-Aether/americanas-loja-api/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// loyaltyCategories cria Eletrônicos (5%) com as subcategorias Celulares,
+// sem regra, e Games (10%), e Livros, sem regra.
+func loyaltyCategories(t *testing.T, db *gorm.DB, loyaltyService *LoyaltyService) (celulares, games, livros *models.Category) {
+	ctx := context.Background()
+	categoryRepo := repository.NewCategoryRepository(db)
+	create := func(name string, parent *models.Category) *models.Category {
+		category := &models.Category{Name: name, Slug: name, Active: true}
+		require.NoError(t, categoryRepo.Create(ctx, category, parent))
+		return category
+	}
+
+	eletronicos := create("eletronicos", nil)
+	celulares = create("celulares", eletronicos)
+	games = create("games", eletronicos)
+	livros = create("livros", nil)
+
+	require.NoError(t, loyaltyService.CreateRule(ctx, &models.LoyaltyRule{CategoryID: eletronicos.ID, Rate: 500}))
+	require.NoError(t, loyaltyService.CreateRule(ctx, &models.LoyaltyRule{CategoryID: games.ID, Rate: 1000}))
+	return celulares, games, livros
+}
+
+func loyaltyLine(category *models.Category, paid string) LoyaltyLine {
+	return LoyaltyLine{Categories: categoryAncestors(category.Path), Paid: money.MustParse(paid)}
+}
+
+func TestLoyaltyService(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	loyaltyService := NewLoyaltyService(repository.NewLoyaltyRepository(db), repository.NewCategoryRepository(db), repository.NewProductRepository(db), 100, 30*24*time.Hour, 90*24*time.Hour)
+	user := testutils.CreateTestUser(t, db)
+	ctx := context.Background()
+
+	now := time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC)
+	loyaltyService.now = func() time.Time { return now }
+	celulares, games, livros := loyaltyCategories(t, db, loyaltyService)
+
+	balanceOf := func(t *testing.T) *LoyaltyBalance {
+		balance, err := loyaltyService.Get(ctx, user.ID)
+		require.NoError(t, err)
+		return balance
+	}
+
+	t.Run("✅ Pontos pela regra da categoria mais próxima", func(t *testing.T) {
+		points, err := loyaltyService.Points(ctx, []LoyaltyLine{
+			loyaltyLine(celulares, "1000.00"), // 5% de Eletrônicos
+			loyaltyLine(games, "200.00"),      // 10% de Games
+			loyaltyLine(livros, "50.00"),      // 1% padrão
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(5000+2000+50), points)
+	})
+
+	t.Run("✅ Acúmulo fica pendente até o prazo", func(t *testing.T) {
+		accrual, err := loyaltyService.Accrue(ctx, user.ID, "order:1", []LoyaltyLine{loyaltyLine(celulares, "1000.00")})
+		require.NoError(t, err)
+		assert.Equal(t, int64(5000), accrual.Points)
+		assert.Equal(t, now.Add(30*24*time.Hour), accrual.AvailableAt.UTC())
+		assert.Equal(t, now.Add(120*24*time.Hour), accrual.ExpiresAt.UTC())
+
+		balance := balanceOf(t)
+		assert.Equal(t, int64(0), balance.Available)
+		assert.Equal(t, int64(5000), balance.Pending)
+		require.Len(t, balance.Upcoming, 1)
+
+		released, err := loyaltyService.Release(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, released, "O prazo ainda não passou")
+
+		now = now.Add(30 * 24 * time.Hour)
+		released, err = loyaltyService.Release(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, released)
+
+		balance = balanceOf(t)
+		assert.Equal(t, int64(5000), balance.Available)
+		assert.Equal(t, int64(0), balance.Pending)
+		assert.Equal(t, money.MustParse("50.00"), balance.Value)
+		assert.Empty(t, balance.Upcoming)
+		require.Len(t, balance.Expiring, 1)
+	})
+
+	t.Run("✅ Repetir o pedido não acumula de novo", func(t *testing.T) {
+		again, err := loyaltyService.Accrue(ctx, user.ID, "order:1", []LoyaltyLine{loyaltyLine(celulares, "1000.00")})
+		require.NoError(t, err)
+		assert.Equal(t, models.LoyaltyAccrue, again.Type)
+		assert.Equal(t, int64(5000), balanceOf(t).Available)
+
+		_, err = loyaltyService.Accrue(ctx, user.ID, "order:1", []LoyaltyLine{loyaltyLine(celulares, "10.00")})
+		assert.ErrorIs(t, err, ErrIdempotencyKeyReused)
+
+		released, err := loyaltyService.Release(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, released)
+	})
+
+	t.Run("✅ Pedido sem pontos não gera lançamento", func(t *testing.T) {
+		accrual, err := loyaltyService.Accrue(ctx, user.ID, "order:0", []LoyaltyLine{loyaltyLine(livros, "0.40")})
+		require.NoError(t, err)
+		assert.Nil(t, accrual)
+	})
+
+	t.Run("✅ Resgate usa primeiro os pontos que expiram antes", func(t *testing.T) {
+		_, err := loyaltyService.Accrue(ctx, user.ID, "order:2", []LoyaltyLine{loyaltyLine(games, "300.00")})
+		require.NoError(t, err)
+		now = now.Add(30 * 24 * time.Hour)
+		_, err = loyaltyService.Release(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(8000), balanceOf(t).Available)
+
+		redeem, err := loyaltyService.Redeem(ctx, user.ID, 6000, "order:3")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2000), redeem.AvailableAfter)
+		assert.Equal(t, money.MustParse("60.00"), models.PointsValue(redeem.Points))
+
+		balance := balanceOf(t)
+		require.Len(t, balance.Expiring, 1, "Os pontos do order:1 foram todos usados")
+		assert.Equal(t, int64(2000), balance.Expiring[0].Remaining)
+
+		again, err := loyaltyService.Redeem(ctx, user.ID, 6000, "order:3")
+		require.NoError(t, err)
+		assert.Equal(t, redeem.ID, again.ID)
+		assert.Equal(t, int64(2000), balanceOf(t).Available)
+	})
+
+	t.Run("❌ Pontos insuficientes e valores inválidos", func(t *testing.T) {
+		_, err := loyaltyService.Redeem(ctx, user.ID, 2001, "order:4")
+		assert.ErrorIs(t, err, ErrInsufficientPoints)
+		_, err = loyaltyService.Redeem(ctx, user.ID, 0, "order:4")
+		assert.ErrorIs(t, err, ErrInvalidPoints)
+		_, err = loyaltyService.Redeem(ctx, user.ID, 100, " ")
+		assert.ErrorIs(t, err, ErrLoyaltyReferenceNeeded)
+		assert.Equal(t, int64(2000), balanceOf(t).Available)
+	})
+
+	t.Run("✅ Devolução cancela os pontos pendentes", func(t *testing.T) {
+		_, err := loyaltyService.Accrue(ctx, user.ID, "order:5", []LoyaltyLine{loyaltyLine(livros, "100.00")})
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), balanceOf(t).Pending)
+
+		cancel, err := loyaltyService.CancelAccrual(ctx, user.ID, "order:5")
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), cancel.Points)
+		assert.Equal(t, int64(0), cancel.PendingAfter)
+		assert.Empty(t, balanceOf(t).Upcoming)
+
+		_, err = loyaltyService.CancelAccrual(ctx, user.ID, "order:2")
+		assert.ErrorIs(t, err, ErrAccrualReleased)
+		_, err = loyaltyService.CancelAccrual(ctx, user.ID, "order:99")
+		assert.ErrorIs(t, err, ErrAccrualNotFound)
+	})
+
+	t.Run("✅ Pontos expiram no prazo e só uma vez", func(t *testing.T) {
+		now = now.Add(90 * 24 * time.Hour)
+		expired, err := loyaltyService.Expire(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, expired)
+
+		balance := balanceOf(t)
+		assert.Equal(t, int64(0), balance.Available)
+		assert.Empty(t, balance.Expiring)
+
+		expired, err = loyaltyService.Expire(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, expired)
+
+		listing, err := loyaltyService.History(ctx, user.ID, 1, nil)
+		require.NoError(t, err)
+		require.Len(t, listing.Transactions, 1)
+		assert.Equal(t, models.LoyaltyExpire, listing.Transactions[0].Type)
+		assert.Equal(t, int64(2000), listing.Transactions[0].Points)
+		assert.NotNil(t, listing.Next)
+	})
+
+	t.Run("✅ Resgates concorrentes não passam do saldo", func(t *testing.T) {
+		_, err := loyaltyService.Accrue(ctx, user.ID, "order:6", []LoyaltyLine{loyaltyLine(celulares, "200.00")})
+		require.NoError(t, err)
+		now = now.Add(30 * 24 * time.Hour)
+		_, err = loyaltyService.Release(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(1000), balanceOf(t).Available)
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		succeeded := 0
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, err := loyaltyService.Redeem(ctx, user.ID, 150, fmt.Sprintf("order:concurrent:%d", i))
+				if err == nil {
+					mu.Lock()
+					succeeded++
+					mu.Unlock()
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Equal(t, 6, succeeded)
+		assert.Equal(t, int64(100), balanceOf(t).Available)
+	})
+}
+
+func TestLoyaltyRules(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	loyaltyService := NewLoyaltyService(repository.NewLoyaltyRepository(db), repository.NewCategoryRepository(db), repository.NewProductRepository(db), 100, time.Hour, time.Hour)
+	ctx := context.Background()
+	celulares, _, _ := loyaltyCategories(t, db, loyaltyService)
+
+	t.Run("✅ Regra própria substitui a herdada", func(t *testing.T) {
+		rule := &models.LoyaltyRule{CategoryID: celulares.ID, Rate: 0}
+		require.NoError(t, loyaltyService.CreateRule(ctx, rule))
+
+		points, err := loyaltyService.Points(ctx, []LoyaltyLine{loyaltyLine(celulares, "1000.00")})
+		require.NoError(t, err)
+		assert.Zero(t, points, "Taxa zero deixa a categoria fora do programa")
+
+		updated, err := loyaltyService.UpdateRule(ctx, rule.ID, 250)
+		require.NoError(t, err)
+		assert.Equal(t, 250, updated.Rate)
+		points, err = loyaltyService.Points(ctx, []LoyaltyLine{loyaltyLine(celulares, "1000.00")})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2500), points)
+
+		require.NoError(t, loyaltyService.DeleteRule(ctx, rule.ID))
+		points, err = loyaltyService.Points(ctx, []LoyaltyLine{loyaltyLine(celulares, "1000.00")})
+		require.NoError(t, err)
+		assert.Equal(t, int64(5000), points, "Volta a valer a regra de Eletrônicos")
+	})
+
+	t.Run("❌ Regras inválidas", func(t *testing.T) {
+		err := loyaltyService.CreateRule(ctx, &models.LoyaltyRule{CategoryID: 9999, Rate: 100})
+		assert.ErrorIs(t, err, ErrCategoryNotFound)
+
+		err = loyaltyService.CreateRule(ctx, &models.LoyaltyRule{CategoryID: celulares.ID, Rate: 10001})
+		assert.ErrorIs(t, err, ErrInvalidLoyaltyRule)
+
+		rules, err := loyaltyService.ListRules(ctx)
+		require.NoError(t, err)
+		require.NotEmpty(t, rules)
+		err = loyaltyService.CreateRule(ctx, &models.LoyaltyRule{CategoryID: rules[0].CategoryID, Rate: 100})
+		assert.ErrorIs(t, err, ErrLoyaltyRuleExists)
+
+		_, err = loyaltyService.UpdateRule(ctx, 9999, 100)
+		assert.ErrorIs(t, err, ErrLoyaltyRuleNotFound)
+		assert.ErrorIs(t, loyaltyService.DeleteRule(ctx, 9999), ErrLoyaltyRuleNotFound)
+	})
+}
+
+func TestLoyaltyOrders(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8080/media", []byte("secret"))
+	require.NoError(t, err)
+
+	productRepo := repository.NewProductRepository(db)
+	loyaltyService := NewLoyaltyService(repository.NewLoyaltyRepository(db), repository.NewCategoryRepository(db), productRepo, 100, 30*24*time.Hour, 90*24*time.Hour)
+	user := testutils.CreateTestUser(t, db)
+	admin := testutils.CreateTestAdmin(t, db)
+	ctx := context.Background()
+	_, games, livros := loyaltyCategories(t, db, loyaltyService)
+
+	newProduct := func(sku string, category *models.Category) *models.Product {
+		product := &models.Product{Name: "Produto " + sku, SKU: sku, Price: money.MustParse("10.00"), Stock: 5, CategoryID: &category.ID, Active: true}
+		require.NoError(t, db.Create(product).Error)
+		return product
+	}
+	game := newProduct("LOY-GAME", games)
+	book := newProduct("LOY-BOOK", livros)
+
+	now := time.Now()
+	order := &orders.Order{ID: "PED-1", UserID: user.ID, Status: orders.StatusDelivered, Total: money.MustParse("300.00"), PaidAt: &now, DeliveredAt: &now, Items: []orders.Item{
+		{ID: 1, ProductID: game.ID, Quantity: 2, Paid: money.MustParse("200.00")}, // 10%: 2000 pontos
+		{ID: 2, ProductID: book.ID, Quantity: 1, Paid: money.MustParse("100.00")}, // 1% padrão: 100 pontos
+	}}
+
+	pendingOf := func(t *testing.T) int64 {
+		balance, err := loyaltyService.Get(ctx, user.ID)
+		require.NoError(t, err)
+		return balance.Pending
+	}
+
+	t.Run("✅ Pedido pago acumula pontos pela categoria de cada item", func(t *testing.T) {
+		unpaid := *order
+		unpaid.PaidAt = nil
+		_, err := loyaltyService.AccrueOrder(ctx, &unpaid)
+		assert.ErrorIs(t, err, ErrOrderNotPaid)
+
+		txn, err := loyaltyService.AccrueOrder(ctx, order)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2100), txn.Points)
+		assert.Equal(t, "PED-1", txn.Reference)
+
+		again, err := loyaltyService.AccrueOrder(ctx, order)
+		require.NoError(t, err)
+		assert.Equal(t, txn.ID, again.ID)
+		assert.Equal(t, int64(2100), pendingOf(t))
+	})
+
+	t.Run("❌ Resgate em pedido já pago ou acima do total", func(t *testing.T) {
+		_, err := loyaltyService.RedeemOrder(ctx, order, 100)
+		assert.ErrorIs(t, err, ErrOrderNotPayable)
+
+		pending := &orders.Order{ID: "PED-2", UserID: user.ID, Status: orders.StatusPending, Total: money.MustParse("10.00")}
+		_, err = loyaltyService.RedeemOrder(ctx, pending, 1001)
+		assert.ErrorIs(t, err, ErrInvalidPoints)
+
+		_, err = loyaltyService.RedeemOrder(ctx, pending, 1000)
+		assert.ErrorIs(t, err, ErrInsufficientPoints, "Pontos pendentes não podem ser resgatados")
+	})
+
+	t.Run("✅ Devolução reembolsada cancela só os pontos dos itens devolvidos", func(t *testing.T) {
+		returnService := NewReturnService(repository.NewReturnRepository(db), NewProductService(productRepo, nil), newFakeOrders(order), store, 7*24*time.Hour, 1<<20, time.Hour)
+		returnService.AddRefundListener(loyaltyService)
+
+		ret, err := returnService.Create(ctx, user.ID, ReturnInput{OrderID: order.ID, Reason: models.ReturnRegret, Items: []ReturnItemInput{{OrderItemID: 1, Quantity: 1}}})
+		require.NoError(t, err)
+		_, err = returnService.Approve(ctx, admin, ret.ID)
+		require.NoError(t, err)
+		_, err = returnService.Receive(ctx, ret.ID, false)
+		require.NoError(t, err)
+		ret, err = returnService.Refund(ctx, ret.ID)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(1100), pendingOf(t), "Um dos dois games devolvido: 1000 pontos cancelados")
+
+		loyaltyService.ReturnRefunded(ctx, ret, order)
+		assert.Equal(t, int64(1100), pendingOf(t), "Repetir o aviso não cancela de novo")
+	})
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/pkg/imaging"
+	"github.com/Code-Aether/americanas-loja-api/pkg/storage"
+	"github.com/Code-Aether/americanas-loja-api/pkg/telemetry"
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	thumbnailSize = 320
+	largeSize     = 1200
+	jpegQuality   = 85
+)
+
+var (
+	ErrProductNotFound      = errors.New("product not found")
+	ErrImageNotFound        = errors.New("image not found")
+	ErrImageTooLarge        = errors.New("image exceeds the maximum upload size")
+	ErrUnsupportedImageType = errors.New("unsupported image type")
+	ErrInvalidImage         = errors.New("invalid image")
+	ErrInvalidImageOrder    = errors.New("image order must list every image of the product exactly once")
+)
+
+// allowedImageTypes maps the sniffed MIME type to the extension of the
+// stored original.
+var allowedImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+type MediaService struct {
+	imageRepo   *repository.ProductImageRepository
+	productRepo *repository.ProductRepository
+	storage     storage.Storage
+	maxSize     int64
+	urlTTL      time.Duration
+}
+
+func NewMediaService(imageRepo *repository.ProductImageRepository, productRepo *repository.ProductRepository, storage storage.Storage, maxSize int64, urlTTL time.Duration) *MediaService {
+	return &MediaService{
+		imageRepo:   imageRepo,
+		productRepo: productRepo,
+		storage:     storage,
+		maxSize:     maxSize,
+		urlTTL:      urlTTL,
+	}
+}
+
+type rendition struct {
+	key         string
+	data        []byte
+	contentType string
+}
+
+// Upload stores the original image together with a JPEG thumbnail and WebP
+// renditions, then appends it to the product gallery. The content type is
+// sniffed from the bytes; the one sent by the client is ignored.
+func (s *MediaService) Upload(ctx context.Context, productID uint, data []byte) (*models.ProductImage, error) {
+	ctx, span := tracer.Start(ctx, "MediaService.Upload")
+	defer span.End()
+
+	if int64(len(data)) > s.maxSize {
+		return nil, telemetry.RecordError(span, ErrImageTooLarge)
+	}
+
+	if _, err := s.productRepo.GetByID(ctx, productID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrProductNotFound
+		}
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	contentType := mimetype.Detect(data).String()
+	ext, ok := allowedImageTypes[contentType]
+	if !ok {
+		return nil, telemetry.RecordError(span, fmt.Errorf("%w: %s", ErrUnsupportedImageType, contentType))
+	}
+
+	img, err := imaging.Decode(data)
+	if err != nil {
+		return nil, telemetry.RecordError(span, fmt.Errorf("%w: %w", ErrInvalidImage, err))
+	}
+
+	thumbnail := imaging.Fit(img, thumbnailSize)
+	thumbnailJPEG, err := imaging.EncodeJPEG(thumbnail, jpegQuality)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	thumbnailWebP, err := imaging.EncodeWebP(thumbnail)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	largeWebP, err := imaging.EncodeWebP(imaging.Fit(img, largeSize))
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	prefix := fmt.Sprintf("products/%d/%s", productID, uuid.NewString())
+	image := &models.ProductImage{
+		ProductID:        productID,
+		ContentType:      contentType,
+		Width:            img.Bounds().Dx(),
+		Height:           img.Bounds().Dy(),
+		Size:             int64(len(data)),
+		OriginalKey:      prefix + "/original" + ext,
+		ThumbnailKey:     prefix + "/thumbnail.jpg",
+		WebPKey:          prefix + "/large.webp",
+		ThumbnailWebPKey: prefix + "/thumbnail.webp",
+	}
+
+	renditions := []rendition{
+		{image.OriginalKey, data, contentType},
+		{image.ThumbnailKey, thumbnailJPEG, "image/jpeg"},
+		{image.WebPKey, largeWebP, "image/webp"},
+		{image.ThumbnailWebPKey, thumbnailWebP, "image/webp"},
+	}
+
+	for i, r := range renditions {
+		if err := s.storage.Put(ctx, r.key, bytes.NewReader(r.data), int64(len(r.data)), r.contentType); err != nil {
+			s.deleteObjects(ctx, image.Keys()[:i])
+			return nil, telemetry.RecordError(span, err)
+		}
+	}
+
+	if err := s.imageRepo.Create(ctx, image); err != nil {
+		s.deleteObjects(ctx, image.Keys())
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	if err := s.sign(ctx, image); err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	return image, nil
+}
+
+// List returns the product gallery in display order with signed URLs.
+func (s *MediaService) List(ctx context.Context, productID uint) ([]models.ProductImage, error) {
+	ctx, span := tracer.Start(ctx, "MediaService.List")
+	defer span.End()
+
+	if _, err := s.productRepo.GetByID(ctx, productID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrProductNotFound
+		}
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	images, err := s.imageRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	for i := range images {
+		if err := s.sign(ctx, &images[i]); err != nil {
+			return nil, telemetry.RecordError(span, err)
+		}
+	}
+
+	return images, nil
+}
+
+// Reorder sets the gallery order; imageIDs must contain every image of the
+// product exactly once.
+func (s *MediaService) Reorder(ctx context.Context, productID uint, imageIDs []uint) ([]models.ProductImage, error) {
+	ctx, span := tracer.Start(ctx, "MediaService.Reorder")
+	defer span.End()
+
+	images, err := s.imageRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	if len(imageIDs) != len(images) {
+		return nil, telemetry.RecordError(span, ErrInvalidImageOrder)
+	}
+
+	existing := make(map[uint]bool, len(images))
+	for _, image := range images {
+		existing[image.ID] = true
+	}
+	for _, id := range imageIDs {
+		if !existing[id] {
+			return nil, telemetry.RecordError(span, ErrInvalidImageOrder)
+		}
+		delete(existing, id)
+	}
+
+	if err := s.imageRepo.Reorder(ctx, productID, imageIDs); err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	return s.List(ctx, productID)
+}
+
+// Delete removes the image from the gallery and its files from storage.
+// Files that fail to delete are left behind rather than failing the request.
+func (s *MediaService) Delete(ctx context.Context, productID, imageID uint) error {
+	ctx, span := tracer.Start(ctx, "MediaService.Delete")
+	defer span.End()
+
+	image, err := s.imageRepo.GetByID(ctx, imageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrImageNotFound
+		}
+		return telemetry.RecordError(span, err)
+	}
+	if image.ProductID != productID {
+		return telemetry.RecordError(span, ErrImageNotFound)
+	}
+
+	if err := s.imageRepo.Delete(ctx, image.ID); err != nil {
+		return telemetry.RecordError(span, err)
+	}
+
+	s.deleteObjects(ctx, image.Keys())
+
+	return nil
+}
+
+func (s *MediaService) sign(ctx context.Context, image *models.ProductImage) error {
+	urls := &models.ImageURLs{ExpiresAt: time.Now().Add(s.urlTTL)}
+
+	targets := []struct {
+		key string
+		url *string
+	}{
+		{image.OriginalKey, &urls.Original},
+		{image.ThumbnailKey, &urls.Thumbnail},
+		{image.WebPKey, &urls.WebP},
+		{image.ThumbnailWebPKey, &urls.ThumbnailWebP},
+	}
+
+	for _, target := range targets {
+		signed, err := s.storage.SignedURL(ctx, target.key, s.urlTTL)
+		if err != nil {
+			return err
+		}
+		*target.url = signed
+	}
+
+	image.URLs = urls
+	return nil
+}
+
+// deleteObjects is best effort cleanup and keeps going after the request is
+// cancelled.
+func (s *MediaService) deleteObjects(ctx context.Context, keys []string) {
+	ctx = context.WithoutCancel(ctx)
+	for _, key := range keys {
+		_ = s.storage.Delete(ctx, key)
+	}
+}
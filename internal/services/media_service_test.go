@@ -0,0 +1,103 @@
+// internal/services/media_service_test.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
+	"github.com/Code-Aether/americanas-loja-api/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, x%height, color.RGBA{R: 255, A: 255})
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestMediaService(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8080/media", []byte("secret"))
+	require.NoError(t, err)
+
+	mediaService := NewMediaService(
+		repository.NewProductImageRepository(db),
+		repository.NewProductRepository(db),
+		store, 1<<20, time.Hour,
+	)
+	product := testutils.CreateTestProduct(t, db)
+	ctx := context.Background()
+
+	t.Run("✅ Enviar imagem gera miniatura e WebP", func(t *testing.T) {
+		image, err := mediaService.Upload(ctx, product.ID, testPNG(t, 800, 400))
+		require.NoError(t, err)
+
+		assert.Equal(t, "image/png", image.ContentType)
+		assert.Equal(t, 800, image.Width)
+		assert.Equal(t, 0, image.Position)
+		require.NotNil(t, image.URLs)
+		assert.Contains(t, image.URLs.Thumbnail, "signature=")
+
+		for _, key := range image.Keys() {
+			path, err := store.Path(key)
+			require.NoError(t, err)
+			assert.FileExists(t, path)
+		}
+	})
+
+	t.Run("❌ Tipo de arquivo não suportado", func(t *testing.T) {
+		_, err := mediaService.Upload(ctx, product.ID, []byte("%PDF-1.4 arquivo qualquer"))
+		assert.ErrorIs(t, err, ErrUnsupportedImageType)
+	})
+
+	t.Run("❌ Imagem maior que o limite", func(t *testing.T) {
+		_, err := mediaService.Upload(ctx, product.ID, make([]byte, 2<<20))
+		assert.ErrorIs(t, err, ErrImageTooLarge)
+	})
+
+	t.Run("❌ Produto inexistente", func(t *testing.T) {
+		_, err := mediaService.Upload(ctx, 9999, testPNG(t, 10, 10))
+		assert.ErrorIs(t, err, ErrProductNotFound)
+	})
+
+	t.Run("✅ Reordenar e remover imagens", func(t *testing.T) {
+		second, err := mediaService.Upload(ctx, product.ID, testPNG(t, 20, 20))
+		require.NoError(t, err)
+		assert.Equal(t, 1, second.Position)
+
+		images, err := mediaService.List(ctx, product.ID)
+		require.NoError(t, err)
+		require.Len(t, images, 2)
+		first := images[0]
+
+		_, err = mediaService.Reorder(ctx, product.ID, []uint{second.ID})
+		assert.ErrorIs(t, err, ErrInvalidImageOrder, "Ordem deve conter todas as imagens")
+
+		images, err = mediaService.Reorder(ctx, product.ID, []uint{second.ID, first.ID})
+		require.NoError(t, err)
+		assert.Equal(t, second.ID, images[0].ID)
+		assert.Equal(t, first.ID, images[1].ID)
+
+		require.NoError(t, mediaService.Delete(ctx, product.ID, first.ID))
+		path, err := store.Path(first.OriginalKey)
+		require.NoError(t, err)
+		_, err = os.Stat(path)
+		assert.True(t, os.IsNotExist(err), "Arquivos da imagem devem ser removidos")
+
+		assert.ErrorIs(t, mediaService.Delete(ctx, product.ID, first.ID), ErrImageNotFound)
+	})
+}
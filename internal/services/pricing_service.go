@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/telemetry"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSaleNotFound      = errors.New("sale not found")
+	ErrSaleOverlap       = errors.New("sale overlaps another sale of the product")
+	ErrInvalidSaleWindow = errors.New("sale must end after it starts and in the future")
+	ErrInvalidSalePrice  = errors.New("sale price must be lower than the list price")
+)
+
+type SaleInput struct {
+	SalePrice money.Money
+	StartsAt  time.Time
+	EndsAt    time.Time
+}
+
+// PricingService schedules sale prices and keeps the price history. The
+// sale price of a product is only ever set by ApplySales, which Run calls
+// at every sale boundary.
+type PricingService struct {
+	pricingRepo    *repository.PricingRepository
+	productRepo    *repository.ProductRepository
+	productService *ProductService
+	now            func() time.Time
+	// wake interrupts Run's wait when the schedule changes.
+	wake chan struct{}
+}
+
+func NewPricingService(pricingRepo *repository.PricingRepository, productRepo *repository.ProductRepository, productService *ProductService) *PricingService {
+	return &PricingService{
+		pricingRepo:    pricingRepo,
+		productRepo:    productRepo,
+		productService: productService,
+		now:            time.Now,
+		wake:           make(chan struct{}, 1),
+	}
+}
+
+// CreateSale schedules a sale price for the product. A sale that is already
+// running is applied right away.
+func (s *PricingService) CreateSale(ctx context.Context, productID uint, input SaleInput) (*models.ProductSale, error) {
+	ctx, span := tracer.Start(ctx, "PricingService.CreateSale")
+	defer span.End()
+
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrProductNotFound
+	}
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	if !input.EndsAt.After(input.StartsAt) || !input.EndsAt.After(s.now()) {
+		return nil, telemetry.RecordError(span, ErrInvalidSaleWindow)
+	}
+	if !input.SalePrice.IsPositive() || input.SalePrice.Cmp(product.Price) >= 0 {
+		return nil, telemetry.RecordError(span, ErrInvalidSalePrice)
+	}
+
+	// Stored in UTC so SQLite, which compares timestamps as text, orders
+	// them correctly whatever offset the client sent.
+	sale := &models.ProductSale{
+		ProductID: productID,
+		SalePrice: input.SalePrice,
+		StartsAt:  input.StartsAt.UTC(),
+		EndsAt:    input.EndsAt.UTC(),
+	}
+	if err := s.pricingRepo.CreateSale(ctx, sale); err != nil {
+		if errors.Is(err, repository.ErrSaleOverlap) {
+			err = ErrSaleOverlap
+		}
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	s.scheduleChanged(ctx)
+
+	return sale, nil
+}
+
+func (s *PricingService) ListSales(ctx context.Context, productID uint) ([]models.ProductSale, error) {
+	ctx, span := tracer.Start(ctx, "PricingService.ListSales")
+	defer span.End()
+
+	if err := s.checkProduct(ctx, productID); err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	sales, err := s.pricingRepo.ListSales(ctx, productID)
+	return sales, telemetry.RecordError(span, err)
+}
+
+// DeleteSale cancels a sale; a running one stops right away.
+func (s *PricingService) DeleteSale(ctx context.Context, productID, saleID uint) error {
+	ctx, span := tracer.Start(ctx, "PricingService.DeleteSale")
+	defer span.End()
+
+	sale, err := s.pricingRepo.GetSale(ctx, saleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrSaleNotFound
+		}
+		return telemetry.RecordError(span, err)
+	}
+	if sale.ProductID != productID {
+		return telemetry.RecordError(span, ErrSaleNotFound)
+	}
+
+	if err := s.pricingRepo.DeleteSale(ctx, saleID); err != nil {
+		return telemetry.RecordError(span, err)
+	}
+
+	s.scheduleChanged(ctx)
+
+	return nil
+}
+
+// History returns the price changes of the product, newest first.
+func (s *PricingService) History(ctx context.Context, productID uint) ([]models.PriceHistory, error) {
+	ctx, span := tracer.Start(ctx, "PricingService.History")
+	defer span.End()
+
+	if err := s.checkProduct(ctx, productID); err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	history, err := s.pricingRepo.ListHistory(ctx, productID)
+	return history, telemetry.RecordError(span, err)
+}
+
+// ApplySales brings the sale price of every product in line with the
+// sales running now and drops the caches of the products it changed.
+func (s *PricingService) ApplySales(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "PricingService.ApplySales")
+	defer span.End()
+
+	changed, err := s.pricingRepo.ApplySales(ctx, s.now().UTC())
+	if len(changed) > 0 {
+		s.productService.InvalidateProducts(ctx, changed...)
+		log.Printf("Sale prices changed for %d product(s)", len(changed))
+	}
+	return telemetry.RecordError(span, err)
+}
+
+// Run applies sales at every boundary until ctx is done. It sleeps until
+// the next sale starts or ends, waking up at least every interval to pick
+// up sales scheduled by other instances.
+func (s *PricingService) Run(ctx context.Context, interval time.Duration) {
+	for {
+		if err := s.ApplySales(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to apply sale prices: %v", err)
+		}
+
+		wait := interval
+		next, err := s.pricingRepo.NextBoundary(ctx, s.now().UTC())
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Failed to find the next sale boundary: %v", err)
+		}
+		if next != nil {
+			wait = min(wait, max(0, next.Sub(s.now())))
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-s.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *PricingService) checkProduct(ctx context.Context, productID uint) error {
+	_, err := s.productRepo.GetByID(ctx, productID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrProductNotFound
+	}
+	return err
+}
+
+// scheduleChanged applies the sales right away, in case the change affects
+// a running one, and makes Run recompute the next boundary.
+func (s *PricingService) scheduleChanged(ctx context.Context) {
+	if err := s.ApplySales(context.WithoutCancel(ctx)); err != nil {
+		log.Printf("Failed to apply sale prices: %v", err)
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,185 @@
+// internal/services/pricing_service_test.go
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPricingService_Sales(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	productRepo := repository.NewProductRepository(db)
+	productService := NewProductService(productRepo, nil)
+	pricingService := NewPricingService(repository.NewPricingRepository(db), productRepo, productService)
+	ctx := context.Background()
+
+	now := time.Date(2026, 11, 20, 12, 0, 0, 0, time.UTC)
+	pricingService.now = func() time.Time { return now }
+
+	product := &models.Product{Name: "Notebook", SKU: "NOTE-SALE", Price: money.MustParse("4000.00"), Stock: 5, Active: true}
+	require.NoError(t, productService.Create(ctx, product))
+	other := &models.Product{Name: "Mouse", SKU: "MOUSE-SALE", Price: money.MustParse("3500.00"), Stock: 5, Active: true}
+	require.NoError(t, productService.Create(ctx, other))
+
+	// Black Friday, in the Brazilian offset the admin would send.
+	brt := time.FixedZone("BRT", -3*60*60)
+	startsAt := time.Date(2026, 11, 27, 0, 0, 0, 0, brt)
+	endsAt := time.Date(2026, 11, 30, 0, 0, 0, 0, brt)
+
+	sale, err := pricingService.CreateSale(ctx, product.ID, SaleInput{
+		SalePrice: money.MustParse("3000.00"), StartsAt: startsAt, EndsAt: endsAt,
+	})
+	require.NoError(t, err)
+
+	t.Run("✅ Promoção futura não altera o preço", func(t *testing.T) {
+		found, err := productService.GetByID(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found.SalePrice)
+		assert.Equal(t, money.MustParse("4000.00"), found.EffectivePrice)
+	})
+
+	t.Run("❌ Promoção sobreposta", func(t *testing.T) {
+		_, err := pricingService.CreateSale(ctx, product.ID, SaleInput{
+			SalePrice: money.MustParse("3500.00"), StartsAt: endsAt.Add(-time.Hour), EndsAt: endsAt.Add(24 * time.Hour),
+		})
+		assert.ErrorIs(t, err, ErrSaleOverlap)
+	})
+
+	t.Run("❌ Janela ou preço inválidos", func(t *testing.T) {
+		_, err := pricingService.CreateSale(ctx, product.ID, SaleInput{
+			SalePrice: money.MustParse("3000.00"), StartsAt: endsAt, EndsAt: startsAt,
+		})
+		assert.ErrorIs(t, err, ErrInvalidSaleWindow)
+
+		_, err = pricingService.CreateSale(ctx, product.ID, SaleInput{
+			SalePrice: money.MustParse("3000.00"), StartsAt: now.Add(-48 * time.Hour), EndsAt: now.Add(-24 * time.Hour),
+		})
+		assert.ErrorIs(t, err, ErrInvalidSaleWindow)
+
+		_, err = pricingService.CreateSale(ctx, product.ID, SaleInput{
+			SalePrice: money.MustParse("4000.00"), StartsAt: endsAt.Add(24 * time.Hour), EndsAt: endsAt.Add(48 * time.Hour),
+		})
+		assert.ErrorIs(t, err, ErrInvalidSalePrice)
+
+		_, err = pricingService.CreateSale(ctx, 9999, SaleInput{
+			SalePrice: money.MustParse("1.00"), StartsAt: startsAt, EndsAt: endsAt,
+		})
+		assert.ErrorIs(t, err, ErrProductNotFound)
+	})
+
+	t.Run("✅ Promoção começa e termina nos limites da janela", func(t *testing.T) {
+		now = startsAt
+		require.NoError(t, pricingService.ApplySales(ctx))
+
+		found, err := productService.GetByID(ctx, product.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found.SalePrice)
+		assert.Equal(t, money.MustParse("3000.00"), *found.SalePrice)
+		assert.Equal(t, money.MustParse("4000.00"), found.ListPrice)
+		assert.Equal(t, money.MustParse("3000.00"), found.EffectivePrice)
+		require.NotNil(t, found.SaleEndsAt)
+		assert.True(t, found.SaleEndsAt.Equal(endsAt))
+
+		// Applying again at the same instant changes nothing.
+		require.NoError(t, pricingService.ApplySales(ctx))
+
+		now = endsAt
+		require.NoError(t, pricingService.ApplySales(ctx))
+
+		found, err = productService.GetByID(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found.SalePrice)
+		assert.Nil(t, found.SaleEndsAt)
+		assert.Equal(t, money.MustParse("4000.00"), found.EffectivePrice)
+
+		history, err := pricingService.History(ctx, product.ID)
+		require.NoError(t, err)
+		reasons := make([]string, len(history))
+		for i, change := range history {
+			reasons[i] = change.Reason
+		}
+		assert.Equal(t, []string{models.PriceChangeSaleEnded, models.PriceChangeSaleStarted, models.PriceChangeCreated}, reasons)
+	})
+
+	t.Run("✅ Listagem ordenada e filtrada pelo preço efetivo", func(t *testing.T) {
+		now = startsAt
+		require.NoError(t, pricingService.ApplySales(ctx))
+
+		listing, err := productService.GetAll(ctx, models.ProductFilter{Page: 1, Limit: 10, Sort: models.SortPriceAsc})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Notebook", "Mouse"}, productNames(listing.Products))
+
+		maxPrice := money.MustParse("3200.00")
+		listing, err = productService.GetAll(ctx, models.ProductFilter{Page: 1, Limit: 10, MaxPrice: &maxPrice})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Notebook"}, productNames(listing.Products))
+	})
+
+	t.Run("✅ Cancelar promoção em andamento restaura o preço", func(t *testing.T) {
+		require.NoError(t, pricingService.DeleteSale(ctx, product.ID, sale.ID))
+
+		found, err := productService.GetByID(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found.SalePrice)
+
+		sales, err := pricingService.ListSales(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Empty(t, sales)
+	})
+
+	t.Run("❌ Cancelar promoção de outro produto", func(t *testing.T) {
+		otherSale, err := pricingService.CreateSale(ctx, other.ID, SaleInput{
+			SalePrice: money.MustParse("3000.00"), StartsAt: endsAt, EndsAt: endsAt.Add(24 * time.Hour),
+		})
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, pricingService.DeleteSale(ctx, product.ID, otherSale.ID), ErrSaleNotFound)
+		assert.ErrorIs(t, pricingService.DeleteSale(ctx, product.ID, 9999), ErrSaleNotFound)
+	})
+}
+
+func TestPricingService_HistoryOnUpdate(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	productRepo := repository.NewProductRepository(db)
+	productService := NewProductService(productRepo, nil)
+	pricingService := NewPricingService(repository.NewPricingRepository(db), productRepo, productService)
+	ctx := context.Background()
+
+	product := &models.Product{Name: "Teclado", SKU: "TEC-1", Price: money.MustParse("200.00"), Stock: 5, Active: true}
+	require.NoError(t, productService.Create(ctx, product))
+
+	t.Run("✅ Mudança de preço é registrada", func(t *testing.T) {
+		product.Price = money.MustParse("180.00")
+		require.NoError(t, productService.Update(ctx, product))
+		assert.Equal(t, money.MustParse("180.00"), product.EffectivePrice)
+
+		history, err := pricingService.History(ctx, product.ID)
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.Equal(t, models.PriceChangeUpdated, history[0].Reason)
+		assert.Equal(t, money.MustParse("180.00"), history[0].Price)
+		assert.Equal(t, money.MustParse("200.00"), history[1].Price)
+	})
+
+	t.Run("✅ Atualização sem mudança de preço não é registrada", func(t *testing.T) {
+		product.Stock = 10
+		require.NoError(t, productService.Update(ctx, product))
+
+		history, err := pricingService.History(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Len(t, history, 2)
+	})
+
+	t.Run("❌ Histórico de produto inexistente", func(t *testing.T) {
+		_, err := pricingService.History(ctx, 9999)
+		assert.ErrorIs(t, err, ErrProductNotFound)
+	})
+}
@@ -0,0 +1,515 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/internal/types"
+	"github.com/Code-Aether/americanas-loja-api/pkg/background"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/telemetry"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnsupportedImportFormat = errors.New("unsupported import format, use csv or ndjson")
+	ErrInvalidImportFile       = errors.New("invalid import file")
+	ErrEmptyImport             = errors.New("import file has no rows")
+	ErrImportJobNotFound       = errors.New("import job not found")
+)
+
+// importColumns are the CSV columns, named like the JSON fields of
+// types.CreateProductRequest. variants holds a JSON array of variants.
+var importColumns = []string{"sku", "name", "description", "price", "stock", "category_id", "image_url", "variants", "reorder_threshold", "weight_grams", "length_cm", "width_cm", "height_cm"}
+
+// importProgressInterval is how many rows a job imports between progress
+// updates.
+const importProgressInterval = 25
+
+// maxImportLine bounds an NDJSON line, which may carry many variants.
+const maxImportLine = 1 << 20
+
+// ImportRow is a product read from an import file. Errors holds the
+// reasons the row could not be read, if any.
+type ImportRow struct {
+	Line    int
+	Product types.CreateProductRequest
+	Errors  []string
+}
+
+// ProductImportService upserts products in bulk by SKU. Every row goes
+// through the same validation and service calls as the product endpoints,
+// so a row fails alone instead of failing the whole file.
+type ProductImportService struct {
+	importRepo     *repository.ImportJobRepository
+	productRepo    *repository.ProductRepository
+	productService *ProductService
+	// jobs runs the background imports, so shutdown can wait for them.
+	jobs      *background.Group
+	validator *validator.Validate
+}
+
+func NewProductImportService(importRepo *repository.ImportJobRepository, productRepo *repository.ProductRepository, productService *ProductService, jobs *background.Group) *ProductImportService {
+	v := utils.NewValidator()
+	// Errors name the fields like the columns of the file.
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	return &ProductImportService{
+		importRepo:     importRepo,
+		productRepo:    productRepo,
+		productService: productService,
+		jobs:           jobs,
+		validator:      v,
+	}
+}
+
+// Parse reads the rows of a CSV or NDJSON file. Rows that cannot be read
+// are returned with their errors; only a file that is unreadable as a
+// whole fails.
+func (s *ProductImportService) Parse(r io.Reader, format string) ([]ImportRow, error) {
+	var rows []ImportRow
+	var err error
+	switch format {
+	case models.ImportFormatCSV:
+		rows, err = parseImportCSV(r)
+	case models.ImportFormatNDJSON:
+		rows, err = parseImportNDJSON(r)
+	default:
+		return nil, ErrUnsupportedImportFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrEmptyImport
+	}
+	return rows, nil
+}
+
+// parseImportCSV reads a CSV file with a header row. Spreadsheets exported
+// with a Brazilian locale separate fields with ";", so the delimiter is
+// taken from the header.
+func parseImportCSV(r io.Reader) ([]ImportRow, error) {
+	br := bufio.NewReader(r)
+	skipBOM(br)
+
+	head, _ := br.Peek(br.Size())
+	if line, _, found := bytes.Cut(head, []byte("\n")); found {
+		head = line
+	}
+
+	reader := csv.NewReader(br)
+	reader.TrimLeadingSpace = true
+	if bytes.Count(head, []byte(";")) > bytes.Count(head, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrEmptyImport
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidImportFile, err)
+	}
+
+	seen := make(map[string]bool, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if !slices.Contains(importColumns, column) {
+			return nil, fmt.Errorf("%w: unknown column %q, expected %s", ErrInvalidImportFile, column, strings.Join(importColumns, ", "))
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("%w: repeated column %q", ErrInvalidImportFile, column)
+		}
+		seen[column] = true
+		header[i] = column
+	}
+
+	var rows []ImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+
+		line, _ := reader.FieldPos(0)
+		if errors.Is(err, csv.ErrFieldCount) {
+			rows = append(rows, ImportRow{
+				Line:   line,
+				Errors: []string{fmt.Sprintf("expected %d fields, got %d", len(header), len(record))},
+			})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidImportFile, err)
+		}
+
+		rows = append(rows, importRowFromCSV(line, header, record))
+	}
+}
+
+// skipBOM drops the byte order mark Excel writes at the start of UTF-8
+// files, which would otherwise stick to the first column name.
+func skipBOM(br *bufio.Reader) {
+	if r, _, err := br.ReadRune(); err == nil && r != '\ufeff' {
+		_ = br.UnreadRune()
+	}
+}
+
+func importRowFromCSV(line int, header, record []string) ImportRow {
+	row := ImportRow{Line: line}
+	req := &row.Product
+
+	for i, column := range header {
+		value := strings.TrimSpace(record[i])
+		switch column {
+		case "sku":
+			req.SKU = value
+		case "name":
+			req.Name = value
+		case "description":
+			req.Description = value
+		case "image_url":
+			req.ImageURL = value
+		case "price":
+			if value == "" {
+				continue
+			}
+			price, err := money.Parse(value)
+			if err != nil {
+				row.Errors = append(row.Errors, "price: "+err.Error())
+			}
+			req.Price = price
+		case "stock":
+			if value == "" {
+				continue
+			}
+			stock, err := strconv.Atoi(value)
+			if err != nil {
+				row.Errors = append(row.Errors, fmt.Sprintf("stock: invalid integer %q", value))
+			}
+			req.Stock = stock
+		case "category_id":
+			if value == "" {
+				continue
+			}
+			id, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				row.Errors = append(row.Errors, fmt.Sprintf("category_id: invalid ID %q", value))
+			}
+			req.CategoryID = uint(id)
+		case "reorder_threshold":
+			if value == "" {
+				continue
+			}
+			threshold, err := strconv.Atoi(value)
+			if err != nil {
+				row.Errors = append(row.Errors, fmt.Sprintf("reorder_threshold: invalid integer %q", value))
+			}
+			req.ReorderThreshold = &threshold
+		case "weight_grams", "length_cm", "width_cm", "height_cm":
+			if value == "" {
+				continue
+			}
+			measure, err := strconv.Atoi(value)
+			if err != nil {
+				row.Errors = append(row.Errors, fmt.Sprintf("%s: invalid integer %q", column, value))
+			}
+			switch column {
+			case "weight_grams":
+				req.WeightGrams = measure
+			case "length_cm":
+				req.LengthCm = measure
+			case "width_cm":
+				req.WidthCm = measure
+			case "height_cm":
+				req.HeightCm = measure
+			}
+		case "variants":
+			if value == "" {
+				continue
+			}
+			if err := json.Unmarshal([]byte(value), &req.Variants); err != nil {
+				row.Errors = append(row.Errors, "variants: invalid JSON: "+err.Error())
+			}
+		}
+	}
+	return row
+}
+
+// parseImportNDJSON reads one product per line, in the body format of
+// POST /products. Blank lines are skipped.
+func parseImportNDJSON(r io.Reader) ([]ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLine)
+
+	var rows []ImportRow
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if line == 1 {
+			text = bytes.TrimPrefix(text, []byte("\ufeff"))
+		}
+		if len(text) == 0 {
+			continue
+		}
+
+		row := ImportRow{Line: line}
+		if err := json.Unmarshal(text, &row.Product); err != nil {
+			row.Errors = []string{"invalid JSON: " + err.Error()}
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidImportFile, err)
+	}
+	return rows, nil
+}
+
+// Import upserts the rows and reports the outcome of each one. On a dry
+// run rows are validated against the catalog but nothing is written.
+func (s *ProductImportService) Import(ctx context.Context, rows []ImportRow, dryRun bool) (*models.ImportReport, error) {
+	ctx, span := tracer.Start(ctx, "ProductImportService.Import")
+	defer span.End()
+
+	report, err := s.importRows(ctx, rows, dryRun, nil)
+	return report, telemetry.RecordError(span, err)
+}
+
+// StartImport imports the rows in the background and returns the job to
+// poll for progress. The job outlives the request that started it, and
+// shutdown waits for it; a job cut short by a restart is failed by
+// FailInterrupted.
+func (s *ProductImportService) StartImport(ctx context.Context, userID uint, format string, rows []ImportRow, dryRun bool) (*models.ImportJob, error) {
+	ctx, span := tracer.Start(ctx, "ProductImportService.StartImport")
+	defer span.End()
+
+	job := &models.ImportJob{
+		UserID:       userID,
+		Format:       format,
+		Status:       models.ImportStatusPending,
+		ImportReport: models.ImportReport{DryRun: dryRun, TotalRows: len(rows)},
+	}
+	if err := s.importRepo.Create(ctx, job); err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	// The job keeps its own copy; the caller's one is the snapshot taken
+	// before it started.
+	started := *job
+	jobCtx := context.WithoutCancel(ctx)
+	s.jobs.Go(func() { s.runJob(jobCtx, &started, rows) })
+
+	return job, nil
+}
+
+func (s *ProductImportService) GetJob(ctx context.Context, id uint) (*models.ImportJob, error) {
+	ctx, span := tracer.Start(ctx, "ProductImportService.GetJob")
+	defer span.End()
+
+	job, err := s.importRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrImportJobNotFound
+	}
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return job, nil
+}
+
+// FailInterrupted marks the jobs left pending or running by a previous run
+// of the server as failed, as they will never finish, and returns how many
+// it marked. It is called at startup, before any job starts.
+func (s *ProductImportService) FailInterrupted(ctx context.Context) (int64, error) {
+	ctx, span := tracer.Start(ctx, "ProductImportService.FailInterrupted")
+	defer span.End()
+
+	failed, err := s.importRepo.FailUnfinished(ctx, "interrupted by a server restart", time.Now())
+	return failed, telemetry.RecordError(span, err)
+}
+
+func (s *ProductImportService) runJob(ctx context.Context, job *models.ImportJob, rows []ImportRow) {
+	ctx, span := tracer.Start(ctx, "ProductImportService.runJob")
+	defer span.End()
+
+	job.Status = models.ImportStatusRunning
+	if err := s.importRepo.UpdateProgress(ctx, job); err != nil {
+		log.Printf("Import job %d: failed to update progress: %v", job.ID, err)
+	}
+
+	report, err := s.importRows(ctx, rows, job.DryRun, func(report *models.ImportReport) {
+		job.Processed = len(report.Results)
+		job.Created, job.Updated, job.Failed = report.Created, report.Updated, report.Failed
+		if err := s.importRepo.UpdateProgress(ctx, job); err != nil {
+			log.Printf("Import job %d: failed to update progress: %v", job.ID, err)
+		}
+	})
+
+	now := time.Now()
+	job.ImportReport = *report
+	job.Processed = len(report.Results)
+	job.FinishedAt = &now
+	job.Status = models.ImportStatusCompleted
+	if err != nil {
+		job.Status = models.ImportStatusFailed
+		job.Error = err.Error()
+		telemetry.RecordError(span, err)
+	}
+
+	if err := s.importRepo.Save(ctx, job); err != nil {
+		log.Printf("Import job %d: failed to save results: %v", job.ID, err)
+		return
+	}
+	log.Printf("Import job %d %s: %d created, %d updated, %d failed", job.ID, job.Status, job.Created, job.Updated, job.Failed)
+}
+
+// importRows imports the rows in order, calling progress every
+// importProgressInterval rows. It stops early only if ctx is done.
+func (s *ProductImportService) importRows(ctx context.Context, rows []ImportRow, dryRun bool, progress func(*models.ImportReport)) (*models.ImportReport, error) {
+	report := &models.ImportReport{DryRun: dryRun, TotalRows: len(rows)}
+	seen := make(map[string]int, len(rows))
+
+	for i, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		report.Add(s.importRow(ctx, row, dryRun, seen))
+
+		if progress != nil && (i+1)%importProgressInterval == 0 {
+			progress(report)
+		}
+	}
+	return report, nil
+}
+
+// importRow creates or updates the product of the row; seen maps the SKUs
+// already imported to their line, since a file may only set each once.
+func (s *ProductImportService) importRow(ctx context.Context, row ImportRow, dryRun bool, seen map[string]int) models.ImportRowResult {
+	req := row.Product
+	result := models.ImportRowResult{Row: row.Line, SKU: req.SKU}
+	fail := func(reasons ...string) models.ImportRowResult {
+		result.Status = models.ImportRowFailed
+		result.Errors = reasons
+		return result
+	}
+
+	if len(row.Errors) > 0 {
+		return fail(row.Errors...)
+	}
+	if err := s.validator.Struct(&req); err != nil {
+		return fail(validationReasons(err)...)
+	}
+	if line, ok := seen[req.SKU]; ok {
+		return fail(fmt.Sprintf("sku: already in row %d", line))
+	}
+	seen[req.SKU] = row.Line
+
+	product, err := s.productRepo.GetBySKU(ctx, req.SKU)
+	existing := err == nil
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		product = &models.Product{SKU: req.SKU, Active: true}
+	case err != nil:
+		return fail(err.Error())
+	case product.DeletedAt.Valid:
+		return fail("sku: belongs to a deleted product")
+	}
+
+	// The row replaces the product fields, as a create request would set them.
+	product.Name = req.Name
+	product.Description = req.Description
+	product.Price = req.Price
+	product.Stock = req.Stock
+	product.CategoryID = &req.CategoryID
+	product.Category = nil
+	product.ImageURL = req.ImageURL
+	product.WeightGrams = req.WeightGrams
+	product.LengthCm = req.LengthCm
+	product.WidthCm = req.WidthCm
+	product.HeightCm = req.HeightCm
+	// Rows without a threshold keep the one set on the product.
+	if req.ReorderThreshold != nil {
+		product.ReorderThreshold = req.ReorderThreshold
+	}
+
+	variants := types.VariantsFromRequest(req.Variants)
+	if existing && variants == nil && len(product.Variants) > 0 {
+		return fail(ErrVariantRequired.Error())
+	}
+
+	switch {
+	case dryRun:
+		err = s.checkRow(ctx, product, variants)
+	case !existing:
+		product.Variants = variants
+		err = s.productService.Create(ctx, product)
+	case variants != nil:
+		err = s.productService.UpdateWithVariants(ctx, product, variants)
+	default:
+		err = s.productService.Update(ctx, product)
+	}
+	if err != nil {
+		return fail(err.Error())
+	}
+
+	result.Status = models.ImportRowCreated
+	if existing {
+		result.Status = models.ImportRowUpdated
+	}
+	result.ProductID = product.ID
+	return result
+}
+
+// checkRow runs the catalog checks of a create or update without writing.
+func (s *ProductImportService) checkRow(ctx context.Context, product *models.Product, variants []models.ProductVariant) error {
+	if err := s.productService.checkCategory(ctx, product.CategoryID); err != nil {
+		return err
+	}
+	if variants == nil {
+		return nil
+	}
+	product.Variants = variants
+	return s.productService.prepareVariants(ctx, product)
+}
+
+// validationReasons lists the failed rules as "field: rule", naming fields
+// after the columns of the file.
+func validationReasons(err error) []string {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return []string{err.Error()}
+	}
+
+	reasons := make([]string, 0, len(errs))
+	for _, fe := range errs {
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		rule := fe.Tag()
+		if fe.Param() != "" {
+			rule += "=" + fe.Param()
+		}
+		reasons = append(reasons, field+": "+rule)
+	}
+	return reasons
+}
@@ -0,0 +1,205 @@
+// internal/services/product_import_test.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
+	"github.com/Code-Aether/americanas-loja-api/internal/types"
+	"github.com/Code-Aether/americanas-loja-api/pkg/background"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductImportService_Parse(t *testing.T) {
+	importService := NewProductImportService(nil, nil, nil, nil)
+
+	t.Run("✅ CSV com ponto e vírgula e BOM do Excel", func(t *testing.T) {
+		file := "\ufeffsku;name;price;stock;category_id\n" +
+			"CSV-1;Produto Um;19.90;5;1\n" +
+			"CSV-2;\"Produto; Dois\";abc;x;1\n"
+
+		rows, err := importService.Parse(strings.NewReader(file), models.ImportFormatCSV)
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+
+		assert.Equal(t, 2, rows[0].Line)
+		assert.Equal(t, "CSV-1", rows[0].Product.SKU)
+		assert.Equal(t, money.MustParse("19.90"), rows[0].Product.Price)
+		assert.Equal(t, 5, rows[0].Product.Stock)
+		assert.Equal(t, uint(1), rows[0].Product.CategoryID)
+		assert.Empty(t, rows[0].Errors)
+
+		assert.Equal(t, 3, rows[1].Line)
+		assert.Equal(t, "Produto; Dois", rows[1].Product.Name)
+		assert.Len(t, rows[1].Errors, 2)
+	})
+
+	t.Run("✅ CSV com variantes e linha com campos faltando", func(t *testing.T) {
+		file := "sku,name,price,stock,category_id,variants\n" +
+			`TEN-1,Tênis,499.90,1,1,"[{""sku"":""TEN-1-40"",""stock"":2,""options"":{""size"":""40""}}]"` + "\n" +
+			"TEN-2,Tênis\n"
+
+		rows, err := importService.Parse(strings.NewReader(file), models.ImportFormatCSV)
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+		require.Len(t, rows[0].Product.Variants, 1)
+		assert.Equal(t, "TEN-1-40", rows[0].Product.Variants[0].SKU)
+		assert.Equal(t, []string{"expected 6 fields, got 2"}, rows[1].Errors)
+	})
+
+	t.Run("✅ NDJSON ignora linhas em branco", func(t *testing.T) {
+		file := `{"sku":"ND-1","name":"Produto","price":"10.00","stock":1,"category_id":1}` + "\n\n" +
+			`{"sku":"ND-2",` + "\n"
+
+		rows, err := importService.Parse(strings.NewReader(file), models.ImportFormatNDJSON)
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+		assert.Equal(t, money.MustParse("10.00"), rows[0].Product.Price)
+		assert.Equal(t, 3, rows[1].Line)
+		assert.NotEmpty(t, rows[1].Errors)
+	})
+
+	t.Run("❌ Arquivos inválidos", func(t *testing.T) {
+		_, err := importService.Parse(strings.NewReader("sku,preco\nA,1\n"), models.ImportFormatCSV)
+		assert.ErrorIs(t, err, ErrInvalidImportFile)
+
+		_, err = importService.Parse(strings.NewReader("sku,name\n"), models.ImportFormatCSV)
+		assert.ErrorIs(t, err, ErrEmptyImport)
+
+		_, err = importService.Parse(strings.NewReader("{}"), "xlsx")
+		assert.ErrorIs(t, err, ErrUnsupportedImportFormat)
+	})
+}
+
+func TestProductImportService_Import(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	productRepo := repository.NewProductRepository(db)
+	productService := NewProductService(productRepo, nil)
+	jobs := &background.Group{}
+	importService := NewProductImportService(repository.NewImportJobRepository(db), productRepo, productService, jobs)
+	ctx := context.Background()
+
+	category := testutils.CreateTestCategory(t, db, "Importados", nil)
+	existing := &models.Product{Name: "Existente", SKU: "IMP-EXISTE", Price: money.MustParse("50.00"), Stock: 1, CategoryID: &category.ID, Active: true}
+	require.NoError(t, productService.Create(ctx, existing))
+
+	file := fmt.Sprintf("sku,name,price,stock,category_id\n"+
+		"IMP-NOVO,Produto Novo,99.90,3,%[1]d\n"+
+		"IMP-EXISTE,Existente Atualizado,45.00,2,%[1]d\n"+
+		"IMP-RUIM,X,0,3,%[1]d\n"+
+		"IMP-NOVO,Repetido,10.00,1,%[1]d\n"+
+		"IMP-CAT,Sem Categoria,10.00,1,9999\n", category.ID)
+
+	parse := func(t *testing.T) []ImportRow {
+		rows, err := importService.Parse(strings.NewReader(file), models.ImportFormatCSV)
+		require.NoError(t, err)
+		return rows
+	}
+
+	statuses := func(report *models.ImportReport) []string {
+		statuses := make([]string, len(report.Results))
+		for i, result := range report.Results {
+			statuses[i] = result.Status
+		}
+		return statuses
+	}
+
+	expected := []string{models.ImportRowCreated, models.ImportRowUpdated, models.ImportRowFailed, models.ImportRowFailed, models.ImportRowFailed}
+
+	t.Run("✅ Dry run informa o resultado sem gravar", func(t *testing.T) {
+		report, err := importService.Import(ctx, parse(t), true)
+		require.NoError(t, err)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, expected, statuses(report))
+		assert.Equal(t, []int{1, 1, 3}, []int{report.Created, report.Updated, report.Failed})
+
+		_, err = productRepo.GetBySKU(ctx, "IMP-NOVO")
+		assert.Error(t, err)
+
+		found, err := productService.GetByID(ctx, existing.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Existente", found.Name)
+	})
+
+	t.Run("✅ Importação cria e atualiza pelo SKU", func(t *testing.T) {
+		report, err := importService.Import(ctx, parse(t), false)
+		require.NoError(t, err)
+		assert.Equal(t, expected, statuses(report))
+
+		assert.Equal(t, []string{"name: min=2", "price: required"}, report.Results[2].Errors)
+		assert.Equal(t, []string{"sku: already in row 2"}, report.Results[3].Errors)
+		assert.Equal(t, []string{ErrCategoryNotFound.Error()}, report.Results[4].Errors)
+
+		created, err := productRepo.GetBySKU(ctx, "IMP-NOVO")
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, report.Results[0].ProductID)
+		assert.Equal(t, money.MustParse("99.90"), created.Price)
+
+		updated, err := productService.GetByID(ctx, existing.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Existente Atualizado", updated.Name)
+		assert.Equal(t, money.MustParse("45.00"), updated.Price)
+		assert.Equal(t, 2, updated.Stock)
+	})
+
+	t.Run("✅ Job em segundo plano reporta o progresso", func(t *testing.T) {
+		admin := testutils.CreateTestAdmin(t, db)
+
+		var rows []ImportRow
+		for i := 0; i < importProgressInterval+5; i++ {
+			rows = append(rows, ImportRow{Line: i + 1, Product: types.CreateProductRequest{
+				Name: fmt.Sprintf("Produto %d", i), SKU: fmt.Sprintf("JOB-%d", i), Price: money.MustParse("10.00"), Stock: 1, CategoryID: category.ID,
+			}})
+		}
+
+		job, err := importService.StartImport(ctx, admin.ID, models.ImportFormatNDJSON, rows, false)
+		require.NoError(t, err)
+		assert.Equal(t, models.ImportStatusPending, job.Status)
+		assert.Equal(t, len(rows), job.TotalRows)
+
+		waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		require.NoError(t, jobs.Wait(waitCtx), "Job rastreado termina antes do desligamento")
+
+		finished, err := importService.GetJob(ctx, job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ImportStatusCompleted, finished.Status)
+		assert.Equal(t, len(rows), finished.Processed)
+		assert.Equal(t, len(rows), finished.Created)
+		assert.Len(t, finished.Results, len(rows))
+		assert.NotNil(t, finished.FinishedAt)
+	})
+
+	t.Run("✅ Jobs interrompidos por um reinício falham", func(t *testing.T) {
+		jobRepo := repository.NewImportJobRepository(db)
+		running := &models.ImportJob{UserID: 1, Format: models.ImportFormatCSV, Status: models.ImportStatusRunning}
+		require.NoError(t, jobRepo.Create(ctx, running))
+
+		failed, err := importService.FailInterrupted(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), failed)
+
+		job, err := importService.GetJob(ctx, running.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ImportStatusFailed, job.Status)
+		assert.NotEmpty(t, job.Error)
+		assert.NotNil(t, job.FinishedAt)
+
+		failed, err = importService.FailInterrupted(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, failed, "Jobs concluídos não são tocados")
+	})
+
+	t.Run("❌ Job inexistente", func(t *testing.T) {
+		_, err := importService.GetJob(ctx, 9999)
+		assert.ErrorIs(t, err, ErrImportJobNotFound)
+	})
+}
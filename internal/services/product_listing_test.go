@@ -0,0 +1,75 @@
+// internal/services/product_listing_test.go
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductService_GetAllFilters(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	productService := NewProductService(repository.NewProductRepository(db), nil)
+	ctx := context.Background()
+
+	eletronicos := testutils.CreateTestCategory(t, db, "Eletrônicos", nil)
+	smartphones := testutils.CreateTestCategory(t, db, "Smartphones", eletronicos)
+	livros := testutils.CreateTestCategory(t, db, "Livros", nil)
+	games := testutils.CreateTestCategory(t, db, "Games", nil)
+
+	for _, product := range []*models.Product{
+		{Name: "iPhone", SKU: "IP-1", CategoryID: &smartphones.ID, Price: money.MustParse("7999"), Stock: 5, Active: true},
+		{Name: "Carregador", SKU: "CAR-1", CategoryID: &eletronicos.ID, Price: money.MustParse("89.9"), Stock: 0, Active: true},
+		{Name: "Romance", SKU: "LIV-1", CategoryID: &livros.ID, Price: money.MustParse("49.9"), Stock: 12, Active: true},
+		{Name: "Console", SKU: "GAM-1", CategoryID: &games.ID, Price: money.MustParse("3999"), Stock: 2, Active: true},
+	} {
+		require.NoError(t, productService.Create(ctx, product))
+	}
+
+	t.Run("✅ Faixa de preço e ordenação por preço", func(t *testing.T) {
+		listing, err := productService.GetAll(ctx, models.ProductFilter{
+			Page: 1, Limit: 10, MinPrice: ptr(money.MustParse("50")), MaxPrice: ptr(money.MustParse("5000")), Sort: models.SortPriceDesc,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Console", "Carregador"}, productNames(listing.Products))
+	})
+
+	t.Run("✅ Várias categorias e apenas com estoque", func(t *testing.T) {
+		listing, err := productService.GetAll(ctx, models.ProductFilter{
+			Page: 1, Limit: 10, Categories: []string{"eletronicos", "Livros"}, InStock: ptr(true), Sort: models.SortName,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, listing.Total)
+		assert.Equal(t, int64(2), *listing.Total)
+		assert.Equal(t, []string{"iPhone", "Romance"}, productNames(listing.Products))
+	})
+
+	t.Run("✅ Facetas ignoram o próprio filtro", func(t *testing.T) {
+		listing, err := productService.GetAll(ctx, models.ProductFilter{
+			Page: 1, Limit: 10, Categories: []string{"livros"}, InStock: ptr(true),
+		})
+		require.NoError(t, err)
+		require.Len(t, listing.Products, 1)
+
+		facets := listing.Facets
+		counts := map[string]int64{}
+		for _, facet := range facets.Categories {
+			counts[facet.Slug] = facet.Count
+		}
+		assert.Equal(t, map[string]int64{"eletronicos": 1, "smartphones": 1, "livros": 1, "games": 1}, counts,
+			"Categorias contam todos os produtos com estoque, e a pai inclui as subcategorias")
+
+		assert.Equal(t, models.AvailabilityFacet{InStock: 1, OutOfStock: 0}, facets.Availability,
+			"Disponibilidade ignora in_stock, mas respeita a categoria")
+
+		require.Len(t, facets.PriceRanges, len(models.PriceBucketLimits)+1)
+		assert.Equal(t, int64(1), facets.PriceRanges[0].Count, "Romance está na faixa até 100")
+		assert.Nil(t, facets.PriceRanges[len(facets.PriceRanges)-1].Max, "Última faixa não tem limite")
+	})
+}
@@ -0,0 +1,150 @@
+// internal/services/product_pagination_test.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductService_CursorPagination(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	productService := NewProductService(repository.NewProductRepository(db), nil)
+	ctx := context.Background()
+
+	// Prices repeat so the ID has to break ties between pages.
+	for i := 1; i <= 7; i++ {
+		require.NoError(t, productService.Create(ctx, &models.Product{
+			Name: fmt.Sprintf("Produto %d", i), SKU: fmt.Sprintf("PAG-%d", i), Price: money.FromCents(int64(1000 * ((i + 1) / 2))), Stock: 1, Active: true,
+		}))
+	}
+
+	// collect follows the next cursors from the first page to the last.
+	collect := func(t *testing.T, filter models.ProductFilter) []string {
+		var names []string
+		for page := 0; page < 10; page++ {
+			listing, err := productService.GetAll(ctx, filter)
+			require.NoError(t, err)
+			names = append(names, productNames(listing.Products)...)
+			if listing.Next == nil {
+				return names
+			}
+			filter.Cursor = listing.Next
+		}
+		t.Fatal("pagination did not end")
+		return nil
+	}
+
+	t.Run("✅ Percorrer todas as páginas sem repetir", func(t *testing.T) {
+		for _, sort := range []string{models.SortNewest, models.SortPriceAsc, models.SortPriceDesc, models.SortName} {
+			all, err := productService.GetAll(ctx, models.ProductFilter{Page: 1, Limit: 100, Sort: sort})
+			require.NoError(t, err)
+
+			names := collect(t, models.ProductFilter{Page: 1, Limit: 3, Sort: sort})
+			assert.Equal(t, productNames(all.Products), names, sort)
+		}
+	})
+
+	t.Run("✅ Inserções durante a navegação não deslocam as páginas", func(t *testing.T) {
+		first, err := productService.GetAll(ctx, models.ProductFilter{Page: 1, Limit: 3, Sort: models.SortNewest})
+		require.NoError(t, err)
+		require.NotNil(t, first.Next)
+
+		require.NoError(t, productService.Create(ctx, &models.Product{Name: "Novidade", SKU: "PAG-NEW", Price: money.MustParse("5"), Stock: 1, Active: true}))
+
+		second, err := productService.GetAll(ctx, models.ProductFilter{Limit: 3, Sort: models.SortNewest, Cursor: first.Next})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Produto 4", "Produto 3", "Produto 2"}, productNames(second.Products))
+		assert.Nil(t, second.Total)
+		assert.Nil(t, second.Facets)
+	})
+
+	t.Run("✅ Voltar para a página anterior", func(t *testing.T) {
+		first, err := productService.GetAll(ctx, models.ProductFilter{Page: 1, Limit: 3, Sort: models.SortPriceAsc})
+		require.NoError(t, err)
+		assert.Nil(t, first.Prev)
+
+		second, err := productService.GetAll(ctx, models.ProductFilter{Limit: 3, Sort: models.SortPriceAsc, Cursor: first.Next})
+		require.NoError(t, err)
+		require.NotNil(t, second.Prev)
+
+		back, err := productService.GetAll(ctx, models.ProductFilter{Limit: 3, Sort: models.SortPriceAsc, Cursor: second.Prev})
+		require.NoError(t, err)
+		assert.Equal(t, productNames(first.Products), productNames(back.Products))
+		assert.Nil(t, back.Prev)
+		assert.NotNil(t, back.Next)
+	})
+
+	t.Run("✅ Busca por relevância pagina por deslocamento", func(t *testing.T) {
+		names := collect(t, models.ProductFilter{Page: 1, Limit: 2, Search: "produto"})
+		assert.Len(t, names, 7)
+	})
+
+	t.Run("✅ Total opcional", func(t *testing.T) {
+		listing, err := productService.GetAll(ctx, models.ProductFilter{Page: 1, Limit: 3, Count: pagination.CountNone})
+		require.NoError(t, err)
+		assert.Nil(t, listing.Total)
+
+		listing, err = productService.GetAll(ctx, models.ProductFilter{Page: 1, Limit: 3, Count: pagination.CountApproximate})
+		require.NoError(t, err)
+		require.NotNil(t, listing.Total)
+		assert.Equal(t, int64(8), *listing.Total)
+	})
+
+	t.Run("❌ Cursor de outra listagem", func(t *testing.T) {
+		first, err := productService.GetAll(ctx, models.ProductFilter{Page: 1, Limit: 3, Sort: models.SortName})
+		require.NoError(t, err)
+
+		_, err = productService.GetAll(ctx, models.ProductFilter{Limit: 3, Sort: models.SortPriceAsc, Cursor: first.Next})
+		assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+	})
+}
+
+func TestUserService_List(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	userService := NewUserService(repository.NewUserRepository(db))
+	ctx := context.Background()
+
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, db.Create(&models.User{
+			Email: fmt.Sprintf("user%d@example.com", i), Password: "hash", Name: fmt.Sprintf("Usuário %d", i), Role: "user", Active: true,
+		}).Error)
+	}
+
+	t.Run("✅ Percorrer usuários por cursor", func(t *testing.T) {
+		first, err := userService.List(ctx, 2, nil, "")
+		require.NoError(t, err)
+		require.NotNil(t, first.Total)
+		assert.Equal(t, int64(5), *first.Total)
+
+		var emails []string
+		listing := first
+		for {
+			for _, user := range listing.Users {
+				emails = append(emails, user.Email)
+			}
+			if listing.Next == nil {
+				break
+			}
+			listing, err = userService.List(ctx, 2, listing.Next, "")
+			require.NoError(t, err)
+			assert.Nil(t, listing.Total)
+		}
+		assert.Equal(t, []string{
+			"user5@example.com", "user4@example.com", "user3@example.com", "user2@example.com", "user1@example.com",
+		}, emails)
+	})
+
+	t.Run("❌ Cursor de produtos", func(t *testing.T) {
+		_, err := userService.List(ctx, 2, &pagination.Cursor{ID: 1, Query: pagination.Fingerprint("products")}, "")
+		assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+	})
+}
@@ -0,0 +1,95 @@
+// internal/services/product_search_test.go
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func requireFTS5(t *testing.T, db *gorm.DB) {
+	var exists int64
+	require.NoError(t, db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE name = 'products_fts'").Scan(&exists).Error)
+	if exists == 0 {
+		t.Skip("FTS5 not available (build with -tags sqlite_fts5), skipping test")
+	}
+}
+
+func productNames(products []models.Product) []string {
+	names := make([]string, len(products))
+	for i, product := range products {
+		names[i] = product.Name
+	}
+	return names
+}
+
+func TestProductService_Search(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	productRepo := repository.NewProductRepository(db)
+	productService := NewProductService(productRepo, nil)
+	ctx := context.Background()
+	requireFTS5(t, db)
+
+	for _, product := range []*models.Product{
+		{Name: "Capa para Smartphone", Description: "Capa de silicone", SKU: "CAPA-1", Price: money.MustParse("29.9"), Active: true},
+		{Name: "Fone Bluetooth", Description: "Compatível com qualquer smartphone", SKU: "FONE-1", Price: money.MustParse("199.9"), Active: true},
+		{Name: "Smartphone Galaxy", Description: "Smartphone Samsung com câmera tripla", SKU: "GALAXY-1", Price: money.MustParse("2999.9"), Active: true},
+		{Name: "Kit Eletrônico Arduino", Description: "Placa e componentes", SKU: "KIT-1", Price: money.MustParse("149.9"), Active: true},
+	} {
+		require.NoError(t, productService.Create(ctx, product))
+	}
+
+	t.Run("✅ Resultados ordenados por relevância", func(t *testing.T) {
+		listing, err := productService.GetAll(ctx, models.ProductFilter{Page: 1, Limit: 10, Search: "smartphone"})
+		require.NoError(t, err)
+		require.NotNil(t, listing.Total)
+		assert.Equal(t, int64(3), *listing.Total)
+		assert.Equal(t, []string{"Smartphone Galaxy", "Capa para Smartphone", "Fone Bluetooth"}, productNames(listing.Products),
+			"Produto com o termo no nome e na descrição vem primeiro; só na descrição vem por último")
+	})
+
+	t.Run("✅ Busca ignora acentos e aceita prefixos", func(t *testing.T) {
+		listing, err := productService.GetAll(ctx, models.ProductFilter{Page: 1, Limit: 10, Search: "eletronico"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Kit Eletrônico Arduino"}, productNames(listing.Products))
+
+		listing, err = productService.GetAll(ctx, models.ProductFilter{Page: 1, Limit: 10, Search: "Câm galax"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Smartphone Galaxy"}, productNames(listing.Products))
+	})
+
+	t.Run("✅ Índice acompanha atualizações e remoções", func(t *testing.T) {
+		products, err := productRepo.SearchByName(ctx, "arduino")
+		require.NoError(t, err)
+		require.Len(t, products, 1)
+
+		product := &products[0]
+		product.Name = "Kit Robótica"
+		require.NoError(t, productService.Update(ctx, product))
+
+		products, err = productRepo.SearchByName(ctx, "arduino")
+		require.NoError(t, err)
+		assert.Empty(t, products, "Nome antigo não deve ser encontrado")
+
+		products, err = productRepo.SearchByName(ctx, "robotica")
+		require.NoError(t, err)
+		require.Len(t, products, 1)
+
+		require.NoError(t, productService.Delete(ctx, product.ID))
+		products, err = productRepo.SearchByName(ctx, "robotica")
+		require.NoError(t, err)
+		assert.Empty(t, products, "Produto removido não deve ser encontrado")
+	})
+
+	t.Run("✅ Sintaxe do FTS5 é tratada como texto", func(t *testing.T) {
+		_, err := productService.GetAll(ctx, models.ProductFilter{Page: 1, Limit: 10, Search: `smartphone" OR NEAR(`})
+		assert.NoError(t, err)
+	})
+}
@@ -4,16 +4,31 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Code-Aether/americanas-loja-api/internal/models"
 	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+	"github.com/Code-Aether/americanas-loja-api/pkg/telemetry"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
 	"github.com/go-redis/redis/v8"
 )
 
+// StockListener is told when the stock of a product changes, with the
+// stock it had before. It runs after the change is committed and must not
+// block the caller for long.
+type StockListener interface {
+	StockChanged(ctx context.Context, product *models.Product, previous int)
+}
+
 type ProductService struct {
-	productRepo *repository.ProductRepository
-	redis       *redis.Client
+	productRepo    *repository.ProductRepository
+	redis          *redis.Client
+	stockListeners []StockListener
 }
 
 func NewProductService(productRepo *repository.ProductRepository, redis *redis.Client) *ProductService {
@@ -23,50 +38,138 @@ func NewProductService(productRepo *repository.ProductRepository, redis *redis.C
 	}
 }
 
-func (s *ProductService) GetAll(page, limit int, category, search string) ([]models.Product, int64, error) {
-	cacheKey := fmt.Sprintf("product:page:%d:limit:%d:category:%s:search:%s",
-		page, limit, category, search)
+func (s *ProductService) GetAll(ctx context.Context, filter models.ProductFilter) (*models.ProductListing, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetAll")
+	defer span.End()
+
+	// Categories are matched by slug or ID, so "Eletrônicos" and
+	// "eletronicos" hit the same query and the same cache entry.
+	categories := make([]string, 0, len(filter.Categories))
+	for _, category := range filter.Categories {
+		if category = utils.Slugify(category); category != "" && !slices.Contains(categories, category) {
+			categories = append(categories, category)
+		}
+	}
+	slices.Sort(categories)
+	filter.Categories = categories
+	filter.Search = strings.Join(strings.Fields(strings.ToLower(filter.Search)), " ")
+
+	if filter.Sort == "" {
+		filter.Sort = models.SortNewest
+		if filter.Search != "" {
+			filter.Sort = models.SortRelevance
+		}
+	}
+
+	// Pages after the first one usually don't need the total again, it was
+	// returned with the first page.
+	if filter.Count == "" {
+		filter.Count = pagination.CountExact
+		if filter.Cursor != nil {
+			filter.Count = pagination.CountNone
+		}
+	}
+
+	query := productListQuery(filter)
+	fingerprint := pagination.Fingerprint(query)
+	if filter.Cursor != nil && filter.Cursor.Query != fingerprint {
+		return nil, telemetry.RecordError(span, pagination.ErrInvalidCursor)
+	}
+
+	cacheKey := productListCacheKey(filter, query)
 
 	if s.redis != nil {
-		cached, err := s.redis.Get(context.Background(), cacheKey).Result()
+		cached, err := s.redis.Get(ctx, cacheKey).Result()
 		if err == nil {
-			var result struct {
-				Products []models.Product `json:"products"`
-				Total    int64            `json:"total"`
-			}
-			if json.Unmarshal([]byte(cached), &result) == nil {
-				return result.Products, result.Total, nil
+			var listing models.ProductListing
+			if json.Unmarshal([]byte(cached), &listing) == nil {
+				return &listing, nil
 			}
 		}
 	}
 
-	products, total, err := s.productRepo.GetWithFilters(page, limit, category, search)
-
+	products, next, prev, err := s.productRepo.GetWithFilters(ctx, filter)
 	if err != nil {
-		return nil, 0, err
+		return nil, telemetry.RecordError(span, err)
 	}
 
-	if s.redis != nil {
-		result := struct {
-			Products []models.Product `json:"products"`
-			Total    int64            `json:"total"`
-		}{
-			Products: products,
-			Total:    total,
+	listing := &models.ProductListing{
+		Products: products,
+		Next:     next,
+		Prev:     prev,
+	}
+	for _, cursor := range []*pagination.Cursor{next, prev} {
+		if cursor != nil {
+			cursor.Query = fingerprint
+		}
+	}
+
+	if filter.Count != pagination.CountNone {
+		total, approximate, err := s.productRepo.CountWithFilters(ctx, filter, filter.Count == pagination.CountApproximate)
+		if err != nil {
+			return nil, telemetry.RecordError(span, err)
+		}
+		listing.Total = &total
+		listing.TotalApproximate = approximate
+	}
+
+	// Facets describe the whole result set and come with the first page.
+	if filter.Cursor == nil {
+		facets, err := s.productRepo.GetFacets(ctx, filter)
+		if err != nil {
+			return nil, telemetry.RecordError(span, err)
 		}
+		listing.Facets = facets
+	}
+
+	if s.redis != nil && ctx.Err() == nil {
+		data, _ := json.Marshal(listing)
+		s.redis.Set(ctx, cacheKey, data, 5*time.Minute)
+	}
+
+	return listing, nil
+}
+
+// productListQuery identifies the filters and sort of a listing, leaving
+// out where the page starts.
+func productListQuery(filter models.ProductFilter) string {
+	price := func(value *money.Money) string {
+		if value == nil {
+			return ""
+		}
+		return value.String()
+	}
+
+	inStock := ""
+	if filter.InStock != nil {
+		inStock = strconv.FormatBool(*filter.InStock)
+	}
+
+	return fmt.Sprintf("categories:%s:search:%s:min:%s:max:%s:in_stock:%s:sort:%s",
+		strings.Join(filter.Categories, ","), filter.Search,
+		price(filter.MinPrice), price(filter.MaxPrice), inStock, filter.Sort)
+}
 
-		data, _ := json.Marshal(result)
-		s.redis.Set(context.Background(), cacheKey, data, 5*time.Minute)
+// productListCacheKey covers every listing parameter. It lives under
+// "products:" so invalidateListCache drops it on any product change.
+func productListCacheKey(filter models.ProductFilter, query string) string {
+	cursor := ""
+	if c := filter.Cursor; c != nil {
+		cursor = fmt.Sprintf("%s,%d,%d,%t", c.Key, c.ID, c.Offset, c.Backward)
 	}
 
-	return products, total, nil
+	return fmt.Sprintf("products:list:page:%d:limit:%d:cursor:%s:count:%s:%s",
+		filter.Page, filter.Limit, cursor, filter.Count, query)
 }
 
-func (s *ProductService) GetByID(id uint) (*models.Product, error) {
+func (s *ProductService) GetByID(ctx context.Context, id uint) (*models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetByID")
+	defer span.End()
+
 	cacheKey := fmt.Sprintf("product:%d", id)
 
 	if s.redis != nil {
-		cached, err := s.redis.Get(context.Background(), cacheKey).Result()
+		cached, err := s.redis.Get(ctx, cacheKey).Result()
 		if err == nil {
 			var product models.Product
 			if json.Unmarshal([]byte(cached), &product) == nil {
@@ -75,89 +178,214 @@ func (s *ProductService) GetByID(id uint) (*models.Product, error) {
 		}
 	}
 
-	product, err := s.productRepo.GetByID(id)
+	product, err := s.productRepo.GetByID(ctx, id)
 	if err != nil {
-		return nil, err
+		return nil, telemetry.RecordError(span, err)
 	}
 
-	if s.redis != nil {
+	if s.redis != nil && ctx.Err() == nil {
 		data, _ := json.Marshal(product)
-		s.redis.Set(context.Background(), cacheKey, data, 10*time.Minute)
+		s.redis.Set(ctx, cacheKey, data, 10*time.Minute)
 	}
 
 	return product, nil
 }
 
-func (s *ProductService) Create(product *models.Product) error {
-	err := s.productRepo.Create(product)
+func (s *ProductService) Create(ctx context.Context, product *models.Product) error {
+	ctx, span := tracer.Start(ctx, "ProductService.Create")
+	defer span.End()
+
+	if err := s.checkCategory(ctx, product.CategoryID); err != nil {
+		return telemetry.RecordError(span, err)
+	}
+
+	if err := s.prepareVariants(ctx, product); err != nil {
+		return telemetry.RecordError(span, err)
+	}
+
+	err := s.productRepo.Create(ctx, product)
 	if err != nil {
-		return err
+		return telemetry.RecordError(span, err)
 	}
+	product.ResolveVariants()
 
-	s.invalidateListCache()
+	s.invalidateListCache(ctx)
 
 	return nil
 }
 
-func (s *ProductService) Update(product *models.Product) error {
-	err := s.productRepo.Update(product)
+func (s *ProductService) Update(ctx context.Context, product *models.Product) error {
+	ctx, span := tracer.Start(ctx, "ProductService.Update")
+	defer span.End()
+
+	if err := s.checkCategory(ctx, product.CategoryID); err != nil {
+		return telemetry.RecordError(span, err)
+	}
+
+	previous, err := s.currentStock(ctx, product.ID)
 	if err != nil {
-		return err
+		return telemetry.RecordError(span, err)
+	}
+
+	err = s.productRepo.Update(ctx, product)
+	if err != nil {
+		return telemetry.RecordError(span, err)
 	}
+	product.ResolveVariants()
 
-	s.invalidateProductCache(product.ID)
-	s.invalidateListCache()
+	s.invalidateProductCache(ctx, product.ID)
+	s.invalidateListCache(ctx)
+	s.stockChanged(ctx, product, previous)
 
 	return nil
 }
 
-func (s *ProductService) Delete(id uint) error {
-	err := s.productRepo.Delete(id)
+func (s *ProductService) Delete(ctx context.Context, id uint) error {
+	ctx, span := tracer.Start(ctx, "ProductService.Delete")
+	defer span.End()
+
+	err := s.productRepo.Delete(ctx, id)
 	if err != nil {
-		return err
+		return telemetry.RecordError(span, err)
 	}
 
-	s.invalidateProductCache(id)
-	s.invalidateListCache()
+	s.invalidateProductCache(ctx, id)
+	s.invalidateListCache(ctx)
 
 	return nil
 }
 
-func (s *ProductService) GetByCategory(category string) ([]models.Product, error) {
-	return s.productRepo.GetByCategory(category)
+func (s *ProductService) GetByCategory(ctx context.Context, category string) ([]models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.GetByCategory")
+	defer span.End()
+
+	products, err := s.productRepo.GetByCategory(ctx, utils.Slugify(category))
+	return products, telemetry.RecordError(span, err)
 }
 
-func (s *ProductService) SearchProducts(query string) ([]models.Product, error) {
-	return s.productRepo.SearchByName(query)
+func (s *ProductService) SearchProducts(ctx context.Context, query string) ([]models.Product, error) {
+	ctx, span := tracer.Start(ctx, "ProductService.SearchProducts")
+	defer span.End()
+
+	products, err := s.productRepo.SearchByName(ctx, query)
+	return products, telemetry.RecordError(span, err)
 }
 
-func (s *ProductService) UpdateStock(id uint, quantity int) error {
-	product, err := s.GetByID(id)
+func (s *ProductService) UpdateStock(ctx context.Context, id uint, quantity int) error {
+	ctx, span := tracer.Start(ctx, "ProductService.UpdateStock")
+	defer span.End()
+
+	product, err := s.GetByID(ctx, id)
 	if err != nil {
-		return err
+		return telemetry.RecordError(span, err)
+	}
+
+	if len(product.Variants) > 0 {
+		return telemetry.RecordError(span, ErrVariantRequired)
 	}
 
 	newStock := product.Stock + quantity
 	if newStock < 0 {
-		return fmt.Errorf("NOT_ENOUGH_STOCK")
+		return telemetry.RecordError(span, fmt.Errorf("NOT_ENOUGH_STOCK"))
 	}
 
 	product.Stock = newStock
-	return s.Update(product)
+	return telemetry.RecordError(span, s.Update(ctx, product))
+}
+
+// Restock puts quantity units of the product, or of its variant when
+// variantID is not zero, back in stock, as when returned goods come back.
+func (s *ProductService) Restock(ctx context.Context, productID, variantID uint, quantity int) error {
+	ctx, span := tracer.Start(ctx, "ProductService.Restock")
+	defer span.End()
+
+	previous, err := s.currentStock(ctx, productID)
+	if err != nil {
+		return telemetry.RecordError(span, err)
+	}
+
+	if variantID != 0 {
+		err = s.productRepo.IncrementVariantStock(ctx, variantID, quantity)
+	} else {
+		err = s.productRepo.IncrementStock(ctx, productID, quantity)
+	}
+	if err != nil {
+		return telemetry.RecordError(span, err)
+	}
+
+	s.afterStockChange(ctx, productID, previous)
+	return nil
+}
+
+func (s *ProductService) checkCategory(ctx context.Context, categoryID *uint) error {
+	if categoryID == nil {
+		return nil
+	}
+
+	exists, err := s.productRepo.CategoryExists(ctx, *categoryID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+// AddStockListener registers listener for stock changes made through
+// Update, UpdateStock, UpdateWithVariants, UpdateVariantStock and Restock.
+// Listeners are added at startup, before the service handles requests.
+func (s *ProductService) AddStockListener(listener StockListener) {
+	s.stockListeners = append(s.stockListeners, listener)
+}
+
+// currentStock reads the stock of the product before a change, for
+// stockChanged to compare against. Without listeners nothing compares.
+func (s *ProductService) currentStock(ctx context.Context, id uint) (int, error) {
+	if len(s.stockListeners) == 0 {
+		return 0, nil
+	}
+	return s.productRepo.GetStock(ctx, id)
+}
+
+// stockChanged tells the listeners when the stock of product is no longer
+// previous.
+func (s *ProductService) stockChanged(ctx context.Context, product *models.Product, previous int) {
+	if product.Stock == previous {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	for _, listener := range s.stockListeners {
+		listener.StockChanged(ctx, product, previous)
+	}
+}
+
+// InvalidateProducts drops the cached products and listings after a change
+// made outside this service, like a sale price applied by the scheduler.
+func (s *ProductService) InvalidateProducts(ctx context.Context, ids ...uint) {
+	for _, id := range ids {
+		s.invalidateProductCache(ctx, id)
+	}
+	s.invalidateListCache(ctx)
 }
 
-func (s *ProductService) invalidateProductCache(id uint) {
+// Invalidation runs after the write is committed, so it must not be skipped
+// because the client disconnected in the meantime.
+func (s *ProductService) invalidateProductCache(ctx context.Context, id uint) {
+	ctx = context.WithoutCancel(ctx)
 	if s.redis != nil {
 		cacheKey := fmt.Sprintf("product:%d", id)
-		s.redis.Del(context.Background(), cacheKey)
+		s.redis.Del(ctx, cacheKey)
 	}
 }
 
-func (s *ProductService) invalidateListCache() {
+func (s *ProductService) invalidateListCache(ctx context.Context) {
+	ctx = context.WithoutCancel(ctx)
 	if s.redis != nil {
-		keys, err := s.redis.Keys(context.Background(), "products:*").Result()
+		keys, err := s.redis.Keys(ctx, "products:*").Result()
 		if err == nil && len(keys) > 0 {
-			s.redis.Del(context.Background(), keys...)
+			s.redis.Del(ctx, keys...)
 		}
 	}
 }
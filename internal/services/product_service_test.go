@@ -2,13 +2,17 @@
 package services
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/Code-Aether/americanas-loja-api/internal/models"
 	"github.com/Code-Aether/americanas-loja-api/internal/repository"
 	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 )
 
 func TestProductService_Create(t *testing.T) {
@@ -18,18 +22,19 @@ func TestProductService_Create(t *testing.T) {
 	productService := NewProductService(productRepo, redis)
 
 	t.Run("✅ Criar produto com sucesso", func(t *testing.T) {
+		category := testutils.CreateTestCategory(t, db, "Eletrônicos", nil)
 		product := &models.Product{
 			Name:        "iPhone 15 Pro",
 			Description: "Smartphone Apple",
-			Price:       8999.99,
+			Price:       money.MustParse("8999.99"),
 			Stock:       10,
-			Category:    "Eletrônicos",
+			CategoryID:  &category.ID,
 			SKU:         "IPHONE-15-PRO",
 			ImageURL:    "https://example.com/iphone15.jpg",
 			Active:      true,
 		}
 
-		err := productService.Create(product)
+		err := productService.Create(context.Background(), product)
 
 		// Assertions
 		assert.NoError(t, err, "Criação não deve retornar erro")
@@ -51,15 +56,14 @@ func TestProductService_Create(t *testing.T) {
 		duplicateProduct := &models.Product{
 			Name:        "Produto Duplicado",
 			Description: "Tentativa de SKU duplicado",
-			Price:       100.00,
+			Price:       money.MustParse("100.00"),
 			Stock:       5,
-			Category:    "Test",
 			SKU:         "TEST-001", // SKU já existe
 			ImageURL:    "",
 			Active:      true,
 		}
 
-		err := productService.Create(duplicateProduct)
+		err := productService.Create(context.Background(), duplicateProduct)
 
 		assert.Error(t, err, "Deve retornar erro para SKU duplicado")
 		assert.Contains(t, err.Error(), "sku already exists", "Erro deve mencionar SKU duplicado")
@@ -76,11 +80,10 @@ func TestProductService_Create(t *testing.T) {
 			{
 				name: "Nome vazio",
 				product: &models.Product{
-					Name:     "",
-					Price:    99.99,
-					Stock:    10,
-					Category: "Test",
-					SKU:      "TEST-002",
+					Name:  "",
+					Price: money.MustParse("99.99"),
+					Stock: 10,
+					SKU:   "TEST-002",
 				},
 				wantErr: true,
 				errMsg:  "name",
@@ -88,11 +91,10 @@ func TestProductService_Create(t *testing.T) {
 			{
 				name: "Preço negativo",
 				product: &models.Product{
-					Name:     "Produto Teste",
-					Price:    -10.00,
-					Stock:    10,
-					Category: "Test",
-					SKU:      "TEST-003",
+					Name:  "Produto Teste",
+					Price: money.MustParse("-10.00"),
+					Stock: 10,
+					SKU:   "TEST-003",
 				},
 				wantErr: true,
 				errMsg:  "price",
@@ -100,11 +102,10 @@ func TestProductService_Create(t *testing.T) {
 			{
 				name: "Stock negativo",
 				product: &models.Product{
-					Name:     "Produto Teste",
-					Price:    99.99,
-					Stock:    -5,
-					Category: "Test",
-					SKU:      "TEST-004",
+					Name:  "Produto Teste",
+					Price: money.MustParse("99.99"),
+					Stock: -5,
+					SKU:   "TEST-004",
 				},
 				wantErr: true,
 				errMsg:  "stock",
@@ -113,7 +114,7 @@ func TestProductService_Create(t *testing.T) {
 
 		for _, tt := range tests {
 			t.Run(tt.name, func(t *testing.T) {
-				err := productService.Create(tt.product)
+				err := productService.Create(context.Background(), tt.product)
 
 				if tt.wantErr {
 					assert.Error(t, err, "Deve retornar erro")
@@ -139,7 +140,7 @@ func TestProductService_GetByID(t *testing.T) {
 	testProduct := testutils.CreateTestProduct(t, db)
 
 	t.Run("✅ Buscar produto existente", func(t *testing.T) {
-		product, err := productService.GetByID(testProduct.ID)
+		product, err := productService.GetByID(context.Background(), testProduct.ID)
 
 		// Assertions
 		assert.NoError(t, err, "Busca não deve retornar erro")
@@ -151,7 +152,7 @@ func TestProductService_GetByID(t *testing.T) {
 	})
 
 	t.Run("❌ Buscar produto inexistente", func(t *testing.T) {
-		product, err := productService.GetByID(99999) // ID que não existe
+		product, err := productService.GetByID(context.Background(), 99999) // ID que não existe
 
 		// Assertions
 		assert.Error(t, err, "Deve retornar erro")
@@ -160,7 +161,7 @@ func TestProductService_GetByID(t *testing.T) {
 	})
 
 	t.Run("❌ Buscar produto com ID zero", func(t *testing.T) {
-		product, err := productService.GetByID(0)
+		product, err := productService.GetByID(context.Background(), 0)
 
 		// Assertions
 		assert.Error(t, err, "Deve retornar erro")
@@ -176,19 +177,22 @@ func TestProductService_GetAll(t *testing.T) {
 	productService := NewProductService(productRepo, redis)
 
 	t.Run("✅ Listar produtos quando existe produtos", func(t *testing.T) {
+		cat1 := testutils.CreateTestCategory(t, db, "Cat1", nil)
+		cat2 := testutils.CreateTestCategory(t, db, "Cat2", nil)
+
 		// Criar alguns produtos de teste
 		products := []*models.Product{
 			{
-				Name: "Produto 1", SKU: "PROD-001", Price: 100.00, Stock: 10,
-				Category: "Cat1", Active: true,
+				Name: "Produto 1", SKU: "PROD-001", Price: money.MustParse("100.00"), Stock: 10,
+				CategoryID: &cat1.ID, Active: true,
 			},
 			{
-				Name: "Produto 2", SKU: "PROD-002", Price: 200.00, Stock: 20,
-				Category: "Cat2", Active: true,
+				Name: "Produto 2", SKU: "PROD-002", Price: money.MustParse("200.00"), Stock: 20,
+				CategoryID: &cat2.ID, Active: true,
 			},
 			{
-				Name: "Produto 3", SKU: "PROD-003", Price: 300.00, Stock: 30,
-				Category: "Cat1", Active: false, // Produto inativo
+				Name: "Produto 3", SKU: "PROD-003", Price: money.MustParse("300.00"), Stock: 30,
+				CategoryID: &cat1.ID, Active: false, // Produto inativo
 			},
 		}
 
@@ -197,11 +201,12 @@ func TestProductService_GetAll(t *testing.T) {
 			require.NoError(t, err)
 		}
 
-		result, _, err := productService.GetAll(1, 10, "", "")
+		listing, err := productService.GetAll(context.Background(), models.ProductFilter{Page: 1, Limit: 10})
 
 		// Assertions
 		assert.NoError(t, err, "Listagem não deve retornar erro")
-		assert.NotNil(t, result, "Lista não deve ser nil")
+		require.NotNil(t, listing, "Lista não deve ser nil")
+		result := listing.Products
 		assert.Len(t, result, 3, "Deve retornar todos os produtos (incluindo inativos)")
 
 		// Verificar se produtos estão ordenados por ID
@@ -217,11 +222,12 @@ func TestProductService_GetAll(t *testing.T) {
 		cleanRepo := repository.NewProductRepository(cleanDB)
 		cleanService := NewProductService(cleanRepo, cleanRedis)
 
-		result, _, err := cleanService.GetAll(1, 10, "", "")
+		listing, err := cleanService.GetAll(context.Background(), models.ProductFilter{Page: 1, Limit: 10})
 
 		// Assertions
 		assert.NoError(t, err, "Listagem de lista vazia não deve retornar erro")
-		assert.NotNil(t, result, "Lista não deve ser nil")
+		require.NotNil(t, listing, "Lista não deve ser nil")
+		result := listing.Products
 		assert.Empty(t, result, "Lista deve estar vazia")
 	})
 }
@@ -240,11 +246,11 @@ func TestProductService_Update(t *testing.T) {
 
 		// Atualizar dados
 		originalProduct.Name = "Nome Atualizado"
-		originalProduct.Price = 199.99
+		originalProduct.Price = money.MustParse("199.99")
 		originalProduct.Stock = 25
 		originalProduct.Description = "Descrição atualizada"
 
-		err := productService.Update(originalProduct)
+		err := productService.Update(context.Background(), originalProduct)
 
 		// Assertions
 		assert.NoError(t, err, "Atualização não deve retornar erro")
@@ -255,7 +261,7 @@ func TestProductService_Update(t *testing.T) {
 		assert.NoError(t, err, "Produto deve existir no banco")
 
 		assert.Equal(t, "Nome Atualizado", updatedProduct.Name)
-		assert.Equal(t, 199.99, updatedProduct.Price)
+		assert.Equal(t, money.MustParse("199.99"), updatedProduct.Price)
 		assert.Equal(t, 25, updatedProduct.Stock)
 		assert.Equal(t, "Descrição atualizada", updatedProduct.Description)
 		assert.True(t, updatedProduct.UpdatedAt.After(originalUpdatedAt), "UpdatedAt deve ser atualizado")
@@ -267,7 +273,7 @@ func TestProductService_Update(t *testing.T) {
 			Name: "Produto Inexistente",
 		}
 
-		err := productService.Update(nonExistentProduct)
+		err := productService.Update(context.Background(), nonExistentProduct)
 
 		// Assertions
 		assert.Error(t, err, "Deve retornar erro")
@@ -278,9 +284,9 @@ func TestProductService_Update(t *testing.T) {
 		product := testutils.CreateTestProduct(t, db)
 
 		// Tentar atualizar com preço negativo
-		product.Price = -50.00
+		product.Price = money.MustParse("-50.00")
 
-		err := productService.Update(product)
+		err := productService.Update(context.Background(), product)
 
 		// Assertions
 		assert.Error(t, err, "Deve retornar erro para preço negativo")
@@ -300,7 +306,7 @@ func TestProductService_Delete(t *testing.T) {
 		product := testutils.CreateTestProduct(t, db)
 		productID := product.ID
 
-		err := productService.Delete(productID)
+		err := productService.Delete(context.Background(), productID)
 
 		// Assertions
 		assert.NoError(t, err, "Deleção não deve retornar erro")
@@ -312,7 +318,7 @@ func TestProductService_Delete(t *testing.T) {
 	})
 
 	t.Run("❌ Deletar produto inexistente", func(t *testing.T) {
-		err := productService.Delete(99999) // ID que não existe
+		err := productService.Delete(context.Background(), 99999) // ID que não existe
 
 		// Assertions
 		assert.Error(t, err, "Deve retornar erro")
@@ -320,13 +326,59 @@ func TestProductService_Delete(t *testing.T) {
 	})
 
 	t.Run("❌ Deletar com ID zero", func(t *testing.T) {
-		err := productService.Delete(0)
+		err := productService.Delete(context.Background(), 0)
 
 		// Assertions
 		assert.Error(t, err, "Deve retornar erro para ID zero")
 	})
 }
 
+func TestProductRepository_DecrementStock(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	productRepo := repository.NewProductRepository(db)
+	ctx := context.Background()
+
+	product := &models.Product{Name: "Caneca", SKU: "CAN-1", Price: money.MustParse("29.9"), Stock: 3, Active: true}
+	require.NoError(t, productRepo.Create(ctx, product))
+
+	t.Run("✅ Saída dentro do estoque", func(t *testing.T) {
+		require.NoError(t, productRepo.DecrementStock(ctx, product.ID, 2))
+
+		found, err := productRepo.GetByID(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, found.Stock)
+	})
+
+	t.Run("❌ Saída maior que o estoque", func(t *testing.T) {
+		err := productRepo.DecrementStock(ctx, product.ID, 2)
+		assert.ErrorIs(t, err, repository.ErrInsufficientStock)
+
+		found, err := productRepo.GetByID(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, found.Stock, "Estoque não pode ficar negativo")
+	})
+
+	t.Run("❌ Produto inexistente", func(t *testing.T) {
+		err := productRepo.DecrementStock(ctx, product.ID+100, 1)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("❌ Produto com variantes", func(t *testing.T) {
+		shoe := &models.Product{
+			Name: "Nike Air Max 90", SKU: "NIKE-90", Price: money.MustParse("499.99"), Active: true,
+			Variants: []models.ProductVariant{sizeVariant("NIKE-90-40", "40", 3)},
+		}
+		require.NoError(t, NewProductService(productRepo, nil).Create(ctx, shoe))
+
+		err := productRepo.DecrementStock(ctx, shoe.ID, 1)
+		assert.ErrorIs(t, err, repository.ErrProductHasVariants)
+
+		found, err := productRepo.GetByID(ctx, shoe.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 3, found.Stock, "Estoque do produto deve continuar igual à soma das variantes")
+	})
+}
+
 func TestProductService_GetBySKU(t *testing.T) {
 	// Setup
 	db := testutils.SetupTestDB(t)
@@ -338,7 +390,7 @@ func TestProductService_GetBySKU(t *testing.T) {
 	testProduct := testutils.CreateTestProduct(t, db)
 
 	t.Run("✅ Buscar produto por SKU existente", func(t *testing.T) {
-		product, err := productService.GetByID(testProduct.ID)
+		product, err := productService.GetByID(context.Background(), testProduct.ID)
 
 		// Assertions
 		assert.NoError(t, err, "Busca por SKU não deve retornar erro")
@@ -349,7 +401,7 @@ func TestProductService_GetBySKU(t *testing.T) {
 	})
 
 	t.Run("❌ Buscar produto por SKU inexistente", func(t *testing.T) {
-		product, err := productService.GetByID(99999)
+		product, err := productService.GetByID(context.Background(), 99999)
 
 		// Assertions
 		assert.Error(t, err, "Deve retornar erro")
@@ -358,7 +410,7 @@ func TestProductService_GetBySKU(t *testing.T) {
 	})
 
 	t.Run("❌ Buscar produto com SKU vazio", func(t *testing.T) {
-		product, err := productService.GetByID(0)
+		product, err := productService.GetByID(context.Background(), 0)
 
 		// Assertions
 		assert.Error(t, err, "Deve retornar erro para SKU vazio")
@@ -374,11 +426,13 @@ func TestProductService_GetByCategory(t *testing.T) {
 	productService := NewProductService(productRepo, redis)
 
 	// Criar produtos de diferentes categorias
+	eletronicos := testutils.CreateTestCategory(t, db, "Eletrônicos", nil)
+	roupas := testutils.CreateTestCategory(t, db, "Roupas", nil)
 	products := []*models.Product{
-		{Name: "iPhone", SKU: "IP-001", Category: "Eletrônicos", Price: 1000, Stock: 10, Active: true},
-		{Name: "Samsung", SKU: "SM-001", Category: "Eletrônicos", Price: 800, Stock: 15, Active: true},
-		{Name: "Camisa", SKU: "CM-001", Category: "Roupas", Price: 50, Stock: 30, Active: true},
-		{Name: "Calça", SKU: "CL-001", Category: "Roupas", Price: 80, Stock: 20, Active: false}, // Inativo
+		{Name: "iPhone", SKU: "IP-001", CategoryID: &eletronicos.ID, Price: money.MustParse("1000"), Stock: 10, Active: true},
+		{Name: "Samsung", SKU: "SM-001", CategoryID: &eletronicos.ID, Price: money.MustParse("800"), Stock: 15, Active: true},
+		{Name: "Camisa", SKU: "CM-001", CategoryID: &roupas.ID, Price: money.MustParse("50"), Stock: 30, Active: true},
+		{Name: "Calça", SKU: "CL-001", CategoryID: &roupas.ID, Price: money.MustParse("80"), Stock: 20, Active: false}, // Inativo
 	}
 
 	for _, product := range products {
@@ -387,19 +441,19 @@ func TestProductService_GetByCategory(t *testing.T) {
 	}
 
 	t.Run("✅ Buscar produtos por categoria existente", func(t *testing.T) {
-		result, err := productService.GetByCategory("Eletrônicos")
+		result, err := productService.GetByCategory(context.Background(), "Eletrônicos")
 
 		// Assertions
 		assert.NoError(t, err, "Busca por categoria não deve retornar erro")
 		assert.Len(t, result, 2, "Deve retornar 2 produtos da categoria Eletrônicos")
 
 		for _, product := range result {
-			assert.Equal(t, "Eletrônicos", product.Category, "Todos produtos devem ser da categoria correta")
+			assert.Equal(t, eletronicos.ID, *product.CategoryID, "Todos produtos devem ser da categoria correta")
 		}
 	})
 
 	t.Run("✅ Buscar produtos por categoria que inclui inativos", func(t *testing.T) {
-		result, err := productService.GetByCategory("Roupas")
+		result, err := productService.GetByCategory(context.Background(), "Roupas")
 
 		// Assertions
 		assert.NoError(t, err, "Busca por categoria não deve retornar erro")
@@ -407,7 +461,7 @@ func TestProductService_GetByCategory(t *testing.T) {
 
 		activeCount := 0
 		for _, product := range result {
-			assert.Equal(t, "Roupas", product.Category, "Todos produtos devem ser da categoria correta")
+			assert.Equal(t, roupas.ID, *product.CategoryID, "Todos produtos devem ser da categoria correta")
 			if product.Active {
 				activeCount++
 			}
@@ -416,10 +470,40 @@ func TestProductService_GetByCategory(t *testing.T) {
 	})
 
 	t.Run("✅ Buscar produtos por categoria inexistente", func(t *testing.T) {
-		result, err := productService.GetByCategory("Categoria Inexistente")
+		result, err := productService.GetByCategory(context.Background(), "Categoria Inexistente")
 
 		// Assertions
 		assert.NoError(t, err, "Busca por categoria inexistente não deve retornar erro")
 		assert.Empty(t, result, "Lista deve estar vazia para categoria inexistente")
 	})
 }
+
+func TestProductService_ContextCancellation(t *testing.T) {
+	// Setup
+	db := testutils.SetupTestDB(t)
+	productRepo := repository.NewProductRepository(db)
+	productService := NewProductService(productRepo, nil)
+	testProduct := testutils.CreateTestProduct(t, db)
+
+	t.Run("❌ Buscar produto com contexto cancelado", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		product, err := productService.GetByID(ctx, testProduct.ID)
+
+		// Assertions
+		assert.ErrorIs(t, err, context.Canceled, "Erro deve ser de cancelamento")
+		assert.Nil(t, product, "Produto não deve ser retornado")
+	})
+
+	t.Run("❌ Listar produtos com contexto expirado", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		listing, err := productService.GetAll(ctx, models.ProductFilter{Page: 1, Limit: 10})
+
+		// Assertions
+		assert.ErrorIs(t, err, context.DeadlineExceeded, "Erro deve ser de timeout")
+		assert.Nil(t, listing, "Lista não deve ser retornada")
+	})
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/pkg/telemetry"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidVariantOption = errors.New("invalid variant option")
+	ErrDuplicateVariant     = errors.New("duplicate variant")
+	ErrVariantSKUTaken      = errors.New("variant sku already exists")
+	ErrVariantNotFound      = errors.New("variant not found")
+	ErrVariantRequired      = errors.New("product has variants, stock must be changed per variant")
+)
+
+// UpdateWithVariants saves the product and replaces its variant set.
+// Variants are matched to the existing ones by SKU, so their IDs survive the
+// update; existing variants left out are deactivated.
+func (s *ProductService) UpdateWithVariants(ctx context.Context, product *models.Product, variants []models.ProductVariant) error {
+	ctx, span := tracer.Start(ctx, "ProductService.UpdateWithVariants")
+	defer span.End()
+
+	if err := s.checkCategory(ctx, product.CategoryID); err != nil {
+		return telemetry.RecordError(span, err)
+	}
+
+	existing, err := s.productRepo.GetVariants(ctx, product.ID)
+	if err != nil {
+		return telemetry.RecordError(span, err)
+	}
+	idsBySKU := make(map[string]uint, len(existing))
+	for _, variant := range existing {
+		idsBySKU[variant.SKU] = variant.ID
+	}
+	for i := range variants {
+		variants[i].ID = idsBySKU[variants[i].SKU]
+	}
+
+	product.Variants = variants
+	if err := s.prepareVariants(ctx, product); err != nil {
+		return telemetry.RecordError(span, err)
+	}
+
+	previous, err := s.currentStock(ctx, product.ID)
+	if err != nil {
+		return telemetry.RecordError(span, err)
+	}
+
+	if err := s.productRepo.UpdateWithVariants(ctx, product); err != nil {
+		return telemetry.RecordError(span, err)
+	}
+	product.ResolveVariants()
+
+	s.invalidateProductCache(ctx, product.ID)
+	s.invalidateListCache(ctx)
+	s.stockChanged(ctx, product, previous)
+
+	return nil
+}
+
+// UpdateVariantStock adds quantity to the variant stock; a negative quantity
+// removes stock and fails if there is not enough of it.
+func (s *ProductService) UpdateVariantStock(ctx context.Context, productID, variantID uint, quantity int) error {
+	ctx, span := tracer.Start(ctx, "ProductService.UpdateVariantStock")
+	defer span.End()
+
+	variant, err := s.productRepo.GetVariant(ctx, variantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrVariantNotFound
+		}
+		return telemetry.RecordError(span, err)
+	}
+	if variant.ProductID != productID {
+		return telemetry.RecordError(span, ErrVariantNotFound)
+	}
+
+	previous, err := s.currentStock(ctx, productID)
+	if err != nil {
+		return telemetry.RecordError(span, err)
+	}
+
+	if quantity >= 0 {
+		err = s.productRepo.IncrementVariantStock(ctx, variantID, quantity)
+	} else {
+		err = s.productRepo.DecrementVariantStock(ctx, variantID, -quantity)
+	}
+	if errors.Is(err, repository.ErrInsufficientStock) {
+		err = fmt.Errorf("NOT_ENOUGH_STOCK")
+	}
+	if err != nil {
+		return telemetry.RecordError(span, err)
+	}
+
+	s.afterStockChange(ctx, productID, previous)
+	return nil
+}
+
+// afterStockChange drops the cached product and listings and tells the
+// listeners about a stock change made straight in the repository. The
+// stock is already changed, so failing to tell them does not fail the
+// change.
+func (s *ProductService) afterStockChange(ctx context.Context, productID uint, previous int) {
+	s.invalidateProductCache(ctx, productID)
+	s.invalidateListCache(ctx)
+
+	if len(s.stockListeners) > 0 {
+		product, err := s.productRepo.GetByID(ctx, productID)
+		if err != nil {
+			log.Printf("failed to load product %d after a stock change: %v", productID, err)
+		} else {
+			s.stockChanged(ctx, product, previous)
+		}
+	}
+}
+
+// prepareVariants resolves option codes to option types and checks that all
+// variants vary along the same options, with no repeated combination or SKU.
+func (s *ProductService) prepareVariants(ctx context.Context, product *models.Product) error {
+	if len(product.Variants) == 0 {
+		return nil
+	}
+
+	optionTypes, err := s.productRepo.GetOptionTypes(ctx)
+	if err != nil {
+		return err
+	}
+	byCode := make(map[string]*models.OptionType, len(optionTypes))
+	for i := range optionTypes {
+		byCode[optionTypes[i].Code] = &optionTypes[i]
+	}
+
+	var axes string
+	combinations := make(map[string]bool, len(product.Variants))
+	skus := make(map[string]bool, len(product.Variants))
+
+	for i := range product.Variants {
+		variant := &product.Variants[i]
+
+		codes := make([]string, 0, len(variant.Options))
+		for j := range variant.Options {
+			option := &variant.Options[j]
+			optionType, ok := byCode[option.Code]
+			if !ok {
+				return fmt.Errorf("%w: unknown option %q", ErrInvalidVariantOption, option.Code)
+			}
+			option.Value = strings.TrimSpace(option.Value)
+			if option.Value == "" {
+				return fmt.Errorf("%w: empty value for %q", ErrInvalidVariantOption, option.Code)
+			}
+			option.OptionTypeID = optionType.ID
+			option.OptionType = optionType
+			codes = append(codes, option.Code)
+		}
+		sort.Slice(variant.Options, func(a, b int) bool {
+			return variant.Options[a].OptionTypeID < variant.Options[b].OptionTypeID
+		})
+
+		sort.Strings(codes)
+		if i == 0 {
+			axes = strings.Join(codes, ",")
+		} else if strings.Join(codes, ",") != axes {
+			return fmt.Errorf("%w: every variant must set the options %s", ErrInvalidVariantOption, axes)
+		}
+
+		if combinations[variant.Key()] {
+			return fmt.Errorf("%w: %s", ErrDuplicateVariant, variant.Key())
+		}
+		combinations[variant.Key()] = true
+
+		if skus[variant.SKU] {
+			return fmt.Errorf("%w: %s", ErrDuplicateVariant, variant.SKU)
+		}
+		skus[variant.SKU] = true
+
+		taken, err := s.productRepo.VariantSKUExists(ctx, variant.SKU, product.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return fmt.Errorf("%w: %s", ErrVariantSKUTaken, variant.SKU)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+// internal/services/product_variant_test.go
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sizeVariant(sku, size string, stock int) models.ProductVariant {
+	return models.ProductVariant{
+		SKU:     sku,
+		Stock:   stock,
+		Active:  true,
+		Options: []models.VariantOption{{Code: models.OptionSize, Value: size}},
+	}
+}
+
+func TestProductService_CreateWithVariants(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	productService := NewProductService(repository.NewProductRepository(db), nil)
+	ctx := context.Background()
+
+	t.Run("✅ Criar produto com variantes soma o estoque", func(t *testing.T) {
+		product := &models.Product{
+			Name: "Nike Air Max 90", SKU: "NIKE-90", Price: money.MustParse("499.99"), Active: true,
+			Variants: []models.ProductVariant{
+				sizeVariant("NIKE-90-40", "40", 3),
+				sizeVariant("NIKE-90-41", "41", 0),
+			},
+		}
+		product.Variants[1].Price = ptr(money.MustParse("549.99"))
+
+		require.NoError(t, productService.Create(ctx, product))
+		assert.Equal(t, 3, product.Stock, "Estoque do produto deve ser a soma das variantes")
+
+		found, err := productService.GetByID(ctx, product.ID)
+		require.NoError(t, err)
+		require.Len(t, found.Variants, 2)
+		assert.Equal(t, money.MustParse("499.99"), found.Variants[0].FinalPrice, "Sem preço próprio usa o preço do produto")
+		assert.Equal(t, money.MustParse("549.99"), found.Variants[1].FinalPrice)
+		assert.True(t, found.Variants[0].Available)
+		assert.False(t, found.Variants[1].Available, "Variante sem estoque não está disponível")
+
+		require.Len(t, found.VariantMatrix, 1)
+		axis := found.VariantMatrix[0]
+		assert.Equal(t, models.OptionSize, axis.Code)
+		assert.Equal(t, "Tamanho", axis.Name)
+		assert.Equal(t, []models.AxisValue{{Value: "40", Available: true}, {Value: "41", Available: false}}, axis.Values)
+	})
+
+	t.Run("❌ Criar produto com combinação repetida", func(t *testing.T) {
+		product := &models.Product{
+			Name: "Tênis", SKU: "TENIS-1", Price: money.MustParse("100"), Active: true,
+			Variants: []models.ProductVariant{
+				sizeVariant("TENIS-1-40", "40", 1),
+				sizeVariant("TENIS-1-40B", "40", 1),
+			},
+		}
+
+		assert.ErrorIs(t, productService.Create(ctx, product), ErrDuplicateVariant)
+	})
+
+	t.Run("❌ Criar produto com opção desconhecida", func(t *testing.T) {
+		variant := sizeVariant("TENIS-2-40", "40", 1)
+		variant.Options[0].Code = "material"
+		product := &models.Product{
+			Name: "Tênis", SKU: "TENIS-2", Price: money.MustParse("100"), Active: true,
+			Variants: []models.ProductVariant{variant},
+		}
+
+		assert.ErrorIs(t, productService.Create(ctx, product), ErrInvalidVariantOption)
+	})
+
+	t.Run("❌ Criar variante com SKU de outro produto", func(t *testing.T) {
+		product := &models.Product{
+			Name: "Outro", SKU: "OUTRO-1", Price: money.MustParse("100"), Active: true,
+			Variants: []models.ProductVariant{sizeVariant("NIKE-90-40", "40", 1)},
+		}
+
+		assert.ErrorIs(t, productService.Create(ctx, product), ErrVariantSKUTaken)
+	})
+}
+
+func TestProductService_UpdateWithVariants(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	productService := NewProductService(repository.NewProductRepository(db), nil)
+	ctx := context.Background()
+
+	product := &models.Product{
+		Name: "Airfryer", SKU: "AIR-1", Price: money.MustParse("899.99"), Active: true,
+		Variants: []models.ProductVariant{
+			{SKU: "AIR-1-110", Stock: 5, Active: true, Options: []models.VariantOption{{Code: models.OptionVoltage, Value: "110V"}}},
+			{SKU: "AIR-1-220", Stock: 5, Active: true, Options: []models.VariantOption{{Code: models.OptionVoltage, Value: "220V"}}},
+		},
+	}
+	require.NoError(t, productService.Create(ctx, product))
+	originalID := product.Variants[0].ID
+
+	t.Run("✅ Substituir variantes mantém IDs e desativa as removidas", func(t *testing.T) {
+		variants := []models.ProductVariant{
+			{SKU: "AIR-1-110", Stock: 7, Active: true, Options: []models.VariantOption{{Code: models.OptionVoltage, Value: "110V"}}},
+		}
+
+		require.NoError(t, productService.UpdateWithVariants(ctx, product, variants))
+		assert.Equal(t, originalID, product.Variants[0].ID, "Variante existente deve manter o ID")
+		assert.Equal(t, 7, product.Stock)
+
+		found, err := productService.GetByID(ctx, product.ID)
+		require.NoError(t, err)
+		require.Len(t, found.Variants, 1, "Variante removida não deve aparecer")
+		assert.Equal(t, "AIR-1-110", found.Variants[0].SKU)
+	})
+}
+
+func TestProductService_UpdateVariantStock(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	productService := NewProductService(repository.NewProductRepository(db), nil)
+	ctx := context.Background()
+
+	product := &models.Product{
+		Name: "Camiseta", SKU: "CAM-1", Price: money.MustParse("59.9"), Active: true,
+		Variants: []models.ProductVariant{
+			sizeVariant("CAM-1-P", "P", 2),
+			sizeVariant("CAM-1-M", "M", 4),
+		},
+	}
+	require.NoError(t, productService.Create(ctx, product))
+	variantID := product.Variants[0].ID
+
+	t.Run("✅ Entrada e saída de estoque por variante", func(t *testing.T) {
+		require.NoError(t, productService.UpdateVariantStock(ctx, product.ID, variantID, 3))
+		require.NoError(t, productService.UpdateVariantStock(ctx, product.ID, variantID, -1))
+
+		found, err := productService.GetByID(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 4, found.Variants[0].Stock)
+		assert.Equal(t, 8, found.Stock, "Estoque do produto deve acompanhar as variantes")
+	})
+
+	t.Run("❌ Saída maior que o estoque", func(t *testing.T) {
+		err := productService.UpdateVariantStock(ctx, product.ID, variantID, -10)
+		assert.EqualError(t, err, "NOT_ENOUGH_STOCK")
+	})
+
+	t.Run("❌ Variante de outro produto", func(t *testing.T) {
+		err := productService.UpdateVariantStock(ctx, product.ID+1, variantID, 1)
+		assert.ErrorIs(t, err, ErrVariantNotFound)
+	})
+
+	t.Run("❌ Estoque do produto com variantes", func(t *testing.T) {
+		err := productService.UpdateStock(ctx, product.ID, 1)
+		assert.ErrorIs(t, err, ErrVariantRequired)
+	})
+}
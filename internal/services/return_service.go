@@ -0,0 +1,453 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/orders"
+	"github.com/Code-Aether/americanas-loja-api/pkg/storage"
+	"github.com/Code-Aether/americanas-loja-api/pkg/telemetry"
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReturnNotFound         = errors.New("return not found")
+	ErrOrderNotFound          = errors.New("order not found")
+	ErrOrdersUnavailable      = errors.New("orders are not available")
+	ErrOrderNotDelivered      = errors.New("order was not delivered yet")
+	ErrReturnWindowClosed     = errors.New("return window has closed")
+	ErrInvalidReturn          = errors.New("invalid return")
+	ErrReturnQuantityExceeded = errors.New("more units returned than ordered")
+	ErrReturnStatus           = errors.New("return is not in a status that allows this")
+	ErrTooManyReturnPhotos    = errors.New("return has the maximum number of photos")
+)
+
+// maxReturnPhotos bounds the photos attached to a return.
+const maxReturnPhotos = 5
+
+// ReturnItemInput is a quantity of an order item the customer returns.
+type ReturnItemInput struct {
+	OrderItemID uint
+	Quantity    int
+}
+
+// ReturnInput is what the customer asks to return and why.
+type ReturnInput struct {
+	OrderID     string
+	Reason      string
+	Description string
+	Items       []ReturnItemInput
+}
+
+// RefundListener is told when a return is refunded, like the cashback
+// program, which takes back the points the returned items earned. It runs
+// after the refund and must not block the caller for long.
+type RefundListener interface {
+	ReturnRefunded(ctx context.Context, ret *models.ReturnRequest, order *orders.Order)
+}
+
+// ReturnService runs the returns of order items: the customer asks for a
+// return within the window after delivery, the store approves or rejects
+// it, puts the goods back in stock when they arrive and refunds what they
+// were paid through the payment layer of the order source.
+type ReturnService struct {
+	returnRepo      *repository.ReturnRepository
+	productService  *ProductService
+	orders          orders.Source
+	storage         storage.Storage
+	window          time.Duration
+	maxPhotoSize    int64
+	photoURLTTL     time.Duration
+	refundListeners []RefundListener
+	now             func() time.Time
+}
+
+func NewReturnService(returnRepo *repository.ReturnRepository, productService *ProductService, source orders.Source, storage storage.Storage, window time.Duration, maxPhotoSize int64, photoURLTTL time.Duration) *ReturnService {
+	return &ReturnService{
+		returnRepo:     returnRepo,
+		productService: productService,
+		orders:         source,
+		storage:        storage,
+		window:         window,
+		maxPhotoSize:   maxPhotoSize,
+		photoURLTTL:    photoURLTTL,
+		now:            time.Now,
+	}
+}
+
+// AddRefundListener registers listener for refunded returns. Listeners are
+// added at startup, before the service handles requests.
+func (s *ReturnService) AddRefundListener(listener RefundListener) {
+	s.refundListeners = append(s.refundListeners, listener)
+}
+
+// Create asks for the return of items of an order of the user. The order
+// must have been delivered within the return window, and each item can be
+// returned up to the quantity ordered, across all returns that were not
+// rejected.
+func (s *ReturnService) Create(ctx context.Context, userID uint, input ReturnInput) (*models.ReturnRequest, error) {
+	ctx, span := tracer.Start(ctx, "ReturnService.Create")
+	defer span.End()
+
+	order, err := s.order(ctx, input.OrderID)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	if order.UserID != userID {
+		return nil, telemetry.RecordError(span, ErrOrderNotFound)
+	}
+
+	switch {
+	case order.DeliveredAt == nil:
+		return nil, telemetry.RecordError(span, ErrOrderNotDelivered)
+	case s.now().After(order.DeliveredAt.Add(s.window)):
+		return nil, telemetry.RecordError(span, ErrReturnWindowClosed)
+	case len(input.Items) == 0:
+		return nil, telemetry.RecordError(span, fmt.Errorf("%w: no items", ErrInvalidReturn))
+	}
+
+	returned, err := s.returnRepo.ReturnedQuantities(ctx, order.ID)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	ret := &models.ReturnRequest{
+		UserID:       userID,
+		OrderID:      order.ID,
+		Status:       models.ReturnRequested,
+		Reason:       input.Reason,
+		Description:  strings.TrimSpace(input.Description),
+		RefundAmount: money.FromCents(0),
+	}
+	ordered := make(map[uint]int, len(order.Items))
+	for _, input := range input.Items {
+		item := order.Item(input.OrderItemID)
+		switch {
+		case item == nil:
+			return nil, telemetry.RecordError(span, fmt.Errorf("%w: order has no item %d", ErrInvalidReturn, input.OrderItemID))
+		case ordered[item.ID] != 0:
+			return nil, telemetry.RecordError(span, fmt.Errorf("%w: item %d is listed twice", ErrInvalidReturn, item.ID))
+		case input.Quantity <= 0:
+			return nil, telemetry.RecordError(span, fmt.Errorf("%w: quantity must be positive", ErrInvalidReturn))
+		case returned[item.ID]+input.Quantity > item.Quantity:
+			return nil, telemetry.RecordError(span, fmt.Errorf("%w: item %d", ErrReturnQuantityExceeded, item.ID))
+		}
+		ordered[item.ID] = item.Quantity
+
+		amount := returnAmount(item, returned[item.ID], input.Quantity)
+		returnItem := models.ReturnItem{
+			OrderItemID: item.ID,
+			ProductID:   item.ProductID,
+			Quantity:    input.Quantity,
+			Amount:      amount,
+		}
+		if item.VariantID != 0 {
+			variantID := item.VariantID
+			returnItem.VariantID = &variantID
+		}
+		ret.Items = append(ret.Items, returnItem)
+		ret.RefundAmount = ret.RefundAmount.Add(amount)
+	}
+
+	err = s.returnRepo.Create(ctx, ret, ordered)
+	if errors.Is(err, repository.ErrReturnQuantityExceeded) {
+		// Another return of the same items was created in the meantime.
+		err = ErrReturnQuantityExceeded
+	}
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	ret.Photos = []models.ReturnPhoto{}
+	return ret, nil
+}
+
+// returnAmount is what quantity units of the item were paid, after the
+// units already returned. The paid amount is split by unit, so returning
+// every unit adds up to exactly what the item was paid.
+func returnAmount(item *orders.Item, alreadyReturned, quantity int) money.Money {
+	amount := money.FromCents(0)
+	for _, unit := range item.Paid.Split(item.Quantity)[alreadyReturned : alreadyReturned+quantity] {
+		amount = amount.Add(unit)
+	}
+	return amount
+}
+
+// AddPhoto attaches a photo to a return of the user while it waits for
+// review. The content type is sniffed from the bytes.
+func (s *ReturnService) AddPhoto(ctx context.Context, userID, id uint, data []byte) (*models.ReturnPhoto, error) {
+	ctx, span := tracer.Start(ctx, "ReturnService.AddPhoto")
+	defer span.End()
+
+	if int64(len(data)) > s.maxPhotoSize {
+		return nil, telemetry.RecordError(span, ErrImageTooLarge)
+	}
+
+	ret, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	if ret.Status != models.ReturnRequested {
+		return nil, telemetry.RecordError(span, ErrReturnStatus)
+	}
+	if len(ret.Photos) >= maxReturnPhotos {
+		return nil, telemetry.RecordError(span, ErrTooManyReturnPhotos)
+	}
+
+	contentType := mimetype.Detect(data).String()
+	ext, ok := allowedImageTypes[contentType]
+	if !ok {
+		return nil, telemetry.RecordError(span, fmt.Errorf("%w: %s", ErrUnsupportedImageType, contentType))
+	}
+
+	photo := &models.ReturnPhoto{
+		ReturnRequestID: ret.ID,
+		StorageKey:      fmt.Sprintf("returns/%d/%s%s", ret.ID, uuid.NewString(), ext),
+		ContentType:     contentType,
+		Size:            int64(len(data)),
+	}
+	if err := s.storage.Put(ctx, photo.StorageKey, bytes.NewReader(data), photo.Size, contentType); err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	if err := s.returnRepo.AddPhoto(ctx, photo); err != nil {
+		_ = s.storage.Delete(context.WithoutCancel(ctx), photo.StorageKey)
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	if photo.URL, err = s.storage.SignedURL(ctx, photo.StorageKey, s.photoURLTTL); err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return photo, nil
+}
+
+// Get returns a return of the user.
+func (s *ReturnService) Get(ctx context.Context, userID, id uint) (*models.ReturnRequest, error) {
+	ctx, span := tracer.Start(ctx, "ReturnService.Get")
+	defer span.End()
+
+	ret, err := s.get(ctx, id)
+	if err == nil && ret.UserID != userID {
+		err = ErrReturnNotFound
+	}
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return ret, nil
+}
+
+// ListByUser returns the returns of the user, newest first.
+func (s *ReturnService) ListByUser(ctx context.Context, userID uint) ([]models.ReturnRequest, error) {
+	ctx, span := tracer.Start(ctx, "ReturnService.ListByUser")
+	defer span.End()
+
+	returns, err := s.returnRepo.ListByUser(ctx, userID)
+	if err == nil {
+		err = s.signAll(ctx, returns)
+	}
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return returns, nil
+}
+
+// List returns the returns in the status, or all of them, oldest first.
+func (s *ReturnService) List(ctx context.Context, status string) ([]models.ReturnRequest, error) {
+	ctx, span := tracer.Start(ctx, "ReturnService.List")
+	defer span.End()
+
+	returns, err := s.returnRepo.List(ctx, status)
+	if err == nil {
+		err = s.signAll(ctx, returns)
+	}
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return returns, nil
+}
+
+// GetAny returns any return, for the admin.
+func (s *ReturnService) GetAny(ctx context.Context, id uint) (*models.ReturnRequest, error) {
+	ctx, span := tracer.Start(ctx, "ReturnService.GetAny")
+	defer span.End()
+
+	ret, err := s.get(ctx, id)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return ret, nil
+}
+
+// Approve accepts a requested return; the customer can send the goods
+// back.
+func (s *ReturnService) Approve(ctx context.Context, admin *models.User, id uint) (*models.ReturnRequest, error) {
+	ctx, span := tracer.Start(ctx, "ReturnService.Approve")
+	defer span.End()
+
+	ret, err := s.transition(ctx, id, models.ReturnRequested, models.ReturnApproved, map[string]any{
+		"reviewed_by": admin.ID,
+		"reviewed_at": s.now(),
+	})
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return ret, nil
+}
+
+// Reject refuses a requested return with the reason given to the
+// customer. Its items can be asked for again.
+func (s *ReturnService) Reject(ctx context.Context, admin *models.User, id uint, reason string) (*models.ReturnRequest, error) {
+	ctx, span := tracer.Start(ctx, "ReturnService.Reject")
+	defer span.End()
+
+	ret, err := s.transition(ctx, id, models.ReturnRequested, models.ReturnRejected, map[string]any{
+		"reviewed_by":      admin.ID,
+		"reviewed_at":      s.now(),
+		"rejection_reason": strings.TrimSpace(reason),
+	})
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return ret, nil
+}
+
+// Receive records that the goods of an approved return came back and puts
+// them back in stock when restock is set; damaged goods are not.
+func (s *ReturnService) Receive(ctx context.Context, id uint, restock bool) (*models.ReturnRequest, error) {
+	ctx, span := tracer.Start(ctx, "ReturnService.Receive")
+	defer span.End()
+
+	ret, err := s.transition(ctx, id, models.ReturnApproved, models.ReturnReceived, map[string]any{
+		"received_at": s.now(),
+		"restocked":   restock,
+	})
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	if !restock {
+		return ret, nil
+	}
+
+	// The return is received at this point, so a failure is logged item by
+	// item to be fixed by hand rather than retried, which would restock
+	// twice.
+	var restockErr error
+	for _, item := range ret.Items {
+		var variantID uint
+		if item.VariantID != nil {
+			variantID = *item.VariantID
+		}
+		if err := s.productService.Restock(ctx, item.ProductID, variantID, item.Quantity); err != nil {
+			log.Printf("failed to restock %d units of product %d (variant %d) of return %d: %v", item.Quantity, item.ProductID, variantID, ret.ID, err)
+			restockErr = errors.Join(restockErr, err)
+		}
+	}
+	return ret, telemetry.RecordError(span, restockErr)
+}
+
+// Refund pays a received return back through the payment layer of the
+// order, which moves the order to partially or fully refunded. The refund
+// is keyed by the return, so retrying after a failure pays once.
+func (s *ReturnService) Refund(ctx context.Context, id uint) (*models.ReturnRequest, error) {
+	ctx, span := tracer.Start(ctx, "ReturnService.Refund")
+	defer span.End()
+
+	ret, err := s.get(ctx, id)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	if ret.Status != models.ReturnReceived {
+		return nil, telemetry.RecordError(span, ErrReturnStatus)
+	}
+
+	order, err := s.order(ctx, ret.OrderID)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	if err := s.orders.Refund(ctx, order.ID, ret.RefundAmount, fmt.Sprintf("return:%d", ret.ID)); err != nil {
+		if errors.Is(err, orders.ErrUnavailable) {
+			err = ErrOrdersUnavailable
+		}
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	ret, err = s.transition(ctx, id, models.ReturnReceived, models.ReturnRefunded, map[string]any{
+		"refunded_at": s.now(),
+	})
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	listenerCtx := context.WithoutCancel(ctx)
+	for _, listener := range s.refundListeners {
+		listener.ReturnRefunded(listenerCtx, ret, order)
+	}
+	return ret, nil
+}
+
+func (s *ReturnService) transition(ctx context.Context, id uint, from, to string, changes map[string]any) (*models.ReturnRequest, error) {
+	if _, err := s.get(ctx, id); err != nil {
+		return nil, err
+	}
+
+	err := s.returnRepo.Transition(ctx, id, from, to, changes)
+	if errors.Is(err, repository.ErrReturnStatusChanged) {
+		err = ErrReturnStatus
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.get(ctx, id)
+}
+
+// order reads the order from the source, mapping its errors.
+func (s *ReturnService) order(ctx context.Context, id string) (*orders.Order, error) {
+	order, err := s.orders.Get(ctx, id)
+	switch {
+	case errors.Is(err, orders.ErrNotFound):
+		err = ErrOrderNotFound
+	case errors.Is(err, orders.ErrUnavailable):
+		err = ErrOrdersUnavailable
+	}
+	return order, err
+}
+
+func (s *ReturnService) get(ctx context.Context, id uint) (*models.ReturnRequest, error) {
+	ret, err := s.returnRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReturnNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ret, s.sign(ctx, ret)
+}
+
+func (s *ReturnService) signAll(ctx context.Context, returns []models.ReturnRequest) error {
+	for i := range returns {
+		if err := s.sign(ctx, &returns[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sign gives the photos of the return short lived URLs.
+func (s *ReturnService) sign(ctx context.Context, ret *models.ReturnRequest) error {
+	for i := range ret.Photos {
+		url, err := s.storage.SignedURL(ctx, ret.Photos[i].StorageKey, s.photoURLTTL)
+		if err != nil {
+			return err
+		}
+		ret.Photos[i].URL = url
+	}
+	return nil
+}
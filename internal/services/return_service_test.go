@@ -0,0 +1,215 @@
+// internal/services/return_service_test.go
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/orders"
+	"github.com/Code-Aether/americanas-loja-api/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOrders simula o sistema de pedidos em memória
+type fakeOrders struct {
+	mu      sync.Mutex
+	orders  map[string]*orders.Order
+	refunds map[string]money.Money
+}
+
+func newFakeOrders(list ...*orders.Order) *fakeOrders {
+	source := &fakeOrders{orders: map[string]*orders.Order{}, refunds: map[string]money.Money{}}
+	for _, order := range list {
+		source.orders[order.ID] = order
+	}
+	return source
+}
+
+func (f *fakeOrders) Get(ctx context.Context, id string) (*orders.Order, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	order, ok := f.orders[id]
+	if !ok {
+		return nil, orders.ErrNotFound
+	}
+	copied := *order
+	return &copied, nil
+}
+
+func (f *fakeOrders) Refund(ctx context.Context, orderID string, amount money.Money, reference string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.orders[orderID]; !ok {
+		return orders.ErrNotFound
+	}
+	f.refunds[reference] = amount
+	return nil
+}
+
+func (f *fakeOrders) HasPurchased(ctx context.Context, userID, productID uint) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, order := range f.orders {
+		if order.UserID != userID || order.PaidAt == nil || order.Status == orders.StatusCancelled {
+			continue
+		}
+		for _, item := range order.Items {
+			if item.ProductID == productID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func TestReturnService(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8080/media", []byte("secret"))
+	require.NoError(t, err)
+
+	productRepo := repository.NewProductRepository(db)
+	productService := NewProductService(productRepo, nil)
+	user := testutils.CreateTestUser(t, db)
+	admin := testutils.CreateTestAdmin(t, db)
+	product := testutils.CreateTestProduct(t, db)
+	ctx := context.Background()
+
+	now := time.Now()
+	deliveredAt := now.Add(-2 * 24 * time.Hour)
+	source := newFakeOrders(
+		// 3 unidades por R$ 100,00 depois do desconto
+		&orders.Order{ID: "PED-1", UserID: user.ID, Status: orders.StatusDelivered, Total: money.MustParse("100.00"), DeliveredAt: &deliveredAt, Items: []orders.Item{
+			{ID: 1, ProductID: product.ID, Quantity: 3, Paid: money.MustParse("100.00")},
+		}},
+		&orders.Order{ID: "PED-2", UserID: user.ID, Status: orders.StatusShipped, Total: money.MustParse("50.00"), Items: []orders.Item{
+			{ID: 2, ProductID: product.ID, Quantity: 1, Paid: money.MustParse("50.00")},
+		}},
+	)
+	returnService := NewReturnService(repository.NewReturnRepository(db), productService, source, store, 7*24*time.Hour, 1<<20, time.Hour)
+
+	ask := func(orderID string, itemID uint, quantity int) (*models.ReturnRequest, error) {
+		return returnService.Create(ctx, user.ID, ReturnInput{
+			OrderID: orderID,
+			Reason:  models.ReturnDefective,
+			Items:   []ReturnItemInput{{OrderItemID: itemID, Quantity: quantity}},
+		})
+	}
+
+	var first *models.ReturnRequest
+
+	t.Run("✅ Solicitar devolução reembolsa o valor pago pelas unidades", func(t *testing.T) {
+		first, err = ask("PED-1", 1, 2)
+		require.NoError(t, err)
+
+		assert.Equal(t, models.ReturnRequested, first.Status)
+		assert.Equal(t, "66.67", first.RefundAmount.String())
+		require.Len(t, first.Items, 1)
+		assert.Equal(t, product.ID, first.Items[0].ProductID)
+	})
+
+	t.Run("❌ Devolver mais do que foi comprado", func(t *testing.T) {
+		_, err := ask("PED-1", 1, 2)
+		assert.ErrorIs(t, err, ErrReturnQuantityExceeded)
+	})
+
+	t.Run("❌ Pedido de outro usuário, não entregue ou fora do prazo", func(t *testing.T) {
+		_, err := returnService.Create(ctx, admin.ID, ReturnInput{OrderID: "PED-1", Reason: models.ReturnRegret, Items: []ReturnItemInput{{OrderItemID: 1, Quantity: 1}}})
+		assert.ErrorIs(t, err, ErrOrderNotFound)
+
+		_, err = ask("PED-2", 2, 1)
+		assert.ErrorIs(t, err, ErrOrderNotDelivered)
+
+		_, err = ask("PED-1", 99, 1)
+		assert.ErrorIs(t, err, ErrInvalidReturn)
+
+		returnService.now = func() time.Time { return now.Add(6 * 24 * time.Hour) }
+		defer func() { returnService.now = time.Now }()
+		_, err = ask("PED-1", 1, 1)
+		assert.ErrorIs(t, err, ErrReturnWindowClosed)
+	})
+
+	t.Run("✅ Foto anexada enquanto aguarda análise", func(t *testing.T) {
+		photo, err := returnService.AddPhoto(ctx, user.ID, first.ID, testPNG(t, 40, 40))
+		require.NoError(t, err)
+		assert.Equal(t, "image/png", photo.ContentType)
+		assert.Contains(t, photo.URL, "signature=")
+
+		_, err = returnService.AddPhoto(ctx, user.ID, first.ID, []byte("%PDF-1.4 arquivo qualquer"))
+		assert.ErrorIs(t, err, ErrUnsupportedImageType)
+
+		_, err = returnService.AddPhoto(ctx, admin.ID, first.ID, testPNG(t, 40, 40))
+		assert.ErrorIs(t, err, ErrReturnNotFound)
+	})
+
+	t.Run("✅ Aprovar, receber com reposição e reembolsar", func(t *testing.T) {
+		_, err := returnService.Refund(ctx, first.ID)
+		assert.ErrorIs(t, err, ErrReturnStatus)
+
+		ret, err := returnService.Approve(ctx, admin, first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ReturnApproved, ret.Status)
+		require.NotNil(t, ret.ReviewedBy)
+		assert.Equal(t, admin.ID, *ret.ReviewedBy)
+
+		_, err = returnService.AddPhoto(ctx, user.ID, first.ID, testPNG(t, 40, 40))
+		assert.ErrorIs(t, err, ErrReturnStatus)
+
+		ret, err = returnService.Receive(ctx, first.ID, true)
+		require.NoError(t, err)
+		assert.Equal(t, models.ReturnReceived, ret.Status)
+		assert.True(t, ret.Restocked)
+
+		restocked, err := productService.GetByID(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 12, restocked.Stock)
+
+		ret, err = returnService.Refund(ctx, first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ReturnRefunded, ret.Status)
+		assert.NotNil(t, ret.RefundedAt)
+		assert.Equal(t, map[string]money.Money{"return:1": money.MustParse("66.67")}, source.refunds)
+
+		_, err = returnService.Refund(ctx, first.ID)
+		assert.ErrorIs(t, err, ErrReturnStatus)
+	})
+
+	t.Run("✅ Devolução recusada libera as unidades", func(t *testing.T) {
+		ret, err := ask("PED-1", 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "33.33", ret.RefundAmount.String())
+
+		ret, err = returnService.Reject(ctx, admin, ret.ID, "Produto com sinais de uso")
+		require.NoError(t, err)
+		assert.Equal(t, models.ReturnRejected, ret.Status)
+		assert.Equal(t, "Produto com sinais de uso", ret.RejectionReason)
+
+		_, err = returnService.Approve(ctx, admin, ret.ID)
+		assert.ErrorIs(t, err, ErrReturnStatus)
+
+		_, err = ask("PED-1", 1, 1)
+		assert.NoError(t, err)
+
+		returns, err := returnService.ListByUser(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Len(t, returns, 3)
+		requested, err := returnService.List(ctx, models.ReturnRequested)
+		require.NoError(t, err)
+		assert.Len(t, requested, 1)
+	})
+
+	t.Run("❌ Sem sistema de pedidos configurado", func(t *testing.T) {
+		unavailable := NewReturnService(repository.NewReturnRepository(db), productService, orders.None{}, store, time.Hour, 1<<20, time.Hour)
+		_, err := unavailable.Create(ctx, user.ID, ReturnInput{OrderID: "PED-1", Reason: models.ReturnRegret, Items: []ReturnItemInput{{OrderItemID: 1, Quantity: 1}}})
+		assert.ErrorIs(t, err, ErrOrdersUnavailable)
+	})
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/pkg/orders"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+	"github.com/Code-Aether/americanas-loja-api/pkg/telemetry"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReviewNotFound      = errors.New("review not found")
+	ErrReviewExists        = errors.New("user already reviewed the product")
+	ErrReviewChanged       = errors.New("review was moderated meanwhile, reload it and try again")
+	ErrInvalidReviewStatus = errors.New("invalid review status")
+)
+
+// PurchaseChecker tells whether a user bought a product, which marks their
+// review as a verified purchase.
+type PurchaseChecker interface {
+	HasPurchased(ctx context.Context, userID, productID uint) (bool, error)
+}
+
+// orderPurchases checks purchases against the order system. Without one
+// nobody has bought anything as far as the store knows, so no review is
+// verified.
+type orderPurchases struct {
+	source orders.Source
+}
+
+func NewOrderPurchases(source orders.Source) PurchaseChecker {
+	return orderPurchases{source: source}
+}
+
+func (p orderPurchases) HasPurchased(ctx context.Context, userID, productID uint) (bool, error) {
+	bought, err := p.source.HasPurchased(ctx, userID, productID)
+	if errors.Is(err, orders.ErrUnavailable) {
+		return false, nil
+	}
+	return bought, err
+}
+
+type ReviewInput struct {
+	Rating int
+	Title  string
+	Body   string
+}
+
+// ReviewService takes reviews into a moderation queue and keeps the rating
+// of each product in step with its approved reviews.
+type ReviewService struct {
+	reviewRepo     *repository.ReviewRepository
+	productRepo    *repository.ProductRepository
+	productService *ProductService
+	// purchases tells who bought what; when nil, no review is verified.
+	purchases PurchaseChecker
+	now       func() time.Time
+}
+
+func NewReviewService(reviewRepo *repository.ReviewRepository, productRepo *repository.ProductRepository, productService *ProductService, purchases PurchaseChecker) *ReviewService {
+	return &ReviewService{
+		reviewRepo:     reviewRepo,
+		productRepo:    productRepo,
+		productService: productService,
+		purchases:      purchases,
+		now:            time.Now,
+	}
+}
+
+// Create adds the review of the user to the moderation queue.
+func (s *ReviewService) Create(ctx context.Context, productID uint, user *models.User, input ReviewInput) (*models.Review, error) {
+	ctx, span := tracer.Start(ctx, "ReviewService.Create")
+	defer span.End()
+
+	if _, err := s.productRepo.GetByID(ctx, productID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrProductNotFound
+		}
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	verified := false
+	if s.purchases != nil {
+		bought, err := s.purchases.HasPurchased(ctx, user.ID, productID)
+		if err != nil {
+			return nil, telemetry.RecordError(span, err)
+		}
+		verified = bought
+	}
+
+	review := &models.Review{
+		ProductID:        productID,
+		UserID:           user.ID,
+		Author:           user.Name,
+		Rating:           input.Rating,
+		Title:            input.Title,
+		Body:             input.Body,
+		VerifiedPurchase: verified,
+		Status:           models.ReviewPending,
+	}
+
+	err := s.reviewRepo.Create(ctx, review)
+	if errors.Is(err, repository.ErrReviewExists) {
+		err = ErrReviewExists
+	}
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	return review, nil
+}
+
+// reviewListQuery fingerprints the review listings, so a cursor of one
+// product or status cannot be replayed on another.
+func reviewListQuery(scope string, productID uint, status string) string {
+	return pagination.Fingerprint("reviews", scope, fmt.Sprint(productID), status)
+}
+
+// List returns a page of the approved reviews of the product, newest first.
+// The total is counted in the given mode, which defaults to exact on the
+// first page and none after it.
+func (s *ReviewService) List(ctx context.Context, productID uint, limit int, cursor *pagination.Cursor, count string) (*models.ReviewListing, error) {
+	ctx, span := tracer.Start(ctx, "ReviewService.List")
+	defer span.End()
+
+	if _, err := s.productRepo.GetByID(ctx, productID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrProductNotFound
+		}
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	listing, err := s.list(ctx, reviewListQuery("product", productID, models.ReviewApproved), cursor, count,
+		func() ([]models.Review, *pagination.Cursor, *pagination.Cursor, error) {
+			return s.reviewRepo.ListByProduct(ctx, productID, models.ReviewApproved, limit, cursor)
+		},
+		func(approximate bool) (int64, bool, error) {
+			return s.reviewRepo.CountByProduct(ctx, productID, models.ReviewApproved, approximate)
+		})
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return listing, nil
+}
+
+// Queue returns a page of the reviews of every product in the status,
+// oldest first, for moderation.
+func (s *ReviewService) Queue(ctx context.Context, status string, limit int, cursor *pagination.Cursor, count string) (*models.ReviewListing, error) {
+	ctx, span := tracer.Start(ctx, "ReviewService.Queue")
+	defer span.End()
+
+	if !validReviewStatus(status) {
+		return nil, telemetry.RecordError(span, ErrInvalidReviewStatus)
+	}
+
+	listing, err := s.list(ctx, reviewListQuery("queue", 0, status), cursor, count,
+		func() ([]models.Review, *pagination.Cursor, *pagination.Cursor, error) {
+			return s.reviewRepo.ListByStatus(ctx, status, limit, cursor)
+		},
+		func(approximate bool) (int64, bool, error) {
+			return s.reviewRepo.CountByStatus(ctx, status, approximate)
+		})
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return listing, nil
+}
+
+func (s *ReviewService) list(ctx context.Context, query string, cursor *pagination.Cursor, count string,
+	page func() ([]models.Review, *pagination.Cursor, *pagination.Cursor, error),
+	total func(approximate bool) (int64, bool, error)) (*models.ReviewListing, error) {
+	if cursor != nil && cursor.Query != query {
+		return nil, pagination.ErrInvalidCursor
+	}
+
+	if count == "" {
+		count = pagination.CountExact
+		if cursor != nil {
+			count = pagination.CountNone
+		}
+	}
+
+	reviews, next, prev, err := page()
+	if err != nil {
+		return nil, err
+	}
+
+	listing := &models.ReviewListing{
+		Reviews: reviews,
+		Next:    next,
+		Prev:    prev,
+	}
+	for _, c := range []*pagination.Cursor{next, prev} {
+		if c != nil {
+			c.Query = query
+		}
+	}
+
+	if count != pagination.CountNone {
+		n, approximate, err := total(count == pagination.CountApproximate)
+		if err != nil {
+			return nil, err
+		}
+		listing.Total = &n
+		listing.TotalApproximate = approximate
+	}
+
+	return listing, nil
+}
+
+// Moderate approves or rejects the review, updating the rating of its
+// product. A rejected review can be approved later and the other way
+// around.
+func (s *ReviewService) Moderate(ctx context.Context, id uint, moderator *models.User, status, reason string) (*models.Review, error) {
+	ctx, span := tracer.Start(ctx, "ReviewService.Moderate")
+	defer span.End()
+
+	if status != models.ReviewApproved && status != models.ReviewRejected {
+		return nil, telemetry.RecordError(span, ErrInvalidReviewStatus)
+	}
+	if status == models.ReviewApproved {
+		reason = ""
+	}
+
+	review, err := s.reviewRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrReviewNotFound
+		}
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	err = s.reviewRepo.Moderate(ctx, review, status, reason, moderator.ID, s.now())
+	if errors.Is(err, repository.ErrReviewChanged) {
+		err = ErrReviewChanged
+	}
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	s.productService.InvalidateProducts(ctx, review.ProductID)
+
+	return review, nil
+}
+
+func validReviewStatus(status string) bool {
+	switch status {
+	case models.ReviewPending, models.ReviewApproved, models.ReviewRejected:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+// internal/services/review_service_test.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
+	"github.com/Code-Aether/americanas-loja-api/pkg/orders"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// purchasesOf simula as compras dos usuários com os IDs do mapa
+type purchasesOf map[uint]bool
+
+func (p purchasesOf) HasPurchased(ctx context.Context, userID, productID uint) (bool, error) {
+	return p[userID], nil
+}
+
+func TestReviewService(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	productRepo := repository.NewProductRepository(db)
+	productService := NewProductService(productRepo, nil)
+	ctx := context.Background()
+
+	product := testutils.CreateTestProduct(t, db)
+	admin := testutils.CreateTestAdmin(t, db)
+
+	var customers []*models.User
+	for i := range 3 {
+		user := &models.User{Name: fmt.Sprintf("Cliente %d", i), Email: fmt.Sprintf("cliente%d@test.com", i), Password: "hash", Role: "user", Active: true}
+		require.NoError(t, db.Create(user).Error)
+		customers = append(customers, user)
+	}
+
+	reviewService := NewReviewService(repository.NewReviewRepository(db), productRepo, productService, purchasesOf{customers[0].ID: true})
+
+	rating := func(t *testing.T) models.ProductRating {
+		found, err := productService.GetByID(ctx, product.ID)
+		require.NoError(t, err)
+		return found.Rating
+	}
+
+	var reviews []*models.Review
+
+	t.Run("✅ Avaliação fica pendente e não entra na nota", func(t *testing.T) {
+		for i, stars := range []int{5, 4, 1} {
+			review, err := reviewService.Create(ctx, product.ID, customers[i], ReviewInput{Rating: stars, Title: "Minha opinião"})
+			require.NoError(t, err)
+			assert.Equal(t, models.ReviewPending, review.Status)
+			assert.Equal(t, customers[i].Name, review.Author)
+			reviews = append(reviews, review)
+		}
+
+		assert.True(t, reviews[0].VerifiedPurchase, "Comprador deve ter compra verificada")
+		assert.False(t, reviews[1].VerifiedPurchase)
+
+		assert.Zero(t, rating(t).Count)
+
+		listing, err := reviewService.List(ctx, product.ID, 10, nil, "")
+		require.NoError(t, err)
+		assert.Empty(t, listing.Reviews)
+
+		queue, err := reviewService.Queue(ctx, models.ReviewPending, 10, nil, "")
+		require.NoError(t, err)
+		require.Len(t, queue.Reviews, 3)
+		assert.Equal(t, reviews[0].ID, queue.Reviews[0].ID, "Fila começa pela mais antiga")
+	})
+
+	t.Run("✅ Aprovar e rejeitar atualiza a nota de forma incremental", func(t *testing.T) {
+		for _, review := range reviews {
+			_, err := reviewService.Moderate(ctx, review.ID, admin, models.ReviewApproved, "")
+			require.NoError(t, err)
+		}
+
+		got := rating(t)
+		assert.Equal(t, 3, got.Count)
+		assert.Equal(t, 3.3, got.Average)
+		assert.Equal(t, models.RatingHistogram{One: 1, Four: 1, Five: 1}, got.Histogram)
+
+		rejected, err := reviewService.Moderate(ctx, reviews[2].ID, admin, models.ReviewRejected, "Ofensiva")
+		require.NoError(t, err)
+		assert.Equal(t, "Ofensiva", rejected.RejectionReason)
+		assert.Equal(t, admin.ID, *rejected.ModeratedBy)
+
+		got = rating(t)
+		assert.Equal(t, 2, got.Count)
+		assert.Equal(t, 4.5, got.Average)
+		assert.Equal(t, models.RatingHistogram{Four: 1, Five: 1}, got.Histogram)
+
+		// Aprovar de novo não conta a avaliação duas vezes
+		_, err = reviewService.Moderate(ctx, reviews[0].ID, admin, models.ReviewApproved, "")
+		require.NoError(t, err)
+		assert.Equal(t, 2, rating(t).Count)
+	})
+
+	t.Run("✅ Atualizar o produto preserva a nota", func(t *testing.T) {
+		found, err := productService.GetByID(ctx, product.ID)
+		require.NoError(t, err)
+		found.Rating = models.ProductRating{}
+		found.Name = "Produto Renomeado"
+		require.NoError(t, productService.Update(ctx, found))
+
+		assert.Equal(t, 2, rating(t).Count)
+	})
+
+	t.Run("✅ Lista apenas aprovadas, paginadas por cursor", func(t *testing.T) {
+		listing, err := reviewService.List(ctx, product.ID, 1, nil, "")
+		require.NoError(t, err)
+		require.Len(t, listing.Reviews, 1)
+		assert.Equal(t, int64(2), *listing.Total)
+		assert.Equal(t, reviews[1].ID, listing.Reviews[0].ID, "Mais recente primeiro")
+		require.NotNil(t, listing.Next)
+
+		next, err := reviewService.List(ctx, product.ID, 1, listing.Next, "")
+		require.NoError(t, err)
+		require.Len(t, next.Reviews, 1)
+		assert.Equal(t, reviews[0].ID, next.Reviews[0].ID)
+
+		_, err = reviewService.Queue(ctx, models.ReviewApproved, 1, listing.Next, "")
+		assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+	})
+
+	t.Run("❌ Uma avaliação por usuário e produto", func(t *testing.T) {
+		_, err := reviewService.Create(ctx, product.ID, customers[0], ReviewInput{Rating: 3, Title: "De novo"})
+		assert.ErrorIs(t, err, ErrReviewExists)
+	})
+
+	t.Run("❌ Produto, avaliação e status inválidos", func(t *testing.T) {
+		_, err := reviewService.Create(ctx, 9999, customers[0], ReviewInput{Rating: 3, Title: "Sumiu"})
+		assert.ErrorIs(t, err, ErrProductNotFound)
+
+		_, err = reviewService.Moderate(ctx, 9999, admin, models.ReviewApproved, "")
+		assert.ErrorIs(t, err, ErrReviewNotFound)
+
+		_, err = reviewService.Moderate(ctx, reviews[0].ID, admin, models.ReviewPending, "")
+		assert.ErrorIs(t, err, ErrInvalidReviewStatus)
+
+		_, err = reviewService.Queue(ctx, "spam", 10, nil, "")
+		assert.ErrorIs(t, err, ErrInvalidReviewStatus)
+	})
+}
+
+func TestOrderPurchases(t *testing.T) {
+	ctx := context.Background()
+	paidAt := time.Now().Add(-48 * time.Hour)
+	source := newFakeOrders(
+		&orders.Order{ID: "PED-1", UserID: 1, Status: orders.StatusDelivered, PaidAt: &paidAt, Items: []orders.Item{{ID: 1, ProductID: 10, Quantity: 1}}},
+		&orders.Order{ID: "PED-2", UserID: 1, Status: orders.StatusPending, Items: []orders.Item{{ID: 2, ProductID: 20, Quantity: 1}}},
+	)
+
+	t.Run("✅ Compra paga marca a avaliação como verificada", func(t *testing.T) {
+		bought, err := NewOrderPurchases(source).HasPurchased(ctx, 1, 10)
+		require.NoError(t, err)
+		assert.True(t, bought)
+	})
+
+	t.Run("❌ Pedido não pago ou de outro cliente", func(t *testing.T) {
+		bought, err := NewOrderPurchases(source).HasPurchased(ctx, 1, 20)
+		require.NoError(t, err)
+		assert.False(t, bought)
+
+		bought, err = NewOrderPurchases(source).HasPurchased(ctx, 2, 10)
+		require.NoError(t, err)
+		assert.False(t, bought)
+	})
+
+	t.Run("✅ Sem sistema de pedidos nenhuma avaliação é verificada", func(t *testing.T) {
+		bought, err := NewOrderPurchases(orders.None{}).HasPurchased(ctx, 1, 10)
+		require.NoError(t, err, "Avaliações continuam sendo aceitas sem sistema de pedidos")
+		assert.False(t, bought)
+	})
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/pkg/br"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/shipping"
+	"github.com/Code-Aether/americanas-loja-api/pkg/telemetry"
+	"gorm.io/gorm"
+)
+
+var ErrNoShippingOptions = errors.New("no delivery option for the destination and package")
+
+// ShippingQuote is the package of a cart and the ways it can be delivered,
+// from the cheapest.
+type ShippingQuote struct {
+	Package             shipping.Package  `json:"package"`
+	CubicWeightGrams    int               `json:"cubic_weight_grams"`
+	BillableWeightGrams int               `json:"billable_weight_grams"`
+	Options             []shipping.Option `json:"options"`
+}
+
+// ShippingService quotes the delivery of carts with the configured
+// carriers, which only see the package; adding a carrier needs no change
+// here.
+type ShippingService struct {
+	productRepo *repository.ProductRepository
+	carrier     shipping.Carrier
+	// defaultWeight (grams) and defaultVolume (cm³) stand for each unit of
+	// products without their own measures.
+	defaultWeight int
+	defaultVolume int
+}
+
+func NewShippingService(productRepo *repository.ProductRepository, carrier shipping.Carrier, defaultWeight, defaultVolume int) *ShippingService {
+	return &ShippingService{
+		productRepo:   productRepo,
+		carrier:       carrier,
+		defaultWeight: defaultWeight,
+		defaultVolume: defaultVolume,
+	}
+}
+
+// Quote packs the items and returns the delivery options to the CEP.
+func (s *ShippingService) Quote(ctx context.Context, cep string, items []CartItem) (*ShippingQuote, error) {
+	ctx, span := tracer.Start(ctx, "ShippingService.Quote")
+	defer span.End()
+
+	cep, err := br.NormalizeCEP(cep)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	pkg, err := s.pack(ctx, items)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	pkg.DestinationCEP = cep
+
+	options, err := s.carrier.Quote(ctx, pkg)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	if len(options) == 0 {
+		return nil, telemetry.RecordError(span, fmt.Errorf("%w: CEP %s, %d g", ErrNoShippingOptions, cep, pkg.BillableWeightGrams()))
+	}
+
+	return &ShippingQuote{
+		Package:             pkg,
+		CubicWeightGrams:    pkg.CubicWeightGrams(),
+		BillableWeightGrams: pkg.BillableWeightGrams(),
+		Options:             options,
+	}, nil
+}
+
+// pack adds up the weight, volume and value of the items. Variants share
+// the measures of their product but are valued at their own price.
+func (s *ShippingService) pack(ctx context.Context, items []CartItem) (shipping.Package, error) {
+	pkg := shipping.Package{Value: money.FromCents(0)}
+	for _, item := range items {
+		product, err := s.productRepo.GetByID(ctx, item.ProductID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = fmt.Errorf("%w: %d", ErrProductNotFound, item.ProductID)
+		}
+		if err != nil {
+			return shipping.Package{}, err
+		}
+
+		price := product.EffectivePrice
+		if item.VariantID != 0 {
+			variant := findVariant(product, item.VariantID)
+			if variant == nil {
+				return shipping.Package{}, fmt.Errorf("%w: %d", ErrVariantNotFound, item.VariantID)
+			}
+			price = variant.FinalPrice
+		}
+
+		weight, volume := product.WeightGrams, product.LengthCm*product.WidthCm*product.HeightCm
+		if weight == 0 {
+			weight = s.defaultWeight
+		}
+		if volume == 0 {
+			volume = s.defaultVolume
+		}
+
+		pkg.WeightGrams += weight * item.Quantity
+		pkg.VolumeCm3 += volume * item.Quantity
+		pkg.Value = pkg.Value.Add(price.Mul(int64(item.Quantity)))
+	}
+	return pkg, nil
+}
@@ -0,0 +1,61 @@
+// internal/services/shipping_service_test.go
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
+	"github.com/Code-Aether/americanas-loja-api/pkg/br"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/shipping"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShippingService(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	productRepo := repository.NewProductRepository(db)
+	productService := NewProductService(productRepo, nil)
+	shippingService := NewShippingService(productRepo, shipping.DefaultTableCarrier(), 1000, 3000)
+	ctx := context.Background()
+
+	// Sem medidas: usa 1 kg e 3000 cm³ por unidade
+	unmeasured := testutils.CreateTestProduct(t, db)
+	// Leve e volumoso: 40x30x20 = 4 kg cúbicos
+	pillow := &models.Product{Name: "Travesseiro", SKU: "PILLOW", Price: money.MustParse("80.00"), Stock: 5, Active: true, WeightGrams: 600, LengthCm: 40, WidthCm: 30, HeightCm: 20}
+	require.NoError(t, productService.Create(ctx, pillow))
+
+	t.Run("✅ Soma pesos e volumes e cobra pelo peso cúbico", func(t *testing.T) {
+		quote, err := shippingService.Quote(ctx, "01310-100", []CartItem{
+			{ProductID: pillow.ID, Quantity: 1},
+			{ProductID: unmeasured.ID, Quantity: 2},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "01310100", quote.Package.DestinationCEP)
+		assert.Equal(t, 2600, quote.Package.WeightGrams)
+		assert.Equal(t, 30000, quote.Package.VolumeCm3)
+		assert.Equal(t, 5000, quote.CubicWeightGrams)
+		assert.Equal(t, 5000, quote.BillableWeightGrams)
+		assert.Equal(t, money.MustParse("80.00").Add(unmeasured.Price.Mul(2)), quote.Package.Value)
+
+		require.Len(t, quote.Options, 2)
+		assert.Equal(t, shipping.ServiceStandard, quote.Options[0].Service, "Mais barato primeiro")
+		assert.Greater(t, quote.Options[0].DeliveryDays, quote.Options[1].DeliveryDays)
+	})
+
+	t.Run("❌ CEP inválido ou produto inexistente", func(t *testing.T) {
+		_, err := shippingService.Quote(ctx, "abc", []CartItem{{ProductID: pillow.ID, Quantity: 1}})
+		assert.ErrorIs(t, err, br.ErrInvalidCEP)
+
+		_, err = shippingService.Quote(ctx, "01310-100", []CartItem{{ProductID: 9999, Quantity: 1}})
+		assert.ErrorIs(t, err, ErrProductNotFound)
+	})
+
+	t.Run("❌ Pacote acima do limite das transportadoras", func(t *testing.T) {
+		_, err := shippingService.Quote(ctx, "01310-100", []CartItem{{ProductID: pillow.ID, Quantity: 10}})
+		assert.ErrorIs(t, err, ErrNoShippingOptions)
+	})
+}
@@ -0,0 +1,5 @@
+package services
+
+import "github.com/Code-Aether/americanas-loja-api/pkg/telemetry"
+
+var tracer = telemetry.Tracer("github.com/Code-Aether/americanas-loja-api/internal/services")
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+	"github.com/Code-Aether/americanas-loja-api/pkg/telemetry"
+)
+
+// userListQuery is the fingerprint of the user listing, which has no
+// filters yet; it keeps product cursors from being replayed here.
+var userListQuery = pagination.Fingerprint("users")
+
+type UserService struct {
+	userRepo *repository.UserRepository
+}
+
+func NewUserService(userRepo *repository.UserRepository) *UserService {
+	return &UserService{
+		userRepo: userRepo,
+	}
+}
+
+// List returns a page of users, newest first. The total is counted in the
+// given mode, which defaults to exact on the first page and none after it.
+func (s *UserService) List(ctx context.Context, limit int, cursor *pagination.Cursor, count string) (*models.UserListing, error) {
+	ctx, span := tracer.Start(ctx, "UserService.List")
+	defer span.End()
+
+	if cursor != nil && cursor.Query != userListQuery {
+		return nil, telemetry.RecordError(span, pagination.ErrInvalidCursor)
+	}
+
+	if count == "" {
+		count = pagination.CountExact
+		if cursor != nil {
+			count = pagination.CountNone
+		}
+	}
+
+	users, next, prev, err := s.userRepo.List(ctx, limit, cursor)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	listing := &models.UserListing{
+		Users: users,
+		Next:  next,
+		Prev:  prev,
+	}
+	for _, c := range []*pagination.Cursor{next, prev} {
+		if c != nil {
+			c.Query = userListQuery
+		}
+	}
+
+	if count != pagination.CountNone {
+		total, approximate, err := s.userRepo.Count(ctx, count == pagination.CountApproximate)
+		if err != nil {
+			return nil, telemetry.RecordError(span, err)
+		}
+		listing.Total = &total
+		listing.TotalApproximate = approximate
+	}
+
+	return listing, nil
+}
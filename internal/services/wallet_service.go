@@ -0,0 +1,462 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/orders"
+	"github.com/Code-Aether/americanas-loja-api/pkg/pagination"
+	"github.com/Code-Aether/americanas-loja-api/pkg/telemetry"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUserNotFound         = errors.New("user not found")
+	ErrInvalidWalletAmount  = errors.New("wallet amount must be positive")
+	ErrIdempotencyKeyNeeded = errors.New("idempotency key is required")
+	ErrInsufficientBalance  = errors.New("wallet balance is not enough")
+	ErrIdempotencyKeyReused = errors.New("idempotency key was used for another transaction")
+	ErrGiftCardNotFound     = errors.New("gift card not found")
+	ErrGiftCardCodeTaken    = errors.New("gift card code already exists")
+	ErrGiftCardRedeemed     = errors.New("gift card was already redeemed")
+	ErrGiftCardExpired      = errors.New("gift card has expired")
+	ErrInvalidGiftCard      = errors.New("invalid gift card")
+	ErrOrderNotPayable      = errors.New("order is not awaiting payment")
+)
+
+// giftCardAlphabet leaves out 0, 1, I and O, which are easily mistaken
+// for one another when typed from a printed card.
+const giftCardAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// WalletBalance is the balance of a wallet and the credits in it that
+// expire, the soonest first.
+type WalletBalance struct {
+	Balance  money.Money        `json:"balance" swaggertype:"number"`
+	Expiring []models.WalletLot `json:"expiring"`
+}
+
+// WalletService keeps the store credit of users. Every change goes through
+// the ledger with an idempotency key, so callers can retry safely: PayOrder
+// keys the debit that pays part of an order by the order.
+type WalletService struct {
+	walletRepo *repository.WalletRepository
+	userRepo   *repository.UserRepository
+	// giftCardValidity is how long new gift cards last when no expiry is
+	// given.
+	giftCardValidity time.Duration
+	now              func() time.Time
+}
+
+func NewWalletService(walletRepo *repository.WalletRepository, userRepo *repository.UserRepository, giftCardValidity time.Duration) *WalletService {
+	return &WalletService{
+		walletRepo:       walletRepo,
+		userRepo:         userRepo,
+		giftCardValidity: giftCardValidity,
+		now:              time.Now,
+	}
+}
+
+// walletListQuery fingerprints the ledger of a user, so a cursor of one
+// ledger cannot be replayed on another.
+func walletListQuery(userID uint) string {
+	return pagination.Fingerprint("wallet", fmt.Sprint(userID))
+}
+
+// Get returns the balance of the user and the credits that expire.
+func (s *WalletService) Get(ctx context.Context, userID uint) (*WalletBalance, error) {
+	ctx, span := tracer.Start(ctx, "WalletService.Get")
+	defer span.End()
+
+	wallet, err := s.walletRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	lots, err := s.walletRepo.Lots(ctx, userID)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	balance := &WalletBalance{Balance: wallet.Balance, Expiring: []models.WalletLot{}}
+	for _, lot := range lots {
+		if lot.ExpiresAt != nil {
+			balance.Expiring = append(balance.Expiring, lot)
+		}
+	}
+	return balance, nil
+}
+
+// Transactions returns a page of the ledger of the user, newest first.
+func (s *WalletService) Transactions(ctx context.Context, userID uint, limit int, cursor *pagination.Cursor) (*models.WalletTransactionListing, error) {
+	ctx, span := tracer.Start(ctx, "WalletService.Transactions")
+	defer span.End()
+
+	query := walletListQuery(userID)
+	if cursor != nil && cursor.Query != query {
+		return nil, telemetry.RecordError(span, pagination.ErrInvalidCursor)
+	}
+
+	transactions, next, prev, err := s.walletRepo.ListTransactions(ctx, userID, limit, cursor)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	for _, c := range []*pagination.Cursor{next, prev} {
+		if c != nil {
+			c.Query = query
+		}
+	}
+	return &models.WalletTransactionListing{Transactions: transactions, Next: next, Prev: prev}, nil
+}
+
+// Credit adds amount to the wallet of the user, as for a refund, expiring
+// at expiresAt when it is not nil.
+func (s *WalletService) Credit(ctx context.Context, userID uint, amount money.Money, reason, reference, key string, expiresAt *time.Time) (*models.WalletTransaction, error) {
+	ctx, span := tracer.Start(ctx, "WalletService.Credit")
+	defer span.End()
+
+	txn := &models.WalletTransaction{
+		UserID:         userID,
+		Type:           models.WalletCredit,
+		Amount:         amount,
+		Reason:         reason,
+		Reference:      reference,
+		IdempotencyKey: key,
+		ExpiresAt:      expiresAt,
+	}
+	if err := s.apply(ctx, txn); err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return txn, nil
+}
+
+// Debit spends amount of the wallet of the user, as PayOrder does to pay
+// part of an order, failing with ErrInsufficientBalance when the
+// balance is short.
+func (s *WalletService) Debit(ctx context.Context, userID uint, amount money.Money, reason, reference, key string) (*models.WalletTransaction, error) {
+	ctx, span := tracer.Start(ctx, "WalletService.Debit")
+	defer span.End()
+
+	txn := &models.WalletTransaction{
+		UserID:         userID,
+		Type:           models.WalletDebit,
+		Amount:         amount,
+		Reason:         reason,
+		Reference:      reference,
+		IdempotencyKey: key,
+	}
+	if err := s.apply(ctx, txn); err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return txn, nil
+}
+
+// PayOrder pays amount of a pending order with the store credit of its
+// customer. The checkout calls it before charging the rest of the total to
+// the payment layer. The debit is keyed by the order, so paying the same
+// order again returns the first debit.
+func (s *WalletService) PayOrder(ctx context.Context, order *orders.Order, amount money.Money) (*models.WalletTransaction, error) {
+	ctx, span := tracer.Start(ctx, "WalletService.PayOrder")
+	defer span.End()
+
+	switch {
+	case order.Status != orders.StatusPending:
+		return nil, telemetry.RecordError(span, ErrOrderNotPayable)
+	case amount.Cmp(order.Total) > 0:
+		return nil, telemetry.RecordError(span, fmt.Errorf("%w: more than the order total", ErrInvalidWalletAmount))
+	}
+
+	reference := orderReference(order.ID)
+	txn, err := s.Debit(ctx, order.UserID, amount, "Pagamento do pedido "+order.ID, reference, reference)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return txn, nil
+}
+
+// RefundOrder gives back to the wallet amount of what the order was paid
+// with store credit, as when the order is cancelled or returned; the rest
+// is refunded by the payment layer. Reference identifies the refund, like
+// the return, so a retried refund credits once. Refunds of an order never
+// add up to more than the wallet paid for it.
+func (s *WalletService) RefundOrder(ctx context.Context, order *orders.Order, amount money.Money, reference string) (*models.WalletTransaction, error) {
+	ctx, span := tracer.Start(ctx, "WalletService.RefundOrder")
+	defer span.End()
+
+	if strings.TrimSpace(reference) == "" {
+		return nil, telemetry.RecordError(span, ErrIdempotencyKeyNeeded)
+	}
+
+	orderRef := orderReference(order.ID)
+	key := orderRef + ":refund:" + reference
+	paid, err := s.walletRepo.SumByReference(ctx, order.UserID, models.WalletDebit, orderRef)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	refunded, err := s.walletRepo.SumByReference(ctx, order.UserID, models.WalletCredit, orderRef)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	if amount.Cmp(paid.Sub(refunded)) > 0 {
+		// A retry of a refund already made is answered by the ledger.
+		if existing, err := s.walletRepo.GetTransactionByKey(ctx, order.UserID, key); err == nil && existing.Amount.Cmp(amount) == 0 {
+			return existing, nil
+		}
+		return nil, telemetry.RecordError(span, fmt.Errorf("%w: more than the wallet paid for the order", ErrInvalidWalletAmount))
+	}
+
+	txn, err := s.Credit(ctx, order.UserID, amount, "Estorno do pedido "+order.ID, orderRef, key, nil)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return txn, nil
+}
+
+// orderReference ties the ledger entries of an order to it.
+func orderReference(orderID string) string {
+	return "order:" + orderID
+}
+
+// Adjust credits a positive amount to, or debits a negative one from, the
+// wallet of the user on behalf of the admin. The reason is kept in the
+// ledger; expiresAt only applies to credits.
+func (s *WalletService) Adjust(ctx context.Context, admin *models.User, userID uint, amount money.Money, reason, key string, expiresAt *time.Time) (*models.WalletTransaction, error) {
+	ctx, span := tracer.Start(ctx, "WalletService.Adjust")
+	defer span.End()
+
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrUserNotFound
+		}
+		return nil, telemetry.RecordError(span, err)
+	}
+	if strings.TrimSpace(key) == "" {
+		return nil, telemetry.RecordError(span, ErrIdempotencyKeyNeeded)
+	}
+
+	txn := &models.WalletTransaction{
+		UserID:         userID,
+		Type:           models.WalletCredit,
+		Amount:         amount,
+		Reason:         strings.TrimSpace(reason),
+		Reference:      fmt.Sprintf("admin:%d", admin.ID),
+		IdempotencyKey: "adjustment:" + key,
+		ExpiresAt:      expiresAt,
+		CreatedBy:      &admin.ID,
+	}
+	if amount.IsNegative() {
+		txn.Type, txn.Amount, txn.ExpiresAt = models.WalletDebit, money.FromCents(-amount.Amount), nil
+	}
+
+	if err := s.apply(ctx, txn); err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return txn, nil
+}
+
+func (s *WalletService) apply(ctx context.Context, txn *models.WalletTransaction) error {
+	if !txn.Amount.IsPositive() {
+		return ErrInvalidWalletAmount
+	}
+	if txn.IdempotencyKey == "" {
+		return ErrIdempotencyKeyNeeded
+	}
+	now := s.now()
+	if txn.ExpiresAt != nil && !txn.ExpiresAt.After(now) {
+		return fmt.Errorf("%w: credit must expire in the future", ErrInvalidWalletAmount)
+	}
+
+	err := s.walletRepo.Apply(ctx, txn, now)
+	switch {
+	case errors.Is(err, repository.ErrInsufficientBalance):
+		err = ErrInsufficientBalance
+	case errors.Is(err, repository.ErrIdempotencyKeyReused):
+		err = ErrIdempotencyKeyReused
+	}
+	return err
+}
+
+// Expire takes away what is left of the credits that expired and returns
+// how many it took.
+func (s *WalletService) Expire(ctx context.Context) (int, error) {
+	ctx, span := tracer.Start(ctx, "WalletService.Expire")
+	defer span.End()
+
+	lots, err := s.walletRepo.DueLots(ctx, s.now())
+	if err != nil {
+		return 0, telemetry.RecordError(span, err)
+	}
+
+	expired := 0
+	for _, lot := range lots {
+		creditID := lot.TransactionID
+		txn := &models.WalletTransaction{
+			UserID:         lot.UserID,
+			Type:           models.WalletExpire,
+			Reason:         "Crédito expirado",
+			IdempotencyKey: fmt.Sprintf("expire:%d", creditID),
+			CreditID:       &creditID,
+		}
+		if err := s.walletRepo.Apply(ctx, txn, s.now()); err != nil {
+			return expired, telemetry.RecordError(span, err)
+		}
+		if txn.ID != 0 {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// Run expires credits every interval until ctx is cancelled.
+func (s *WalletService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		expired, err := s.Expire(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Failed to expire wallet credits: %v", err)
+		}
+		if expired > 0 {
+			log.Printf("Expired %d wallet credits", expired)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// IssueGiftCard creates a gift card with a new code, worth its balance
+// until its expiry, or for the default validity when it has none.
+func (s *WalletService) IssueGiftCard(ctx context.Context, admin *models.User, card *models.GiftCard) error {
+	ctx, span := tracer.Start(ctx, "WalletService.IssueGiftCard")
+	defer span.End()
+
+	now := s.now()
+	if !card.Balance.IsPositive() {
+		return telemetry.RecordError(span, fmt.Errorf("%w: balance must be positive", ErrInvalidGiftCard))
+	}
+	if card.ExpiresAt.IsZero() {
+		card.ExpiresAt = now.Add(s.giftCardValidity)
+	}
+	if !card.ExpiresAt.After(now) {
+		return telemetry.RecordError(span, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidGiftCard))
+	}
+	card.ID, card.InitialBalance, card.CreatedBy = 0, card.Balance, &admin.ID
+	card.RedeemedBy, card.RedeemedAt = nil, nil
+
+	// A new code colliding is unlikely, but cheap to retry.
+	for attempt := 1; ; attempt++ {
+		code, err := newGiftCardCode()
+		if err != nil {
+			return telemetry.RecordError(span, err)
+		}
+		card.Code = code
+
+		err = s.walletRepo.CreateGiftCard(ctx, card)
+		if errors.Is(err, repository.ErrGiftCardCodeTaken) && attempt < 3 {
+			continue
+		}
+		if errors.Is(err, repository.ErrGiftCardCodeTaken) {
+			err = ErrGiftCardCodeTaken
+		}
+		return telemetry.RecordError(span, err)
+	}
+}
+
+func (s *WalletService) ListGiftCards(ctx context.Context) ([]models.GiftCard, error) {
+	ctx, span := tracer.Start(ctx, "WalletService.ListGiftCards")
+	defer span.End()
+
+	cards, err := s.walletRepo.ListGiftCards(ctx)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return cards, nil
+}
+
+func (s *WalletService) GetGiftCard(ctx context.Context, id uint) (*models.GiftCard, error) {
+	ctx, span := tracer.Start(ctx, "WalletService.GetGiftCard")
+	defer span.End()
+
+	card, err := s.walletRepo.GetGiftCard(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrGiftCardNotFound
+	}
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return card, nil
+}
+
+// RedeemGiftCard moves the balance of the card with the code into the
+// wallet of the user.
+func (s *WalletService) RedeemGiftCard(ctx context.Context, userID uint, code string) (*models.WalletTransaction, error) {
+	ctx, span := tracer.Start(ctx, "WalletService.RedeemGiftCard")
+	defer span.End()
+
+	card, err := s.walletRepo.GetGiftCardByCode(ctx, normalizeGiftCardCode(code))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrGiftCardNotFound
+	}
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	now := s.now()
+	switch {
+	case card.RedeemedBy != nil:
+		return nil, telemetry.RecordError(span, ErrGiftCardRedeemed)
+	case !card.ExpiresAt.After(now):
+		return nil, telemetry.RecordError(span, ErrGiftCardExpired)
+	}
+
+	txn, err := s.walletRepo.RedeemGiftCard(ctx, card, userID, now)
+	if errors.Is(err, repository.ErrGiftCardUnavailable) {
+		// Another request redeemed it between the read and the write.
+		err = ErrGiftCardRedeemed
+	}
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return txn, nil
+}
+
+// newGiftCardCode makes a code of 16 random characters in groups of four,
+// like ABCD-EFGH-JKLM-NPQR.
+func newGiftCardCode() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	var code strings.Builder
+	for i, v := range b {
+		if i > 0 && i%4 == 0 {
+			code.WriteByte('-')
+		}
+		code.WriteByte(giftCardAlphabet[int(v)%len(giftCardAlphabet)])
+	}
+	return code.String(), nil
+}
+
+// normalizeGiftCardCode accepts codes typed in any case, with or without
+// the dashes.
+func normalizeGiftCardCode(code string) string {
+	code = strings.ToUpper(strings.Join(strings.FieldsFunc(code, func(r rune) bool {
+		return r == '-' || r == ' '
+	}), ""))
+	if len(code) != 16 {
+		return code
+	}
+	return code[:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:]
+}
@@ -0,0 +1,278 @@
+// internal/services/wallet_service_test.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/orders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletService(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	walletService := NewWalletService(repository.NewWalletRepository(db), repository.NewUserRepository(db), 365*24*time.Hour)
+	user := testutils.CreateTestUser(t, db)
+	admin := testutils.CreateTestAdmin(t, db)
+	ctx := context.Background()
+
+	now := time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC)
+	walletService.now = func() time.Time { return now }
+	days := func(n int) *time.Time {
+		at := now.AddDate(0, 0, n)
+		return &at
+	}
+
+	balanceOf := func(t *testing.T, userID uint) money.Money {
+		balance, err := walletService.Get(ctx, userID)
+		require.NoError(t, err)
+		return balance.Balance
+	}
+
+	t.Run("✅ Carteira vazia", func(t *testing.T) {
+		balance, err := walletService.Get(ctx, user.ID)
+		require.NoError(t, err)
+		assert.True(t, balance.Balance.IsZero())
+		assert.Empty(t, balance.Expiring)
+	})
+
+	t.Run("✅ Débito usa primeiro o crédito que expira antes", func(t *testing.T) {
+		_, err := walletService.Credit(ctx, user.ID, money.MustParse("30.00"), "Compensação", "", "c1", days(10))
+		require.NoError(t, err)
+		_, err = walletService.Credit(ctx, user.ID, money.MustParse("50.00"), "Reembolso", "", "c2", nil)
+		require.NoError(t, err)
+		soonest, err := walletService.Credit(ctx, user.ID, money.MustParse("20.00"), "Compensação", "", "c3", days(5))
+		require.NoError(t, err)
+		assert.Equal(t, money.MustParse("100.00"), soonest.BalanceAfter)
+
+		debit, err := walletService.Debit(ctx, user.ID, money.MustParse("25.00"), "Pagamento do pedido", "order:1", "order:1")
+		require.NoError(t, err)
+		assert.Equal(t, money.MustParse("75.00"), debit.BalanceAfter)
+
+		balance, err := walletService.Get(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, money.MustParse("75.00"), balance.Balance)
+		require.Len(t, balance.Expiring, 1, "O crédito de 20,00 foi todo usado")
+		assert.Equal(t, money.MustParse("25.00"), balance.Expiring[0].Remaining)
+	})
+
+	t.Run("✅ Repetir a chave não aplica de novo", func(t *testing.T) {
+		again, err := walletService.Debit(ctx, user.ID, money.MustParse("25.00"), "Pagamento do pedido", "order:1", "order:1")
+		require.NoError(t, err)
+		assert.Equal(t, money.MustParse("75.00"), again.BalanceAfter)
+		assert.Equal(t, money.MustParse("75.00"), balanceOf(t, user.ID))
+
+		_, err = walletService.Debit(ctx, user.ID, money.MustParse("10.00"), "Pagamento do pedido", "order:1", "order:1")
+		assert.ErrorIs(t, err, ErrIdempotencyKeyReused)
+	})
+
+	t.Run("❌ Saldo insuficiente e valores inválidos", func(t *testing.T) {
+		_, err := walletService.Debit(ctx, user.ID, money.MustParse("75.01"), "Pagamento do pedido", "order:2", "order:2")
+		assert.ErrorIs(t, err, ErrInsufficientBalance)
+
+		_, err = walletService.Credit(ctx, user.ID, money.FromCents(0), "Nada", "", "zero", nil)
+		assert.ErrorIs(t, err, ErrInvalidWalletAmount)
+		_, err = walletService.Credit(ctx, user.ID, money.MustParse("1.00"), "Sem chave", "", "", nil)
+		assert.ErrorIs(t, err, ErrIdempotencyKeyNeeded)
+		_, err = walletService.Credit(ctx, user.ID, money.MustParse("1.00"), "Vencido", "", "old", days(-1))
+		assert.ErrorIs(t, err, ErrInvalidWalletAmount)
+		assert.Equal(t, money.MustParse("75.00"), balanceOf(t, user.ID))
+	})
+
+	t.Run("✅ Crédito expira no prazo e só uma vez", func(t *testing.T) {
+		now = now.AddDate(0, 0, 11)
+
+		expired, err := walletService.Expire(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, expired)
+		assert.Equal(t, money.MustParse("50.00"), balanceOf(t, user.ID))
+
+		expired, err = walletService.Expire(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, expired)
+
+		listing, err := walletService.Transactions(ctx, user.ID, 10, nil)
+		require.NoError(t, err)
+		require.Len(t, listing.Transactions, 5)
+		latest := listing.Transactions[0]
+		assert.Equal(t, models.WalletExpire, latest.Type)
+		assert.Equal(t, money.MustParse("25.00"), latest.Amount, "Expira o que restou do crédito")
+		assert.Equal(t, money.MustParse("50.00"), latest.BalanceAfter)
+		require.NotNil(t, latest.CreditID)
+	})
+
+	t.Run("✅ Ajuste do admin com motivo", func(t *testing.T) {
+		txn, err := walletService.Adjust(ctx, admin, user.ID, money.MustParse("-20.00"), "Estorno de compensação indevida", "adj-1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, models.WalletDebit, txn.Type)
+		assert.Equal(t, money.MustParse("20.00"), txn.Amount)
+		assert.Equal(t, admin.ID, *txn.CreatedBy)
+		assert.Equal(t, money.MustParse("30.00"), balanceOf(t, user.ID))
+
+		_, err = walletService.Adjust(ctx, admin, 9999, money.MustParse("10.00"), "Compensação", "adj-2", nil)
+		assert.ErrorIs(t, err, ErrUserNotFound)
+		_, err = walletService.Adjust(ctx, admin, user.ID, money.MustParse("10.00"), "Compensação", " ", nil)
+		assert.ErrorIs(t, err, ErrIdempotencyKeyNeeded)
+	})
+
+	t.Run("✅ Débitos concorrentes não passam do saldo", func(t *testing.T) {
+		_, err := walletService.Credit(ctx, admin.ID, money.MustParse("100.00"), "Compensação", "", "start", nil)
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		debited := 0
+		for i := range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				key := fmt.Sprintf("order:%d", i)
+				if _, err := walletService.Debit(ctx, admin.ID, money.MustParse("15.00"), "Pagamento do pedido", key, key); err == nil {
+					mu.Lock()
+					debited++
+					mu.Unlock()
+				} else {
+					assert.ErrorIs(t, err, ErrInsufficientBalance)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 6, debited)
+		assert.Equal(t, money.MustParse("10.00"), balanceOf(t, admin.ID))
+	})
+
+	t.Run("✅ Mesma chave em paralelo aplica uma vez", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := walletService.Credit(ctx, admin.ID, money.MustParse("5.00"), "Reembolso", "order:99", "refund:99", nil)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, money.MustParse("15.00"), balanceOf(t, admin.ID))
+	})
+}
+
+func TestWalletOrders(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	walletService := NewWalletService(repository.NewWalletRepository(db), repository.NewUserRepository(db), 365*24*time.Hour)
+	user := testutils.CreateTestUser(t, db)
+	ctx := context.Background()
+
+	_, err := walletService.Credit(ctx, user.ID, money.MustParse("80.00"), "Reembolso", "", "c1", nil)
+	require.NoError(t, err)
+
+	order := &orders.Order{ID: "PED-1", UserID: user.ID, Status: orders.StatusPending, Total: money.MustParse("100.00")}
+
+	t.Run("❌ Pedido que não aguarda pagamento ou valor acima do total", func(t *testing.T) {
+		paid := *order
+		paid.Status = orders.StatusPaid
+		_, err := walletService.PayOrder(ctx, &paid, money.MustParse("10.00"))
+		assert.ErrorIs(t, err, ErrOrderNotPayable)
+
+		_, err = walletService.PayOrder(ctx, order, money.MustParse("100.01"))
+		assert.ErrorIs(t, err, ErrInvalidWalletAmount)
+	})
+
+	t.Run("✅ Pagar o pedido com a carteira uma única vez", func(t *testing.T) {
+		txn, err := walletService.PayOrder(ctx, order, money.MustParse("60.00"))
+		require.NoError(t, err)
+		assert.Equal(t, models.WalletDebit, txn.Type)
+		assert.Equal(t, "order:PED-1", txn.Reference)
+		assert.Equal(t, money.MustParse("20.00"), txn.BalanceAfter)
+
+		again, err := walletService.PayOrder(ctx, order, money.MustParse("60.00"))
+		require.NoError(t, err)
+		assert.Equal(t, txn.ID, again.ID)
+
+		_, err = walletService.PayOrder(ctx, order, money.MustParse("50.00"))
+		assert.ErrorIs(t, err, ErrIdempotencyKeyReused)
+	})
+
+	t.Run("✅ Estorno devolve até o que a carteira pagou", func(t *testing.T) {
+		refund, err := walletService.RefundOrder(ctx, order, money.MustParse("40.00"), "return:1")
+		require.NoError(t, err)
+		assert.Equal(t, models.WalletCredit, refund.Type)
+		assert.Equal(t, money.MustParse("60.00"), refund.BalanceAfter)
+
+		again, err := walletService.RefundOrder(ctx, order, money.MustParse("40.00"), "return:1")
+		require.NoError(t, err)
+		assert.Equal(t, refund.ID, again.ID)
+
+		_, err = walletService.RefundOrder(ctx, order, money.MustParse("20.01"), "return:2")
+		assert.ErrorIs(t, err, ErrInvalidWalletAmount)
+
+		_, err = walletService.RefundOrder(ctx, order, money.MustParse("20.00"), "return:2")
+		require.NoError(t, err)
+		balance, err := walletService.Get(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, money.MustParse("80.00"), balance.Balance)
+	})
+}
+
+func TestGiftCards(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	walletService := NewWalletService(repository.NewWalletRepository(db), repository.NewUserRepository(db), 365*24*time.Hour)
+	user := testutils.CreateTestUser(t, db)
+	admin := testutils.CreateTestAdmin(t, db)
+	ctx := context.Background()
+
+	now := time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC)
+	walletService.now = func() time.Time { return now }
+
+	card := &models.GiftCard{Balance: money.MustParse("100.00"), Note: "Campanha de Natal"}
+
+	t.Run("✅ Emitir com código e validade padrão", func(t *testing.T) {
+		require.NoError(t, walletService.IssueGiftCard(ctx, admin, card))
+		assert.Regexp(t, regexp.MustCompile(`^[A-HJ-NP-Z2-9]{4}(-[A-HJ-NP-Z2-9]{4}){3}$`), card.Code)
+		assert.Equal(t, money.MustParse("100.00"), card.InitialBalance)
+		assert.Equal(t, now.AddDate(1, 0, 0), card.ExpiresAt)
+
+		err := walletService.IssueGiftCard(ctx, admin, &models.GiftCard{Balance: money.FromCents(0)})
+		assert.ErrorIs(t, err, ErrInvalidGiftCard)
+	})
+
+	t.Run("✅ Resgate credita a carteira com a validade do vale", func(t *testing.T) {
+		typed := strings.ToLower(strings.ReplaceAll(card.Code, "-", ""))
+		txn, err := walletService.RedeemGiftCard(ctx, user.ID, typed)
+		require.NoError(t, err)
+		assert.Equal(t, models.WalletCredit, txn.Type)
+		assert.Equal(t, money.MustParse("100.00"), txn.Amount)
+		require.NotNil(t, txn.ExpiresAt)
+		assert.True(t, card.ExpiresAt.Equal(*txn.ExpiresAt))
+
+		redeemed, err := walletService.GetGiftCard(ctx, card.ID)
+		require.NoError(t, err)
+		assert.True(t, redeemed.Balance.IsZero())
+		assert.Equal(t, user.ID, *redeemed.RedeemedBy)
+	})
+
+	t.Run("❌ Vale já resgatado, vencido ou inexistente", func(t *testing.T) {
+		_, err := walletService.RedeemGiftCard(ctx, admin.ID, card.Code)
+		assert.ErrorIs(t, err, ErrGiftCardRedeemed)
+
+		soon := &models.GiftCard{Balance: money.MustParse("50.00"), ExpiresAt: now.Add(time.Hour)}
+		require.NoError(t, walletService.IssueGiftCard(ctx, admin, soon))
+		now = now.Add(2 * time.Hour)
+		_, err = walletService.RedeemGiftCard(ctx, user.ID, soon.Code)
+		assert.ErrorIs(t, err, ErrGiftCardExpired)
+
+		_, err = walletService.RedeemGiftCard(ctx, user.ID, "AAAA-BBBB-CCCC-DDDD")
+		assert.ErrorIs(t, err, ErrGiftCardNotFound)
+	})
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/pkg/telemetry"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWishlistNotFound     = errors.New("wishlist not found")
+	ErrWishlistNameTaken    = errors.New("user already has a wishlist with this name")
+	ErrWishlistItemNotFound = errors.New("product is not in the wishlist")
+)
+
+type WishlistService struct {
+	wishlistRepo *repository.WishlistRepository
+	productRepo  *repository.ProductRepository
+}
+
+func NewWishlistService(wishlistRepo *repository.WishlistRepository, productRepo *repository.ProductRepository) *WishlistService {
+	return &WishlistService{
+		wishlistRepo: wishlistRepo,
+		productRepo:  productRepo,
+	}
+}
+
+func (s *WishlistService) List(ctx context.Context, userID uint) ([]models.Wishlist, error) {
+	ctx, span := tracer.Start(ctx, "WishlistService.List")
+	defer span.End()
+
+	wishlists, err := s.wishlistRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return wishlists, nil
+}
+
+// Get returns the wishlist when it belongs to the user; the lists of other
+// users are reported as not found.
+func (s *WishlistService) Get(ctx context.Context, userID, id uint) (*models.Wishlist, error) {
+	ctx, span := tracer.Start(ctx, "WishlistService.Get")
+	defer span.End()
+
+	wishlist, err := s.get(ctx, userID, id)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return wishlist, nil
+}
+
+func (s *WishlistService) get(ctx context.Context, userID, id uint) (*models.Wishlist, error) {
+	wishlist, err := s.wishlistRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && wishlist.UserID != userID) {
+		err = ErrWishlistNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return wishlist, nil
+}
+
+// GetShared returns the wishlist shared under the token.
+func (s *WishlistService) GetShared(ctx context.Context, token string) (*models.Wishlist, error) {
+	ctx, span := tracer.Start(ctx, "WishlistService.GetShared")
+	defer span.End()
+
+	wishlist, err := s.wishlistRepo.GetByShareToken(ctx, token)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrWishlistNotFound
+	}
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return wishlist, nil
+}
+
+func (s *WishlistService) Create(ctx context.Context, userID uint, name string) (*models.Wishlist, error) {
+	ctx, span := tracer.Start(ctx, "WishlistService.Create")
+	defer span.End()
+
+	wishlist := &models.Wishlist{UserID: userID, Name: name, Items: []models.WishlistItem{}}
+
+	err := s.wishlistRepo.Create(ctx, wishlist)
+	if errors.Is(err, repository.ErrWishlistNameTaken) {
+		err = ErrWishlistNameTaken
+	}
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return wishlist, nil
+}
+
+// Update renames the wishlist and turns sharing on or off. Sharing again
+// after turning it off gives the list a new token, so old links stop
+// working.
+func (s *WishlistService) Update(ctx context.Context, userID, id uint, name *string, shared *bool) (*models.Wishlist, error) {
+	ctx, span := tracer.Start(ctx, "WishlistService.Update")
+	defer span.End()
+
+	wishlist, err := s.get(ctx, userID, id)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	if name != nil {
+		wishlist.Name = *name
+	}
+	if shared != nil {
+		switch {
+		case !*shared:
+			wishlist.ShareToken = nil
+		case wishlist.ShareToken == nil:
+			token, err := newShareToken()
+			if err != nil {
+				return nil, telemetry.RecordError(span, err)
+			}
+			wishlist.ShareToken = &token
+		}
+	}
+
+	err = s.wishlistRepo.Update(ctx, wishlist)
+	if errors.Is(err, repository.ErrWishlistNameTaken) {
+		err = ErrWishlistNameTaken
+	}
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return wishlist, nil
+}
+
+func (s *WishlistService) Delete(ctx context.Context, userID, id uint) error {
+	ctx, span := tracer.Start(ctx, "WishlistService.Delete")
+	defer span.End()
+
+	if _, err := s.get(ctx, userID, id); err != nil {
+		return telemetry.RecordError(span, err)
+	}
+	return telemetry.RecordError(span, s.wishlistRepo.Delete(ctx, id))
+}
+
+// AddItem adds the product to the wishlist and returns the updated list.
+func (s *WishlistService) AddItem(ctx context.Context, userID, id, productID uint) (*models.Wishlist, error) {
+	ctx, span := tracer.Start(ctx, "WishlistService.AddItem")
+	defer span.End()
+
+	if _, err := s.get(ctx, userID, id); err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	if _, err := s.productRepo.GetByID(ctx, productID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrProductNotFound
+		}
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	if err := s.wishlistRepo.AddItem(ctx, id, productID); err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+
+	wishlist, err := s.get(ctx, userID, id)
+	if err != nil {
+		return nil, telemetry.RecordError(span, err)
+	}
+	return wishlist, nil
+}
+
+func (s *WishlistService) RemoveItem(ctx context.Context, userID, id, productID uint) error {
+	ctx, span := tracer.Start(ctx, "WishlistService.RemoveItem")
+	defer span.End()
+
+	if _, err := s.get(ctx, userID, id); err != nil {
+		return telemetry.RecordError(span, err)
+	}
+
+	removed, err := s.wishlistRepo.RemoveItem(ctx, id, productID)
+	if err != nil {
+		return telemetry.RecordError(span, err)
+	}
+	if !removed {
+		return telemetry.RecordError(span, ErrWishlistItemNotFound)
+	}
+	return nil
+}
+
+// newShareToken makes the unguessable token of a shared wishlist link.
+func newShareToken() (string, error) {
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
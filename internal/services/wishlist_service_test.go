@@ -0,0 +1,188 @@
+// internal/services/wishlist_service_test.go
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/internal/repository"
+	"github.com/Code-Aether/americanas-loja-api/internal/testutils"
+	"github.com/Code-Aether/americanas-loja-api/pkg/background"
+	"github.com/Code-Aether/americanas-loja-api/pkg/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWishlistService(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	wishlistService := NewWishlistService(repository.NewWishlistRepository(db), repository.NewProductRepository(db))
+	ctx := context.Background()
+
+	user := testutils.CreateTestUser(t, db)
+	other := &models.User{Name: "Outro", Email: "outro@test.com", Password: "hash", Role: "user", Active: true}
+	require.NoError(t, db.Create(other).Error)
+	product := testutils.CreateTestProduct(t, db)
+
+	var wishlist *models.Wishlist
+
+	t.Run("✅ Cria listas com nomes diferentes", func(t *testing.T) {
+		var err error
+		wishlist, err = wishlistService.Create(ctx, user.ID, "Aniversário")
+		require.NoError(t, err)
+		assert.Nil(t, wishlist.ShareToken)
+
+		_, err = wishlistService.Create(ctx, user.ID, "Natal")
+		require.NoError(t, err)
+
+		// O mesmo nome pode ser usado por outro usuário
+		_, err = wishlistService.Create(ctx, other.ID, "Aniversário")
+		require.NoError(t, err)
+
+		_, err = wishlistService.Create(ctx, user.ID, "Aniversário")
+		assert.ErrorIs(t, err, ErrWishlistNameTaken)
+
+		lists, err := wishlistService.List(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Len(t, lists, 2)
+	})
+
+	t.Run("✅ Adicionar o mesmo produto não duplica o item", func(t *testing.T) {
+		_, err := wishlistService.AddItem(ctx, user.ID, wishlist.ID, product.ID)
+		require.NoError(t, err)
+
+		updated, err := wishlistService.AddItem(ctx, user.ID, wishlist.ID, product.ID)
+		require.NoError(t, err)
+		require.Len(t, updated.Items, 1)
+		assert.Equal(t, product.ID, updated.Items[0].ProductID)
+		assert.Equal(t, product.Name, updated.Items[0].Product.Name)
+
+		_, err = wishlistService.AddItem(ctx, user.ID, wishlist.ID, 9999)
+		assert.ErrorIs(t, err, ErrProductNotFound)
+	})
+
+	t.Run("✅ Compartilhar gera um link e desligar invalida", func(t *testing.T) {
+		shared := true
+		updated, err := wishlistService.Update(ctx, user.ID, wishlist.ID, nil, &shared)
+		require.NoError(t, err)
+		require.NotNil(t, updated.ShareToken)
+		token := *updated.ShareToken
+
+		found, err := wishlistService.GetShared(ctx, token)
+		require.NoError(t, err)
+		assert.Equal(t, wishlist.ID, found.ID)
+		assert.Len(t, found.Items, 1)
+
+		shared = false
+		_, err = wishlistService.Update(ctx, user.ID, wishlist.ID, nil, &shared)
+		require.NoError(t, err)
+
+		_, err = wishlistService.GetShared(ctx, token)
+		assert.ErrorIs(t, err, ErrWishlistNotFound)
+
+		shared = true
+		updated, err = wishlistService.Update(ctx, user.ID, wishlist.ID, nil, &shared)
+		require.NoError(t, err)
+		assert.NotEqual(t, token, *updated.ShareToken, "Compartilhar de novo deve gerar outro link")
+	})
+
+	t.Run("❌ Listas de outro usuário não são encontradas", func(t *testing.T) {
+		_, err := wishlistService.Get(ctx, other.ID, wishlist.ID)
+		assert.ErrorIs(t, err, ErrWishlistNotFound)
+
+		_, err = wishlistService.AddItem(ctx, other.ID, wishlist.ID, product.ID)
+		assert.ErrorIs(t, err, ErrWishlistNotFound)
+
+		err = wishlistService.Delete(ctx, other.ID, wishlist.ID)
+		assert.ErrorIs(t, err, ErrWishlistNotFound)
+	})
+
+	t.Run("❌ Renomear para um nome já usado", func(t *testing.T) {
+		name := "Natal"
+		_, err := wishlistService.Update(ctx, user.ID, wishlist.ID, &name, nil)
+		assert.ErrorIs(t, err, ErrWishlistNameTaken)
+	})
+
+	t.Run("✅ Remove item e lista", func(t *testing.T) {
+		require.NoError(t, wishlistService.RemoveItem(ctx, user.ID, wishlist.ID, product.ID))
+
+		err := wishlistService.RemoveItem(ctx, user.ID, wishlist.ID, product.ID)
+		assert.ErrorIs(t, err, ErrWishlistItemNotFound)
+
+		require.NoError(t, wishlistService.Delete(ctx, user.ID, wishlist.ID))
+
+		_, err = wishlistService.Get(ctx, user.ID, wishlist.ID)
+		assert.ErrorIs(t, err, ErrWishlistNotFound)
+	})
+}
+
+func TestBackInStockService(t *testing.T) {
+	db := testutils.SetupTestDB(t)
+	productRepo := repository.NewProductRepository(db)
+	productService := NewProductService(productRepo, nil)
+	notifier := notify.NewMemoryNotifier()
+	jobs := &background.Group{}
+	backInStockService := NewBackInStockService(repository.NewStockSubscriptionRepository(db), productRepo, notifier, jobs)
+	productService.AddStockListener(backInStockService)
+	ctx := context.Background()
+
+	user := testutils.CreateTestUser(t, db)
+	product := testutils.CreateTestProduct(t, db)
+
+	t.Run("❌ Produto em estoque não aceita aviso", func(t *testing.T) {
+		_, err := backInStockService.Subscribe(ctx, user.ID, product.ID)
+		assert.ErrorIs(t, err, ErrProductInStock)
+
+		_, err = backInStockService.Subscribe(ctx, user.ID, 9999)
+		assert.ErrorIs(t, err, ErrProductNotFound)
+	})
+
+	t.Run("✅ Reposição avisa o cliente uma única vez", func(t *testing.T) {
+		require.NoError(t, productService.UpdateStock(ctx, product.ID, -product.Stock))
+
+		first, err := backInStockService.Subscribe(ctx, user.ID, product.ID)
+		require.NoError(t, err)
+
+		// Pedir de novo devolve o aviso pendente
+		second, err := backInStockService.Subscribe(ctx, user.ID, product.ID)
+		require.NoError(t, err)
+		assert.Equal(t, first.ID, second.ID)
+
+		require.NoError(t, productService.UpdateStock(ctx, product.ID, 5))
+
+		waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		require.NoError(t, jobs.Wait(waitCtx), "Aviso rastreado termina antes do desligamento")
+		require.Len(t, notifier.Messages(), 1)
+
+		msg := notifier.Messages()[0]
+		assert.Equal(t, notify.EventBackInStock, msg.Event)
+		assert.Equal(t, user.ID, msg.UserID)
+		assert.Equal(t, user.Email, msg.To)
+		assert.Contains(t, msg.Subject, product.Name)
+
+		// Mais estoque não gera um novo aviso
+		require.NoError(t, productService.UpdateStock(ctx, product.ID, 3))
+		sent, err := backInStockService.NotifyRestock(ctx, product)
+		require.NoError(t, err)
+		assert.Zero(t, sent)
+		assert.Len(t, notifier.Messages(), 1)
+
+		// O aviso já enviado não pode mais ser cancelado
+		err = backInStockService.Unsubscribe(ctx, user.ID, product.ID)
+		assert.ErrorIs(t, err, ErrSubscriptionNotFound)
+	})
+
+	t.Run("✅ Cancelar o aviso", func(t *testing.T) {
+		require.NoError(t, productService.UpdateStock(ctx, product.ID, -8))
+
+		_, err := backInStockService.Subscribe(ctx, user.ID, product.ID)
+		require.NoError(t, err)
+		require.NoError(t, backInStockService.Unsubscribe(ctx, user.ID, product.ID))
+
+		sent, err := backInStockService.NotifyRestock(ctx, product)
+		require.NoError(t, err)
+		assert.Zero(t, sent)
+	})
+}
@@ -4,13 +4,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/Code-Aether/americanas-loja-api/internal/config"
 	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/migrations"
+	"github.com/Code-Aether/americanas-loja-api/pkg/database"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/seal"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
@@ -21,15 +28,29 @@ import (
 	"gorm.io/gorm/logger"
 )
 
+var testDBCounter atomic.Int64
+
 func SetupTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+	// Banco em memória nomeado e compartilhado: todas as conexões do pool
+	// enxergam o mesmo schema criado pelas migrações
+	dsn := fmt.Sprintf("file:testdb%d?mode=memory&cache=shared", testDBCounter.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent), // Silenciar logs nos testes
 	})
 	assert.NoError(t, err, "Erro ao conectar com banco de teste")
 
-	// Auto migrate
-	err = db.AutoMigrate(&models.User{}, &models.Product{})
+	// Mesmas migrações usadas em produção
+	migrator, err := database.NewMigrator(db, migrations.FS)
+	assert.NoError(t, err, "Erro ao carregar migrações")
+	err = migrator.Up(context.Background(), 0)
 	assert.NoError(t, err, "Erro ao migrar banco de teste")
+	err = database.EnsureSearchIndex(db)
+	assert.NoError(t, err, "Erro ao criar índice de busca")
+
+	// CPF/CNPJ criptografados como em produção
+	box, err := seal.New("test-document-key")
+	assert.NoError(t, err, "Erro ao configurar criptografia de documentos")
+	models.SetDocumentBox(box)
 
 	return db
 }
@@ -66,13 +87,37 @@ func CreateTestAdmin(t *testing.T, db *gorm.DB) *models.User {
 	return admin
 }
 
+func CreateTestCategory(t *testing.T, db *gorm.DB, name string, parent *models.Category) *models.Category {
+	category := &models.Category{
+		Name:   name,
+		Slug:   utils.Slugify(name),
+		Active: true,
+	}
+	parentPath := ""
+	if parent != nil {
+		category.ParentID = &parent.ID
+		category.Depth = parent.Depth + 1
+		parentPath = parent.Path
+	}
+
+	err := db.Create(category).Error
+	assert.NoError(t, err, "Erro ao criar categoria de teste")
+
+	category.Path = models.PathFor(parentPath, category.ID)
+	err = db.Model(category).Update("path", category.Path).Error
+	assert.NoError(t, err, "Erro ao gravar caminho da categoria de teste")
+
+	return category
+}
+
 func CreateTestProduct(t *testing.T, db *gorm.DB) *models.Product {
+	category := CreateTestCategory(t, db, "Test Category", nil)
 	product := &models.Product{
 		Name:        "Test Product",
 		Description: "Test Description",
-		Price:       99.99,
+		Price:       money.MustParse("99.99"),
 		Stock:       10,
-		Category:    "Test Category",
+		CategoryID:  &category.ID,
 		SKU:         "TEST-001",
 		ImageURL:    "https://test.com/image.jpg",
 		Active:      true,
@@ -0,0 +1,20 @@
+package types
+
+import "github.com/Code-Aether/americanas-loja-api/pkg/buildinfo"
+
+// Health Types
+type DependencyStatus struct {
+	Status    string  `json:"status" example:"up"`
+	Critical  bool    `json:"critical" example:"true"`
+	LatencyMS float64 `json:"latency_ms" example:"1.25"`
+	Error     string  `json:"error,omitempty" example:"dial tcp: connection refused"`
+}
+
+type HealthResponse struct {
+	Status       string                      `json:"status" example:"ready"`
+	Reason       string                      `json:"reason,omitempty" example:"starting"`
+	Service      string                      `json:"service" example:"americanas-loja-api"`
+	Build        buildinfo.Info              `json:"build"`
+	Timestamp    string                      `json:"timestamp" example:"2025-01-01T00:00:00Z"`
+	Dependencies map[string]DependencyStatus `json:"dependencies,omitempty"`
+}
@@ -1,12 +1,21 @@
 package types
 
-import "github.com/Code-Aether/americanas-loja-api/internal/models"
+import (
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+)
 
 // Auth Types
 type RegisterRequest struct {
 	Name     string `json:"name" validate:"required,min=2,max=100" example:"João Silva"`
 	Email    string `json:"email" validate:"required,email" example:"joao@teste.com"`
 	Password string `json:"password" validate:"required,min=6" example:"123456"`
+	// DocumentType and Document, the CPF or CNPJ of the buyer, are optional
+	// but go together.
+	DocumentType string `json:"document_type" validate:"required_with=Document,omitempty,oneof=cpf cnpj" example:"cpf"`
+	Document     string `json:"document" validate:"required_with=DocumentType,omitempty,cpf|cnpj" example:"529.982.247-25"`
 }
 
 type LoginRequest struct {
@@ -21,29 +30,307 @@ type AuthResponse struct {
 
 // Product Types
 type CreateProductRequest struct {
-	Name        string  `json:"name" validate:"required,min=2,max=200" example:"iPhone 15 Pro Max"`
-	Description string  `json:"description" example:"Smartphone Apple com 256GB de armazenamento"`
-	Price       float64 `json:"price" validate:"required,gt=0" example:"8999.99"`
-	Stock       int     `json:"stock" validate:"required,gte=0" example:"50"`
-	Category    string  `json:"category" validate:"required,min=2,max=100" example:"Eletrônicos"`
-	SKU         string  `json:"sku" validate:"required,min=3,max=50" example:"IPHONE-15-PRO-MAX-256"`
-	ImageURL    string  `json:"image_url" example:"https://example.com/iphone15.jpg"`
+	Name        string           `json:"name" validate:"required,min=2,max=200" example:"iPhone 15 Pro Max"`
+	Description string           `json:"description" example:"Smartphone Apple com 256GB de armazenamento"`
+	Price       money.Money      `json:"price" validate:"required,gt=0" swaggertype:"number" example:"8999.99"`
+	Stock       int              `json:"stock" validate:"required,gte=0" example:"50"`
+	CategoryID  uint             `json:"category_id" validate:"required,gt=0" example:"1"`
+	SKU         string           `json:"sku" validate:"required,min=3,max=50" example:"IPHONE-15-PRO-MAX-256"`
+	ImageURL    string           `json:"image_url" example:"https://example.com/iphone15.jpg"`
+	Variants    []VariantRequest `json:"variants,omitempty" validate:"omitempty,dive"`
+	// Estoque no qual o produto entra em alerta; sem ele vale o da categoria
+	ReorderThreshold *int `json:"reorder_threshold,omitempty" validate:"omitempty,gte=0" example:"5"`
+	// Peso e dimensões da unidade embalada, usados no cálculo do frete
+	WeightGrams int `json:"weight_grams,omitempty" validate:"gte=0" example:"221"`
+	LengthCm    int `json:"length_cm,omitempty" validate:"gte=0" example:"20"`
+	WidthCm     int `json:"width_cm,omitempty" validate:"gte=0" example:"12"`
+	HeightCm    int `json:"height_cm,omitempty" validate:"gte=0" example:"6"`
 }
 
 type UpdateProductRequest struct {
-	Name        *string  `json:"name,omitempty" validate:"omitempty,min=2,max=200" example:"iPhone 15 Pro Max - Atualizado"`
-	Description *string  `json:"description,omitempty" example:"Descrição atualizada do produto"`
-	Price       *float64 `json:"price,omitempty" validate:"omitempty,gt=0" example:"8499.99"`
-	Stock       *int     `json:"stock,omitempty" validate:"omitempty,gte=0" example:"45"`
-	Category    *string  `json:"category,omitempty" validate:"omitempty,min=2,max=100" example:"Smartphones"`
-	ImageURL    *string  `json:"image_url,omitempty" example:"https://example.com/new-iphone15.jpg"`
-	SKU         *string  `json:"sku" validate:"required,min=3,max=50" example:"IPHONE-15-PRO-MAX-256"`
-	Active      *bool    `json:"active,omitempty" example:"true"`
-}
-
-type ProductListResponse struct {
-	Products []models.Product `json:"products"`
-	Total    int64            `json:"total" example:"150"`
-	Page     int              `json:"page" example:"1"`
-	Limit    int              `json:"limit" example:"10"`
+	Name        *string      `json:"name,omitempty" validate:"omitempty,min=2,max=200" example:"iPhone 15 Pro Max - Atualizado"`
+	Description *string      `json:"description,omitempty" example:"Descrição atualizada do produto"`
+	Price       *money.Money `json:"price,omitempty" validate:"omitempty,gt=0" swaggertype:"number" example:"8499.99"`
+	Stock       *int         `json:"stock,omitempty" validate:"omitempty,gte=0" example:"45"`
+	CategoryID  *uint        `json:"category_id,omitempty" validate:"omitempty,gt=0" example:"2"`
+	ImageURL    *string      `json:"image_url,omitempty" example:"https://example.com/new-iphone15.jpg"`
+	SKU         *string      `json:"sku" validate:"required,min=3,max=50" example:"IPHONE-15-PRO-MAX-256"`
+	Active      *bool        `json:"active,omitempty" example:"true"`
+	// Estoque no qual o produto entra em alerta; sem ele vale o da categoria
+	ReorderThreshold *int `json:"reorder_threshold,omitempty" validate:"omitempty,gte=0" example:"5"`
+	// Peso e dimensões da unidade embalada, usados no cálculo do frete
+	WeightGrams *int `json:"weight_grams,omitempty" validate:"omitempty,gte=0" example:"221"`
+	LengthCm    *int `json:"length_cm,omitempty" validate:"omitempty,gte=0" example:"20"`
+	WidthCm     *int `json:"width_cm,omitempty" validate:"omitempty,gte=0" example:"12"`
+	HeightCm    *int `json:"height_cm,omitempty" validate:"omitempty,gte=0" example:"6"`
+	// Quando informado, substitui o conjunto de variantes; variantes
+	// existentes que ficarem de fora são desativadas
+	Variants *[]VariantRequest `json:"variants,omitempty" validate:"omitempty,dive"`
+}
+
+// VariantRequest descreve uma variante pelos valores das opções
+// (size, color, voltage). Sem price, a variante usa o preço do produto.
+type VariantRequest struct {
+	SKU     string            `json:"sku" validate:"required,min=3,max=100" example:"NIKE-AIRMAX-90-42"`
+	Price   *money.Money      `json:"price,omitempty" validate:"omitempty,gt=0" swaggertype:"number" example:"549.99"`
+	Stock   int               `json:"stock" validate:"gte=0" example:"5"`
+	Images  []string          `json:"images,omitempty" validate:"omitempty,dive,url" example:"https://example.com/nike-42.jpg"`
+	Options map[string]string `json:"options" validate:"required,min=1,dive,keys,required,endkeys,required,max=100"`
+	Active  *bool             `json:"active,omitempty" example:"true"`
+}
+
+// VariantsFromRequest builds the variants described by reqs, active unless
+// the request says otherwise.
+func VariantsFromRequest(reqs []VariantRequest) []models.ProductVariant {
+	if len(reqs) == 0 {
+		return nil
+	}
+
+	variants := make([]models.ProductVariant, 0, len(reqs))
+	for _, req := range reqs {
+		variant := models.ProductVariant{
+			SKU:    req.SKU,
+			Price:  req.Price,
+			Stock:  req.Stock,
+			Images: req.Images,
+			Active: true,
+		}
+		if req.Active != nil {
+			variant.Active = *req.Active
+		}
+		for code, value := range req.Options {
+			variant.Options = append(variant.Options, models.VariantOption{Code: code, Value: value})
+		}
+		variants = append(variants, variant)
+	}
+	return variants
+}
+
+// Category Types
+type CreateCategoryRequest struct {
+	Name      string `json:"name" validate:"required,min=2,max=100" example:"Smartphones"`
+	Slug      string `json:"slug,omitempty" validate:"omitempty,max=120" example:"smartphones"`
+	ParentID  *uint  `json:"parent_id,omitempty" example:"1"`
+	SortOrder int    `json:"sort_order" example:"0"`
+	Active    *bool  `json:"active,omitempty" example:"true"`
+	// Estoque no qual os produtos da categoria e das subcategorias entram
+	// em alerta, quando o produto não define o seu
+	ReorderThreshold *int `json:"reorder_threshold,omitempty" validate:"omitempty,gte=0" example:"10"`
+}
+
+type UpdateCategoryRequest struct {
+	Name      *string `json:"name,omitempty" validate:"omitempty,min=2,max=100" example:"Celulares"`
+	Slug      *string `json:"slug,omitempty" validate:"omitempty,min=1,max=120" example:"celulares"`
+	ParentID  *uint   `json:"parent_id,omitempty" example:"0"`
+	SortOrder *int    `json:"sort_order,omitempty" example:"1"`
+	Active    *bool   `json:"active,omitempty" example:"true"`
+	// Estoque no qual os produtos da categoria e das subcategorias entram
+	// em alerta, quando o produto não define o seu
+	ReorderThreshold *int `json:"reorder_threshold,omitempty" validate:"omitempty,gte=0" example:"10"`
+}
+
+// Media Types
+type ReorderImagesRequest struct {
+	ImageIDs []uint `json:"image_ids" validate:"required,min=1,dive,gt=0" example:"3,1,2"`
+}
+
+// Pricing Types
+type CreateSaleRequest struct {
+	SalePrice money.Money `json:"sale_price" validate:"required,gt=0" swaggertype:"number" example:"7999.99"`
+	StartsAt  time.Time   `json:"starts_at" validate:"required" example:"2026-11-27T00:00:00-03:00"`
+	EndsAt    time.Time   `json:"ends_at" validate:"required" example:"2026-11-30T23:59:59-03:00"`
+}
+
+// Review Types
+type CreateReviewRequest struct {
+	Rating int    `json:"rating" validate:"required,min=1,max=5" example:"5"`
+	Title  string `json:"title" validate:"required,min=3,max=150" example:"Excelente"`
+	Body   string `json:"body" validate:"max=5000" example:"Chegou antes do prazo e funciona muito bem."`
+}
+
+type ModerateReviewRequest struct {
+	Status string `json:"status" validate:"required,oneof=approved rejected" example:"approved"`
+	Reason string `json:"reason" validate:"max=500" example:"Contém dados pessoais"`
+}
+
+// Wishlist Types
+type CreateWishlistRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=100" example:"Aniversário"`
+}
+
+type UpdateWishlistRequest struct {
+	Name   *string `json:"name,omitempty" validate:"omitempty,min=1,max=100" example:"Natal"`
+	Shared *bool   `json:"shared,omitempty" example:"true"`
+}
+
+type AddWishlistItemRequest struct {
+	ProductID uint `json:"product_id" validate:"required" example:"1"`
+}
+
+// Coupon Types
+type CouponRequest struct {
+	Code        string      `json:"code" validate:"required,min=3,max=50" example:"BEMVINDO10"`
+	Description string      `json:"description" validate:"max=255" example:"10% na primeira compra"`
+	Type        string      `json:"type" validate:"required,oneof=percentage fixed free_shipping buy_x_get_y" example:"percentage"`
+	Percent     int         `json:"percent,omitempty" validate:"omitempty,min=1,max=100" example:"10"`
+	Amount      money.Money `json:"amount,omitempty" swaggertype:"number" example:"50.00"`
+	BuyQuantity int         `json:"buy_quantity,omitempty" validate:"omitempty,min=1" example:"2"`
+	GetQuantity int         `json:"get_quantity,omitempty" validate:"omitempty,min=1" example:"1"`
+	// Valor mínimo do carrinho para o cupom valer
+	MinSubtotal  money.Money `json:"min_subtotal,omitempty" swaggertype:"number" example:"100.00"`
+	StartsAt     *time.Time  `json:"starts_at,omitempty" example:"2026-11-27T00:00:00-03:00"`
+	EndsAt       *time.Time  `json:"ends_at,omitempty" example:"2026-11-30T23:59:59-03:00"`
+	UsageLimit   *int        `json:"usage_limit,omitempty" validate:"omitempty,min=1" example:"1000"`
+	PerUserLimit *int        `json:"per_user_limit,omitempty" validate:"omitempty,min=1" example:"1"`
+	// Categorias incluem as subcategorias; sem listas de inclusão, todos os
+	// itens valem, menos os excluídos
+	IncludeCategories []uint   `json:"include_categories,omitempty" example:"1"`
+	ExcludeCategories []uint   `json:"exclude_categories,omitempty"`
+	IncludeSKUs       []string `json:"include_skus,omitempty"`
+	ExcludeSKUs       []string `json:"exclude_skus,omitempty" example:"IPHONE-15-PRO-MAX-256"`
+	Active            *bool    `json:"active,omitempty" example:"true"`
+}
+
+// CouponFromRequest builds the coupon described by req, active unless the
+// request says otherwise.
+func CouponFromRequest(req *CouponRequest) *models.Coupon {
+	coupon := &models.Coupon{
+		Code:              req.Code,
+		Description:       req.Description,
+		Type:              req.Type,
+		Percent:           req.Percent,
+		Amount:            req.Amount,
+		BuyQuantity:       req.BuyQuantity,
+		GetQuantity:       req.GetQuantity,
+		MinSubtotal:       req.MinSubtotal,
+		StartsAt:          req.StartsAt,
+		EndsAt:            req.EndsAt,
+		UsageLimit:        req.UsageLimit,
+		PerUserLimit:      req.PerUserLimit,
+		IncludeCategories: req.IncludeCategories,
+		ExcludeCategories: req.ExcludeCategories,
+		IncludeSKUs:       req.IncludeSKUs,
+		ExcludeSKUs:       req.ExcludeSKUs,
+		Active:            true,
+	}
+	if req.Active != nil {
+		coupon.Active = *req.Active
+	}
+	return coupon
+}
+
+type ValidateCouponRequest struct {
+	Code  string            `json:"code" validate:"required" example:"BEMVINDO10"`
+	Items []CartItemRequest `json:"items" validate:"required,min=1,max=100,dive"`
+}
+
+type CartItemRequest struct {
+	ProductID uint `json:"product_id" validate:"required" example:"1"`
+	// Obrigatório para produtos com variantes
+	VariantID uint `json:"variant_id,omitempty" example:"3"`
+	Quantity  int  `json:"quantity" validate:"required,min=1,max=1000" example:"2"`
+}
+
+// Shipping Types
+type ShippingQuoteRequest struct {
+	CEP   string            `json:"cep" validate:"required" example:"01310-100"`
+	Items []CartItemRequest `json:"items" validate:"required,min=1,max=100,dive"`
+}
+
+// Address Types
+type AddressRequest struct {
+	Label        string `json:"label" validate:"max=50" example:"Casa"`
+	Recipient    string `json:"recipient" validate:"required,max=100" example:"Maria Silva"`
+	CEP          string `json:"cep" validate:"required,cep" example:"01310-100"`
+	Street       string `json:"street" validate:"required,max=200" example:"Avenida Paulista"`
+	Number       string `json:"number" validate:"required,max=20" example:"1578"`
+	Complement   string `json:"complement" validate:"max=100" example:"Apto 42"`
+	Neighborhood string `json:"neighborhood" validate:"required,max=100" example:"Bela Vista"`
+	City         string `json:"city" validate:"required,max=100" example:"São Paulo"`
+	State        string `json:"state" validate:"required,uf" example:"SP"`
+	// Torna este o endereço padrão no lugar do atual
+	IsDefault bool `json:"is_default" example:"true"`
+}
+
+// AddressFromRequest builds the address described by req.
+func AddressFromRequest(req *AddressRequest) *models.Address {
+	return &models.Address{
+		Label: req.Label,
+		PostalAddress: models.PostalAddress{
+			Recipient:    req.Recipient,
+			CEP:          req.CEP,
+			Street:       req.Street,
+			Number:       req.Number,
+			Complement:   req.Complement,
+			Neighborhood: req.Neighborhood,
+			City:         req.City,
+			State:        req.State,
+		},
+		IsDefault: req.IsDefault,
+	}
+}
+
+// WalletAdjustmentRequest credits a positive amount to a wallet or debits a
+// negative one; expires_at only applies to credits.
+type WalletAdjustmentRequest struct {
+	Amount    money.Money `json:"amount" validate:"ne=0" swaggertype:"number" example:"50.00"`
+	Reason    string      `json:"reason" validate:"required,min=3,max=255" example:"Compensação pelo atraso na entrega"`
+	ExpiresAt *time.Time  `json:"expires_at,omitempty" example:"2027-05-01T00:00:00-03:00"`
+}
+
+// GiftCardRequest issues a gift card; without expires_at it lasts
+// GIFT_CARD_VALIDITY.
+type GiftCardRequest struct {
+	Balance   money.Money `json:"balance" validate:"gt=0" swaggertype:"number" example:"100.00"`
+	ExpiresAt *time.Time  `json:"expires_at,omitempty" example:"2027-12-31T23:59:59-03:00"`
+	Note      string      `json:"note" validate:"max=255" example:"Campanha de Natal"`
+}
+
+type RedeemGiftCardRequest struct {
+	Code string `json:"code" validate:"required,max=30" example:"ABCD-EFGH-JKLM-NPQR"`
+}
+
+func GiftCardFromRequest(req *GiftCardRequest) *models.GiftCard {
+	card := &models.GiftCard{
+		Balance: req.Balance,
+		Note:    req.Note,
+	}
+	if req.ExpiresAt != nil {
+		card.ExpiresAt = *req.ExpiresAt
+	}
+	return card
+}
+
+// LoyaltyRuleRequest sets the cashback rate of a category, in basis points
+// (250 is 2.5%); zero leaves the category out of the program.
+type LoyaltyRuleRequest struct {
+	CategoryID uint `json:"category_id" validate:"required" example:"1"`
+	Rate       *int `json:"rate" validate:"required,min=0,max=10000" example:"500"`
+}
+
+type UpdateLoyaltyRuleRequest struct {
+	Rate *int `json:"rate" validate:"required,min=0,max=10000" example:"250"`
+}
+
+// Return Types
+type ReturnRequestInput struct {
+	OrderID     string              `json:"order_id" validate:"required,max=64" example:"PED-2026-000123"`
+	Reason      string              `json:"reason" validate:"required,oneof=regret defective damaged wrong_item other" example:"defective"`
+	Description string              `json:"description" validate:"max=1000" example:"O tênis veio com a sola descolando"`
+	Items       []ReturnItemRequest `json:"items" validate:"required,min=1,max=100,dive"`
+}
+
+type ReturnItemRequest struct {
+	OrderItemID uint `json:"order_item_id" validate:"required" example:"1"`
+	Quantity    int  `json:"quantity" validate:"required,min=1,max=1000" example:"1"`
+}
+
+type RejectReturnRequest struct {
+	Reason string `json:"reason" validate:"required,min=3,max=255" example:"Produto com sinais de uso"`
+}
+
+// ReceiveReturnRequest tells whether the goods that came back go back in
+// stock; damaged goods do not.
+type ReceiveReturnRequest struct {
+	Restock *bool `json:"restock" validate:"required" example:"true"`
 }
@@ -0,0 +1,9 @@
+// Package migrations embeds the versioned SQL migrations. Each dialect has
+// its own directory with files named <version>_<name>.up.sql and
+// <version>_<name>.down.sql.
+package migrations
+
+import "embed"
+
+//go:embed postgres/*.sql sqlite/*.sql
+var FS embed.FS
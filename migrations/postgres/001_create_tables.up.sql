@@ -0,0 +1,33 @@
+-- IF NOT EXISTS lets this baseline adopt databases created by the old
+-- AutoMigrate boot path.
+CREATE TABLE IF NOT EXISTS users (
+    id         BIGSERIAL PRIMARY KEY,
+    email      TEXT NOT NULL,
+    password   TEXT NOT NULL,
+    name       TEXT NOT NULL,
+    role       TEXT DEFAULT 'user',
+    active     BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS products (
+    id          BIGSERIAL PRIMARY KEY,
+    name        VARCHAR(255) NOT NULL,
+    description TEXT,
+    price       DECIMAL NOT NULL,
+    stock       BIGINT NOT NULL DEFAULT 0,
+    category    TEXT,
+    sku         VARCHAR(100),
+    active      BOOLEAN DEFAULT TRUE,
+    image_url   TEXT,
+    created_at  TIMESTAMPTZ,
+    updated_at  TIMESTAMPTZ,
+    deleted_at  TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products (sku);
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at);
@@ -0,0 +1,46 @@
+CREATE TABLE categories (
+    id         BIGSERIAL PRIMARY KEY,
+    name       VARCHAR(100) NOT NULL,
+    slug       VARCHAR(120) NOT NULL,
+    parent_id  BIGINT REFERENCES categories (id),
+    path       TEXT NOT NULL,
+    depth      BIGINT NOT NULL DEFAULT 0,
+    sort_order BIGINT NOT NULL DEFAULT 0,
+    active     BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_categories_slug ON categories (slug);
+CREATE INDEX idx_categories_parent_id ON categories (parent_id);
+-- text_pattern_ops lets the subtree lookup (path LIKE '/1/%') use the index.
+CREATE INDEX idx_categories_path ON categories (path text_pattern_ops);
+
+ALTER TABLE products ADD COLUMN category_id BIGINT REFERENCES categories (id);
+CREATE INDEX idx_products_category_id ON products (category_id);
+
+-- Every distinct free-text category becomes a root category. Spellings that
+-- only differ in case, accents or surrounding spaces collapse into one slug.
+ALTER TABLE products ADD COLUMN category_slug TEXT;
+
+UPDATE products
+SET category_slug = TRIM(BOTH '-' FROM REGEXP_REPLACE(
+        TRANSLATE(LOWER(TRIM(category)), 'áàâãäéèêëíìîïóòôõöúùûüçñ', 'aaaaaeeeeiiiiooooouuuucn'),
+        '[^a-z0-9]+', '-', 'g'))
+WHERE TRIM(COALESCE(category, '')) <> '';
+
+INSERT INTO categories (name, slug, path, depth, sort_order, active, created_at, updated_at)
+SELECT MIN(TRIM(category)), category_slug, '', 0, 0, TRUE, NOW(), NOW()
+FROM products
+WHERE COALESCE(category_slug, '') <> ''
+GROUP BY category_slug;
+
+UPDATE categories SET path = '/' || id || '/' WHERE path = '';
+
+UPDATE products
+SET category_id = categories.id
+FROM categories
+WHERE categories.slug = products.category_slug;
+
+ALTER TABLE products DROP COLUMN category_slug;
+ALTER TABLE products DROP COLUMN category;
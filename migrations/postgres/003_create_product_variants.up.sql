@@ -0,0 +1,38 @@
+CREATE TABLE option_types (
+    id         BIGSERIAL PRIMARY KEY,
+    code       VARCHAR(50) NOT NULL,
+    name       VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_option_types_code ON option_types (code);
+
+INSERT INTO option_types (code, name, created_at, updated_at) VALUES
+    ('size', 'Tamanho', NOW(), NOW()),
+    ('color', 'Cor', NOW(), NOW()),
+    ('voltage', 'Voltagem', NOW(), NOW());
+
+CREATE TABLE product_variants (
+    id         BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products (id),
+    sku        VARCHAR(100) NOT NULL,
+    price      DECIMAL,
+    stock      BIGINT NOT NULL DEFAULT 0,
+    images     TEXT NOT NULL DEFAULT '[]',
+    active     BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_product_variants_sku ON product_variants (sku);
+CREATE INDEX idx_product_variants_product_id ON product_variants (product_id);
+
+CREATE TABLE variant_options (
+    id             BIGSERIAL PRIMARY KEY,
+    variant_id     BIGINT NOT NULL REFERENCES product_variants (id) ON DELETE CASCADE,
+    option_type_id BIGINT NOT NULL REFERENCES option_types (id),
+    value          VARCHAR(100) NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_variant_options_variant_type ON variant_options (variant_id, option_type_id);
@@ -0,0 +1,17 @@
+CREATE TABLE product_images (
+    id                 BIGSERIAL PRIMARY KEY,
+    product_id         BIGINT NOT NULL REFERENCES products (id),
+    position           BIGINT NOT NULL DEFAULT 0,
+    content_type       VARCHAR(50) NOT NULL,
+    width              BIGINT,
+    height             BIGINT,
+    size               BIGINT,
+    original_key       TEXT NOT NULL,
+    thumbnail_key      TEXT NOT NULL,
+    webp_key           TEXT NOT NULL,
+    thumbnail_webp_key TEXT NOT NULL,
+    created_at         TIMESTAMPTZ,
+    updated_at         TIMESTAMPTZ
+);
+
+CREATE INDEX idx_product_images_product_id ON product_images (product_id, position);
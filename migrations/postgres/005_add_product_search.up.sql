@@ -0,0 +1,22 @@
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+ALTER TABLE products ADD COLUMN search_vector TSVECTOR;
+
+CREATE FUNCTION products_search_vector_update() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('portuguese', unaccent(COALESCE(NEW.name, ''))), 'A') ||
+        setweight(to_tsvector('simple', unaccent(COALESCE(NEW.sku, ''))), 'B') ||
+        setweight(to_tsvector('portuguese', unaccent(COALESCE(NEW.description, ''))), 'C');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER products_search_vector_trigger
+    BEFORE INSERT OR UPDATE OF name, sku, description ON products
+    FOR EACH ROW EXECUTE FUNCTION products_search_vector_update();
+
+-- Fires the trigger for the existing rows.
+UPDATE products SET name = name;
+
+CREATE INDEX idx_products_search_vector ON products USING GIN (search_vector);
@@ -0,0 +1,31 @@
+-- The sale price and end of the running sale are copied to products by
+-- the price scheduler, so listings can filter and sort by the effective
+-- price without joining the sale windows.
+ALTER TABLE products ADD COLUMN sale_price DECIMAL;
+ALTER TABLE products ADD COLUMN sale_ends_at TIMESTAMPTZ;
+
+CREATE TABLE product_sales (
+    id         BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    sale_price DECIMAL NOT NULL,
+    starts_at  TIMESTAMPTZ NOT NULL,
+    ends_at    TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX idx_product_sales_product_id ON product_sales (product_id, starts_at);
+CREATE INDEX idx_product_sales_starts_at ON product_sales (starts_at);
+CREATE INDEX idx_product_sales_ends_at ON product_sales (ends_at);
+
+CREATE TABLE price_history (
+    id         BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    price      DECIMAL NOT NULL,
+    sale_price DECIMAL,
+    reason     VARCHAR(20) NOT NULL,
+    created_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_price_history_product_id ON price_history (product_id, created_at);
@@ -0,0 +1,19 @@
+CREATE TABLE import_jobs (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT NOT NULL REFERENCES users (id),
+    format      VARCHAR(10) NOT NULL,
+    status      VARCHAR(20) NOT NULL,
+    processed   BIGINT NOT NULL DEFAULT 0,
+    dry_run     BOOLEAN NOT NULL DEFAULT FALSE,
+    total_rows  BIGINT NOT NULL DEFAULT 0,
+    created     BIGINT NOT NULL DEFAULT 0,
+    updated     BIGINT NOT NULL DEFAULT 0,
+    failed      BIGINT NOT NULL DEFAULT 0,
+    results     TEXT NOT NULL DEFAULT '[]',
+    error       TEXT,
+    created_at  TIMESTAMPTZ,
+    updated_at  TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_import_jobs_user_id ON import_jobs (user_id);
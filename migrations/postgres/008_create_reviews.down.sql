@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS reviews;
+
+ALTER TABLE products DROP COLUMN rating_stars5;
+ALTER TABLE products DROP COLUMN rating_stars4;
+ALTER TABLE products DROP COLUMN rating_stars3;
+ALTER TABLE products DROP COLUMN rating_stars2;
+ALTER TABLE products DROP COLUMN rating_stars1;
+ALTER TABLE products DROP COLUMN rating_sum;
+ALTER TABLE products DROP COLUMN rating_count;
@@ -0,0 +1,32 @@
+CREATE TABLE wishlists (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name        VARCHAR(100) NOT NULL,
+    share_token VARCHAR(64),
+    created_at  TIMESTAMPTZ,
+    updated_at  TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_wishlists_user_name ON wishlists (user_id, name);
+CREATE UNIQUE INDEX idx_wishlists_share_token ON wishlists (share_token);
+
+CREATE TABLE wishlist_items (
+    id          BIGSERIAL PRIMARY KEY,
+    wishlist_id BIGINT NOT NULL REFERENCES wishlists (id) ON DELETE CASCADE,
+    product_id  BIGINT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    created_at  TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_wishlist_items_product ON wishlist_items (wishlist_id, product_id);
+
+CREATE TABLE stock_subscriptions (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    product_id  BIGINT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    notified_at TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ
+);
+
+-- One pending subscription per user and product; notified ones are kept
+-- as a record.
+CREATE UNIQUE INDEX idx_stock_subscriptions_pending ON stock_subscriptions (product_id, user_id) WHERE notified_at IS NULL;
@@ -0,0 +1,35 @@
+CREATE TABLE coupons (
+    id                 BIGSERIAL PRIMARY KEY,
+    code               VARCHAR(50) NOT NULL,
+    description        TEXT,
+    type               VARCHAR(20) NOT NULL,
+    percent            INTEGER NOT NULL DEFAULT 0,
+    amount             DECIMAL NOT NULL DEFAULT 0,
+    buy_quantity       INTEGER NOT NULL DEFAULT 0,
+    get_quantity       INTEGER NOT NULL DEFAULT 0,
+    min_subtotal       DECIMAL NOT NULL DEFAULT 0,
+    starts_at          TIMESTAMPTZ,
+    ends_at            TIMESTAMPTZ,
+    usage_limit        INTEGER,
+    per_user_limit     INTEGER,
+    used_count         INTEGER NOT NULL DEFAULT 0,
+    include_categories TEXT,
+    exclude_categories TEXT,
+    include_skus       TEXT,
+    exclude_skus       TEXT,
+    active             BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at         TIMESTAMPTZ,
+    updated_at         TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_coupons_code ON coupons (code);
+
+-- Redemptions per customer, counted with a conditional upsert so the
+-- per-user limit holds under concurrent redemptions.
+CREATE TABLE coupon_usages (
+    coupon_id  BIGINT NOT NULL REFERENCES coupons (id) ON DELETE CASCADE,
+    user_id    BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    uses       INTEGER NOT NULL,
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (coupon_id, user_id)
+);
@@ -0,0 +1,7 @@
+-- Shipping measures of a product, per unit: weight in grams and packed
+-- dimensions in centimeters. 0 means not informed; shipping quotes use
+-- SHIPPING_DEFAULT_WEIGHT_GRAMS and SHIPPING_DEFAULT_VOLUME_CM3 instead.
+ALTER TABLE products ADD COLUMN weight_grams INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN length_cm INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN width_cm INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN height_cm INTEGER NOT NULL DEFAULT 0;
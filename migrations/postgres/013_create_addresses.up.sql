@@ -0,0 +1,20 @@
+CREATE TABLE addresses (
+    id           BIGSERIAL PRIMARY KEY,
+    user_id      BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    label        VARCHAR(50),
+    recipient    VARCHAR(100) NOT NULL,
+    cep          VARCHAR(9) NOT NULL,
+    street       VARCHAR(200) NOT NULL,
+    number       VARCHAR(20) NOT NULL,
+    complement   VARCHAR(100),
+    neighborhood VARCHAR(100) NOT NULL,
+    city         VARCHAR(100) NOT NULL,
+    state        VARCHAR(2) NOT NULL,
+    is_default   BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at   TIMESTAMPTZ,
+    updated_at   TIMESTAMPTZ
+);
+
+CREATE INDEX idx_addresses_user_id ON addresses (user_id);
+-- At most one default address per user.
+CREATE UNIQUE INDEX idx_addresses_default ON addresses (user_id) WHERE is_default;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_users_document_hash;
+
+ALTER TABLE users DROP COLUMN document_hash;
+ALTER TABLE users DROP COLUMN document;
+ALTER TABLE users DROP COLUMN document_type;
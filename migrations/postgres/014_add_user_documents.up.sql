@@ -0,0 +1,8 @@
+-- CPF or CNPJ of the user, for invoices and payments. document holds the
+-- number encrypted with DOCUMENT_ENCRYPTION_KEY and document_hash its HMAC,
+-- which keeps documents unique without decrypting them.
+ALTER TABLE users ADD COLUMN document_type VARCHAR(4);
+ALTER TABLE users ADD COLUMN document TEXT;
+ALTER TABLE users ADD COLUMN document_hash VARCHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_document_hash ON users (document_hash) WHERE deleted_at IS NULL;
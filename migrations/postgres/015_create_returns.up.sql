@@ -0,0 +1,46 @@
+-- Returns of order items. Orders live in the order system, so order_id
+-- and order_item_id are its identifiers, not foreign keys.
+CREATE TABLE return_requests (
+    id               BIGSERIAL PRIMARY KEY,
+    user_id          BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    order_id         VARCHAR(64) NOT NULL,
+    status           VARCHAR(20) NOT NULL,
+    reason           VARCHAR(20) NOT NULL,
+    description      TEXT,
+    refund_amount    DECIMAL NOT NULL,
+    rejection_reason TEXT,
+    restocked        BOOLEAN NOT NULL DEFAULT FALSE,
+    reviewed_by      BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    reviewed_at      TIMESTAMPTZ,
+    received_at      TIMESTAMPTZ,
+    refunded_at      TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ,
+    updated_at       TIMESTAMPTZ
+);
+
+CREATE INDEX idx_return_requests_user_id ON return_requests (user_id);
+CREATE INDEX idx_return_requests_order_id ON return_requests (order_id);
+CREATE INDEX idx_return_requests_status ON return_requests (status);
+
+CREATE TABLE return_items (
+    id                BIGSERIAL PRIMARY KEY,
+    return_request_id BIGINT NOT NULL REFERENCES return_requests (id) ON DELETE CASCADE,
+    order_item_id     BIGINT NOT NULL,
+    product_id        BIGINT NOT NULL,
+    variant_id        BIGINT,
+    quantity          INTEGER NOT NULL,
+    amount            DECIMAL NOT NULL
+);
+
+CREATE INDEX idx_return_items_return_request_id ON return_items (return_request_id);
+
+CREATE TABLE return_photos (
+    id                BIGSERIAL PRIMARY KEY,
+    return_request_id BIGINT NOT NULL REFERENCES return_requests (id) ON DELETE CASCADE,
+    storage_key       TEXT NOT NULL,
+    content_type      VARCHAR(50) NOT NULL,
+    size              BIGINT,
+    created_at        TIMESTAMPTZ
+);
+
+CREATE INDEX idx_return_photos_return_request_id ON return_photos (return_request_id);
@@ -0,0 +1,57 @@
+-- Store credit of each user. balance caches the sum of the ledger and
+-- version is bumped by every transaction, so concurrent ones retry
+-- instead of spending the same balance.
+CREATE TABLE wallets (
+    user_id    BIGINT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    balance    DECIMAL NOT NULL DEFAULT 0,
+    version    INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ
+);
+
+-- Append-only ledger: rows are never updated or deleted. The key makes
+-- retries of a transaction apply once.
+CREATE TABLE wallet_transactions (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type            VARCHAR(10) NOT NULL,
+    amount          DECIMAL NOT NULL,
+    balance_after   DECIMAL NOT NULL,
+    reason          TEXT,
+    reference       TEXT,
+    idempotency_key TEXT NOT NULL,
+    expires_at      TIMESTAMPTZ,
+    credit_id       BIGINT REFERENCES wallet_transactions (id),
+    created_by      BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ
+);
+
+CREATE INDEX idx_wallet_transactions_user_id ON wallet_transactions (user_id);
+CREATE UNIQUE INDEX idx_wallet_transactions_key ON wallet_transactions (user_id, idempotency_key);
+
+-- What is left to spend of each credit, consumed by debits and expiry.
+-- Spent lots are removed.
+CREATE TABLE wallet_lots (
+    transaction_id BIGINT PRIMARY KEY REFERENCES wallet_transactions (id),
+    user_id        BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    remaining      DECIMAL NOT NULL,
+    expires_at     TIMESTAMPTZ
+);
+
+CREATE INDEX idx_wallet_lots_user_id ON wallet_lots (user_id);
+CREATE INDEX idx_wallet_lots_expires_at ON wallet_lots (expires_at);
+
+CREATE TABLE gift_cards (
+    id              BIGSERIAL PRIMARY KEY,
+    code            VARCHAR(19) NOT NULL,
+    initial_balance DECIMAL NOT NULL,
+    balance         DECIMAL NOT NULL,
+    note            TEXT,
+    expires_at      TIMESTAMPTZ NOT NULL,
+    created_by      BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    redeemed_by     BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    redeemed_at     TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ,
+    updated_at      TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_gift_cards_code ON gift_cards (code);
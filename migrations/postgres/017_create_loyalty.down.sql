@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS loyalty_lots;
+DROP TABLE IF EXISTS loyalty_transactions;
+DROP TABLE IF EXISTS loyalty_accounts;
+DROP TABLE IF EXISTS loyalty_rules;
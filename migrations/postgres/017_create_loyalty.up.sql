@@ -0,0 +1,57 @@
+-- Cashback rate of each category, in basis points. Categories without a
+-- rule take the one of the nearest ancestor that has one.
+CREATE TABLE loyalty_rules (
+    id          BIGSERIAL PRIMARY KEY,
+    category_id BIGINT NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+    rate        INTEGER NOT NULL,
+    created_at  TIMESTAMPTZ,
+    updated_at  TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_loyalty_rules_category_id ON loyalty_rules (category_id);
+
+-- Points of each user. available and pending cache the sums of the ledger
+-- and version is bumped by every transaction, so concurrent ones retry
+-- instead of spending the same points.
+CREATE TABLE loyalty_accounts (
+    user_id    BIGINT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    available  BIGINT NOT NULL DEFAULT 0,
+    pending    BIGINT NOT NULL DEFAULT 0,
+    version    INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ
+);
+
+-- Append-only ledger: rows are never updated or deleted. The key makes
+-- retries of a transaction apply once.
+CREATE TABLE loyalty_transactions (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type            VARCHAR(10) NOT NULL,
+    points          BIGINT NOT NULL,
+    available_after BIGINT NOT NULL,
+    pending_after   BIGINT NOT NULL,
+    reference       TEXT,
+    idempotency_key TEXT NOT NULL,
+    available_at    TIMESTAMPTZ,
+    expires_at      TIMESTAMPTZ,
+    accrual_id      BIGINT REFERENCES loyalty_transactions (id),
+    created_at      TIMESTAMPTZ
+);
+
+CREATE INDEX idx_loyalty_transactions_user_id ON loyalty_transactions (user_id);
+CREATE UNIQUE INDEX idx_loyalty_transactions_key ON loyalty_transactions (user_id, idempotency_key);
+
+-- What is left of each accrual, released after its delay and consumed by
+-- redemptions and expiry. Spent lots are removed.
+CREATE TABLE loyalty_lots (
+    transaction_id BIGINT PRIMARY KEY REFERENCES loyalty_transactions (id),
+    user_id        BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    remaining      BIGINT NOT NULL,
+    pending        BOOLEAN NOT NULL,
+    available_at   TIMESTAMPTZ NOT NULL,
+    expires_at     TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_loyalty_lots_user_id ON loyalty_lots (user_id);
+CREATE INDEX idx_loyalty_lots_available_at ON loyalty_lots (available_at);
+CREATE INDEX idx_loyalty_lots_expires_at ON loyalty_lots (expires_at);
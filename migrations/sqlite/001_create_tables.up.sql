@@ -0,0 +1,33 @@
+-- IF NOT EXISTS lets this baseline adopt databases created by the old
+-- AutoMigrate boot path.
+CREATE TABLE IF NOT EXISTS users (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    email      TEXT NOT NULL,
+    password   TEXT NOT NULL,
+    name       TEXT NOT NULL,
+    role       TEXT DEFAULT 'user',
+    active     NUMERIC DEFAULT TRUE,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS products (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    name        TEXT NOT NULL,
+    description TEXT,
+    price       REAL NOT NULL,
+    stock       INTEGER NOT NULL DEFAULT 0,
+    category    TEXT,
+    sku         TEXT,
+    active      NUMERIC DEFAULT TRUE,
+    image_url   TEXT,
+    created_at  DATETIME,
+    updated_at  DATETIME,
+    deleted_at  DATETIME
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products (sku);
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at);
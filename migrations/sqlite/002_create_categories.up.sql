@@ -0,0 +1,89 @@
+CREATE TABLE categories (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    name       TEXT NOT NULL,
+    slug       TEXT NOT NULL,
+    parent_id  INTEGER REFERENCES categories (id),
+    path       TEXT NOT NULL,
+    depth      INTEGER NOT NULL DEFAULT 0,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    active     NUMERIC DEFAULT TRUE,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+
+CREATE UNIQUE INDEX idx_categories_slug ON categories (slug);
+CREATE INDEX idx_categories_parent_id ON categories (parent_id);
+CREATE INDEX idx_categories_path ON categories (path);
+
+-- No REFERENCES clause: SQLite cannot drop a column that takes part in a
+-- foreign key, which the down migration needs to do.
+ALTER TABLE products ADD COLUMN category_id INTEGER;
+CREATE INDEX idx_products_category_id ON products (category_id);
+
+-- Every distinct free-text category becomes a root category. Spellings that
+-- only differ in case, accents, punctuation or spaces collapse into one slug,
+-- made like utils.Slugify. SQLite has no TRANSLATE or regular expressions,
+-- and LOWER only folds ASCII, so accents are folded one character at a time
+-- and the rest of the slug is built one character at a time below.
+ALTER TABLE products ADD COLUMN category_slug TEXT;
+
+UPDATE products SET category_slug = TRIM(category) WHERE TRIM(COALESCE(category, '')) <> '';
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'á', 'a'), 'Á', 'a');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'à', 'a'), 'À', 'a');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'â', 'a'), 'Â', 'a');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'ã', 'a'), 'Ã', 'a');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'ä', 'a'), 'Ä', 'a');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'é', 'e'), 'É', 'e');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'è', 'e'), 'È', 'e');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'ê', 'e'), 'Ê', 'e');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'ë', 'e'), 'Ë', 'e');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'í', 'i'), 'Í', 'i');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'ì', 'i'), 'Ì', 'i');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'î', 'i'), 'Î', 'i');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'ï', 'i'), 'Ï', 'i');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'ó', 'o'), 'Ó', 'o');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'ò', 'o'), 'Ò', 'o');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'ô', 'o'), 'Ô', 'o');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'õ', 'o'), 'Õ', 'o');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'ö', 'o'), 'Ö', 'o');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'ú', 'u'), 'Ú', 'u');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'ù', 'u'), 'Ù', 'u');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'û', 'u'), 'Û', 'u');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'ü', 'u'), 'Ü', 'u');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'ç', 'c'), 'Ç', 'c');
+UPDATE products SET category_slug = REPLACE(REPLACE(category_slug, 'ñ', 'n'), 'Ñ', 'n');
+UPDATE products SET category_slug = LOWER(category_slug);
+
+-- Keeps letters and digits and turns every run of other characters into a
+-- single dash, dropping the dashes at the ends: "moda & calcados" becomes
+-- "moda-calcados".
+WITH RECURSIVE slugs (original, rest, slug) AS (
+    SELECT category_slug, category_slug, ''
+    FROM (SELECT DISTINCT category_slug FROM products WHERE category_slug IS NOT NULL)
+    UNION ALL
+    SELECT original, SUBSTR(rest, 2),
+        CASE
+            WHEN SUBSTR(rest, 1, 1) GLOB '[a-z0-9]' THEN slug || SUBSTR(rest, 1, 1)
+            WHEN slug = '' OR SUBSTR(slug, -1) = '-' THEN slug
+            ELSE slug || '-'
+        END
+    FROM slugs
+    WHERE rest <> ''
+)
+UPDATE products
+SET category_slug = (SELECT RTRIM(slug, '-') FROM slugs WHERE original = products.category_slug AND rest = '')
+WHERE category_slug IS NOT NULL;
+
+INSERT INTO categories (name, slug, path, depth, sort_order, active, created_at, updated_at)
+SELECT MIN(TRIM(category)), category_slug, '', 0, 0, TRUE, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+FROM products
+WHERE COALESCE(category_slug, '') <> ''
+GROUP BY category_slug;
+
+UPDATE categories SET path = '/' || id || '/' WHERE path = '';
+
+UPDATE products
+SET category_id = (SELECT id FROM categories WHERE categories.slug = products.category_slug);
+
+ALTER TABLE products DROP COLUMN category_slug;
+ALTER TABLE products DROP COLUMN category;
@@ -0,0 +1,38 @@
+CREATE TABLE option_types (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    code       TEXT NOT NULL,
+    name       TEXT NOT NULL,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+
+CREATE UNIQUE INDEX idx_option_types_code ON option_types (code);
+
+INSERT INTO option_types (code, name, created_at, updated_at) VALUES
+    ('size', 'Tamanho', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+    ('color', 'Cor', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+    ('voltage', 'Voltagem', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);
+
+CREATE TABLE product_variants (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INTEGER NOT NULL REFERENCES products (id),
+    sku        TEXT NOT NULL,
+    price      REAL,
+    stock      INTEGER NOT NULL DEFAULT 0,
+    images     TEXT NOT NULL DEFAULT '[]',
+    active     NUMERIC DEFAULT TRUE,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+
+CREATE UNIQUE INDEX idx_product_variants_sku ON product_variants (sku);
+CREATE INDEX idx_product_variants_product_id ON product_variants (product_id);
+
+CREATE TABLE variant_options (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    variant_id     INTEGER NOT NULL REFERENCES product_variants (id) ON DELETE CASCADE,
+    option_type_id INTEGER NOT NULL REFERENCES option_types (id),
+    value          TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_variant_options_variant_type ON variant_options (variant_id, option_type_id);
@@ -0,0 +1,17 @@
+CREATE TABLE product_images (
+    id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id         INTEGER NOT NULL REFERENCES products (id),
+    position           INTEGER NOT NULL DEFAULT 0,
+    content_type       TEXT NOT NULL,
+    width              INTEGER,
+    height             INTEGER,
+    size               INTEGER,
+    original_key       TEXT NOT NULL,
+    thumbnail_key      TEXT NOT NULL,
+    webp_key           TEXT NOT NULL,
+    thumbnail_webp_key TEXT NOT NULL,
+    created_at         DATETIME,
+    updated_at         DATETIME
+);
+
+CREATE INDEX idx_product_images_product_id ON product_images (product_id, position);
@@ -0,0 +1,6 @@
+-- The SQLite search index is an FTS5 table, and go-sqlite3 only ships FTS5
+-- when built with -tags sqlite_fts5. It is created right after the
+-- migrations by database.EnsureSearchIndex instead (migrate up, or the
+-- server with DB_AUTO_MIGRATE), so this migration runs on any build. The
+-- down migration drops it.
+SELECT 1;
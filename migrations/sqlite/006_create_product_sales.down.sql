@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS price_history;
+DROP TABLE IF EXISTS product_sales;
+
+ALTER TABLE products DROP COLUMN sale_ends_at;
+ALTER TABLE products DROP COLUMN sale_price;
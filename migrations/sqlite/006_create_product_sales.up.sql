@@ -0,0 +1,31 @@
+-- The sale price and end of the running sale are copied to products by
+-- the price scheduler, so listings can filter and sort by the effective
+-- price without joining the sale windows.
+ALTER TABLE products ADD COLUMN sale_price REAL;
+ALTER TABLE products ADD COLUMN sale_ends_at DATETIME;
+
+CREATE TABLE product_sales (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    sale_price REAL NOT NULL,
+    starts_at  DATETIME NOT NULL,
+    ends_at    DATETIME NOT NULL,
+    created_at DATETIME,
+    updated_at DATETIME,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX idx_product_sales_product_id ON product_sales (product_id, starts_at);
+CREATE INDEX idx_product_sales_starts_at ON product_sales (starts_at);
+CREATE INDEX idx_product_sales_ends_at ON product_sales (ends_at);
+
+CREATE TABLE price_history (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    price      REAL NOT NULL,
+    sale_price REAL,
+    reason     TEXT NOT NULL,
+    created_at DATETIME
+);
+
+CREATE INDEX idx_price_history_product_id ON price_history (product_id, created_at);
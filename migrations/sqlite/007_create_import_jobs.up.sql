@@ -0,0 +1,19 @@
+CREATE TABLE import_jobs (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id     INTEGER NOT NULL REFERENCES users (id),
+    format      TEXT NOT NULL,
+    status      TEXT NOT NULL,
+    processed   INTEGER NOT NULL DEFAULT 0,
+    dry_run     NUMERIC NOT NULL DEFAULT FALSE,
+    total_rows  INTEGER NOT NULL DEFAULT 0,
+    created     INTEGER NOT NULL DEFAULT 0,
+    updated     INTEGER NOT NULL DEFAULT 0,
+    failed      INTEGER NOT NULL DEFAULT 0,
+    results     TEXT NOT NULL DEFAULT '[]',
+    error       TEXT,
+    created_at  DATETIME,
+    updated_at  DATETIME,
+    finished_at DATETIME
+);
+
+CREATE INDEX idx_import_jobs_user_id ON import_jobs (user_id);
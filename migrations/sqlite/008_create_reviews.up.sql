@@ -0,0 +1,30 @@
+-- The rating of a product is kept on the product itself and updated as
+-- reviews are moderated, so listings show it without aggregating reviews.
+ALTER TABLE products ADD COLUMN rating_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN rating_sum INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN rating_stars1 INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN rating_stars2 INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN rating_stars3 INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN rating_stars4 INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN rating_stars5 INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE reviews (
+    id                INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id        INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    user_id           INTEGER NOT NULL REFERENCES users (id),
+    author            TEXT NOT NULL,
+    rating            INTEGER NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    title             TEXT NOT NULL,
+    body              TEXT,
+    verified_purchase NUMERIC NOT NULL DEFAULT FALSE,
+    status            TEXT NOT NULL,
+    rejection_reason  TEXT,
+    moderated_by      INTEGER REFERENCES users (id),
+    moderated_at      DATETIME,
+    created_at        DATETIME,
+    updated_at        DATETIME
+);
+
+CREATE UNIQUE INDEX idx_reviews_product_user ON reviews (product_id, user_id);
+CREATE INDEX idx_reviews_product_status ON reviews (product_id, status, created_at);
+CREATE INDEX idx_reviews_status ON reviews (status, created_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS stock_subscriptions;
+DROP TABLE IF EXISTS wishlist_items;
+DROP TABLE IF EXISTS wishlists;
@@ -0,0 +1,32 @@
+CREATE TABLE wishlists (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id     INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name        TEXT NOT NULL,
+    share_token TEXT,
+    created_at  DATETIME,
+    updated_at  DATETIME
+);
+
+CREATE UNIQUE INDEX idx_wishlists_user_name ON wishlists (user_id, name);
+CREATE UNIQUE INDEX idx_wishlists_share_token ON wishlists (share_token);
+
+CREATE TABLE wishlist_items (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    wishlist_id INTEGER NOT NULL REFERENCES wishlists (id) ON DELETE CASCADE,
+    product_id  INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    created_at  DATETIME
+);
+
+CREATE UNIQUE INDEX idx_wishlist_items_product ON wishlist_items (wishlist_id, product_id);
+
+CREATE TABLE stock_subscriptions (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id     INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    product_id  INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    notified_at DATETIME,
+    created_at  DATETIME
+);
+
+-- One pending subscription per user and product; notified ones are kept
+-- as a record.
+CREATE UNIQUE INDEX idx_stock_subscriptions_pending ON stock_subscriptions (product_id, user_id) WHERE notified_at IS NULL;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS low_stock_alerts;
+
+ALTER TABLE categories DROP COLUMN reorder_threshold;
+ALTER TABLE products DROP COLUMN reorder_threshold;
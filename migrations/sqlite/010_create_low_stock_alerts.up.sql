@@ -0,0 +1,17 @@
+-- Reorder thresholds: a product without one uses the nearest category up
+-- its tree that has one, then LOW_STOCK_THRESHOLD.
+ALTER TABLE products ADD COLUMN reorder_threshold INTEGER;
+ALTER TABLE categories ADD COLUMN reorder_threshold INTEGER;
+
+CREATE TABLE low_stock_alerts (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id  INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    stock       INTEGER NOT NULL,
+    threshold   INTEGER NOT NULL,
+    resolved_at DATETIME,
+    created_at  DATETIME
+);
+
+-- One open alert per product; it is resolved once the product is restocked
+-- and a new one opens if the stock drops again.
+CREATE UNIQUE INDEX idx_low_stock_alerts_open ON low_stock_alerts (product_id) WHERE resolved_at IS NULL;
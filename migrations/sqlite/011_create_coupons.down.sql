@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS coupon_usages;
+DROP TABLE IF EXISTS coupons;
@@ -0,0 +1,35 @@
+CREATE TABLE coupons (
+    id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+    code               TEXT NOT NULL,
+    description        TEXT,
+    type               TEXT NOT NULL,
+    percent            INTEGER NOT NULL DEFAULT 0,
+    amount             REAL NOT NULL DEFAULT 0,
+    buy_quantity       INTEGER NOT NULL DEFAULT 0,
+    get_quantity       INTEGER NOT NULL DEFAULT 0,
+    min_subtotal       REAL NOT NULL DEFAULT 0,
+    starts_at          DATETIME,
+    ends_at            DATETIME,
+    usage_limit        INTEGER,
+    per_user_limit     INTEGER,
+    used_count         INTEGER NOT NULL DEFAULT 0,
+    include_categories TEXT,
+    exclude_categories TEXT,
+    include_skus       TEXT,
+    exclude_skus       TEXT,
+    active             NUMERIC NOT NULL DEFAULT TRUE,
+    created_at         DATETIME,
+    updated_at         DATETIME
+);
+
+CREATE UNIQUE INDEX idx_coupons_code ON coupons (code);
+
+-- Redemptions per customer, counted with a conditional upsert so the
+-- per-user limit holds under concurrent redemptions.
+CREATE TABLE coupon_usages (
+    coupon_id  INTEGER NOT NULL REFERENCES coupons (id) ON DELETE CASCADE,
+    user_id    INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    uses       INTEGER NOT NULL,
+    updated_at DATETIME,
+    PRIMARY KEY (coupon_id, user_id)
+);
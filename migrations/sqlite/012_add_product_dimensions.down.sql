@@ -0,0 +1,4 @@
+ALTER TABLE products DROP COLUMN height_cm;
+ALTER TABLE products DROP COLUMN width_cm;
+ALTER TABLE products DROP COLUMN length_cm;
+ALTER TABLE products DROP COLUMN weight_grams;
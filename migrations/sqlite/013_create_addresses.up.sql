@@ -0,0 +1,20 @@
+CREATE TABLE addresses (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id      INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    label        TEXT,
+    recipient    TEXT NOT NULL,
+    cep          TEXT NOT NULL,
+    street       TEXT NOT NULL,
+    number       TEXT NOT NULL,
+    complement   TEXT,
+    neighborhood TEXT NOT NULL,
+    city         TEXT NOT NULL,
+    state        TEXT NOT NULL,
+    is_default   NUMERIC NOT NULL DEFAULT FALSE,
+    created_at   DATETIME,
+    updated_at   DATETIME
+);
+
+CREATE INDEX idx_addresses_user_id ON addresses (user_id);
+-- At most one default address per user.
+CREATE UNIQUE INDEX idx_addresses_default ON addresses (user_id) WHERE is_default;
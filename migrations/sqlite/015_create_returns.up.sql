@@ -0,0 +1,46 @@
+-- Returns of order items. Orders live in the order system, so order_id
+-- and order_item_id are its identifiers, not foreign keys.
+CREATE TABLE return_requests (
+    id               INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id          INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    order_id         TEXT NOT NULL,
+    status           TEXT NOT NULL,
+    reason           TEXT NOT NULL,
+    description      TEXT,
+    refund_amount    REAL NOT NULL,
+    rejection_reason TEXT,
+    restocked        NUMERIC NOT NULL DEFAULT FALSE,
+    reviewed_by      INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    reviewed_at      DATETIME,
+    received_at      DATETIME,
+    refunded_at      DATETIME,
+    created_at       DATETIME,
+    updated_at       DATETIME
+);
+
+CREATE INDEX idx_return_requests_user_id ON return_requests (user_id);
+CREATE INDEX idx_return_requests_order_id ON return_requests (order_id);
+CREATE INDEX idx_return_requests_status ON return_requests (status);
+
+CREATE TABLE return_items (
+    id                INTEGER PRIMARY KEY AUTOINCREMENT,
+    return_request_id INTEGER NOT NULL REFERENCES return_requests (id) ON DELETE CASCADE,
+    order_item_id     INTEGER NOT NULL,
+    product_id        INTEGER NOT NULL,
+    variant_id        INTEGER,
+    quantity          INTEGER NOT NULL,
+    amount            REAL NOT NULL
+);
+
+CREATE INDEX idx_return_items_return_request_id ON return_items (return_request_id);
+
+CREATE TABLE return_photos (
+    id                INTEGER PRIMARY KEY AUTOINCREMENT,
+    return_request_id INTEGER NOT NULL REFERENCES return_requests (id) ON DELETE CASCADE,
+    storage_key       TEXT NOT NULL,
+    content_type      TEXT NOT NULL,
+    size              INTEGER,
+    created_at        DATETIME
+);
+
+CREATE INDEX idx_return_photos_return_request_id ON return_photos (return_request_id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS gift_cards;
+DROP TABLE IF EXISTS wallet_lots;
+DROP TABLE IF EXISTS wallet_transactions;
+DROP TABLE IF EXISTS wallets;
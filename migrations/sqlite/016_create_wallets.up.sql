@@ -0,0 +1,57 @@
+-- Store credit of each user. balance caches the sum of the ledger and
+-- version is bumped by every transaction, so concurrent ones retry
+-- instead of spending the same balance.
+CREATE TABLE wallets (
+    user_id    INTEGER PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    balance    REAL NOT NULL DEFAULT 0,
+    version    INTEGER NOT NULL DEFAULT 0,
+    updated_at DATETIME
+);
+
+-- Append-only ledger: rows are never updated or deleted. The key makes
+-- retries of a transaction apply once.
+CREATE TABLE wallet_transactions (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id         INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type            TEXT NOT NULL,
+    amount          REAL NOT NULL,
+    balance_after   REAL NOT NULL,
+    reason          TEXT,
+    reference       TEXT,
+    idempotency_key TEXT NOT NULL,
+    expires_at      DATETIME,
+    credit_id       INTEGER REFERENCES wallet_transactions (id),
+    created_by      INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    created_at      DATETIME
+);
+
+CREATE INDEX idx_wallet_transactions_user_id ON wallet_transactions (user_id);
+CREATE UNIQUE INDEX idx_wallet_transactions_key ON wallet_transactions (user_id, idempotency_key);
+
+-- What is left to spend of each credit, consumed by debits and expiry.
+-- Spent lots are removed.
+CREATE TABLE wallet_lots (
+    transaction_id INTEGER PRIMARY KEY REFERENCES wallet_transactions (id),
+    user_id        INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    remaining      REAL NOT NULL,
+    expires_at     DATETIME
+);
+
+CREATE INDEX idx_wallet_lots_user_id ON wallet_lots (user_id);
+CREATE INDEX idx_wallet_lots_expires_at ON wallet_lots (expires_at);
+
+CREATE TABLE gift_cards (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    code            TEXT NOT NULL,
+    initial_balance REAL NOT NULL,
+    balance         REAL NOT NULL,
+    note            TEXT,
+    expires_at      DATETIME NOT NULL,
+    created_by      INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    redeemed_by     INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    redeemed_at     DATETIME,
+    created_at      DATETIME,
+    updated_at      DATETIME
+);
+
+CREATE UNIQUE INDEX idx_gift_cards_code ON gift_cards (code);
@@ -0,0 +1,40 @@
+// Package background runs the work the server does outside of requests,
+// like the scheduled jobs, so shutdown can wait for it to finish before
+// closing the database and Redis it uses.
+package background
+
+import (
+	"context"
+	"sync"
+)
+
+// Group tracks goroutines. The zero value is ready to use.
+type Group struct {
+	wg sync.WaitGroup
+}
+
+// Go runs fn in a goroutine of the group.
+func (g *Group) Go(fn func()) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		fn()
+	}()
+}
+
+// Wait blocks until every goroutine of the group returns, or until ctx is
+// done, returning its error then.
+func (g *Group) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
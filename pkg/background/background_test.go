@@ -0,0 +1,37 @@
+package background
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup(t *testing.T) {
+	t.Run("✅ Wait espera todas as goroutines", func(t *testing.T) {
+		var group Group
+		var finished atomic.Int32
+		for i := 0; i < 3; i++ {
+			group.Go(func() {
+				time.Sleep(20 * time.Millisecond)
+				finished.Add(1)
+			})
+		}
+
+		assert.NoError(t, group.Wait(context.Background()))
+		assert.Equal(t, int32(3), finished.Load())
+	})
+
+	t.Run("❌ Wait desiste no prazo do contexto", func(t *testing.T) {
+		var group Group
+		release := make(chan struct{})
+		defer close(release)
+		group.Go(func() { <-release })
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, group.Wait(ctx), context.DeadlineExceeded)
+	})
+}
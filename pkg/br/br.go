@@ -0,0 +1,88 @@
+// Package br validates and formats Brazilian identifiers: CEPs and the
+// states (UF) of addresses, and the CPF and CNPJ of people and companies.
+package br
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidCEP = errors.New("invalid CEP, it must have 8 digits")
+	ErrInvalidUF  = errors.New("invalid UF")
+)
+
+// States are the names of the 26 states and the Distrito Federal by UF.
+var States = map[string]string{
+	"AC": "Acre",
+	"AL": "Alagoas",
+	"AP": "Amapá",
+	"AM": "Amazonas",
+	"BA": "Bahia",
+	"CE": "Ceará",
+	"DF": "Distrito Federal",
+	"ES": "Espírito Santo",
+	"GO": "Goiás",
+	"MA": "Maranhão",
+	"MT": "Mato Grosso",
+	"MS": "Mato Grosso do Sul",
+	"MG": "Minas Gerais",
+	"PA": "Pará",
+	"PB": "Paraíba",
+	"PR": "Paraná",
+	"PE": "Pernambuco",
+	"PI": "Piauí",
+	"RJ": "Rio de Janeiro",
+	"RN": "Rio Grande do Norte",
+	"RS": "Rio Grande do Sul",
+	"RO": "Rondônia",
+	"RR": "Roraima",
+	"SC": "Santa Catarina",
+	"SP": "São Paulo",
+	"SE": "Sergipe",
+	"TO": "Tocantins",
+}
+
+// ValidUF reports whether uf, in any case, is the UF of a state.
+func ValidUF(uf string) bool {
+	_, ok := States[strings.ToUpper(strings.TrimSpace(uf))]
+	return ok
+}
+
+// NormalizeCEP returns the 8 digits of a CEP written with or without the
+// dash, like "01310-100".
+func NormalizeCEP(cep string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' || r == ' ' {
+			return -1
+		}
+		return r
+	}, cep)
+
+	if !onlyDigits(digits, 8) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCEP, cep)
+	}
+	return digits, nil
+}
+
+// FormatCEP writes a CEP as 01310-100.
+func FormatCEP(cep string) (string, error) {
+	digits, err := NormalizeCEP(cep)
+	if err != nil {
+		return "", err
+	}
+	return digits[:5] + "-" + digits[5:], nil
+}
+
+func onlyDigits(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package br
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCEP(t *testing.T) {
+	for _, cep := range []string{"01310-100", "01310100", " 01310-100 ", "01.310-100"} {
+		digits, err := NormalizeCEP(cep)
+		require.NoError(t, err, cep)
+		assert.Equal(t, "01310100", digits)
+	}
+
+	formatted, err := FormatCEP("01310100")
+	require.NoError(t, err)
+	assert.Equal(t, "01310-100", formatted)
+
+	for _, cep := range []string{"", "1310-100", "01310-1000", "0131O-100", "01310/100"} {
+		_, err := NormalizeCEP(cep)
+		assert.ErrorIs(t, err, ErrInvalidCEP, cep)
+	}
+}
+
+func TestValidUF(t *testing.T) {
+	assert.Len(t, States, 27)
+	assert.True(t, ValidUF("SP"))
+	assert.True(t, ValidUF("df"))
+	assert.False(t, ValidUF("XX"))
+	assert.False(t, ValidUF(""))
+}
+
+func TestDocuments(t *testing.T) {
+	t.Run("✅ CPF e CNPJ válidos, com ou sem pontuação", func(t *testing.T) {
+		for _, cpf := range []string{"529.982.247-25", "52998224725", "111.444.777-35"} {
+			_, err := NormalizeCPF(cpf)
+			assert.NoError(t, err, cpf)
+		}
+		digits, err := NormalizeDocument("CPF", "529.982.247-25")
+		require.NoError(t, err)
+		assert.Equal(t, "52998224725", digits)
+
+		for _, cnpj := range []string{"11.222.333/0001-81", "11222333000181", "45.997.418/0001-53"} {
+			_, err := NormalizeCNPJ(cnpj)
+			assert.NoError(t, err, cnpj)
+		}
+		digits, err = NormalizeDocument("cnpj", "11.222.333/0001-81")
+		require.NoError(t, err)
+		assert.Equal(t, "11222333000181", digits)
+	})
+
+	t.Run("❌ Dígitos verificadores, tamanho e números repetidos", func(t *testing.T) {
+		for _, cpf := range []string{"529.982.247-24", "529.982.247-52", "111.111.111-11", "5299822472", "5299822472a", ""} {
+			_, err := NormalizeCPF(cpf)
+			assert.ErrorIs(t, err, ErrInvalidCPF, cpf)
+		}
+		for _, cnpj := range []string{"11.222.333/0001-80", "11.222.333/0001-18", "00.000.000/0000-00", "1122233300018"} {
+			_, err := NormalizeCNPJ(cnpj)
+			assert.ErrorIs(t, err, ErrInvalidCNPJ, cnpj)
+		}
+		_, err := NormalizeDocument("cnpj", "529.982.247-25")
+		assert.ErrorIs(t, err, ErrInvalidCNPJ)
+		_, err = NormalizeDocument("rg", "123456789")
+		assert.ErrorIs(t, err, ErrInvalidDocumentType)
+	})
+
+	t.Run("✅ Máscara", func(t *testing.T) {
+		assert.Equal(t, "***.982.247-**", MaskDocument("52998224725"))
+		assert.Equal(t, "**.222.333/0001-**", MaskDocument("11222333000181"))
+		assert.Equal(t, "****", MaskDocument("1234"))
+	})
+}
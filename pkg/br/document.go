@@ -0,0 +1,101 @@
+package br
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Document types: the CPF of people and the CNPJ of companies.
+const (
+	DocumentCPF  = "cpf"
+	DocumentCNPJ = "cnpj"
+)
+
+var (
+	ErrInvalidCPF          = errors.New("invalid CPF")
+	ErrInvalidCNPJ         = errors.New("invalid CNPJ")
+	ErrInvalidDocumentType = errors.New("invalid document type, it must be cpf or cnpj")
+)
+
+// NormalizeCPF returns the 11 digits of a CPF written with or without the
+// punctuation, like "529.982.247-25", after checking its check digits.
+func NormalizeCPF(cpf string) (string, error) {
+	digits := stripDocument(cpf)
+	if !onlyDigits(digits, 11) || repeated(digits) ||
+		checkDigit(digits[:9], 10) != digits[9] || checkDigit(digits[:10], 11) != digits[10] {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCPF, cpf)
+	}
+	return digits, nil
+}
+
+// NormalizeCNPJ returns the 14 digits of a CNPJ written with or without the
+// punctuation, like "11.222.333/0001-81", after checking its check digits.
+func NormalizeCNPJ(cnpj string) (string, error) {
+	digits := stripDocument(cnpj)
+	if !onlyDigits(digits, 14) || repeated(digits) ||
+		checkDigit(digits[:12], 5) != digits[12] || checkDigit(digits[:13], 6) != digits[13] {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCNPJ, cnpj)
+	}
+	return digits, nil
+}
+
+// NormalizeDocument normalizes number as a document of the type, "cpf" or
+// "cnpj" in any case.
+func NormalizeDocument(documentType, number string) (string, error) {
+	switch strings.ToLower(documentType) {
+	case DocumentCPF:
+		return NormalizeCPF(number)
+	case DocumentCNPJ:
+		return NormalizeCNPJ(number)
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidDocumentType, documentType)
+	}
+}
+
+// MaskDocument hides the first and the check digits of a normalized CPF
+// (***.456.789-**) or CNPJ (**.345.678/0001-**), enough for customers to
+// recognize their document. Other values are hidden whole.
+func MaskDocument(digits string) string {
+	switch {
+	case onlyDigits(digits, 11):
+		return "***." + digits[3:6] + "." + digits[6:9] + "-**"
+	case onlyDigits(digits, 14):
+		return "**." + digits[2:5] + "." + digits[5:8] + "/" + digits[8:12] + "-**"
+	default:
+		return strings.Repeat("*", len(digits))
+	}
+}
+
+func stripDocument(document string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r == '/' || r == ' ' {
+			return -1
+		}
+		return r
+	}, document)
+}
+
+// repeated reports whether all digits are the same. Such numbers, like
+// 111.111.111-11, pass the check digits but are not issued.
+func repeated(digits string) bool {
+	return strings.Count(digits, digits[:1]) == len(digits)
+}
+
+// checkDigit computes the modulo 11 check digit of digits, weighted from
+// weight down to 2, from left to right; CNPJs restart the weights at 9.
+func checkDigit(digits string, weight int) byte {
+	sum := 0
+	for _, r := range digits {
+		sum += int(r-'0') * weight
+		weight--
+		if weight < 2 {
+			weight = 9
+		}
+	}
+	rest := sum % 11
+	if rest < 2 {
+		return '0'
+	}
+	return byte('0' + 11 - rest)
+}
@@ -0,0 +1,28 @@
+package buildinfo
+
+import "runtime"
+
+// These values are injected at build time, e.g.:
+//
+//	go build -ldflags "-X github.com/Code-Aether/americanas-loja-api/pkg/buildinfo.Version=1.2.0"
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+type Info struct {
+	Version   string `json:"version" example:"1.2.0"`
+	Commit    string `json:"commit" example:"3f2c1a9"`
+	BuildTime string `json:"build_time" example:"2025-01-01T00:00:00Z"`
+	GoVersion string `json:"go_version" example:"go1.23.10"`
+}
+
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+}
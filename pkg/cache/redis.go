@@ -3,16 +3,22 @@ package cache
 import (
 	"context"
 	"log"
+	"time"
 
+	"github.com/go-redis/redis/extra/redisotel/v8"
 	"github.com/go-redis/redis/v8"
 )
 
-func NewRedisClient(redisURL, password string, db int) *redis.Client {
+func NewRedisClient(redisURL, password string, db int, timeout time.Duration) *redis.Client {
 	rdb := redis.NewClient(&redis.Options{
-		Addr:     redisURL,
-		Password: "", // no password set
-		DB:       0,  // use default DB
+		Addr:         redisURL,
+		Password:     "", // no password set
+		DB:           0,  // use default DB
+		DialTimeout:  timeout,
+		ReadTimeout:  timeout,
+		WriteTimeout: timeout,
 	})
+	rdb.AddHook(redisotel.NewTracingHook())
 
 	_, err := rdb.Ping(context.Background()).Result()
 	if err != nil {
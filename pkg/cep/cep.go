@@ -0,0 +1,46 @@
+// Package cep looks addresses up by CEP.
+package cep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/config"
+)
+
+const (
+	ResolverTable = "table"
+)
+
+var ErrNotFound = errors.New("CEP not found")
+
+// Address is what a CEP tells about an address. CEPs of a street carry
+// all fields; general CEPs of a city only City and State, and some
+// resolvers know no more than the State.
+type Address struct {
+	CEP          string `json:"cep"`
+	Street       string `json:"street,omitempty"`
+	Neighborhood string `json:"neighborhood,omitempty"`
+	City         string `json:"city,omitempty"`
+	State        string `json:"state"`
+}
+
+// Resolver looks up the address of a CEP, given as 8 digits. Unknown CEPs
+// return ErrNotFound; other errors are failures to look up.
+type Resolver interface {
+	Lookup(ctx context.Context, cep string) (*Address, error)
+}
+
+// New builds the resolver selected by CEP_RESOLVER.
+func New(cfg *config.Config) (Resolver, error) {
+	switch cfg.CEPResolver {
+	case ResolverTable:
+		if cfg.CEPTablePath == "" {
+			return DefaultTableResolver(), nil
+		}
+		return LoadTableResolver(cfg.CEPTablePath)
+	default:
+		return nil, fmt.Errorf("unknown CEP resolver %q", cfg.CEPResolver)
+	}
+}
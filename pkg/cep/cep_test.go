@@ -0,0 +1,70 @@
+package cep
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/config"
+	"github.com/Code-Aether/americanas-loja-api/pkg/br"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableResolver(t *testing.T) {
+	resolver := DefaultTableResolver()
+	ctx := context.Background()
+
+	t.Run("✅ CEP de logradouro traz rua e bairro", func(t *testing.T) {
+		address, err := resolver.Lookup(ctx, "01310-100")
+		require.NoError(t, err)
+		assert.Equal(t, Address{CEP: "01310100", Street: "Avenida Paulista", Neighborhood: "Bela Vista", City: "São Paulo", State: "SP"}, *address)
+	})
+
+	t.Run("✅ Faixas de cidade e de estado", func(t *testing.T) {
+		address, err := resolver.Lookup(ctx, "22070-011")
+		require.NoError(t, err)
+		assert.Equal(t, "Rio de Janeiro", address.City)
+		assert.Equal(t, "RJ", address.State)
+
+		address, err = resolver.Lookup(ctx, "13010-000")
+		require.NoError(t, err)
+		assert.Empty(t, address.City)
+		assert.Equal(t, "SP", address.State)
+	})
+
+	t.Run("✅ Toda faixa de CEP válida tem estado", func(t *testing.T) {
+		for _, cep := range []string{"01000-000", "69300-000", "69400-000", "72800-000", "73000-000", "76800-000", "99999-999"} {
+			address, err := resolver.Lookup(ctx, cep)
+			require.NoError(t, err, cep)
+			assert.True(t, br.ValidUF(address.State), cep)
+		}
+	})
+
+	t.Run("❌ CEP inexistente ou inválido", func(t *testing.T) {
+		_, err := resolver.Lookup(ctx, "00000-000")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		_, err = resolver.Lookup(ctx, "123")
+		assert.ErrorIs(t, err, br.ErrInvalidCEP)
+	})
+}
+
+func TestNew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ceps.json")
+	table := `{"states": [{"from": "20000-000", "to": "28999-999", "state": "RJ"}]}`
+	require.NoError(t, os.WriteFile(path, []byte(table), 0o644))
+
+	resolver, err := New(&config.Config{CEPResolver: "table", CEPTablePath: path})
+	require.NoError(t, err)
+	_, err = resolver.Lookup(context.Background(), "01310-100")
+	assert.ErrorIs(t, err, ErrNotFound, "Tabela do arquivo só conhece o RJ")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"states": [{"from": "20000-000", "to": "28999-999", "state": "XX"}]}`), 0o644))
+	_, err = New(&config.Config{CEPResolver: "table", CEPTablePath: path})
+	assert.Error(t, err)
+
+	_, err = New(&config.Config{CEPResolver: "viacep"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,151 @@
+package cep
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Code-Aether/americanas-loja-api/pkg/br"
+)
+
+//go:embed default_table.json
+var defaultTable []byte
+
+// Table maps CEPs to addresses offline, from the most to the least
+// precise: CEPs of streets, CEP ranges of cities and CEP ranges of states.
+type Table struct {
+	Streets []Address `json:"streets"`
+	Cities  []Range   `json:"cities"`
+	States  []Range   `json:"states"`
+}
+
+// Range is the city or state of the CEPs from From to To, both included.
+type Range struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	City  string `json:"city,omitempty"`
+	State string `json:"state"`
+}
+
+// TableResolver looks CEPs up in a Table, with no calls to outside
+// services.
+type TableResolver struct {
+	streets map[string]Address
+	cities  []Range
+	states  []Range
+}
+
+// NewTableResolver checks the table and returns the resolver that looks
+// CEPs up in it.
+func NewTableResolver(table Table) (*TableResolver, error) {
+	r := &TableResolver{streets: make(map[string]Address, len(table.Streets))}
+
+	for i, address := range table.Streets {
+		cep, err := br.NormalizeCEP(address.CEP)
+		if err != nil {
+			return nil, fmt.Errorf("CEP table: street %d: %w", i, err)
+		}
+		if !br.ValidUF(address.State) || address.City == "" {
+			return nil, fmt.Errorf("CEP table: street %s needs a city and a valid state", cep)
+		}
+		address.CEP = cep
+		r.streets[cep] = address
+	}
+
+	var err error
+	if r.cities, err = checkRanges("city", table.Cities); err != nil {
+		return nil, err
+	}
+	if r.states, err = checkRanges("state", table.States); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func checkRanges(kind string, ranges []Range) ([]Range, error) {
+	checked := make([]Range, len(ranges))
+	for i, rng := range ranges {
+		from, err := br.NormalizeCEP(rng.From)
+		if err != nil {
+			return nil, fmt.Errorf("CEP table: %s range %d: %w", kind, i, err)
+		}
+		to, err := br.NormalizeCEP(rng.To)
+		if err != nil {
+			return nil, fmt.Errorf("CEP table: %s range %d: %w", kind, i, err)
+		}
+		if from > to {
+			return nil, fmt.Errorf("CEP table: %s range %d ends before it starts", kind, i)
+		}
+		if !br.ValidUF(rng.State) || (kind == "city" && rng.City == "") {
+			return nil, fmt.Errorf("CEP table: %s range %d needs a valid state", kind, i)
+		}
+		rng.From, rng.To = from, to
+		checked[i] = rng
+	}
+	return checked, nil
+}
+
+// ReadTable reads a table in JSON.
+func ReadTable(r io.Reader) (Table, error) {
+	var table Table
+	if err := json.NewDecoder(r).Decode(&table); err != nil {
+		return Table{}, fmt.Errorf("CEP table: %w", err)
+	}
+	return table, nil
+}
+
+// LoadTableResolver returns the resolver of the table in the JSON file at
+// path, set by CEP_TABLE_PATH.
+func LoadTableResolver(path string) (*TableResolver, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	table, err := ReadTable(file)
+	if err != nil {
+		return nil, err
+	}
+	return NewTableResolver(table)
+}
+
+// DefaultTableResolver looks CEPs up in the table built into the API: the
+// CEP ranges of every state and of the largest capitals.
+func DefaultTableResolver() *TableResolver {
+	var table Table
+	if err := json.Unmarshal(defaultTable, &table); err != nil {
+		panic(err)
+	}
+	resolver, err := NewTableResolver(table)
+	if err != nil {
+		panic(err)
+	}
+	return resolver
+}
+
+func (r *TableResolver) Lookup(ctx context.Context, cep string) (*Address, error) {
+	cep, err := br.NormalizeCEP(cep)
+	if err != nil {
+		return nil, err
+	}
+
+	if address, ok := r.streets[cep]; ok {
+		return &address, nil
+	}
+	// CEPs have the same number of digits, so they compare as strings.
+	for _, rng := range r.cities {
+		if cep >= rng.From && cep <= rng.To {
+			return &Address{CEP: cep, City: rng.City, State: rng.State}, nil
+		}
+	}
+	for _, rng := range r.states {
+		if cep >= rng.From && cep <= rng.To {
+			return &Address{CEP: cep, State: rng.State}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, cep)
+}
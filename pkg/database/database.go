@@ -6,12 +6,15 @@ import (
 
 	"github.com/Code-Aether/americanas-loja-api/internal/config"
 	"github.com/Code-Aether/americanas-loja-api/internal/models"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
 	"golang.org/x/crypto/bcrypt"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/plugin/opentelemetry/tracing"
 )
 
 func NewConnection(cfg *config.Config) (*gorm.DB, error) {
@@ -35,29 +38,25 @@ func NewConnection(cfg *config.Config) (*gorm.DB, error) {
 		return nil, err
 	}
 
+	if err := db.Use(tracing.NewPlugin(tracing.WithoutMetrics())); err != nil {
+		return nil, fmt.Errorf("failed to enable database tracing: %w", err)
+	}
+
+	if err := db.Use(TimeoutPlugin{Timeout: cfg.DBTimeout}); err != nil {
+		return nil, fmt.Errorf("failed to enable database timeouts: %w", err)
+	}
+
 	log.Println("Connected to the database")
 	return db, nil
 }
 
-func AutoMigrate(db *gorm.DB) error {
-	log.Println("Starting database migration...")
-
-	err := db.AutoMigrate(
-		&models.User{},
-		&models.Product{},
-	)
+// Close releases the underlying connection pool.
+func Close(db *gorm.DB) error {
+	sqlDB, err := db.DB()
 	if err != nil {
-		return fmt.Errorf("failed to migrate database: %w", err)
+		return err
 	}
-
-	log.Println("Database migrated successfully")
-
-	if !db.Migrator().HasTable(&models.Product{}) {
-		log.Println("Error: Table products has not created!")
-		return fmt.Errorf("products table does not exists")
-	}
-
-	return nil
+	return sqlDB.Close()
 }
 
 func SeedData(db *gorm.DB) error {
@@ -71,13 +70,23 @@ func SeedData(db *gorm.DB) error {
 		return nil
 	}
 
+	categories, err := seedCategories(db)
+	if err != nil {
+		return err
+	}
+
+	var optionTypes []models.OptionType
+	if err := db.Find(&optionTypes).Error; err != nil {
+		return fmt.Errorf("failed to load option types: %w", err)
+	}
+
 	products := []models.Product{
 		{
 			Name:        "iPhone 15 Pro Max",
 			Description: "O iPhone mais avançado da Apple com chip A17 Pro, câmera de 48MP e tela ProMotion de 6.7 polegadas.",
-			Price:       8999.99,
+			Price:       money.MustParse("8999.99"),
 			Stock:       25,
-			Category:    "Eletrônicos",
+			CategoryID:  categories["smartphones"],
 			SKU:         "IPHONE-15-PRO-MAX-001",
 			Active:      true,
 			ImageURL:    "https://example.com/iphone15.jpg",
@@ -85,9 +94,9 @@ func SeedData(db *gorm.DB) error {
 		{
 			Name:        "MacBook Air M3",
 			Description: "Notebook ultrafino com chip M3, 8GB RAM, 256GB SSD. Perfeito para trabalho e estudos.",
-			Price:       12499.99,
+			Price:       money.MustParse("12499.99"),
 			Stock:       15,
-			Category:    "Eletrônicos",
+			CategoryID:  categories["notebooks"],
 			SKU:         "MACBOOK-AIR-M3-002",
 			Active:      true,
 			ImageURL:    "https://example.com/macbook.jpg",
@@ -95,9 +104,9 @@ func SeedData(db *gorm.DB) error {
 		{
 			Name:        "Smart TV LG 55\" 4K",
 			Description: "Smart TV LG NanoCell 55 polegadas 4K UHD com WebOS e HDR10.",
-			Price:       2799.99,
+			Price:       money.MustParse("2799.99"),
 			Stock:       30,
-			Category:    "Eletrônicos",
+			CategoryID:  categories["tvs"],
 			SKU:         "LG-TV-55-4K-003",
 			Active:      true,
 			ImageURL:    "https://example.com/tv-lg.jpg",
@@ -105,9 +114,9 @@ func SeedData(db *gorm.DB) error {
 		{
 			Name:        "PlayStation 5",
 			Description: "Console de última geração da Sony com SSD ultra-rápido e controle DualSense.",
-			Price:       4499.99,
+			Price:       money.MustParse("4499.99"),
 			Stock:       10,
-			Category:    "Games",
+			CategoryID:  categories["games"],
 			SKU:         "SONY-PS5-004",
 			Active:      true,
 			ImageURL:    "https://example.com/ps5.jpg",
@@ -115,19 +124,21 @@ func SeedData(db *gorm.DB) error {
 		{
 			Name:        "Airfryer Philips XL",
 			Description: "Fritadeira elétrica sem óleo, 4L de capacidade, ideal para famílias.",
-			Price:       899.99,
+			Price:       money.MustParse("899.99"),
 			Stock:       50,
-			Category:    "Casa e Cozinha",
+			CategoryID:  categories["casa-e-cozinha"],
 			SKU:         "PHILIPS-AIRFRYER-XL-005",
 			Active:      true,
 			ImageURL:    "https://example.com/airfryer.jpg",
+			Variants: seedVariants(optionTypes, "PHILIPS-AIRFRYER-XL-005", models.OptionVoltage, 25,
+				"110V", "220V"),
 		},
 		{
 			Name:        "JBL Charge 5",
 			Description: "Caixa de som Bluetooth portátil à prova d'água com 20h de bateria.",
-			Price:       599.99,
+			Price:       money.MustParse("599.99"),
 			Stock:       40,
-			Category:    "Eletrônicos",
+			CategoryID:  categories["audio"],
 			SKU:         "JBL-CHARGE-5-006",
 			Active:      true,
 			ImageURL:    "https://example.com/jbl.jpg",
@@ -135,19 +146,21 @@ func SeedData(db *gorm.DB) error {
 		{
 			Name:        "Nike Air Max 90",
 			Description: "Tênis Nike Air Max 90 original, conforto e estilo para o dia a dia.",
-			Price:       499.99,
+			Price:       money.MustParse("499.99"),
 			Stock:       60,
-			Category:    "Moda e Calçados",
+			CategoryID:  categories["moda-e-calcados"],
 			SKU:         "NIKE-AIRMAX-90-007",
 			Active:      true,
 			ImageURL:    "https://example.com/nike.jpg",
+			Variants: seedVariants(optionTypes, "NIKE-AIRMAX-90-007", models.OptionSize, 15,
+				"39", "40", "41", "42"),
 		},
 		{
 			Name:        "Kindle Paperwhite",
 			Description: "E-reader à prova d'água com tela de 6.8 polegadas e iluminação ajustável.",
-			Price:       449.99,
+			Price:       money.MustParse("449.99"),
 			Stock:       35,
-			Category:    "Livros",
+			CategoryID:  categories["e-readers"],
 			SKU:         "AMAZON-KINDLE-PW-008",
 			Active:      true,
 			ImageURL:    "https://example.com/kindle.jpg",
@@ -164,6 +177,93 @@ func SeedData(db *gorm.DB) error {
 	return nil
 }
 
+// seedVariants builds one variant per value of a single option, splitting
+// the product stock evenly between them.
+func seedVariants(optionTypes []models.OptionType, sku, code string, stock int, values ...string) []models.ProductVariant {
+	var optionTypeID uint
+	for _, optionType := range optionTypes {
+		if optionType.Code == code {
+			optionTypeID = optionType.ID
+		}
+	}
+
+	variants := make([]models.ProductVariant, 0, len(values))
+	for _, value := range values {
+		variants = append(variants, models.ProductVariant{
+			SKU:     fmt.Sprintf("%s-%s", sku, value),
+			Stock:   stock,
+			Active:  true,
+			Options: []models.VariantOption{{OptionTypeID: optionTypeID, Value: value}},
+		})
+	}
+	return variants
+}
+
+type seedCategory struct {
+	name     string
+	children []seedCategory
+}
+
+var defaultCategories = []seedCategory{
+	{name: "Eletrônicos", children: []seedCategory{
+		{name: "Smartphones"},
+		{name: "Notebooks"},
+		{name: "TVs"},
+		{name: "Áudio"},
+	}},
+	{name: "Games"},
+	{name: "Casa e Cozinha"},
+	{name: "Moda e Calçados"},
+	{name: "Livros", children: []seedCategory{
+		{name: "E-readers"},
+	}},
+}
+
+// seedCategories creates the default category tree and returns the IDs
+// indexed by slug.
+func seedCategories(db *gorm.DB) (map[string]*uint, error) {
+	ids := make(map[string]*uint)
+
+	var create func(nodes []seedCategory, parent *models.Category) error
+	create = func(nodes []seedCategory, parent *models.Category) error {
+		for i, node := range nodes {
+			category := models.Category{
+				Name:      node.name,
+				Slug:      utils.Slugify(node.name),
+				SortOrder: i,
+				Active:    true,
+			}
+			parentPath := ""
+			if parent != nil {
+				category.ParentID = &parent.ID
+				category.Depth = parent.Depth + 1
+				parentPath = parent.Path
+			}
+
+			if err := db.Create(&category).Error; err != nil {
+				return fmt.Errorf("failed to seed category %s: %w", node.name, err)
+			}
+			category.Path = models.PathFor(parentPath, category.ID)
+			if err := db.Model(&category).Update("path", category.Path).Error; err != nil {
+				return fmt.Errorf("failed to seed category %s: %w", node.name, err)
+			}
+
+			ids[category.Slug] = &category.ID
+			if err := create(node.children, &category); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := create(defaultCategories, nil); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Seeded %d categories successfully!", len(ids))
+	return ids, nil
+}
+
 func SeedAdminUser(db *gorm.DB) error {
 	log.Println("Staring admin user seeding...")
 
@@ -0,0 +1,489 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	migrationsTable = "schema_migrations"
+	lockTable       = "schema_migrations_lock"
+
+	// Arbitrary key shared by every replica for pg_advisory_lock.
+	postgresLockKey = 4815162342
+
+	lockTimeout  = 2 * time.Minute
+	lockInterval = 500 * time.Millisecond
+)
+
+var (
+	ErrSchemaOutdated  = errors.New("database schema is outdated")
+	ErrUnknownDialect  = errors.New("unsupported database dialect")
+	ErrMissingDownFile = errors.New("migration has no down file")
+
+	migrationFileRegex = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+	migrationNameRegex = regexp.MustCompile(`^[a-z0-9_]+$`)
+)
+
+type Migration struct {
+	Version int64
+	Name    string
+	UpSQL   string
+	DownSQL string
+}
+
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+	// Missing marks versions recorded in the database without a file,
+	// usually because the binary is older than the schema.
+	Missing bool
+}
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    string
+	migrations []Migration
+}
+
+// NewMigrator loads the migrations for the dialect of db from source, which
+// must contain one directory per dialect ("postgres", "sqlite").
+func NewMigrator(db *gorm.DB, source fs.FS) (*Migrator, error) {
+	dialect := db.Dialector.Name()
+	if dialect != "postgres" && dialect != "sqlite" {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDialect, dialect)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := fs.Sub(source, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := loadMigrations(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         sqlDB,
+		dialect:    dialect,
+		migrations: migrations,
+	}, nil
+}
+
+// Latest returns the highest known migration version.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies pending migrations in order. A steps value of 0 applies all.
+func (m *Migrator) Up(ctx context.Context, steps int) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		count := 0
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if steps > 0 && count >= steps {
+				break
+			}
+
+			log.Printf("Applying migration %03d_%s", migration.Version, migration.Name)
+			if err := m.apply(ctx, conn, migration.UpSQL, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx,
+					m.rebind("INSERT INTO "+migrationsTable+" (version, name, applied_at) VALUES (?, ?, ?)"),
+					migration.Version, migration.Name, time.Now().UTC())
+				return err
+			}); err != nil {
+				return fmt.Errorf("migration %03d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			count++
+		}
+
+		if count == 0 {
+			log.Println("Database schema is up to date")
+		} else {
+			log.Printf("Applied %d migration(s)", count)
+		}
+		return nil
+	})
+}
+
+// Down rolls back the most recently applied migrations. A steps value of 0
+// rolls back a single migration.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps <= 0 {
+		steps = 1
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if migration.DownSQL == "" {
+				return fmt.Errorf("%w: %03d_%s", ErrMissingDownFile, migration.Version, migration.Name)
+			}
+
+			log.Printf("Rolling back migration %03d_%s", migration.Version, migration.Name)
+			if err := m.apply(ctx, conn, migration.DownSQL, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx,
+					m.rebind("DELETE FROM "+migrationsTable+" WHERE version = ?"), migration.Version)
+				return err
+			}); err != nil {
+				return fmt.Errorf("rollback of %03d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			steps--
+		}
+
+		return nil
+	})
+}
+
+// Status lists every known migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// Status must not mutate the schema (Verify relies on it), so a missing
+	// bookkeeping table simply means nothing was applied yet.
+	applied := map[int64]time.Time{}
+	exists, err := m.tableExists(ctx, conn, migrationsTable)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		if applied, err = m.appliedVersions(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
+
+	known := make(map[int64]bool, len(m.migrations))
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	for version, appliedAt := range applied {
+		if !known[version] {
+			appliedAt := appliedAt
+			statuses = append(statuses, MigrationStatus{
+				Version:   version,
+				Applied:   true,
+				AppliedAt: &appliedAt,
+				Missing:   true,
+			})
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Verify checks, without changing anything, that every known migration has
+// been applied. Production boots use it instead of Up.
+func (m *Migrator) Verify(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, status := range statuses {
+		if !status.Applied {
+			pending = append(pending, fmt.Sprintf("%03d_%s", status.Version, status.Name))
+		}
+	}
+
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: pending migrations %s (run `migrate up`)", ErrSchemaOutdated, strings.Join(pending, ", "))
+	}
+
+	log.Printf("Database schema verified at version %03d", m.Latest())
+	return nil
+}
+
+// CreateMigration writes empty up/down files for the next version in every
+// dialect directory under dir and returns their paths.
+func CreateMigration(dir, name string) ([]string, error) {
+	name = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), " ", "_"))
+	if !migrationNameRegex.MatchString(name) {
+		return nil, fmt.Errorf("invalid migration name %q: use letters, digits and underscores", name)
+	}
+
+	var next int64 = 1
+	for _, dialect := range []string{"postgres", "sqlite"} {
+		migrations, err := loadMigrations(os.DirFS(filepath.Join(dir, dialect)))
+		if err != nil {
+			return nil, err
+		}
+		if n := len(migrations); n > 0 && migrations[n-1].Version >= next {
+			next = migrations[n-1].Version + 1
+		}
+	}
+
+	var created []string
+	for _, dialect := range []string{"postgres", "sqlite"} {
+		for _, direction := range []string{"up", "down"} {
+			path := filepath.Join(dir, dialect, fmt.Sprintf("%03d_%s.%s.sql", next, name, direction))
+			header := fmt.Sprintf("-- %03d_%s (%s, %s)\n", next, name, dialect, direction)
+			if err := os.WriteFile(path, []byte(header), 0o644); err != nil {
+				return created, err
+			}
+			created = append(created, path)
+		}
+	}
+
+	return created, nil
+}
+
+func loadMigrations(dir fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(dir, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		match := migrationFileRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := fs.ReadFile(dir, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("duplicate migration version %03d (%s, %s)", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.UpSQL = string(content)
+		} else {
+			migration.DownSQL = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.UpSQL == "" {
+			return nil, fmt.Errorf("migration %03d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// withLock runs fn on a dedicated connection while holding the migration
+// lock, so only one replica migrates at a time.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := m.ensureTables(ctx, conn); err != nil {
+		return err
+	}
+
+	if err := m.lock(ctx, conn); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if err := m.unlock(context.WithoutCancel(ctx), conn); err != nil {
+			log.Printf("failed to release migration lock: %v", err)
+		}
+	}()
+
+	return fn(conn)
+}
+
+func (m *Migrator) ensureTables(ctx context.Context, conn *sql.Conn) error {
+	statements := []string{
+		"CREATE TABLE IF NOT EXISTS " + migrationsTable + ` (
+			version    BIGINT PRIMARY KEY,
+			name       VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)`,
+	}
+
+	if m.dialect == "sqlite" {
+		statements = append(statements, "CREATE TABLE IF NOT EXISTS "+lockTable+` (
+			id        INTEGER PRIMARY KEY CHECK (id = 1),
+			locked_at TIMESTAMP NOT NULL
+		)`)
+	}
+
+	for _, statement := range statements {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) tableExists(ctx context.Context, conn *sql.Conn, table string) (bool, error) {
+	var exists bool
+	query := "SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = ?"
+	if m.dialect == "postgres" {
+		query = "SELECT to_regclass($1) IS NOT NULL"
+	}
+
+	err := conn.QueryRowContext(ctx, query, table).Scan(&exists)
+	return exists, err
+}
+
+func (m *Migrator) lock(ctx context.Context, conn *sql.Conn) error {
+	if m.dialect == "postgres" {
+		_, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", postgresLockKey)
+		return err
+	}
+
+	// SQLite has no advisory locks: the single-row lock table acts as one.
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		_, err := conn.ExecContext(ctx, "INSERT INTO "+lockTable+" (id, locked_at) VALUES (1, ?)", time.Now().UTC())
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for lock (remove the row in %s if a migration crashed): %w", lockTable, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockInterval):
+		}
+	}
+}
+
+func (m *Migrator) unlock(ctx context.Context, conn *sql.Conn) error {
+	if m.dialect == "postgres" {
+		_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", postgresLockKey)
+		return err
+	}
+
+	_, err := conn.ExecContext(ctx, "DELETE FROM "+lockTable+" WHERE id = 1")
+	return err
+}
+
+// apply runs a migration script and its bookkeeping in one transaction, so
+// a failed migration leaves neither schema changes nor a version row behind.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, script string, record func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := record(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM "+migrationsTable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+func (m *Migrator) rebind(query string) string {
+	if m.dialect != "postgres" {
+		return query
+	}
+
+	var builder strings.Builder
+	n := 0
+	for _, char := range query {
+		if char == '?' {
+			n++
+			builder.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		builder.WriteRune(char)
+	}
+	return builder.String()
+}
@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Code-Aether/americanas-loja-api/migrations"
+	"github.com/Code-Aether/americanas-loja-api/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupMigrator(t *testing.T) (*gorm.DB, *Migrator) {
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+
+	source := fstest.MapFS{
+		"sqlite/001_create_items.up.sql":   {Data: []byte("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT);")},
+		"sqlite/001_create_items.down.sql": {Data: []byte("DROP TABLE items;")},
+		"sqlite/002_add_price.up.sql":      {Data: []byte("ALTER TABLE items ADD COLUMN price INTEGER;")},
+		"sqlite/002_add_price.down.sql":    {Data: []byte("ALTER TABLE items DROP COLUMN price;")},
+	}
+
+	migrator, err := NewMigrator(db, source)
+	require.NoError(t, err)
+
+	return db, migrator
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("✅ Aplica e reverte migrações em ordem", func(t *testing.T) {
+		db, migrator := setupMigrator(t)
+
+		require.NoError(t, migrator.Up(ctx, 0))
+		assert.True(t, db.Migrator().HasColumn("items", "price"), "Coluna da migração 002 deve existir")
+		assert.NoError(t, migrator.Verify(ctx))
+
+		require.NoError(t, migrator.Down(ctx, 1))
+		assert.False(t, db.Migrator().HasColumn("items", "price"), "Migração 002 deve ser revertida")
+		assert.True(t, db.Migrator().HasTable("items"), "Migração 001 deve continuar aplicada")
+
+		statuses, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		require.Len(t, statuses, 2)
+		assert.True(t, statuses[0].Applied)
+		assert.False(t, statuses[1].Applied)
+	})
+
+	t.Run("✅ Up é idempotente", func(t *testing.T) {
+		_, migrator := setupMigrator(t)
+
+		require.NoError(t, migrator.Up(ctx, 0))
+		assert.NoError(t, migrator.Up(ctx, 0), "Segunda execução não deve falhar")
+	})
+
+	t.Run("❌ Verify falha com migrações pendentes sem alterar o banco", func(t *testing.T) {
+		db, migrator := setupMigrator(t)
+
+		require.NoError(t, migrator.Up(ctx, 1))
+
+		err := migrator.Verify(ctx)
+		assert.ErrorIs(t, err, ErrSchemaOutdated)
+		assert.Contains(t, err.Error(), "002_add_price")
+		assert.False(t, db.Migrator().HasColumn("items", "price"), "Verify não deve aplicar migrações")
+	})
+
+	t.Run("❌ Migração com erro não registra versão", func(t *testing.T) {
+		dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+		db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+		require.NoError(t, err)
+
+		migrator, err := NewMigrator(db, fstest.MapFS{
+			"sqlite/001_broken.up.sql": {Data: []byte("CREATE TABLE ok (id INTEGER); INVALID SQL;")},
+		})
+		require.NoError(t, err)
+
+		assert.Error(t, migrator.Up(ctx, 0))
+		assert.False(t, db.Migrator().HasTable("ok"), "Transação deve ser desfeita")
+
+		statuses, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		assert.False(t, statuses[0].Applied)
+	})
+}
+
+func TestMigrations_CategoryBackfill(t *testing.T) {
+	ctx := context.Background()
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+
+	migrator, err := NewMigrator(db, migrations.FS)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up(ctx, 1))
+
+	for i, category := range []string{"Eletrônicos", "eletronicos", "Eletrônicos ", "Casa e Cozinha", "", "Moda & Calçados", " moda - calçados!"} {
+		err := db.Exec("INSERT INTO products (name, price, stock, category, sku) VALUES (?, ?, ?, ?, ?)",
+			fmt.Sprintf("Produto %d", i), 10, 1, category, fmt.Sprintf("SKU-%d", i)).Error
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, migrator.Up(ctx, 1))
+
+	type row struct {
+		ID   uint
+		Name string
+		Slug string
+		Path string
+	}
+	var categories []row
+	require.NoError(t, db.Raw("SELECT id, name, slug, path FROM categories ORDER BY slug").Scan(&categories).Error)
+	require.Len(t, categories, 3, "Grafias diferentes devem virar uma única categoria")
+	assert.Equal(t, "casa-e-cozinha", categories[0].Slug)
+	assert.Equal(t, "eletronicos", categories[1].Slug)
+	assert.Equal(t, "moda-calcados", categories[2].Slug, "Pontuação vira um único hífen, como em utils.Slugify")
+	for _, category := range categories {
+		assert.Equal(t, utils.Slugify(category.Name), category.Slug)
+	}
+	assert.Equal(t, "Eletrônicos", categories[1].Name)
+	assert.Equal(t, fmt.Sprintf("/%d/", categories[1].ID), categories[1].Path)
+
+	var linked int64
+	require.NoError(t, db.Raw("SELECT COUNT(*) FROM products WHERE category_id = ?", categories[1].ID).Scan(&linked).Error)
+	assert.Equal(t, int64(3), linked, "Produtos devem apontar para a categoria criada")
+	assert.False(t, db.Migrator().HasColumn("products", "category"), "Coluna de texto deve ser removida")
+
+	require.NoError(t, migrator.Down(ctx, 1))
+	var restored string
+	require.NoError(t, db.Raw("SELECT category FROM products WHERE sku = ?", "SKU-1").Scan(&restored).Error)
+	assert.Equal(t, "Eletrônicos", restored, "Down deve restaurar o nome da categoria")
+}
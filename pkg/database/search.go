@@ -0,0 +1,66 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+// sqliteFTSSchema creates the FTS5 product index and the triggers that keep
+// it in sync. It lives outside the migration files because go-sqlite3 only
+// ships FTS5 when built with -tags sqlite_fts5, and migrations must run on
+// every build; the down migration of 005 drops it.
+var sqliteFTSSchema = []string{
+	`CREATE VIRTUAL TABLE products_fts USING fts5(
+		name, sku, description,
+		tokenize = 'unicode61 remove_diacritics 2'
+	)`,
+	`CREATE TRIGGER products_fts_insert AFTER INSERT ON products BEGIN
+		INSERT INTO products_fts (rowid, name, sku, description)
+		SELECT new.id, new.name, new.sku, new.description WHERE new.deleted_at IS NULL;
+	END`,
+	`CREATE TRIGGER products_fts_update AFTER UPDATE OF name, sku, description, deleted_at ON products BEGIN
+		DELETE FROM products_fts WHERE rowid = old.id;
+		INSERT INTO products_fts (rowid, name, sku, description)
+		SELECT new.id, new.name, new.sku, new.description WHERE new.deleted_at IS NULL;
+	END`,
+	`CREATE TRIGGER products_fts_delete AFTER DELETE ON products BEGIN
+		DELETE FROM products_fts WHERE rowid = old.id;
+	END`,
+	`INSERT INTO products_fts (rowid, name, sku, description)
+	SELECT id, name, sku, description FROM products WHERE deleted_at IS NULL`,
+}
+
+// HasFTS5 reports whether the SQLite driver was built with FTS5.
+func HasFTS5(db *gorm.DB) bool {
+	var enabled bool
+	err := db.Raw("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&enabled).Error
+	return err == nil && enabled
+}
+
+// EnsureSearchIndex creates the SQLite full-text index when it is missing
+// and fills it with the existing products. It is part of migrating, run
+// after Migrator.Up by the migrate command and by the server with
+// DB_AUTO_MIGRATE. Postgres keeps its index in the migrations, so there is
+// nothing to do there.
+func EnsureSearchIndex(db *gorm.DB) error {
+	if db.Dialector.Name() != "sqlite" {
+		return nil
+	}
+	if !HasFTS5(db) {
+		return nil
+	}
+
+	var exists int64
+	err := db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'products_fts'").Scan(&exists).Error
+	if err != nil || exists > 0 {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range sqliteFTSSchema {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
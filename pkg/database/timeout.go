@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const timeoutStateKey = "database:timeout_state"
+
+type timeoutState struct {
+	parent context.Context
+	cancel context.CancelFunc
+}
+
+// TimeoutPlugin bounds every statement with a deadline derived from the
+// statement context, so a slow query is cancelled even when the caller's
+// context has no deadline of its own.
+type TimeoutPlugin struct {
+	Timeout time.Duration
+}
+
+func (p TimeoutPlugin) Name() string {
+	return "timeout"
+}
+
+func (p TimeoutPlugin) Initialize(db *gorm.DB) error {
+	if p.Timeout <= 0 {
+		return nil
+	}
+
+	cb := db.Callback()
+	errs := []error{
+		cb.Create().Before("gorm:create").Register("timeout:before_create", p.before),
+		cb.Create().After("gorm:create").Register("timeout:after_create", p.after),
+		cb.Query().Before("gorm:query").Register("timeout:before_query", p.before),
+		cb.Query().After("gorm:query").Register("timeout:after_query", p.after),
+		cb.Update().Before("gorm:update").Register("timeout:before_update", p.before),
+		cb.Update().After("gorm:update").Register("timeout:after_update", p.after),
+		cb.Delete().Before("gorm:delete").Register("timeout:before_delete", p.before),
+		cb.Delete().After("gorm:delete").Register("timeout:after_delete", p.after),
+		cb.Raw().Before("gorm:raw").Register("timeout:before_raw", p.before),
+		cb.Raw().After("gorm:raw").Register("timeout:after_raw", p.after),
+		cb.Row().Before("gorm:row").Register("timeout:before_row", p.before),
+		cb.Row().After("gorm:row").Register("timeout:after_row", p.afterRow),
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p TimeoutPlugin) before(db *gorm.DB) {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, p.Timeout)
+	db.Statement.Context = timeoutCtx
+	db.InstanceSet(timeoutStateKey, timeoutState{parent: ctx, cancel: cancel})
+}
+
+func (p TimeoutPlugin) after(db *gorm.DB) {
+	// Statements can be reused by chained calls such as Count followed by
+	// Find, so the original context is restored once this one is done.
+	if value, ok := db.InstanceGet(timeoutStateKey); ok {
+		state := value.(timeoutState)
+		state.cancel()
+		db.Statement.Context = state.parent
+	}
+}
+
+func (p TimeoutPlugin) afterRow(db *gorm.DB) {
+	// Row and Rows hand back rows that are scanned after the callback chain
+	// returns, and cancelling the context would close them before that. The
+	// deadline is left to expire on its own instead, which also bounds the
+	// time spent scanning them.
+	if value, ok := db.InstanceGet(timeoutStateKey); ok {
+		db.Statement.Context = value.(timeoutState).parent
+	}
+}
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestTimeoutPlugin(t *testing.T) {
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(TimeoutPlugin{Timeout: time.Minute}))
+	require.NoError(t, db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)").Error)
+	require.NoError(t, db.Exec("INSERT INTO items (name) VALUES ('a'), ('b')").Error)
+
+	// Guarda se a consulta rodou com prazo
+	var hasDeadline bool
+	capture := func(db *gorm.DB) {
+		_, hasDeadline = db.Statement.Context.Deadline()
+	}
+	require.NoError(t, db.Callback().Query().Before("gorm:query").After("timeout:before_query").Register("test:query", capture))
+	require.NoError(t, db.Callback().Row().Before("gorm:row").After("timeout:before_row").Register("test:row", capture))
+
+	t.Run("✅ Consultas recebem prazo", func(t *testing.T) {
+		hasDeadline = false
+		var names []string
+		require.NoError(t, db.WithContext(context.Background()).Table("items").Pluck("name", &names).Error)
+		assert.True(t, hasDeadline)
+		assert.Len(t, names, 2)
+	})
+
+	t.Run("✅ Row recebe prazo e continua legível depois do callback", func(t *testing.T) {
+		hasDeadline = false
+		var count int
+		require.NoError(t, db.WithContext(context.Background()).Table("items").Select("COUNT(*)").Row().Scan(&count))
+		assert.True(t, hasDeadline)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("✅ Rows recebe prazo e continua legível depois do callback", func(t *testing.T) {
+		hasDeadline = false
+		rows, err := db.WithContext(context.Background()).Table("items").Select("name").Order("id").Rows()
+		require.NoError(t, err)
+		defer rows.Close()
+		assert.True(t, hasDeadline)
+
+		var names []string
+		for rows.Next() {
+			var name string
+			require.NoError(t, rows.Scan(&name))
+			names = append(names, name)
+		}
+		require.NoError(t, rows.Err())
+		assert.Equal(t, []string{"a", "b"}, names)
+	})
+}
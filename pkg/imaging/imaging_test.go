@@ -0,0 +1,40 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFit(t *testing.T) {
+	t.Run("✅ Reduz mantendo a proporção", func(t *testing.T) {
+		img := Fit(image.NewRGBA(image.Rect(0, 0, 1000, 500)), 200)
+		assert.Equal(t, 200, img.Bounds().Dx())
+		assert.Equal(t, 100, img.Bounds().Dy())
+	})
+
+	t.Run("✅ Não amplia imagens pequenas", func(t *testing.T) {
+		original := image.NewRGBA(image.Rect(0, 0, 50, 80))
+		assert.Same(t, original, Fit(original, 200))
+	})
+}
+
+func TestDecode(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 10, 20))))
+
+	t.Run("✅ Decodificar PNG", func(t *testing.T) {
+		img, err := Decode(buf.Bytes())
+		require.NoError(t, err)
+		assert.Equal(t, 20, img.Bounds().Dy())
+	})
+
+	t.Run("❌ Dados inválidos", func(t *testing.T) {
+		_, err := Decode([]byte("not an image"))
+		assert.Error(t, err)
+	})
+}
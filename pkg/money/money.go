@@ -0,0 +1,268 @@
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency of amounts that don't carry one, like
+// prices read from the database.
+const DefaultCurrency = "BRL"
+
+var (
+	ErrInvalidAmount = errors.New("invalid monetary amount")
+	ErrTooPrecise    = errors.New("monetary amount has more than 2 decimal places")
+)
+
+// Money is an amount in cents (centavos) of an ISO 4217 currency. Being an
+// integer, sums, products and splits are exact, unlike float64 prices.
+//
+// In JSON it is the decimal number clients already send and receive
+// (8999.99). In the database it is stored as a decimal too, so the existing
+// price columns, filters and indexes keep working.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// New returns amount cents of currency.
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// FromCents returns amount cents of the default currency.
+func FromCents(amount int64) Money {
+	return New(amount, DefaultCurrency)
+}
+
+// Parse reads a decimal amount of the default currency, like "8999.99" or
+// "10". Amounts with fractions of a cent are rejected rather than rounded.
+func Parse(s string) (Money, error) {
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || strings.Contains(s, "/") {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	cents := value.Mul(value, big.NewRat(100, 1))
+	if !cents.IsInt() {
+		return Money{}, fmt.Errorf("%w: %q", ErrTooPrecise, s)
+	}
+	if !cents.Num().IsInt64() {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	return FromCents(cents.Num().Int64()), nil
+}
+
+// MustParse is Parse for literals; it panics on invalid input.
+func MustParse(s string) Money {
+	m, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// currency treats the zero value as the default currency, so Money{} can
+// be used as the start of a sum.
+func (m Money) currency() string {
+	if m.Currency == "" {
+		return DefaultCurrency
+	}
+	return m.Currency
+}
+
+// mustMatch panics when mixing currencies: there is no exchange rate here,
+// so it can only be a programming error.
+func (m Money) mustMatch(other Money) {
+	if m.currency() != other.currency() {
+		panic(fmt.Sprintf("money: currency mismatch %s and %s", m.currency(), other.currency()))
+	}
+}
+
+func (m Money) Add(other Money) Money {
+	m.mustMatch(other)
+	return New(m.Amount+other.Amount, m.currency())
+}
+
+func (m Money) Sub(other Money) Money {
+	m.mustMatch(other)
+	return New(m.Amount-other.Amount, m.currency())
+}
+
+// Mul multiplies the amount by a quantity.
+func (m Money) Mul(quantity int64) Money {
+	return New(m.Amount*quantity, m.currency())
+}
+
+// Percent returns the given percentage in basis points (1250 is 12.5%),
+// rounded half away from zero to the cent.
+func (m Money) Percent(basisPoints int64) Money {
+	product := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(basisPoints))
+	quotient, remainder := new(big.Int).QuoRem(product, big.NewInt(10000), new(big.Int))
+	if remainder.Abs(remainder).Cmp(big.NewInt(5000)) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(product.Sign())))
+	}
+	return New(quotient.Int64(), m.currency())
+}
+
+// Split divides the amount into n installments that add up to it exactly.
+// The leftover cents go to the first installments, so 100.00 in 3 is
+// 33.34, 33.33 and 33.33.
+func (m Money) Split(n int) []Money {
+	if n <= 0 {
+		return nil
+	}
+	ratios := make([]int64, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	return m.Allocate(ratios...)
+}
+
+// Allocate divides the amount in proportion to ratios, like a discount
+// spread over the items of an order. The parts add up to the amount; the
+// cents lost to rounding go one each to the first parts.
+func (m Money) Allocate(ratios ...int64) []Money {
+	var total int64
+	for _, ratio := range ratios {
+		total += ratio
+	}
+	if len(ratios) == 0 || total <= 0 {
+		return nil
+	}
+
+	parts := make([]Money, len(ratios))
+	remainder := m.Amount
+	for i, ratio := range ratios {
+		share := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(ratio))
+		share.Quo(share, big.NewInt(total))
+		parts[i] = New(share.Int64(), m.currency())
+		remainder -= share.Int64()
+	}
+
+	step := int64(1)
+	if remainder < 0 {
+		step = -1
+	}
+	for i := 0; remainder != 0; i = (i + 1) % len(parts) {
+		if ratios[i] == 0 {
+			continue
+		}
+		parts[i].Amount += step
+		remainder -= step
+	}
+	return parts
+}
+
+func (m Money) IsZero() bool     { return m.Amount == 0 }
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+
+// Cmp compares two amounts of the same currency, returning -1, 0 or 1.
+func (m Money) Cmp(other Money) int {
+	m.mustMatch(other)
+	switch {
+	case m.Amount < other.Amount:
+		return -1
+	case m.Amount > other.Amount:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// String formats the amount as a plain decimal with two places, the format
+// of the API and the database.
+func (m Money) String() string {
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+	}
+	units, cents := amount/100, amount%100
+	if cents < 0 {
+		units, cents = -units, -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, units, cents)
+}
+
+// Float64 is for display and statistics only; never compute with it.
+func (m Money) Float64() float64 {
+	f, _ := strconv.ParseFloat(m.String(), 64)
+	return f
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a number or a numeric string.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	} else if !json.Valid(data) {
+		return fmt.Errorf("%w: %s", ErrInvalidAmount, text)
+	}
+
+	parsed, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value stores the amount as a decimal string, which both Postgres NUMERIC
+// and SQLite REAL columns convert without loss of cents.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan reads a decimal column. Values from floating point columns are
+// rounded to the nearest cent, undoing the binary representation error.
+func (m *Money) Scan(src any) error {
+	switch value := src.(type) {
+	case nil:
+		*m = Money{}
+		return nil
+	case float64:
+		*m = FromCents(int64(math.Round(value * 100)))
+		return nil
+	case int64:
+		*m = FromCents(value * 100)
+		return nil
+	case []byte:
+		return m.scanText(string(value))
+	case string:
+		return m.scanText(value)
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidAmount, src)
+	}
+}
+
+func (m *Money) scanText(text string) error {
+	parsed, err := Parse(text)
+	if errors.Is(err, ErrTooPrecise) {
+		// Columns without a scale may hold more places; round like floats.
+		f, ferr := strconv.ParseFloat(text, 64)
+		if ferr != nil {
+			return err
+		}
+		return m.Scan(f)
+	}
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,125 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("✅ Valores decimais", func(t *testing.T) {
+		for input, cents := range map[string]int64{
+			"8999.99": 899999,
+			"10":      1000,
+			"0.1":     10,
+			"-5.5":    -550,
+			"1e3":     100000,
+		} {
+			m, err := Parse(input)
+			require.NoError(t, err, input)
+			assert.Equal(t, FromCents(cents), m, input)
+		}
+	})
+
+	t.Run("❌ Valores inválidos", func(t *testing.T) {
+		_, err := Parse("10.005")
+		assert.ErrorIs(t, err, ErrTooPrecise)
+
+		for _, input := range []string{"", "abc", "1/3", "NaN", "1e30"} {
+			_, err := Parse(input)
+			assert.ErrorIs(t, err, ErrInvalidAmount, input)
+		}
+	})
+}
+
+func TestMoney_String(t *testing.T) {
+	assert.Equal(t, "8999.99", FromCents(899999).String())
+	assert.Equal(t, "0.05", FromCents(5).String())
+	assert.Equal(t, "-0.50", FromCents(-50).String())
+	assert.Equal(t, "-1.50", FromCents(-150).String())
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	price := MustParse("8999.99")
+
+	t.Run("✅ Soma e multiplicação exatas", func(t *testing.T) {
+		assert.Equal(t, MustParse("26999.97"), price.Mul(3))
+		assert.Equal(t, MustParse("0.30"), MustParse("0.10").Add(MustParse("0.20")))
+		assert.Equal(t, MustParse("8989.99"), price.Sub(MustParse("10")))
+		assert.Equal(t, price, Money{}.Add(price), "Valor zero serve de início da soma")
+	})
+
+	t.Run("✅ Parcelas somam o total", func(t *testing.T) {
+		installments := MustParse("100").Split(3)
+		assert.Equal(t, []Money{MustParse("33.34"), MustParse("33.33"), MustParse("33.33")}, installments)
+
+		total := Money{}
+		for _, installment := range price.Split(7) {
+			total = total.Add(installment)
+		}
+		assert.Equal(t, price, total)
+	})
+
+	t.Run("✅ Rateio proporcional", func(t *testing.T) {
+		parts := MustParse("10").Allocate(1, 2, 0)
+		assert.Equal(t, []Money{MustParse("3.34"), MustParse("6.66"), MustParse("0")}, parts)
+
+		parts = MustParse("-10").Allocate(1, 1, 1)
+		assert.Equal(t, []Money{MustParse("-3.34"), MustParse("-3.33"), MustParse("-3.33")}, parts)
+	})
+
+	t.Run("✅ Percentual arredondado", func(t *testing.T) {
+		assert.Equal(t, MustParse("900.00"), price.Percent(1000))
+		assert.Equal(t, MustParse("0.13"), MustParse("1.25").Percent(1000))
+		assert.Equal(t, MustParse("-0.13"), MustParse("-1.25").Percent(1000))
+	})
+
+	t.Run("❌ Moedas diferentes", func(t *testing.T) {
+		assert.Panics(t, func() { price.Add(New(100, "USD")) })
+	})
+}
+
+func TestMoney_JSON(t *testing.T) {
+	var product struct {
+		Price *Money `json:"price"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(`{"price": 8999.99}`), &product))
+	assert.Equal(t, MustParse("8999.99"), *product.Price)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"price": "49.90"}`), &product))
+	assert.Equal(t, MustParse("49.9"), *product.Price)
+
+	data, err := json.Marshal(product)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"price": 49.90}`, string(data))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"price": 0.001}`), &product))
+	assert.Error(t, json.Unmarshal([]byte(`{"price": true}`), &product))
+}
+
+func TestMoney_Scan(t *testing.T) {
+	for src, want := range map[any]Money{
+		8999.99:               MustParse("8999.99"),
+		0.1 + 0.2:             MustParse("0.30"),
+		int64(10):             MustParse("10"),
+		"449.99":              MustParse("449.99"),
+		"449.990000":          MustParse("449.99"),
+		"12.5":                MustParse("12.50"),
+		"0.30000000000000004": MustParse("0.30"),
+	} {
+		var m Money
+		require.NoError(t, m.Scan(src), src)
+		assert.Equal(t, want, m, src)
+	}
+
+	var m Money
+	require.NoError(t, m.Scan([]byte("8999.99")))
+	assert.Equal(t, MustParse("8999.99"), m)
+
+	value, err := m.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "8999.99", value)
+}
@@ -0,0 +1,19 @@
+package notify
+
+import (
+	"context"
+	"log"
+)
+
+// LogNotifier writes messages to the log instead of delivering them, for
+// development and as a record next to other channels.
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, msg Message) error {
+	log.Printf("[NOTIFY] %s to %s: %s", msg.Event, msg.To, msg.Subject)
+	return nil
+}
@@ -0,0 +1,34 @@
+package notify
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// MemoryNotifier keeps the messages it is given, for tests and for
+// inspecting what would have been sent.
+type MemoryNotifier struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryNotifier() *MemoryNotifier {
+	return &MemoryNotifier{}
+}
+
+func (n *MemoryNotifier) Notify(ctx context.Context, msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.messages = append(n.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of the messages received so far.
+func (n *MemoryNotifier) Messages() []Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return slices.Clone(n.messages)
+}
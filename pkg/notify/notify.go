@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/config"
+)
+
+const (
+	DriverLog     = "log"
+	DriverMemory  = "memory"
+	DriverSMTP    = "smtp"
+	DriverWebhook = "webhook"
+)
+
+// Events a message can be about.
+const (
+	EventBackInStock = "back_in_stock"
+	EventLowStock    = "low_stock"
+)
+
+// Message is a notification to one recipient. Subject and Body are ready
+// to show; Data carries the fields of the event for channels that format
+// their own messages.
+type Message struct {
+	Event   string         `json:"event"`
+	UserID  uint           `json:"user_id,omitempty"`
+	To      string         `json:"to"`
+	Subject string         `json:"subject"`
+	Body    string         `json:"body"`
+	Data    map[string]any `json:"data,omitempty"`
+}
+
+// Notifier delivers messages through some channel.
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// New builds the notifier of a comma separated list of channels, like
+// NOTIFIER_DRIVER or ALERT_CHANNELS. With several channels every message
+// goes through all of them.
+func New(cfg *config.Config, channels string) (Notifier, error) {
+	var notifiers Multi
+	for _, driver := range strings.Split(channels, ",") {
+		driver = strings.TrimSpace(driver)
+		if driver == "" {
+			continue
+		}
+
+		notifier, err := newDriver(cfg, driver)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, notifier)
+	}
+
+	switch len(notifiers) {
+	case 0:
+		return nil, fmt.Errorf("no notification channel in %q", channels)
+	case 1:
+		return notifiers[0], nil
+	default:
+		return notifiers, nil
+	}
+}
+
+func newDriver(cfg *config.Config, driver string) (Notifier, error) {
+	switch driver {
+	case DriverLog:
+		return NewLogNotifier(), nil
+	case DriverMemory:
+		return NewMemoryNotifier(), nil
+	case DriverSMTP:
+		if cfg.SMTPHost == "" || cfg.SMTPFrom == "" {
+			return nil, errors.New("the smtp channel needs SMTP_HOST and SMTP_FROM")
+		}
+		return NewSMTPNotifier(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPass, cfg.SMTPFrom), nil
+	case DriverWebhook:
+		if cfg.NotifyWebhookURL == "" {
+			return nil, errors.New("the webhook channel needs NOTIFY_WEBHOOK_URL")
+		}
+		return NewWebhookNotifier(cfg.NotifyWebhookURL, cfg.NotifyWebhookSecret), nil
+	default:
+		return nil, fmt.Errorf("unknown notification channel %q", driver)
+	}
+}
+
+// Multi sends every message through all of its notifiers, even when some
+// of them fail.
+type Multi []Notifier
+
+func (m Multi) Notify(ctx context.Context, msg Message) error {
+	var errs []error
+	for _, notifier := range m {
+		if err := notifier.Notify(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
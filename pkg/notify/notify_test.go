@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failing struct{}
+
+func (failing) Notify(ctx context.Context, msg Message) error {
+	return errors.New("fora do ar")
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	msg := Message{Event: EventLowStock, To: "estoque@loja.com", Subject: "Estoque baixo", Data: map[string]any{"sku": "SKU-1"}}
+
+	t.Run("✅ Envia JSON assinado", func(t *testing.T) {
+		var received Message
+		var signature string
+		var body []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ = io.ReadAll(r.Body)
+			signature = r.Header.Get(SignatureHeader)
+			_ = json.Unmarshal(body, &received)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		require.NoError(t, NewWebhookNotifier(server.URL, "segredo").Notify(context.Background(), msg))
+		assert.Equal(t, msg.Event, received.Event)
+		assert.Equal(t, "SKU-1", received.Data["sku"])
+		assert.Equal(t, Sign([]byte("segredo"), body), signature)
+	})
+
+	t.Run("❌ Resposta de erro do receptor", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		err := NewWebhookNotifier(server.URL, "").Notify(context.Background(), msg)
+		assert.ErrorContains(t, err, "502")
+	})
+}
+
+func TestMulti(t *testing.T) {
+	memory := NewMemoryNotifier()
+
+	// Um canal com falha não impede os outros
+	err := Multi{failing{}, memory}.Notify(context.Background(), Message{Event: EventBackInStock})
+	assert.Error(t, err)
+	assert.Len(t, memory.Messages(), 1)
+}
+
+func TestNew(t *testing.T) {
+	cfg := &config.Config{SMTPHost: "smtp.loja.com", SMTPPort: 587, SMTPFrom: "loja@loja.com"}
+
+	t.Run("✅ Um canal ou vários", func(t *testing.T) {
+		notifier, err := New(cfg, "log")
+		require.NoError(t, err)
+		assert.IsType(t, &LogNotifier{}, notifier)
+
+		notifier, err = New(cfg, "log, smtp")
+		require.NoError(t, err)
+		assert.Len(t, notifier, 2)
+	})
+
+	t.Run("❌ Canal desconhecido ou sem configuração", func(t *testing.T) {
+		for _, channels := range []string{"", "sms", "webhook"} {
+			_, err := New(cfg, channels)
+			assert.Error(t, err, channels)
+		}
+	})
+}
+
+func TestSMTPNotifierCompose(t *testing.T) {
+	n := NewSMTPNotifier("smtp.loja.com", 587, "", "", "loja@loja.com")
+
+	body, err := n.compose([]string{"a@loja.com", "b@loja.com"}, Message{Subject: "Estoque baixo: Câmera", Body: "Restam 2 unidades."})
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "To: a@loja.com, b@loja.com\r\n")
+	assert.Contains(t, string(body), "Subject: =?utf-8?q?Estoque_baixo:_C=C3=A2mera?=\r\n")
+
+	assert.Equal(t, []string{"a@loja.com", "b@loja.com"}, recipients(" a@loja.com,,b@loja.com "))
+	assert.ErrorIs(t, n.Notify(context.Background(), Message{}), ErrNoRecipient)
+}
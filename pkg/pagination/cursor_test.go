@@ -0,0 +1,42 @@
+package pagination
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner(t *testing.T) {
+	signer := NewSigner("secret")
+	cursor := Cursor{Key: "2026-01-02T03:04:05Z", ID: 42, Backward: true, Query: Fingerprint("sort:newest")}
+
+	t.Run("✅ Codificar e decodificar", func(t *testing.T) {
+		decoded, err := signer.Decode(signer.Encode(cursor))
+		require.NoError(t, err)
+		assert.Equal(t, cursor, *decoded)
+	})
+
+	t.Run("❌ Cursor adulterado", func(t *testing.T) {
+		payload, signature, _ := strings.Cut(signer.Encode(cursor), ".")
+		forged := NewSigner("secret").Encode(Cursor{ID: 1})
+		forgedPayload, _, _ := strings.Cut(forged, ".")
+
+		for _, token := range []string{"", "abc", payload, forgedPayload + "." + signature, payload + "." + signature + "x"} {
+			_, err := signer.Decode(token)
+			assert.ErrorIs(t, err, ErrInvalidCursor, token)
+		}
+	})
+
+	t.Run("❌ Assinado com outra chave", func(t *testing.T) {
+		_, err := signer.Decode(NewSigner("other").Encode(cursor))
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+}
+
+func TestFingerprint(t *testing.T) {
+	assert.Equal(t, Fingerprint("a", "b"), Fingerprint("a", "b"))
+	assert.NotEqual(t, Fingerprint("a", "b"), Fingerprint("ab"))
+	assert.NotEqual(t, Fingerprint("a", "b"), Fingerprint("b", "a"))
+}
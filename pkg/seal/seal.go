@@ -0,0 +1,87 @@
+// Package seal encrypts small values to be stored at rest, like the
+// documents of customers, and indexes them so equal values can be found
+// without decrypting every row.
+package seal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+)
+
+var (
+	ErrNoKey   = errors.New("seal: key is required")
+	ErrCorrupt = errors.New("seal: value was not sealed with this key or was changed")
+)
+
+// Box seals values with AES-256-GCM. The encryption and index keys are
+// both derived from the secret, so one setting is enough and neither key
+// reveals the other.
+type Box struct {
+	aead     cipher.AEAD
+	indexKey []byte
+}
+
+func New(secret string) (*Box, error) {
+	if secret == "" {
+		return nil, ErrNoKey
+	}
+
+	block, err := aes.NewCipher(derive(secret, "seal:encrypt"))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Box{
+		aead:     aead,
+		indexKey: derive(secret, "seal:index"),
+	}, nil
+}
+
+// Seal encrypts plaintext with a random nonce; sealing the same value twice
+// gives different results. Use Index to look values up.
+func (b *Box) Seal(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+func (b *Box) Open(sealed string) (string, error) {
+	data, err := base64.RawStdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < b.aead.NonceSize() {
+		return "", ErrCorrupt
+	}
+
+	nonce, ciphertext := data[:b.aead.NonceSize()], data[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrCorrupt
+	}
+	return string(plaintext), nil
+}
+
+// Index is the HMAC of plaintext: the same for equal values, for unique
+// indexes and lookups, and useless to guess the value without the secret.
+func (b *Box) Index(plaintext string) string {
+	mac := hmac.New(sha256.New, b.indexKey)
+	mac.Write([]byte(plaintext))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func derive(secret, purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
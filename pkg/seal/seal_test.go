@@ -0,0 +1,56 @@
+package seal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBox(t *testing.T) {
+	box, err := New("chave-de-teste")
+	require.NoError(t, err)
+
+	t.Run("✅ Abre o que selou, com nonce aleatório", func(t *testing.T) {
+		first, err := box.Seal("52998224725")
+		require.NoError(t, err)
+		second, err := box.Seal("52998224725")
+		require.NoError(t, err)
+		assert.NotEqual(t, first, second)
+		assert.NotContains(t, first, "52998224725")
+
+		plaintext, err := box.Open(first)
+		require.NoError(t, err)
+		assert.Equal(t, "52998224725", plaintext)
+	})
+
+	t.Run("✅ Índice determinístico por chave", func(t *testing.T) {
+		assert.Equal(t, box.Index("52998224725"), box.Index("52998224725"))
+		assert.NotEqual(t, box.Index("52998224725"), box.Index("11144477735"))
+
+		other, err := New("outra-chave")
+		require.NoError(t, err)
+		assert.NotEqual(t, box.Index("52998224725"), other.Index("52998224725"))
+	})
+
+	t.Run("❌ Chave errada, valor alterado ou sem chave", func(t *testing.T) {
+		sealed, err := box.Seal("52998224725")
+		require.NoError(t, err)
+
+		other, err := New("outra-chave")
+		require.NoError(t, err)
+		_, err = other.Open(sealed)
+		assert.ErrorIs(t, err, ErrCorrupt)
+
+		tampered := []byte(sealed)
+		tampered[10] ^= 1
+		_, err = box.Open(string(tampered))
+		assert.ErrorIs(t, err, ErrCorrupt)
+
+		_, err = box.Open("não é base64")
+		assert.ErrorIs(t, err, ErrCorrupt)
+
+		_, err = New("")
+		assert.ErrorIs(t, err, ErrNoKey)
+	})
+}
@@ -0,0 +1,134 @@
+package shipping
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/config"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+)
+
+const (
+	CarrierTable = "table"
+)
+
+// Services carriers usually offer.
+const (
+	ServiceStandard = "standard"
+	ServiceExpress  = "express"
+)
+
+// CubicDivisor converts the volume of a package in cm³ to its cubic weight
+// in kg, the factor used by Correios and most Brazilian carriers.
+const CubicDivisor = 6000
+
+// Package is what is shipped to DestinationCEP: the items of an order,
+// packed together.
+type Package struct {
+	DestinationCEP string `json:"destination_cep"`
+	// WeightGrams is the actual weight of the items and VolumeCm3 the
+	// space they take.
+	WeightGrams int `json:"weight_grams"`
+	VolumeCm3   int `json:"volume_cm3"`
+	// Value is the declared value of the items, for carriers that charge
+	// insurance.
+	Value money.Money `json:"value" swaggertype:"number"`
+}
+
+// CubicWeightGrams is the weight carriers charge light but bulky packages
+// for.
+func (p Package) CubicWeightGrams() int {
+	return (p.VolumeCm3*1000 + CubicDivisor - 1) / CubicDivisor
+}
+
+// BillableWeightGrams is the higher of the actual and the cubic weight.
+func (p Package) BillableWeightGrams() int {
+	return max(p.WeightGrams, p.CubicWeightGrams())
+}
+
+// Option is a way of delivering a package, with its price and the business
+// days it takes.
+type Option struct {
+	Carrier      string      `json:"carrier"`
+	Service      string      `json:"service"`
+	Name         string      `json:"name"`
+	Price        money.Money `json:"price" swaggertype:"number"`
+	DeliveryDays int         `json:"delivery_days"`
+}
+
+// Carrier quotes the delivery of packages. A carrier that does not deliver
+// a package, to its CEP or at its weight, returns no options rather than
+// an error; errors are for failures to quote.
+type Carrier interface {
+	Quote(ctx context.Context, pkg Package) ([]Option, error)
+}
+
+// New builds the carrier of a comma separated list of carriers, like
+// SHIPPING_CARRIERS. With several carriers the options of all of them are
+// offered.
+func New(cfg *config.Config, carriers string) (Carrier, error) {
+	var all Multi
+	for _, name := range strings.Split(carriers, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		carrier, err := newCarrier(cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, carrier)
+	}
+
+	switch len(all) {
+	case 0:
+		return nil, fmt.Errorf("no shipping carrier in %q", carriers)
+	case 1:
+		return all[0], nil
+	default:
+		return all, nil
+	}
+}
+
+func newCarrier(cfg *config.Config, name string) (Carrier, error) {
+	switch name {
+	case CarrierTable:
+		if cfg.ShippingTablePath == "" {
+			return DefaultTableCarrier(), nil
+		}
+		return LoadTableCarrier(cfg.ShippingTablePath)
+	default:
+		return nil, fmt.Errorf("unknown shipping carrier %q", name)
+	}
+}
+
+// Multi quotes a package with all of its carriers and offers their options
+// from the cheapest to the most expensive. It fails only when every
+// carrier does.
+type Multi []Carrier
+
+func (m Multi) Quote(ctx context.Context, pkg Package) ([]Option, error) {
+	var options []Option
+	var errs []error
+	for _, carrier := range m {
+		quoted, err := carrier.Quote(ctx, pkg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		options = append(options, quoted...)
+	}
+	if len(errs) == len(m) {
+		return nil, errors.Join(errs...)
+	}
+
+	slices.SortStableFunc(options, func(a, b Option) int {
+		return cmp.Or(a.Price.Cmp(b.Price), cmp.Compare(a.DeliveryDays, b.DeliveryDays))
+	})
+	return options, nil
+}
@@ -0,0 +1,154 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/config"
+	"github.com/Code-Aether/americanas-loja-api/pkg/br"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTable = `{
+  "carrier": "Transportadora Teste",
+  "zones": [
+    {"code": "local", "ranges": [{"from": "01000-000", "to": "05999-999"}]},
+    {"code": "longe", "ranges": [{"from": "40000000", "to": "79999999"}]}
+  ],
+  "services": [
+    {"code": "standard", "name": "Econômico", "rates": {
+      "local": {"delivery_days": 3, "brackets": [{"up_to_grams": 1000, "price": 10.00}, {"up_to_grams": 5000, "price": 20.00}]},
+      "longe": {"delivery_days": 8, "brackets": [{"up_to_grams": 1000, "price": 30.00}]}
+    }},
+    {"code": "express", "name": "Expresso", "rates": {
+      "local": {"delivery_days": 1, "brackets": [{"up_to_grams": 1000, "price": 25.00}]}
+    }}
+  ]
+}`
+
+type failing struct{}
+
+func (failing) Quote(ctx context.Context, pkg Package) ([]Option, error) {
+	return nil, errors.New("fora do ar")
+}
+
+func testCarrier(t *testing.T) *TableCarrier {
+	table, err := ReadTable(strings.NewReader(testTable))
+	require.NoError(t, err)
+	carrier, err := NewTableCarrier(table)
+	require.NoError(t, err)
+	return carrier
+}
+
+func TestPackage(t *testing.T) {
+	// 40x30x20 = 24000 cm³ / 6000 = 4 kg cúbicos
+	pkg := Package{WeightGrams: 1500, VolumeCm3: 24000}
+	assert.Equal(t, 4000, pkg.CubicWeightGrams())
+	assert.Equal(t, 4000, pkg.BillableWeightGrams(), "Pacote leve e volumoso paga pelo peso cúbico")
+
+	pkg.WeightGrams = 6000
+	assert.Equal(t, 6000, pkg.BillableWeightGrams())
+}
+
+func TestTableCarrier(t *testing.T) {
+	carrier := testCarrier(t)
+	ctx := context.Background()
+
+	t.Run("✅ Cota os serviços da zona do CEP", func(t *testing.T) {
+		options, err := carrier.Quote(ctx, Package{DestinationCEP: "01310-100", WeightGrams: 800})
+		require.NoError(t, err)
+		require.Len(t, options, 2)
+		assert.Equal(t, Option{Carrier: "Transportadora Teste", Service: ServiceStandard, Name: "Econômico", Price: money.MustParse("10.00"), DeliveryDays: 3}, options[0])
+		assert.Equal(t, ServiceExpress, options[1].Service)
+	})
+
+	t.Run("✅ Faixa de peso usa o peso cúbico", func(t *testing.T) {
+		options, err := carrier.Quote(ctx, Package{DestinationCEP: "01310100", WeightGrams: 800, VolumeCm3: 12000})
+		require.NoError(t, err)
+		require.Len(t, options, 1, "Expresso não leva mais de 1 kg")
+		assert.Equal(t, money.MustParse("20.00"), options[0].Price)
+	})
+
+	t.Run("✅ CEP fora das zonas ou pacote pesado demais não tem opções", func(t *testing.T) {
+		options, err := carrier.Quote(ctx, Package{DestinationCEP: "90000-000", WeightGrams: 800})
+		require.NoError(t, err)
+		assert.Empty(t, options)
+
+		options, err = carrier.Quote(ctx, Package{DestinationCEP: "40000-000", WeightGrams: 2000})
+		require.NoError(t, err)
+		assert.Empty(t, options)
+	})
+
+	t.Run("❌ CEP inválido", func(t *testing.T) {
+		_, err := carrier.Quote(ctx, Package{DestinationCEP: "0131-100", WeightGrams: 800})
+		assert.ErrorIs(t, err, br.ErrInvalidCEP)
+	})
+
+	t.Run("❌ Tabela com zona desconhecida ou faixas fora de ordem", func(t *testing.T) {
+		table, err := ReadTable(strings.NewReader(testTable))
+		require.NoError(t, err)
+		table.Services[1].Rates["centro-oeste"] = table.Services[1].Rates["local"]
+		_, err = NewTableCarrier(table)
+		assert.Error(t, err)
+
+		table, err = ReadTable(strings.NewReader(testTable))
+		require.NoError(t, err)
+		brackets := table.Services[0].Rates["local"].Brackets
+		brackets[0], brackets[1] = brackets[1], brackets[0]
+		_, err = NewTableCarrier(table)
+		assert.Error(t, err)
+	})
+}
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("✅ Tabela padrão cobre o país inteiro", func(t *testing.T) {
+		carrier, err := New(&config.Config{}, "table")
+		require.NoError(t, err)
+
+		for _, cep := range []string{"01310-100", "13010-000", "20040-020", "69005-040", "90010-000"} {
+			options, err := carrier.Quote(ctx, Package{DestinationCEP: cep, WeightGrams: 500})
+			require.NoError(t, err)
+			assert.Len(t, options, 2, cep)
+		}
+	})
+
+	t.Run("✅ Tabela lida do arquivo", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "frete.json")
+		require.NoError(t, os.WriteFile(path, []byte(testTable), 0o644))
+
+		carrier, err := New(&config.Config{ShippingTablePath: path}, "table")
+		require.NoError(t, err)
+		options, err := carrier.Quote(ctx, Package{DestinationCEP: "40000-000", WeightGrams: 500})
+		require.NoError(t, err)
+		assert.Equal(t, "Transportadora Teste", options[0].Carrier)
+	})
+
+	t.Run("✅ Várias transportadoras ordenadas por preço, ignorando as que falham", func(t *testing.T) {
+		carriers := Multi{testCarrier(t), DefaultTableCarrier(), failing{}}
+
+		options, err := carriers.Quote(ctx, Package{DestinationCEP: "01310-100", WeightGrams: 500})
+		require.NoError(t, err)
+		require.Len(t, options, 4)
+		for i := 1; i < len(options); i++ {
+			assert.LessOrEqual(t, options[i-1].Price.Cmp(options[i].Price), 0)
+		}
+
+		_, err = Multi{failing{}}.Quote(ctx, Package{DestinationCEP: "01310-100"})
+		assert.Error(t, err)
+	})
+
+	t.Run("❌ Transportadora desconhecida", func(t *testing.T) {
+		_, err := New(&config.Config{}, "table,correios")
+		assert.Error(t, err)
+		_, err = New(&config.Config{}, " ")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,201 @@
+package shipping
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Code-Aether/americanas-loja-api/pkg/br"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+)
+
+//go:embed default_table.json
+var defaultTable []byte
+
+// Table is the price list of a carrier. CEP ranges map a destination to a
+// zone, and each service has, for every zone it delivers to, the business
+// days it takes and the price of each weight bracket.
+type Table struct {
+	Carrier  string         `json:"carrier"`
+	Zones    []Zone         `json:"zones"`
+	Services []TableService `json:"services"`
+}
+
+type Zone struct {
+	Code   string     `json:"code"`
+	Ranges []CEPRange `json:"ranges"`
+}
+
+// CEPRange covers the CEPs from From to To, both included.
+type CEPRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type TableService struct {
+	Code  string          `json:"code"`
+	Name  string          `json:"name"`
+	Rates map[string]Rate `json:"rates"`
+}
+
+// Rate is the delivery of a service to a zone. Packages heavier than the
+// last bracket are not delivered.
+type Rate struct {
+	DeliveryDays int             `json:"delivery_days"`
+	Brackets     []WeightBracket `json:"brackets"`
+}
+
+// WeightBracket is the price of packages up to UpToGrams, billable weight,
+// and over the previous bracket.
+type WeightBracket struct {
+	UpToGrams int         `json:"up_to_grams"`
+	Price     money.Money `json:"price"`
+}
+
+// TableCarrier quotes from a Table, with no calls to outside services; it
+// stands for the store's own delivery or the contract price list of a
+// carrier.
+type TableCarrier struct {
+	table Table
+}
+
+// NewTableCarrier checks the table and returns the carrier that quotes
+// from it.
+func NewTableCarrier(table Table) (*TableCarrier, error) {
+	if table.Carrier == "" {
+		return nil, fmt.Errorf("shipping table: carrier is required")
+	}
+
+	zones := make(map[string]bool, len(table.Zones))
+	for i, zone := range table.Zones {
+		if zone.Code == "" {
+			return nil, fmt.Errorf("shipping table: zone %d has no code", i)
+		}
+		zones[zone.Code] = true
+		for j, r := range zone.Ranges {
+			from, err := br.NormalizeCEP(r.From)
+			if err != nil {
+				return nil, fmt.Errorf("shipping table: zone %s range %d: %w", zone.Code, j, err)
+			}
+			to, err := br.NormalizeCEP(r.To)
+			if err != nil {
+				return nil, fmt.Errorf("shipping table: zone %s range %d: %w", zone.Code, j, err)
+			}
+			if from > to {
+				return nil, fmt.Errorf("shipping table: zone %s range %d ends before it starts", zone.Code, j)
+			}
+			table.Zones[i].Ranges[j] = CEPRange{From: from, To: to}
+		}
+	}
+
+	for _, service := range table.Services {
+		if service.Code == "" {
+			return nil, fmt.Errorf("shipping table: service %q has no code", service.Name)
+		}
+		for zone, rate := range service.Rates {
+			if !zones[zone] {
+				return nil, fmt.Errorf("shipping table: service %s has rates for unknown zone %s", service.Code, zone)
+			}
+			if len(rate.Brackets) == 0 {
+				return nil, fmt.Errorf("shipping table: service %s has no brackets for zone %s", service.Code, zone)
+			}
+			for k, bracket := range rate.Brackets {
+				if bracket.Price.IsNegative() || (k > 0 && bracket.UpToGrams <= rate.Brackets[k-1].UpToGrams) {
+					return nil, fmt.Errorf("shipping table: service %s zone %s: brackets must grow in weight and cost nothing negative", service.Code, zone)
+				}
+			}
+		}
+	}
+	return &TableCarrier{table: table}, nil
+}
+
+// ReadTable reads a table in JSON.
+func ReadTable(r io.Reader) (Table, error) {
+	var table Table
+	if err := json.NewDecoder(r).Decode(&table); err != nil {
+		return Table{}, fmt.Errorf("shipping table: %w", err)
+	}
+	return table, nil
+}
+
+// LoadTableCarrier returns the carrier of the table in the JSON file at
+// path, set by SHIPPING_TABLE_PATH.
+func LoadTableCarrier(path string) (*TableCarrier, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	table, err := ReadTable(file)
+	if err != nil {
+		return nil, err
+	}
+	return NewTableCarrier(table)
+}
+
+// DefaultTableCarrier quotes from the table built into the API: standard
+// and express delivery from the city of São Paulo to the whole country.
+func DefaultTableCarrier() *TableCarrier {
+	var table Table
+	if err := json.Unmarshal(defaultTable, &table); err != nil {
+		panic(err)
+	}
+	carrier, err := NewTableCarrier(table)
+	if err != nil {
+		panic(err)
+	}
+	return carrier
+}
+
+// Quote offers every service with a rate for the zone of the destination
+// and a bracket for the billable weight of the package.
+func (c *TableCarrier) Quote(ctx context.Context, pkg Package) ([]Option, error) {
+	cep, err := br.NormalizeCEP(pkg.DestinationCEP)
+	if err != nil {
+		return nil, err
+	}
+
+	zone := c.zoneOf(cep)
+	if zone == "" {
+		return nil, nil
+	}
+
+	weight := pkg.BillableWeightGrams()
+	var options []Option
+	for _, service := range c.table.Services {
+		rate, ok := service.Rates[zone]
+		if !ok {
+			continue
+		}
+		for _, bracket := range rate.Brackets {
+			if weight <= bracket.UpToGrams {
+				options = append(options, Option{
+					Carrier:      c.table.Carrier,
+					Service:      service.Code,
+					Name:         service.Name,
+					Price:        bracket.Price,
+					DeliveryDays: rate.DeliveryDays,
+				})
+				break
+			}
+		}
+	}
+	return options, nil
+}
+
+// zoneOf returns the first zone with a range covering the CEP. CEPs have
+// the same number of digits, so they compare as strings.
+func (c *TableCarrier) zoneOf(cep string) string {
+	for _, zone := range c.table.Zones {
+		for _, r := range zone.Ranges {
+			if cep >= r.From && cep <= r.To {
+				return zone.Code
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStorage(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir(), "http://localhost:8080/media/", []byte("secret"))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("✅ Gravar e assinar URL", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "products/1/a.txt", strings.NewReader("conteudo"), 8, "text/plain"))
+
+		path, err := store.Path("products/1/a.txt")
+		require.NoError(t, err)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "conteudo", string(data))
+
+		signed, err := store.SignedURL(ctx, "products/1/a.txt", time.Minute)
+		require.NoError(t, err)
+
+		parsed, err := url.Parse(signed)
+		require.NoError(t, err)
+		assert.Equal(t, "/media/products/1/a.txt", parsed.Path)
+
+		query := parsed.Query()
+		assert.NoError(t, store.Verify("products/1/a.txt", query.Get("expires"), query.Get("signature")))
+		assert.ErrorIs(t, store.Verify("products/1/b.txt", query.Get("expires"), query.Get("signature")), ErrInvalidSignature,
+			"Assinatura não deve valer para outra chave")
+	})
+
+	t.Run("❌ URL expirada", func(t *testing.T) {
+		signed, err := store.SignedURL(ctx, "products/1/a.txt", -time.Minute)
+		require.NoError(t, err)
+
+		parsed, err := url.Parse(signed)
+		require.NoError(t, err)
+		query := parsed.Query()
+		assert.ErrorIs(t, store.Verify("products/1/a.txt", query.Get("expires"), query.Get("signature")), ErrInvalidSignature)
+	})
+
+	t.Run("❌ Chave fora do diretório", func(t *testing.T) {
+		for _, key := range []string{"../etc/passwd", "products/../../x", "", "products//a"} {
+			_, err := store.Path(key)
+			assert.ErrorIs(t, err, ErrInvalidKey, key)
+		}
+	})
+
+	t.Run("✅ Remover arquivo inexistente", func(t *testing.T) {
+		assert.NoError(t, store.Delete(ctx, "products/1/nao-existe.txt"))
+	})
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Code-Aether/americanas-loja-api/internal/config"
+)
+
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+var (
+	ErrInvalidKey       = errors.New("invalid object key")
+	ErrInvalidSignature = errors.New("invalid or expired signature")
+)
+
+// Storage keeps binary objects addressed by slash separated keys and hands
+// out time limited URLs to read them.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Delete(ctx context.Context, key string) error
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+// New builds the storage selected by STORAGE_DRIVER.
+func New(cfg *config.Config) (Storage, error) {
+	switch cfg.StorageDriver {
+	case DriverLocal:
+		return NewLocalStorage(cfg.StorageLocalPath, cfg.APIURL+"/media", []byte(cfg.StorageSigningKey))
+	case DriverS3:
+		return NewS3Storage(S3Options{
+			Endpoint:        cfg.AWSS3Endpoint,
+			Region:          cfg.AWSRegion,
+			Bucket:          cfg.AWSS3Bucket,
+			AccessKeyID:     cfg.AWSAccessKeyID,
+			SecretAccessKey: cfg.AWSSecretAccessKey,
+			UseSSL:          cfg.AWSS3UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.StorageDriver)
+	}
+}
@@ -1,11 +1,17 @@
 package utils
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
+// StatusClientClosedRequest is the non-standard status used when the client
+// disconnects before the response is written.
+const StatusClientClosedRequest = 499
+
 type Response struct {
 	Success bool        `json:"success"`
 	Message string      `json:"message"`
@@ -19,13 +25,33 @@ type PaginatedResponse struct {
 	Data       interface{} `json:"data,omitempty"`
 	Error      string      `json:"error,omitempty"`
 	Pagination Pagination  `json:"pagination,omitempty"`
+	Facets     interface{} `json:"facets,omitempty"`
 }
 
+// Pagination describes where a page sits in a listing. Total and
+// TotalPages are left out when the listing was not counted, and Page when
+// it was reached by cursor. NextCursor and PrevCursor are opaque tokens to
+// pass back as the cursor parameter.
 type Pagination struct {
-	Page       int `json:"page"`
-	Limit      int `json:"limit"`
-	Total      int `json:"total"`
-	TotalPages int `json:"total_pages"`
+	Page             int    `json:"page,omitempty"`
+	Limit            int    `json:"limit"`
+	Total            *int64 `json:"total,omitempty"`
+	TotalPages       *int   `json:"total_pages,omitempty"`
+	TotalApproximate bool   `json:"total_approximate,omitempty"`
+	NextCursor       string `json:"next_cursor,omitempty"`
+	PrevCursor       string `json:"prev_cursor,omitempty"`
+}
+
+// SetTotal fills Total and TotalPages from the count of the listing.
+func (p *Pagination) SetTotal(total int64, approximate bool) {
+	totalPages := 0
+	if p.Limit > 0 {
+		totalPages = int((total + int64(p.Limit) - 1) / int64(p.Limit))
+	}
+
+	p.Total = &total
+	p.TotalPages = &totalPages
+	p.TotalApproximate = approximate
 }
 
 func SuccessResponse(c *gin.Context, message string, data interface{}) {
@@ -65,6 +91,21 @@ func ErrorResponse(c *gin.Context, status int, message string, err error) {
 	})
 }
 
+// ContextErrorResponse answers requests whose context was cancelled or timed
+// out. It reports whether err was a context error and a response was sent.
+func ContextErrorResponse(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, context.Canceled):
+		c.AbortWithStatus(StatusClientClosedRequest)
+		return true
+	case errors.Is(err, context.DeadlineExceeded):
+		ErrorResponse(c, http.StatusGatewayTimeout, "REQUEST_TIMEOUT", err)
+		c.Abort()
+		return true
+	}
+	return false
+}
+
 func BadRequestResponse(c *gin.Context, message string, err error) {
 	ErrorResponse(c, http.StatusBadRequest, message, err)
 }
@@ -89,3 +130,13 @@ func PaginatedSuccessResponse(c *gin.Context, message string, data interface{},
 		Pagination: pagination,
 	})
 }
+
+func FacetedSuccessResponse(c *gin.Context, message string, data interface{}, pagination Pagination, facets interface{}) {
+	c.JSON(http.StatusOK, PaginatedResponse{
+		Success:    true,
+		Message:    message,
+		Data:       data,
+		Pagination: pagination,
+		Facets:     facets,
+	})
+}
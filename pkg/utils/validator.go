@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"reflect"
+
+	"github.com/Code-Aether/americanas-loja-api/pkg/br"
+	"github.com/Code-Aether/americanas-loja-api/pkg/money"
+	"github.com/go-playground/validator/v10"
+)
+
+// NewValidator returns a validator that also understands the custom field
+// types of the API: Money is validated by its amount in cents, so rules
+// like gt=0 keep working on prices. The cep and uf tags check Brazilian
+// CEPs, with or without the dash, and state abbreviations; cpf and cnpj
+// check the documents, with or without punctuation, by their check digits.
+func NewValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		return field.Interface().(money.Money).Amount
+	}, money.Money{})
+	_ = validate.RegisterValidation("cep", func(fl validator.FieldLevel) bool {
+		_, err := br.NormalizeCEP(fl.Field().String())
+		return err == nil
+	})
+	_ = validate.RegisterValidation("uf", func(fl validator.FieldLevel) bool {
+		return br.ValidUF(fl.Field().String())
+	})
+	_ = validate.RegisterValidation("cpf", func(fl validator.FieldLevel) bool {
+		_, err := br.NormalizeCPF(fl.Field().String())
+		return err == nil
+	})
+	_ = validate.RegisterValidation("cnpj", func(fl validator.FieldLevel) bool {
+		_, err := br.NormalizeCNPJ(fl.Field().String())
+		return err == nil
+	})
+	return validate
+}